@@ -81,8 +81,21 @@ func run() (err error) {
 		-.5, -.5, 0, 0.0, 0.0, 1.0,
 		-.5, +.5, 0, 1.0, 0.0, 0.0,
 	}
-	const vertexSize = 6
 	const f32size = 4
+	// the inputs of vertexShaderSource, packed one after another in each
+	// vertex. This is what device.VertexLayout computes, spelled out so
+	// brian keeps building without draft/texturing.
+	attributes := []struct {
+		location uint32
+		size     int32
+	}{
+		{0, 3}, // position
+		{1, 3}, // color
+	}
+	var stride int32
+	for _, a := range attributes {
+		stride += f32size * a.size
+	}
 	var vbo, vao uint32
 	{
 		gl.GenVertexArrays(1, &vao)
@@ -92,13 +105,12 @@ func run() (err error) {
 		gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
 		gl.BufferData(gl.ARRAY_BUFFER, f32size*len(points), gl.Ptr(points), gl.STATIC_DRAW)
 
-		// position attribute
-		gl.VertexAttribPointer(0, 3, gl.FLOAT, false, f32size*vertexSize, nil)
-		gl.EnableVertexAttribArray(0)
-
-		// color attribute
-		gl.VertexAttribPointer(1, 3, gl.FLOAT, false, f32size*vertexSize, gl.PtrOffset(f32size*3))
-		gl.EnableVertexAttribArray(1)
+		offset := 0
+		for _, a := range attributes {
+			gl.VertexAttribPointer(a.location, a.size, gl.FLOAT, false, stride, gl.PtrOffset(offset))
+			gl.EnableVertexAttribArray(a.location)
+			offset += f32size * int(a.size)
+		}
 
 		defer gl.DeleteVertexArrays(1, &vao)
 		defer gl.DeleteBuffers(1, &vbo)
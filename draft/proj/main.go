@@ -2,17 +2,15 @@ package main
 
 import (
 	"fmt"
-	"image"
+	"os"
 	"runtime"
 
-	"neilpa.me/go-stbi"
-	_ "neilpa.me/go-stbi/jpeg"
-	_ "neilpa.me/go-stbi/png"
-
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/pgeowng/rende/draft/texturing/device"
 	"github.com/pgeowng/rende/draft/texturing/glm"
 	"github.com/pgeowng/rende/draft/texturing/shader"
+	"github.com/pgeowng/rende/draft/texturing/texture"
 )
 
 const (
@@ -60,6 +58,15 @@ func run() (err error) {
 		return
 	}
 
+	layout := device.PositionColorTexCoord
+	vertexSource, err := os.ReadFile(vertexPath)
+	if err != nil {
+		return
+	}
+	if err = layout.Validate(string(vertexSource)); err != nil {
+		return
+	}
+
 	sh := shader.New(vertexPath, fragmentPath)
 	prog, err := sh.Compile()
 	if err != nil {
@@ -73,8 +80,11 @@ func run() (err error) {
 		-.5, -.5, 0, 0.0, 0.0, 1.0, 0.0, 1.0,
 		-.5, +.5, 0, 1.0, 0.0, 0.0, 0.0, 0.0,
 	}
-	const vertexSize = 8
-	const f32size = 4
+	var data []byte
+	data, err = layout.Pack(points)
+	if err != nil {
+		return
+	}
 	var vbo, vao uint32
 	{
 		gl.GenVertexArrays(1, &vao)
@@ -82,19 +92,9 @@ func run() (err error) {
 		gl.BindVertexArray(vao)
 
 		gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-		gl.BufferData(gl.ARRAY_BUFFER, f32size*len(points), gl.Ptr(points), gl.STATIC_DRAW)
+		gl.BufferData(gl.ARRAY_BUFFER, len(data), gl.Ptr(data), gl.STATIC_DRAW)
 
-		// position attribute
-		gl.VertexAttribPointer(0, 3, gl.FLOAT, false, f32size*vertexSize, nil)
-		gl.EnableVertexAttribArray(0)
-
-		// color attribute
-		gl.VertexAttribPointer(1, 3, gl.FLOAT, false, f32size*vertexSize, gl.PtrOffset(f32size*3))
-		gl.EnableVertexAttribArray(1)
-
-		// tex attribute
-		gl.VertexAttribPointer(2, 2, gl.FLOAT, false, f32size*vertexSize, gl.PtrOffset(f32size*6))
-		gl.EnableVertexAttribArray(2)
+		layout.Enable()
 
 		defer gl.DeleteVertexArrays(1, &vao)
 		defer gl.DeleteBuffers(1, &vbo)
@@ -115,59 +115,37 @@ func run() (err error) {
 
 	var texture1 uint32
 	{
-		gl.GenTextures(1, &texture1)
-		gl.BindTexture(gl.TEXTURE_2D, texture1)
-
-		// repeat texture1
-		gl.TexParameteri(texture1, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(texture1, gl.TEXTURE_WRAP_T, gl.REPEAT)
-
-		// texture1 interpolation
-		gl.TexParameteri(texture1, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-		gl.TexParameteri(texture1, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-		var rgba *image.RGBA
-		rgba, err = stbi.Load(texturePath1)
+		var img *texture.Image
+		img, err = texture.Load(texturePath1, texture.Options{})
 		if err != nil {
 			return
 		}
-		// if rgba.Stride != rgba.Rect.Size().X*4 {
-		// 	return fmt.Errorf("image: unsupported stride: %d", rgba.Stride)
-		// }
 
-		fmt.Println(rgba.Rect.Size())
+		fmt.Println(img.Width, img.Height)
 
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-		gl.GenerateMipmap(gl.TEXTURE_2D)
+		// repeat, linear with mipmaps built on the CPU
+		texture1, err = texture.UploadLevels(img.Mipmaps(texture.Box, texture.Repeat, 0), texture.Sampler{})
+		if err != nil {
+			return
+		}
 		defer gl.DeleteTextures(1, &texture1)
 	}
 
 	var texture2 uint32
 	{
-		gl.GenTextures(1, &texture2)
-		gl.BindTexture(gl.TEXTURE_2D, texture2)
-
-		// repeat texture2
-		gl.TexParameteri(texture2, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(texture2, gl.TEXTURE_WRAP_T, gl.REPEAT)
-
-		// texture2 interpolation
-		gl.TexParameteri(texture2, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-		gl.TexParameteri(texture2, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-		var rgba *image.RGBA
-		rgba, err = stbi.Load(texturePath2)
+		var img *texture.Image
+		img, err = texture.Load(texturePath2, texture.Options{})
 		if err != nil {
 			return
 		}
-		// if rgba.Stride != rgba.Rect.Size().X*4 {
-		// 	return fmt.Errorf("image: unsupported stride: %d", rgba.Stride)
-		// }
 
-		fmt.Println(rgba.Rect.Size())
+		fmt.Println(img.Width, img.Height)
 
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-		gl.GenerateMipmap(gl.TEXTURE_2D)
+		// repeat, linear with mipmaps built on the CPU
+		texture2, err = texture.UploadLevels(img.Mipmaps(texture.Box, texture.Repeat, 0), texture.Sampler{})
+		if err != nil {
+			return
+		}
 		defer gl.DeleteTextures(1, &texture2)
 	}
 
@@ -181,7 +159,7 @@ func run() (err error) {
 	fmt.Println("model", model)
 	// model = model.Times(glm.RotationY(glm.Rad(20)))
 
-	view := glm.Identity().Translate(glm.Vec3{0, -.2, 0})
+	view := glm.LookAt(glm.Vec3{0, .2, 0}, glm.Vec3{0, .2, -1}, glm.Vec3{0, 1, 0})
 	fmt.Println("view", view)
 	projection := glm.Perspect(glm.Rad(45), float32(screenWidth)/float32(screenHeight), 0.1, 100.0)
 
@@ -0,0 +1,256 @@
+// Package atlas packs many small images into a few texture pages so they
+// can be drawn from one texture unit.
+//
+// Images are placed with the MaxRects algorithm. Each is surrounded by
+// extruded copies of its edge pixels, so bilinear filtering at its border
+// does not pick up a neighbour, and by padding that stays transparent.
+package atlas
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/mesh"
+)
+
+type Input struct {
+	Name  string
+	Image *image.RGBA
+}
+
+type Options struct {
+	// MaxWidth and MaxHeight bound the pages; 0 is 2048.
+	MaxWidth, MaxHeight int
+	// Padding is the number of transparent pixels between images.
+	Padding int
+	// Extrude is the number of times the edge pixels of each image are
+	// repeated around it.
+	Extrude int
+	// PowerOfTwo rounds the page sizes up to powers of two, and the bounds
+	// down to them.
+	PowerOfTwo bool
+}
+
+func (o Options) size() (int, int) {
+	w, h := o.MaxWidth, o.MaxHeight
+	if w <= 0 {
+		w = 2048
+	}
+	if h <= 0 {
+		h = 2048
+	}
+	if o.PowerOfTwo {
+		w, h = nextPowerOfTwo(w+1)/2, nextPowerOfTwo(h+1)/2
+	}
+	return w, h
+}
+
+// Rect is a rectangle in texture coordinates, Min at the top left.
+type Rect struct {
+	Min glm.Vec2 `json:"min"`
+	Max glm.Vec2 `json:"max"`
+}
+
+// Map takes uv in [0, 1] over the original image to the atlas.
+func (r Rect) Map(uv glm.Vec2) glm.Vec2 {
+	return r.Min.Add(uv.Mul(r.Max.Sub(r.Min)))
+}
+
+// Entry is where an image went. X, Y, Width and Height are the pixels of the
+// image itself, without extrusion.
+type Entry struct {
+	Name   string `json:"name"`
+	Page   int    `json:"page"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	UV     Rect   `json:"uv"`
+}
+
+type Page struct {
+	Image *image.RGBA `json:"-"`
+	// File is the page image next to the manifest, set by Save.
+	File   string `json:"file,omitempty"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+type Atlas struct {
+	Pages []*Page `json:"pages"`
+	// Entries are in the order of the inputs.
+	Entries []Entry `json:"entries"`
+	Padding int     `json:"padding"`
+	Extrude int     `json:"extrude"`
+
+	index map[string]int
+}
+
+// Lookup returns the entry of the named image.
+func (a *Atlas) Lookup(name string) (Entry, bool) {
+	if a.index == nil {
+		a.index = make(map[string]int, len(a.Entries))
+		for i, e := range a.Entries {
+			a.index[e.Name] = i
+		}
+	}
+	i, ok := a.index[name]
+	if !ok {
+		return Entry{}, false
+	}
+	return a.Entries[i], true
+}
+
+// Pack places the inputs on as many pages as needed. Names must be unique.
+func Pack(inputs []Input, o Options) (*Atlas, error) {
+	if o.Padding < 0 || o.Extrude < 0 {
+		return nil, fmt.Errorf("atlas: negative padding %d or extrude %d", o.Padding, o.Extrude)
+	}
+	maxW, maxH := o.size()
+	border := 2*o.Extrude + o.Padding
+
+	seen := make(map[string]bool, len(inputs))
+	for _, in := range inputs {
+		if in.Image == nil {
+			return nil, fmt.Errorf("atlas: %q has no image", in.Name)
+		}
+		if seen[in.Name] {
+			return nil, fmt.Errorf("atlas: duplicate name %q", in.Name)
+		}
+		seen[in.Name] = true
+		b := in.Image.Bounds()
+		// padding after the last cell may run past the page edge
+		if b.Dx()+2*o.Extrude > maxW || b.Dy()+2*o.Extrude > maxH {
+			return nil, fmt.Errorf("atlas: %q (%dx%d) does not fit a %dx%d page", in.Name, b.Dx(), b.Dy(), maxW, maxH)
+		}
+	}
+
+	// big and long images first
+	order := make([]int, len(inputs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := inputs[order[i]].Image.Bounds().Size(), inputs[order[j]].Image.Bounds().Size()
+		if ma, mb := max(a.X, a.Y), max(b.X, b.Y); ma != mb {
+			return ma > mb
+		}
+		return a.X*a.Y > b.X*b.Y
+	})
+
+	a := &Atlas{Entries: make([]Entry, len(inputs)), Padding: o.Padding, Extrude: o.Extrude}
+	var bins []*maxRects
+	for _, i := range order {
+		in := inputs[i]
+		b := in.Image.Bounds()
+		w, h := b.Dx()+border, b.Dy()+border
+
+		page := -1
+		var r rect
+		for p, bin := range bins {
+			var ok bool
+			if r, ok = bin.insert(w, h); ok {
+				page = p
+				break
+			}
+		}
+		if page < 0 {
+			bins = append(bins, newMaxRects(maxW+o.Padding, maxH+o.Padding))
+			page = len(bins) - 1
+			r, _ = bins[page].insert(w, h)
+		}
+		a.Entries[i] = Entry{
+			Name:   in.Name,
+			Page:   page,
+			X:      r.x + o.Extrude,
+			Y:      r.y + o.Extrude,
+			Width:  b.Dx(),
+			Height: b.Dy(),
+		}
+	}
+
+	for _, bin := range bins {
+		w, h := bin.right-o.Padding, bin.bottom-o.Padding
+		if o.PowerOfTwo {
+			w, h = min(nextPowerOfTwo(w), maxW), min(nextPowerOfTwo(h), maxH)
+		}
+		a.Pages = append(a.Pages, &Page{Image: image.NewRGBA(image.Rect(0, 0, w, h)), Width: w, Height: h})
+	}
+	for i, e := range a.Entries {
+		p := a.Pages[e.Page]
+		a.Entries[i].UV = Rect{
+			Min: glm.Vec2{float32(e.X) / float32(p.Width), float32(e.Y) / float32(p.Height)},
+			Max: glm.Vec2{float32(e.X+e.Width) / float32(p.Width), float32(e.Y+e.Height) / float32(p.Height)},
+		}
+		blit(p.Image, inputs[i].Image, e.X, e.Y, o.Extrude)
+	}
+	return a, nil
+}
+
+// blit copies src to x, y of dst and repeats its edge pixels extrude times
+// around it, corners included.
+func blit(dst, src *image.RGBA, x, y, extrude int) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return
+	}
+	for dy := -extrude; dy < h+extrude; dy++ {
+		sy := clamp(dy, 0, h-1)
+		for dx := -extrude; dx < w+extrude; dx++ {
+			sx := clamp(dx, 0, w-1)
+			s := src.PixOffset(b.Min.X+sx, b.Min.Y+sy)
+			d := dst.PixOffset(x+dx, y+dy)
+			copy(dst.Pix[d:d+4], src.Pix[s:s+4])
+		}
+	}
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p *= 2
+	}
+	return p
+}
+
+// RemapMesh moves the texture coordinates of m into the named image of the
+// atlas. They must lie in [0, 1]: an atlas cannot repeat an image.
+func (a *Atlas) RemapMesh(m *mesh.Mesh, name string) error {
+	e, ok := a.Lookup(name)
+	if !ok {
+		return fmt.Errorf("atlas: no image %q", name)
+	}
+	if m.UVs == nil {
+		return fmt.Errorf("atlas: mesh has no texture coordinates")
+	}
+	for i, uv := range m.UVs {
+		if uv[0] < 0 || uv[0] > 1 || uv[1] < 0 || uv[1] > 1 {
+			return fmt.Errorf("atlas: texture coordinate %v at %d is outside [0, 1]", uv, i)
+		}
+	}
+	for i, uv := range m.UVs {
+		m.UVs[i] = e.UV.Map(uv)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package atlas
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/mesh"
+	"github.com/pgeowng/rende/draft/texturing/texture"
+)
+
+// solid returns a w x h image whose pixels encode their position and the
+// image index, so a misplaced copy shows.
+func solid(i, w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x), uint8(y), uint8(i), 200})
+		}
+	}
+	return img
+}
+
+func randomInputs(n int, seed int64) []Input {
+	r := rand.New(rand.NewSource(seed))
+	in := make([]Input, n)
+	for i := range in {
+		in[i] = Input{Name: string(rune('a'+i%26)) + strings.Repeat("_", i/26), Image: solid(i, 1+r.Intn(60), 1+r.Intn(60))}
+	}
+	return in
+}
+
+// cell is the area an entry claims, extrusion and padding included.
+func cell(e Entry, o Options) rect {
+	return rect{e.X - o.Extrude, e.Y - o.Extrude, e.Width + 2*o.Extrude + o.Padding, e.Height + 2*o.Extrude + o.Padding}
+}
+
+func TestPack(t *testing.T) {
+	for _, o := range []Options{
+		{MaxWidth: 256, MaxHeight: 256},
+		{MaxWidth: 256, MaxHeight: 128, Padding: 2, Extrude: 1},
+		{MaxWidth: 200, MaxHeight: 300, Padding: 1, Extrude: 2, PowerOfTwo: true},
+	} {
+		in := randomInputs(60, 1)
+		a, err := Pack(in, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(a.Entries) != len(in) {
+			t.Fatalf("%+v: %d entries, want %d", o, len(a.Entries), len(in))
+		}
+
+		for i, e := range a.Entries {
+			if e.Name != in[i].Name || e.Width != in[i].Image.Rect.Dx() || e.Height != in[i].Image.Rect.Dy() {
+				t.Fatalf("%+v: entry %d is %+v for %q", o, i, e, in[i].Name)
+			}
+			p := a.Pages[e.Page]
+			if p.Width > o.MaxWidth || p.Height > o.MaxHeight || p.Image.Rect.Dx() != p.Width || p.Image.Rect.Dy() != p.Height {
+				t.Fatalf("%+v: page %d is %dx%d", o, e.Page, p.Width, p.Height)
+			}
+			if o.PowerOfTwo && (p.Width&(p.Width-1) != 0 || p.Height&(p.Height-1) != 0) {
+				t.Fatalf("%+v: page %dx%d is not a power of two", o, p.Width, p.Height)
+			}
+			if e.X-o.Extrude < 0 || e.Y-o.Extrude < 0 || e.X+e.Width+o.Extrude > p.Width || e.Y+e.Height+o.Extrude > p.Height {
+				t.Fatalf("%+v: %q at %d,%d %dx%d is off its %dx%d page", o, e.Name, e.X, e.Y, e.Width, e.Height, p.Width, p.Height)
+			}
+			for _, f := range a.Entries[:i] {
+				if f.Page == e.Page && cell(f, o).overlaps(cell(e, o)) {
+					t.Fatalf("%+v: %q and %q overlap", o, f.Name, e.Name)
+				}
+			}
+
+			// the image is copied as is
+			for y := 0; y < e.Height; y++ {
+				for x := 0; x < e.Width; x++ {
+					if got, want := p.Image.RGBAAt(e.X+x, e.Y+y), in[i].Image.RGBAAt(x, y); got != want {
+						t.Fatalf("%+v: %q pixel %d,%d is %v, want %v", o, e.Name, x, y, got, want)
+					}
+				}
+			}
+
+			uv := e.UV
+			if !uv.Min.ApproxEqual(glm.Vec2{float32(e.X) / float32(p.Width), float32(e.Y) / float32(p.Height)}) ||
+				!uv.Max.ApproxEqual(glm.Vec2{float32(e.X+e.Width) / float32(p.Width), float32(e.Y+e.Height) / float32(p.Height)}) {
+				t.Fatalf("%+v: %q uv %+v", o, e.Name, uv)
+			}
+		}
+		if len(a.Pages) < 2 && o.MaxHeight == 128 {
+			t.Errorf("%+v: %d pages, want several", o, len(a.Pages))
+		}
+	}
+}
+
+func TestExtrude(t *testing.T) {
+	img := solid(7, 3, 2)
+	a, err := Pack([]Input{{"a", img}, {"b", solid(8, 5, 5)}}, Options{Padding: 3, Extrude: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := a.Lookup("a")
+	p := a.Pages[e.Page].Image
+	for y := -2; y < 4; y++ {
+		for x := -2; x < 5; x++ {
+			sx, sy := clamp(x, 0, 2), clamp(y, 0, 1)
+			if got, want := p.RGBAAt(e.X+x, e.Y+y), img.RGBAAt(sx, sy); got != want {
+				t.Errorf("extruded pixel %d,%d is %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	// padding between the cells stays transparent
+	b, _ := a.Lookup("b")
+	for y := 0; y < p.Rect.Dy(); y++ {
+		for x := 0; x < p.Rect.Dx(); x++ {
+			in := func(e Entry) bool {
+				return x >= e.X-2 && x < e.X+e.Width+2 && y >= e.Y-2 && y < e.Y+e.Height+2
+			}
+			if !in(e) && !in(b) && p.RGBAAt(x, y) != (color.RGBA{}) {
+				t.Fatalf("pixel %d,%d outside both images is %v", x, y, p.RGBAAt(x, y))
+			}
+		}
+	}
+}
+
+func TestPackErrors(t *testing.T) {
+	for _, c := range []struct {
+		in   []Input
+		o    Options
+		want string
+	}{
+		{[]Input{{"a", solid(0, 300, 10)}}, Options{MaxWidth: 256}, "does not fit"},
+		{[]Input{{"a", solid(0, 256, 10)}}, Options{MaxWidth: 256, Extrude: 1}, "does not fit"},
+		{[]Input{{"a", solid(0, 4, 4)}, {"a", solid(1, 4, 4)}}, Options{}, "duplicate"},
+		{[]Input{{"a", nil}}, Options{}, "no image"},
+		{nil, Options{Padding: -1}, "negative"},
+	} {
+		_, err := Pack(c.in, c.o)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%+v: error %v, want %q", c.o, err, c.want)
+		}
+	}
+
+	// an image as large as a page fits: padding past the edge is not stored
+	a, err := Pack([]Input{{"a", solid(0, 64, 64)}, {"b", solid(1, 64, 64)}}, Options{MaxWidth: 64, MaxHeight: 64, Padding: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Pages) != 2 || a.Pages[0].Width != 64 {
+		t.Errorf("%d pages of %dx%d, want 2 of 64x64", len(a.Pages), a.Pages[0].Width, a.Pages[0].Height)
+	}
+}
+
+func TestRemapMesh(t *testing.T) {
+	a, err := Pack([]Input{{"a", solid(0, 10, 20)}, {"b", solid(1, 30, 30)}}, Options{Padding: 1, Extrude: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := a.Lookup("a")
+
+	m := mesh.Quad()
+	before := append([]glm.Vec2(nil), m.UVs...)
+	if err := a.RemapMesh(m, "a"); err != nil {
+		t.Fatal(err)
+	}
+	for i, uv := range m.UVs {
+		want := glm.Vec2{
+			e.UV.Min[0] + before[i][0]*(e.UV.Max[0]-e.UV.Min[0]),
+			e.UV.Min[1] + before[i][1]*(e.UV.Max[1]-e.UV.Min[1]),
+		}
+		if !uv.ApproxEqual(want) {
+			t.Errorf("uv %d is %v, want %v", i, uv, want)
+		}
+	}
+	if !e.UV.Map(glm.Vec2{0, 0}).ApproxEqual(e.UV.Min) || !e.UV.Map(glm.Vec2{1, 1}).ApproxEqual(e.UV.Max) {
+		t.Errorf("Map does not take the corners to the rect %+v", e.UV)
+	}
+
+	if err := a.RemapMesh(mesh.Quad(), "missing"); err == nil {
+		t.Error("an unknown name did not fail")
+	}
+	tiled := mesh.Quad()
+	tiled.UVs[0] = glm.Vec2{2, 0}
+	if err := a.RemapMesh(tiled, "a"); err == nil {
+		t.Error("repeating coordinates did not fail")
+	}
+	if tiled.UVs[1] != mesh.Quad().UVs[1] {
+		t.Error("a failed remap changed the mesh")
+	}
+}
+
+func TestManifest(t *testing.T) {
+	in := randomInputs(30, 2)
+	a, err := Pack(in, Options{MaxWidth: 128, MaxHeight: 128, Padding: 2, Extrude: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := a.WriteManifest(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"min": [`) || strings.Contains(buf.String(), "Pix") {
+		t.Errorf("unexpected manifest:\n%s", buf.String())
+	}
+	b, err := ReadManifest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Pages) != len(a.Pages) || b.Padding != 2 || b.Extrude != 1 {
+		t.Fatalf("read %d pages, padding %d, extrude %d", len(b.Pages), b.Padding, b.Extrude)
+	}
+	for i, e := range a.Entries {
+		if got, ok := b.Lookup(e.Name); !ok || got != e || b.Entries[i] != e {
+			t.Fatalf("entry %q read as %+v", e.Name, got)
+		}
+	}
+
+	for _, bad := range []string{
+		`{"pages": [], "entries": [{"name": "a", "page": 0}]}`,
+		`{"pages": [{}], "entries": [{"name": "a"}, {"name": "a"}]}`,
+		`{"pages": `,
+	} {
+		if _, err := ReadManifest(strings.NewReader(bad)); err == nil {
+			t.Errorf("%s did not fail", bad)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	tex, err := texture.Load("../tex.png", texture.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lumi, err := texture.Load("../lumi.jpg", texture.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := []Input{
+		{"tex", texture.Resize(tex.RGBA(), 100, 100, texture.ResampleOptions{})},
+		{"lumi", texture.Resize(lumi.RGBA(), 64, 48, texture.ResampleOptions{})},
+		{"dot", solid(0, 1, 1)},
+	}
+	a, err := Pack(in, Options{MaxWidth: 128, MaxHeight: 128, Extrude: 1, Padding: 1, PowerOfTwo: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Pages) != 2 {
+		t.Errorf("%d pages, want 2", len(a.Pages))
+	}
+
+	path := filepath.Join(t.TempDir(), "sprites.json")
+	if err := a.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range a.Pages {
+		if b.Pages[i].File != "sprites_"+string(rune('0'+i))+".png" {
+			t.Errorf("page %d file %q", i, b.Pages[i].File)
+		}
+		// straight alpha survives the round trip
+		if !bytes.Equal(b.Pages[i].Image.Pix, p.Image.Pix) {
+			t.Errorf("page %d pixels differ after loading", i)
+		}
+	}
+	if e, ok := b.Lookup("lumi"); !ok || e != a.Entries[1] {
+		t.Errorf("lumi loaded as %+v", e)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("loading a missing atlas did not fail")
+	}
+}
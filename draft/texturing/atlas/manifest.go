@@ -0,0 +1,128 @@
+package atlas
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pgeowng/rende/draft/texturing/texture"
+)
+
+// WriteManifest writes the pages and entries of a as JSON, without the page
+// images.
+func (a *Atlas) WriteManifest(w io.Writer) error {
+	data, err := json.MarshalIndent(a, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadManifest reads an atlas written by WriteManifest. The pages have no
+// images.
+func ReadManifest(r io.Reader) (*Atlas, error) {
+	var a Atlas
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, fmt.Errorf("atlas: manifest: %w", err)
+	}
+	a.index = make(map[string]int, len(a.Entries))
+	for i, e := range a.Entries {
+		if e.Page < 0 || e.Page >= len(a.Pages) {
+			return nil, fmt.Errorf("atlas: entry %q is on page %d of %d", e.Name, e.Page, len(a.Pages))
+		}
+		if _, ok := a.index[e.Name]; ok {
+			return nil, fmt.Errorf("atlas: duplicate name %q", e.Name)
+		}
+		a.index[e.Name] = i
+	}
+	return &a, nil
+}
+
+// Save writes the manifest to path and each page as a PNG next to it, named
+// after the manifest: atlas.json, atlas_0.png, atlas_1.png and so on.
+func (a *Atlas) Save(path string) error {
+	dir := filepath.Dir(path)
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for i, p := range a.Pages {
+		if p.Image == nil {
+			return fmt.Errorf("atlas: page %d has no image", i)
+		}
+		p.File = fmt.Sprintf("%s_%d.png", base, i)
+		if err := savePNG(filepath.Join(dir, p.File), p.Image); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := a.WriteManifest(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads an atlas written by Save with its page images.
+func Load(path string) (*Atlas, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	a, err := ReadManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, p := range a.Pages {
+		if p.File == "" {
+			return nil, fmt.Errorf("atlas: %s: page %d has no file", path, i)
+		}
+		img, err := loadPNG(filepath.Join(filepath.Dir(path), p.File))
+		if err != nil {
+			return nil, err
+		}
+		if b := img.Bounds(); b.Dx() != p.Width || b.Dy() != p.Height {
+			return nil, fmt.Errorf("atlas: %s is %dx%d, want %dx%d", p.File, b.Dx(), b.Dy(), p.Width, p.Height)
+		}
+		p.Image = img
+	}
+	return a, nil
+}
+
+// savePNG writes img as is: the pixels hold straight alpha, which png would
+// otherwise take as premultiplied.
+func savePNG(path string, img *image.RGBA) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	n := &image.NRGBA{Pix: img.Pix, Stride: img.Stride, Rect: img.Rect}
+	if err := png.Encode(f, n); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func loadPNG(path string) (*image.RGBA, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("atlas: %s: %w", path, err)
+	}
+	return texture.FromImage(img).RGBA(), nil
+}
@@ -0,0 +1,97 @@
+package atlas
+
+// rect is an area of a page in pixels.
+type rect struct {
+	x, y, w, h int
+}
+
+func (r rect) contains(o rect) bool {
+	return o.x >= r.x && o.y >= r.y && o.x+o.w <= r.x+r.w && o.y+o.h <= r.y+r.h
+}
+
+func (r rect) overlaps(o rect) bool {
+	return r.x < o.x+o.w && o.x < r.x+r.w && r.y < o.y+o.h && o.y < r.y+r.h
+}
+
+// maxRects packs rectangles into one bin, keeping every maximal free
+// rectangle (Jukka Jylänki, "A Thousand Ways to Pack the Bin").
+type maxRects struct {
+	free []rect
+	// right and bottom are the extent of the placed rectangles.
+	right, bottom int
+}
+
+func newMaxRects(w, h int) *maxRects {
+	return &maxRects{free: []rect{{0, 0, w, h}}}
+}
+
+// insert places a w x h rectangle by best short side fit and reports whether
+// it fit.
+func (b *maxRects) insert(w, h int) (rect, bool) {
+	best := -1
+	bestShort, bestLong := 0, 0
+	for i, f := range b.free {
+		if f.w < w || f.h < h {
+			continue
+		}
+		short, long := f.w-w, f.h-h
+		if short > long {
+			short, long = long, short
+		}
+		if best < 0 || short < bestShort || (short == bestShort && long < bestLong) {
+			best, bestShort, bestLong = i, short, long
+		}
+	}
+	if best < 0 {
+		return rect{}, false
+	}
+
+	r := rect{b.free[best].x, b.free[best].y, w, h}
+	b.split(r)
+	if r.x+r.w > b.right {
+		b.right = r.x + r.w
+	}
+	if r.y+r.h > b.bottom {
+		b.bottom = r.y + r.h
+	}
+	return r, true
+}
+
+// split replaces the free rectangles overlapping r by the maximal parts of
+// them around it, then drops those contained in others.
+func (b *maxRects) split(r rect) {
+	var free []rect
+	for _, f := range b.free {
+		if !f.overlaps(r) {
+			free = append(free, f)
+			continue
+		}
+		if r.x > f.x {
+			free = append(free, rect{f.x, f.y, r.x - f.x, f.h})
+		}
+		if r.x+r.w < f.x+f.w {
+			free = append(free, rect{r.x + r.w, f.y, f.x + f.w - r.x - r.w, f.h})
+		}
+		if r.y > f.y {
+			free = append(free, rect{f.x, f.y, f.w, r.y - f.y})
+		}
+		if r.y+r.h < f.y+f.h {
+			free = append(free, rect{f.x, r.y + r.h, f.w, f.y + f.h - r.y - r.h})
+		}
+	}
+
+	b.free = b.free[:0]
+	for i, f := range free {
+		redundant := false
+		for j, g := range free {
+			// of two equal rectangles keep the first
+			if i != j && g.contains(f) && (f != g || j < i) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			b.free = append(b.free, f)
+		}
+	}
+}
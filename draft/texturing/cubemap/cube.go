@@ -0,0 +1,299 @@
+// Package cubemap builds environment maps on the CPU: cube maps from six
+// images or an equirectangular panorama, spherical harmonics irradiance and
+// prefiltered specular mip chains for image based lighting, and the skybox
+// pass that draws them.
+//
+// Faces follow GL: Face i is TEXTURE_CUBE_MAP_POSITIVE_X + i and its first
+// row is t = 0, so face images are stored top row first as elsewhere in the
+// project and upload without flipping.
+package cubemap
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/texture"
+)
+
+type Face int
+
+const (
+	PositiveX Face = iota
+	NegativeX
+	PositiveY
+	NegativeY
+	PositiveZ
+	NegativeZ
+)
+
+// Cube is a linear float cube map with square faces.
+type Cube struct {
+	Size  int
+	Faces [6]*texture.FloatImage
+}
+
+func New(size int) *Cube {
+	c := &Cube{Size: size}
+	for i := range c.Faces {
+		c.Faces[i] = texture.NewFloat(size, size)
+	}
+	return c
+}
+
+// FromImages makes a cube of six faces, which must be square and of one
+// size.
+func FromImages(faces [6]*texture.FloatImage) (*Cube, error) {
+	c := &Cube{Faces: faces}
+	if faces[0] != nil {
+		c.Size = faces[0].Width
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate reports missing faces or faces of the wrong size.
+func (c *Cube) Validate() error {
+	if c.Size <= 0 {
+		return fmt.Errorf("cubemap: size %d", c.Size)
+	}
+	for i, f := range c.Faces {
+		if f == nil {
+			return fmt.Errorf("cubemap: face %d is missing", i)
+		}
+		if err := f.Validate(); err != nil {
+			return fmt.Errorf("cubemap: face %d: %w", i, err)
+		}
+		if f.Width != c.Size || f.Height != c.Size {
+			return fmt.Errorf("cubemap: face %d is %dx%d, want %dx%d", i, f.Width, f.Height, c.Size, c.Size)
+		}
+	}
+	return nil
+}
+
+// Load reads six face images in Face order. Radiance .hdr and OpenEXR files
+// are linear; other formats are decoded from sRGB.
+func Load(paths [6]string) (*Cube, error) {
+	var faces [6]*texture.FloatImage
+	for i, path := range paths {
+		var err error
+		if faces[i], err = loadFace(path); err != nil {
+			return nil, err
+		}
+	}
+	return FromImages(faces)
+}
+
+func loadFace(path string) (*texture.FloatImage, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".hdr", ".exr":
+		return texture.LoadFloat(path)
+	}
+	m, err := texture.Load(path, texture.Options{SRGB: true})
+	if err != nil {
+		return nil, err
+	}
+	return Linear(m), nil
+}
+
+// Linear converts an 8 bit image to float, decoding sRGB color.
+func Linear(m *texture.Image) *texture.FloatImage {
+	f := texture.NewFloat(m.Width, m.Height)
+	for i, v := range m.Pix {
+		if m.SRGB && i%4 != 3 {
+			f.Pix[i] = texture.DecodeSRGB(v)
+		} else {
+			f.Pix[i] = float32(v) / 255
+		}
+	}
+	return f
+}
+
+// Direction is the unnormalized direction through face coordinates u, v in
+// [0, 1], u to the right and v down the face image.
+func Direction(f Face, u, v float32) glm.Vec3 {
+	s, t := 2*u-1, 2*v-1
+	switch f {
+	case PositiveX:
+		return glm.Vec3{1, -t, -s}
+	case NegativeX:
+		return glm.Vec3{-1, -t, s}
+	case PositiveY:
+		return glm.Vec3{s, 1, t}
+	case NegativeY:
+		return glm.Vec3{s, -1, -t}
+	case PositiveZ:
+		return glm.Vec3{s, -t, 1}
+	}
+	return glm.Vec3{-s, -t, -1}
+}
+
+// Lookup is the inverse of Direction: the face d points at, by its major
+// axis, and the coordinates on it. d must not be zero.
+func Lookup(d glm.Vec3) (Face, float32, float32) {
+	ax, ay, az := m32.Abs(d[0]), m32.Abs(d[1]), m32.Abs(d[2])
+	var f Face
+	var s, t, ma float32
+	switch {
+	case ax >= ay && ax >= az && d[0] > 0:
+		f, s, t, ma = PositiveX, -d[2], -d[1], ax
+	case ax >= ay && ax >= az:
+		f, s, t, ma = NegativeX, d[2], -d[1], ax
+	case ay >= az && d[1] > 0:
+		f, s, t, ma = PositiveY, d[0], d[2], ay
+	case ay >= az:
+		f, s, t, ma = NegativeY, d[0], -d[2], ay
+	case d[2] > 0:
+		f, s, t, ma = PositiveZ, d[0], -d[1], az
+	default:
+		f, s, t, ma = NegativeZ, -d[0], -d[1], az
+	}
+	return f, (s/ma + 1) / 2, (t/ma + 1) / 2
+}
+
+// texel is the direction through the center of texel x, y of a face.
+func (c *Cube) texel(f Face, x, y int) glm.Vec3 {
+	return Direction(f, (float32(x)+.5)/float32(c.Size), (float32(y)+.5)/float32(c.Size))
+}
+
+// Sample filters the cube bilinearly in direction d. Texels at the edge of
+// a face are clamped rather than blended with the neighbouring face.
+func (c *Cube) Sample(d glm.Vec3) glm.Vec4 {
+	f, u, v := Lookup(d)
+	return bilinear(c.Faces[f], u, v, false)
+}
+
+// bilinear samples m at u, v in [0, 1], clamping to the edges, or wrapping
+// horizontally when wrapX is set.
+func bilinear(m *texture.FloatImage, u, v float32, wrapX bool) glm.Vec4 {
+	x, y := u*float32(m.Width)-.5, v*float32(m.Height)-.5
+	x0, y0 := m32.Floor(x), m32.Floor(y)
+	wx := [2]float32{1 - (x - x0), x - x0}
+	wy := [2]float32{1 - (y - y0), y - y0}
+	var out glm.Vec4
+	for j := 0; j < 2; j++ {
+		for i := 0; i < 2; i++ {
+			w := wx[i] * wy[j]
+			if w == 0 {
+				continue
+			}
+			px, py := int(x0)+i, clampInt(int(y0)+j, m.Height-1)
+			if wrapX {
+				px = (px%m.Width + m.Width) % m.Width
+			} else {
+				px = clampInt(px, m.Width-1)
+			}
+			p := m.At(px, py)
+			for k := range out {
+				out[k] += w * p[k]
+			}
+		}
+	}
+	return out
+}
+
+func clampInt(v, max int) int {
+	switch {
+	case v < 0:
+		return 0
+	case v > max:
+		return max
+	}
+	return v
+}
+
+// EquirectDirection is the direction through u, v of an equirectangular
+// panorama: v = 0 is straight up, and the center of the image, u = 0.5,
+// looks down -Z with +X at u = 0.75.
+func EquirectDirection(u, v float32) glm.Vec3 {
+	phi := (u - .5) * 2 * m32.Pi
+	theta := v * m32.Pi
+	st := m32.Sin(theta)
+	return glm.Vec3{st * m32.Sin(phi), m32.Cos(theta), -st * m32.Cos(phi)}
+}
+
+// EquirectUV is the inverse of EquirectDirection for a unit direction.
+func EquirectUV(d glm.Vec3) (float32, float32) {
+	u := .5 + m32.Atan2(d[0], -d[2])/(2*m32.Pi)
+	y := d[1]
+	if y > 1 {
+		y = 1
+	} else if y < -1 {
+		y = -1
+	}
+	return u, m32.Acos(y) / m32.Pi
+}
+
+// FromEquirect resamples a panorama onto a cube of the given face size,
+// filtering bilinearly. It does not average over the texel footprint, so a
+// much smaller cube aliases; resize the panorama down first for that.
+func FromEquirect(m *texture.FloatImage, size int) *Cube {
+	c := New(size)
+	for f := range c.Faces {
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				u, v := EquirectUV(c.texel(Face(f), x, y).Normalize())
+				c.Faces[f].Set(x, y, bilinear(m, u, v, true))
+			}
+		}
+	}
+	return c
+}
+
+// Equirect resamples the cube onto a width x height panorama.
+func (c *Cube) Equirect(width, height int) *texture.FloatImage {
+	m := texture.NewFloat(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			d := EquirectDirection((float32(x)+.5)/float32(width), (float32(y)+.5)/float32(height))
+			m.Set(x, y, c.Sample(d))
+		}
+	}
+	return m
+}
+
+// Downsample halves the faces with a box filter, as the next mip level.
+func (c *Cube) Downsample() *Cube {
+	size := c.Size / 2
+	if size < 1 {
+		size = 1
+	}
+	out := New(size)
+	for f, src := range c.Faces {
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				var sum [4]float32
+				n := float32(0)
+				for j := 2 * y; j < 2*y+2 && j < c.Size; j++ {
+					for i := 2 * x; i < 2*x+2 && i < c.Size; i++ {
+						p := src.At(i, j)
+						for k := range sum {
+							sum[k] += p[k]
+						}
+						n++
+					}
+				}
+				for k := range sum {
+					sum[k] /= n
+				}
+				out.Faces[f].Set(x, y, sum)
+			}
+		}
+	}
+	return out
+}
+
+// Upload creates a GL cube map from a mip chain, as Prefilter returns it,
+// or a single cube. See texture.UploadFloatCube.
+func Upload(levels []*Cube, f texture.FloatFormat, s texture.Sampler) (uint32, error) {
+	faces := make([][6]*texture.FloatImage, len(levels))
+	for i, c := range levels {
+		faces[i] = c.Faces
+	}
+	return texture.UploadFloatCube(faces, f, s)
+}
@@ -0,0 +1,246 @@
+package cubemap
+
+import (
+	"testing"
+
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/texture"
+)
+
+func near(a, b, eps float32) bool {
+	return m32.Abs(a-b) <= eps
+}
+
+func TestDirection(t *testing.T) {
+	// GL's layout: the top row of the +Y face looks towards -Z, of the side
+	// faces up
+	tests := []struct {
+		f    Face
+		u, v float32
+		want glm.Vec3
+	}{
+		{PositiveX, .5, .5, glm.Vec3{1, 0, 0}},
+		{NegativeZ, .5, .5, glm.Vec3{0, 0, -1}},
+		{PositiveY, .5, 0, glm.Vec3{0, 1, -1}},
+		{NegativeY, .5, 0, glm.Vec3{0, -1, 1}},
+		{PositiveZ, 0, 0, glm.Vec3{-1, 1, 1}},
+		{PositiveX, 1, 1, glm.Vec3{1, -1, -1}},
+		{NegativeX, 1, .5, glm.Vec3{-1, 0, 1}},
+	}
+	for _, tt := range tests {
+		if got := Direction(tt.f, tt.u, tt.v); !got.ApproxEqual(tt.want) {
+			t.Errorf("face %d at %v, %v: %v, want %v", tt.f, tt.u, tt.v, got, tt.want)
+		}
+	}
+
+	for f := PositiveX; f <= NegativeZ; f++ {
+		for _, uv := range [][2]float32{{.5, .5}, {.1, .2}, {.9, .3}, {.25, .95}} {
+			d := Direction(f, uv[0], uv[1]).Normalize()
+			g, u, v := Lookup(d)
+			if g != f || !near(u, uv[0], 1e-5) || !near(v, uv[1], 1e-5) {
+				t.Errorf("face %d at %v: looked up face %d at %v, %v", f, uv, g, u, v)
+			}
+		}
+	}
+}
+
+func TestEquirectUV(t *testing.T) {
+	for _, uv := range [][2]float32{{.5, .5}, {.1, .2}, {.75, .5}, {.99, .9}} {
+		d := EquirectDirection(uv[0], uv[1])
+		if !near(d.Len(), 1, 1e-5) {
+			t.Errorf("%v: direction %v is not unit", uv, d)
+		}
+		if u, v := EquirectUV(d); !near(u, uv[0], 1e-5) || !near(v, uv[1], 1e-5) {
+			t.Errorf("%v: round trip gives %v, %v", uv, u, v)
+		}
+	}
+	if d := EquirectDirection(.75, .5); !d.ApproxEqual(glm.Vec3{1, 0, 0}) {
+		t.Errorf("u 0.75 looks at %v", d)
+	}
+}
+
+// gradient is a smooth environment: the direction mapped to [0, 1].
+func gradient(d glm.Vec3) [4]float32 {
+	d = d.Normalize()
+	return [4]float32{d[0]*.5 + .5, d[1]*.5 + .5, d[2]*.5 + .5, 1}
+}
+
+func TestEquirectConversion(t *testing.T) {
+	w, h := 256, 128
+	pano := texture.NewFloat(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			pano.Set(x, y, gradient(EquirectDirection((float32(x)+.5)/float32(w), (float32(y)+.5)/float32(h))))
+		}
+	}
+
+	c := FromEquirect(pano, 32)
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for f := range c.Faces {
+		for y := 0; y < c.Size; y += 3 {
+			for x := 0; x < c.Size; x += 3 {
+				want := gradient(c.texel(Face(f), x, y))
+				got := c.Faces[f].At(x, y)
+				for k := range got {
+					if !near(got[k], want[k], .02) {
+						t.Fatalf("face %d (%d, %d): %v, want %v", f, x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+
+	back := c.Equirect(w, h)
+	for y := 0; y < h; y += 5 {
+		for x := 0; x < w; x += 5 {
+			got, want := back.At(x, y), pano.At(x, y)
+			for k := range got {
+				if !near(got[k], want[k], .03) {
+					t.Fatalf("(%d, %d): %v, want %v", x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestFromImages(t *testing.T) {
+	var faces [6]*texture.FloatImage
+	for i := range faces {
+		faces[i] = texture.NewFloat(4, 4)
+	}
+	if _, err := FromImages(faces); err != nil {
+		t.Fatal(err)
+	}
+	faces[3] = texture.NewFloat(4, 2)
+	if _, err := FromImages(faces); err == nil {
+		t.Error("accepted a face that is not square")
+	}
+	faces[3] = nil
+	if _, err := FromImages(faces); err == nil {
+		t.Error("accepted a missing face")
+	}
+
+	m := texture.New(1, 1)
+	m.Pix = []byte{255, 188, 0, 51}
+	m.SRGB = true
+	if got := Linear(m).At(0, 0); !near(got[0], 1, 1e-6) || !near(got[1], texture.SRGBToLinear(188./255), 1e-6) || !near(got[3], .2, 1e-6) {
+		t.Errorf("linear %v", got)
+	}
+}
+
+// fill sets every texel of c to f of its direction.
+func fill(c *Cube, f func(d glm.Vec3) [4]float32) {
+	for i := range c.Faces {
+		for y := 0; y < c.Size; y++ {
+			for x := 0; x < c.Size; x++ {
+				c.Faces[i].Set(x, y, f(c.texel(Face(i), x, y).Normalize()))
+			}
+		}
+	}
+}
+
+func TestSH(t *testing.T) {
+	// a constant sky: the cosine weighted mean is the radiance
+	c := New(16)
+	fill(c, func(glm.Vec3) [4]float32 { return [4]float32{1, 2, 3, 1} })
+	sh := ProjectSH(c)
+	for _, n := range []glm.Vec3{{1, 0, 0}, {0, -1, 0}, glm.Vec3{1, 1, 1}.Normalize()} {
+		if got := sh.Irradiance(n); !got.ApproxEqual(glm.Vec3{1, 2, 3}) {
+			t.Errorf("constant: irradiance at %v is %v", n, got)
+		}
+	}
+
+	// radiance linear in y lies in bands 0 and 1, which the cosine lobe
+	// scales by 2/3: E/pi = 1 + 2/3 * 0.5 * n.y
+	fill(c, func(d glm.Vec3) [4]float32 { v := 1 + .5*d[1]; return [4]float32{v, v, v, 1} })
+	sh = ProjectSH(c)
+	for _, n := range []glm.Vec3{{0, 1, 0}, {0, -1, 0}, {1, 0, 0}, glm.Vec3{0, 1, 1}.Normalize()} {
+		want := 1 + .5*n[1]*2/3
+		if got := sh.Irradiance(n); !near(got[0], want, 1e-3) {
+			t.Errorf("linear: irradiance at %v is %v, want %v", n, got[0], want)
+		}
+	}
+
+	irr := sh.Cube(4)
+	if got := irr.Faces[PositiveY].At(1, 1); got[0] <= irr.Faces[NegativeY].At(1, 1)[0] || got[3] != 1 {
+		t.Errorf("irradiance cube: up %v, down %v", got, irr.Faces[NegativeY].At(1, 1))
+	}
+
+	// the texel solid angles cover the sphere
+	var total float32
+	for y := 0; y < 7; y++ {
+		for x := 0; x < 7; x++ {
+			total += 6 * solidAngle(x, y, 7)
+		}
+	}
+	if !near(total, 4*m32.Pi, 1e-4) {
+		t.Errorf("solid angles add up to %v", total)
+	}
+}
+
+func TestPrefilter(t *testing.T) {
+	// a constant environment stays constant at every roughness
+	c := New(16)
+	fill(c, func(glm.Vec3) [4]float32 { return [4]float32{.5, 1, 2, 1} })
+	levels := Prefilter(c, 5, 32)
+	if len(levels) != 5 {
+		t.Fatalf("%d levels", len(levels))
+	}
+	for i, l := range levels {
+		if want := 16 >> i; l.Size != want {
+			t.Errorf("level %d is %d wide, want %d", i, l.Size, want)
+		}
+		for f := range l.Faces {
+			if got := l.Faces[f].At(0, l.Size-1); !near(got[0], .5, 1e-4) || !near(got[2], 2, 1e-4) {
+				t.Errorf("level %d face %d: %v", i, f, got)
+			}
+		}
+	}
+	if Roughness(0, 5) != 0 || Roughness(4, 5) != 1 || Roughness(0, 1) != 0 {
+		t.Error("roughness does not span [0, 1]")
+	}
+
+	// light from above spreads out with roughness
+	fill(c, func(d glm.Vec3) [4]float32 {
+		if d[1] > .9 {
+			return [4]float32{1, 1, 1, 1}
+		}
+		return [4]float32{0, 0, 0, 1}
+	})
+	levels = Prefilter(c, 4, 128)
+	up, side := glm.Vec3{0, 1, 0}, glm.Vec3{1, .5, 0}.Normalize()
+	prevUp, prevSide := float32(2), float32(-1)
+	for i, l := range levels {
+		u, s := l.Sample(up)[0], l.Sample(side)[0]
+		if u > prevUp+1e-3 || s < prevSide-1e-3 {
+			t.Errorf("level %d: up %v side %v after %v, %v", i, u, s, prevUp, prevSide)
+		}
+		prevUp, prevSide = u, s
+	}
+	if prevSide <= 0 {
+		t.Error("the roughest level does not spread the light sideways")
+	}
+}
+
+func TestSkybox(t *testing.T) {
+	if err := SkyboxLayout.Validate(SkyboxVertexSource); err != nil {
+		t.Fatal(err)
+	}
+	proj := glm.Perspect(glm.Rad(60), 1, .1, 100)
+	at := func(eye glm.Vec3) glm.Mat4 {
+		return SkyboxViewProjection(glm.LookAt(eye, eye.Add(glm.Vec3{0, 0, -1}), glm.Vec3{0, 1, 0}), proj)
+	}
+	a, b := at(glm.Vec3{}), at(glm.Vec3{5, -3, 40})
+	if !a.ApproxEqual(b) {
+		t.Errorf("translation moves the sky:\n%v\n%v", a, b)
+	}
+	// the sky in front of the camera lands inside the clip volume
+	p := a.Mulv(glm.Vec4{.2, .1, -1, 1})
+	if p[3] <= 0 || m32.Abs(p[0]) > p[3] || m32.Abs(p[1]) > p[3] {
+		t.Errorf("forward projects to %v", p)
+	}
+}
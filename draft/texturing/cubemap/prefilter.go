@@ -0,0 +1,125 @@
+package cubemap
+
+import (
+	"math/bits"
+
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// hammersley is point i of n of the Hammersley set in [0, 1)^2.
+func hammersley(i, n int) (float32, float32) {
+	return float32(i) / float32(n), float32(bits.Reverse32(uint32(i))) / (1 << 32)
+}
+
+// basis returns two unit vectors completing n to an orthonormal basis.
+func basis(n glm.Vec3) (glm.Vec3, glm.Vec3) {
+	up := glm.Vec3{0, 0, 1}
+	if m32.Abs(n[2]) > .999 {
+		up = glm.Vec3{1, 0, 0}
+	}
+	t := up.Cross(n).Normalize()
+	return t, n.Cross(t)
+}
+
+// ggx is the GGX distribution of the half vector at cos(theta) nh for
+// alpha = roughness^2.
+func ggx(nh, alpha float32) float32 {
+	a2 := alpha * alpha
+	d := nh*nh*(a2-1) + 1
+	return a2 / (m32.Pi * d * d)
+}
+
+// Roughness is the roughness Prefilter stores in level i of n.
+func Roughness(i, n int) float32 {
+	if n <= 1 {
+		return 0
+	}
+	return float32(i) / float32(n-1)
+}
+
+// Prefilter convolves c with the GGX lobe for the split sum approximation
+// of specular image based lighting. Level i of the n returned is half the
+// size of the one before, from c's size, and filtered for Roughness(i, n);
+// level 0 is a copy of c. Each texel importance samples the lobe with
+// samples directions, reading from a box filtered mip chain of c by the
+// solid angle of each sample to keep fireflies down. Like most real time
+// prefiltering it assumes the view direction is the normal.
+func Prefilter(c *Cube, n, samples int) []*Cube {
+	chain := []*Cube{c}
+	for chain[len(chain)-1].Size > 1 {
+		chain = append(chain, chain[len(chain)-1].Downsample())
+	}
+	texelAngle := 4 * m32.Pi / float32(6*c.Size*c.Size)
+
+	levels := make([]*Cube, n)
+	for i := range levels {
+		size := c.Size >> i
+		if size < 1 {
+			size = 1
+		}
+		out := New(size)
+		levels[i] = out
+		rough := Roughness(i, n)
+		if rough == 0 && size == c.Size {
+			for f := range out.Faces {
+				copy(out.Faces[f].Pix, c.Faces[f].Pix)
+			}
+			continue
+		}
+		alpha := rough * rough
+		if alpha < 1e-3 {
+			alpha = 1e-3
+		}
+		for f := range out.Faces {
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					nrm := out.texel(Face(f), x, y).Normalize()
+					t, b := basis(nrm)
+					var sum glm.Vec3
+					var weight float32
+					for k := 0; k < samples; k++ {
+						// sample a half vector of the lobe around n = v
+						u1, u2 := hammersley(k, samples)
+						phi := 2 * m32.Pi * u1
+						cos := m32.Sqrt((1 - u2) / (1 + (alpha*alpha-1)*u2))
+						sin := m32.Sqrt(1 - cos*cos)
+						h := t.Scale(sin * m32.Cos(phi)).Add(b.Scale(sin * m32.Sin(phi))).Add(nrm.Scale(cos))
+						l := h.Scale(2 * nrm.Dot(h)).Sub(nrm)
+						nl := nrm.Dot(l)
+						if nl <= 0 {
+							continue
+						}
+						// with n = v the pdf of l is D / 4
+						sampleAngle := 1 / (float32(samples) * ggx(cos, alpha) / 4)
+						lod := .5 * m32.Log2(sampleAngle/texelAngle)
+						p := sampleChain(chain, l, lod)
+						sum = sum.Add(glm.Vec3{p[0], p[1], p[2]}.Scale(nl))
+						weight += nl
+					}
+					if weight > 0 {
+						sum = sum.Scale(1 / weight)
+					}
+					out.Faces[f].Set(x, y, [4]float32{sum[0], sum[1], sum[2], 1})
+				}
+			}
+		}
+	}
+	return levels
+}
+
+// sampleChain filters a mip chain trilinearly at level lod.
+func sampleChain(chain []*Cube, d glm.Vec3, lod float32) glm.Vec4 {
+	max := float32(len(chain) - 1)
+	switch {
+	case lod <= 0:
+		return chain[0].Sample(d)
+	case lod >= max:
+		return chain[len(chain)-1].Sample(d)
+	}
+	i := int(lod)
+	t := lod - float32(i)
+	a, b := chain[i].Sample(d), chain[i+1].Sample(d)
+	return a.Lerp(b, t)
+}
@@ -0,0 +1,98 @@
+package cubemap
+
+import (
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// SH holds the nine RGB coefficients of second order (L2) spherical
+// harmonics, bands 0, 1 and 2 in the order Y00, Y1-1, Y10, Y11, Y2-2, Y2-1,
+// Y20, Y21, Y22.
+type SH [9]glm.Vec3
+
+// shBasis evaluates the real SH basis in unit direction d.
+func shBasis(d glm.Vec3) [9]float32 {
+	x, y, z := d[0], d[1], d[2]
+	return [9]float32{
+		.282095,
+		.488603 * y,
+		.488603 * z,
+		.488603 * x,
+		1.092548 * x * y,
+		1.092548 * y * z,
+		.315392 * (3*z*z - 1),
+		1.092548 * x * z,
+		.546274 * (x*x - y*y),
+	}
+}
+
+// shBands are the cosine lobe convolution factors of each coefficient,
+// pi, 2pi/3 and pi/4 by band, divided by pi so Irradiance is a radiance.
+var shBands = [9]float32{1, 2. / 3, 2. / 3, 2. / 3, .25, .25, .25, .25, .25}
+
+// texelArea is the solid angle of the face rectangle from the center to
+// x, y in [-1, 1] face coordinates.
+func texelArea(x, y float32) float32 {
+	return m32.Atan2(x*y, m32.Sqrt(x*x+y*y+1))
+}
+
+// solidAngle is the solid angle texel x, y of a face of size n covers.
+func solidAngle(x, y, n int) float32 {
+	inv := 1 / float32(n)
+	x0, y0 := 2*float32(x)*inv-1, 2*float32(y)*inv-1
+	x1, y1 := x0+2*inv, y0+2*inv
+	return texelArea(x0, y0) - texelArea(x0, y1) - texelArea(x1, y0) + texelArea(x1, y1)
+}
+
+// ProjectSH projects the radiance of c onto SH, weighting every texel by
+// its solid angle.
+func ProjectSH(c *Cube) SH {
+	var sh SH
+	var total float32
+	for f, face := range c.Faces {
+		for y := 0; y < c.Size; y++ {
+			for x := 0; x < c.Size; x++ {
+				w := solidAngle(x, y, c.Size)
+				total += w
+				p := face.At(x, y)
+				for i, b := range shBasis(c.texel(Face(f), x, y).Normalize()) {
+					for k := 0; k < 3; k++ {
+						sh[i][k] += p[k] * b * w
+					}
+				}
+			}
+		}
+	}
+	// the texel areas add up to 4pi up to rounding; keep the sum exact
+	for i := range sh {
+		sh[i] = sh[i].Scale(4 * m32.Pi / total)
+	}
+	return sh
+}
+
+// Irradiance is the irradiance around unit normal n divided by pi: the
+// cosine weighted mean radiance, so a Lambertian surface of albedo a
+// reflects a times it. Values below zero from ringing are clamped.
+func (sh *SH) Irradiance(n glm.Vec3) glm.Vec3 {
+	var e glm.Vec3
+	for i, b := range shBasis(n) {
+		e = e.Add(sh[i].Scale(shBands[i] * b))
+	}
+	return e.Max(glm.Vec3{})
+}
+
+// Cube renders Irradiance into a cube of the given face size, for shaders
+// that sample an irradiance map instead of evaluating SH.
+func (sh *SH) Cube(size int) *Cube {
+	c := New(size)
+	for f := range c.Faces {
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				e := sh.Irradiance(c.texel(Face(f), x, y).Normalize())
+				c.Faces[f].Set(x, y, [4]float32{e[0], e[1], e[2], 1})
+			}
+		}
+	}
+	return c
+}
@@ -0,0 +1,169 @@
+package cubemap
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+
+	"github.com/pgeowng/rende/draft/texturing/device"
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/mesh"
+	"github.com/pgeowng/rende/draft/texturing/shader"
+	"github.com/pgeowng/rende/draft/texturing/tonemap"
+)
+
+// SkyboxLayout is the vertex of the skybox cube: positions only.
+var SkyboxLayout = device.NewVertexLayout(
+	device.Attribute{Name: "aPos", Location: 0, Size: 3},
+)
+
+// SkyboxVertexSource draws the cube around the camera at the far plane:
+// writing w as z puts every fragment at depth 1.
+const SkyboxVertexSource = `#version 330 core
+
+layout (location = 0) in vec3 aPos;
+
+out vec3 Direction;
+
+uniform mat4 viewProjection;
+
+void main()
+{
+  Direction = aPos;
+  gl_Position = (viewProjection * vec4(aPos, 1.0)).xyww;
+}
+`
+
+// SkyboxFragmentSource samples the environment at level lod and tone maps
+// it as the tonemap pass does.
+var SkyboxFragmentSource = `#version 330 core
+
+in vec3 Direction;
+out vec4 FragColor;
+
+uniform samplerCube environment;
+uniform float lod;
+uniform int tonemapOperator;
+uniform float exposure;
+
+` + tonemap.Source + `
+void main()
+{
+  vec3 c = textureLod(environment, Direction, lod).rgb;
+  FragColor = vec4(linearToSRGB(tonemap(c, tonemapOperator, exposure)), 1.0);
+}
+`
+
+// SkyboxViewProjection drops the translation of view so the sky stays
+// around the camera, and projects with projection, as built by
+// glm.Perspect.
+func SkyboxViewProjection(view, projection glm.Mat4) glm.Mat4 {
+	return projection.Times(view.Mat3().Mat4())
+}
+
+// Skybox draws a cube map behind the scene. Draw it after the opaque
+// geometry so the depth test discards the covered sky.
+type Skybox struct {
+	program  uint32
+	vao, vbo uint32
+	ebo      uint32
+	count    int32
+	// uniform locations
+	viewProjection, environment, lod, operator, exposure int32
+
+	// Operator and Exposure tone map the sky; LOD picks the mip level, to
+	// show a blurred prefiltered level behind the scene.
+	Operator tonemap.Operator
+	Exposure float32
+	LOD      float32
+}
+
+// NewSkybox compiles the skybox program and uploads its cube. It must run
+// on the thread owning the context.
+func NewSkybox() (*Skybox, error) {
+	prog, err := shader.Link(SkyboxVertexSource, SkyboxFragmentSource)
+	if err != nil {
+		return nil, err
+	}
+	s := &Skybox{program: prog}
+	uniform := func(name string) int32 {
+		return gl.GetUniformLocation(prog, gl.Str(name+"\x00"))
+	}
+	s.viewProjection = uniform("viewProjection")
+	s.environment = uniform("environment")
+	s.lod = uniform("lod")
+	s.operator = uniform("tonemapOperator")
+	s.exposure = uniform("exposure")
+
+	m := mesh.Cube(2)
+	positions := make([]float32, 0, 3*len(m.Positions))
+	for _, p := range m.Positions {
+		positions = append(positions, p[:]...)
+	}
+	s.count = int32(len(m.Indices))
+
+	gl.GenVertexArrays(1, &s.vao)
+	gl.BindVertexArray(s.vao)
+	gl.GenBuffers(1, &s.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(positions), gl.Ptr(positions), gl.STATIC_DRAW)
+	SkyboxLayout.Enable()
+	gl.GenBuffers(1, &s.ebo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, s.ebo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*len(m.Indices), gl.Ptr(m.Indices), gl.STATIC_DRAW)
+	gl.BindVertexArray(0)
+	return s, nil
+}
+
+// Draw draws the cube map texture, as Upload returns it, seen through view
+// and projection. The depth test passes at the far plane without writing
+// depth, faces are not culled since the camera is inside the cube, and
+// seamless filtering blends prefiltered levels across face edges. The depth
+// test, function and mask, culling and seamless filtering are restored
+// afterwards.
+func (s *Skybox) Draw(texture uint32, view, projection glm.Mat4) {
+	var depthFunc int32
+	var depthMask bool
+	gl.GetIntegerv(gl.DEPTH_FUNC, &depthFunc)
+	gl.GetBooleanv(gl.DEPTH_WRITEMASK, &depthMask)
+	depthTest := gl.IsEnabled(gl.DEPTH_TEST)
+	cull := gl.IsEnabled(gl.CULL_FACE)
+	seamless := gl.IsEnabled(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+	gl.Enable(gl.DEPTH_TEST)
+	gl.DepthFunc(gl.LEQUAL)
+	gl.DepthMask(false)
+	gl.Disable(gl.CULL_FACE)
+	gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+
+	vp := SkyboxViewProjection(view, projection)
+	gl.UseProgram(s.program)
+	gl.UniformMatrix4fv(s.viewProjection, 1, false, &vp[0])
+	gl.Uniform1i(s.environment, 0)
+	gl.Uniform1f(s.lod, s.LOD)
+	gl.Uniform1i(s.operator, int32(s.Operator))
+	gl.Uniform1f(s.exposure, s.Exposure)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, texture)
+
+	gl.BindVertexArray(s.vao)
+	gl.DrawElements(gl.TRIANGLES, s.count, gl.UNSIGNED_INT, nil)
+	gl.BindVertexArray(0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+
+	gl.DepthMask(depthMask)
+	gl.DepthFunc(uint32(depthFunc))
+	if !depthTest {
+		gl.Disable(gl.DEPTH_TEST)
+	}
+	if cull {
+		gl.Enable(gl.CULL_FACE)
+	}
+	if !seamless {
+		gl.Disable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+	}
+}
+
+func (s *Skybox) Delete() {
+	gl.DeleteProgram(s.program)
+	gl.DeleteBuffers(1, &s.vbo)
+	gl.DeleteBuffers(1, &s.ebo)
+	gl.DeleteVertexArrays(1, &s.vao)
+}
@@ -0,0 +1,121 @@
+package device
+
+import (
+	"image"
+	"unsafe"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/texture"
+)
+
+// Device is the set of GPU operations the renderer needs. GL wraps the raw
+// OpenGL 3.3 calls the demos make; Recorder implements it without a context
+// so draw sequences can be asserted in tests.
+type Device interface {
+	NewBuffer(kind BufferKind, data []byte) (Buffer, error)
+	// NewVertexArray reads vertices from a vertex buffer as described by
+	// layout. indices may be nil for non-indexed drawing.
+	NewVertexArray(vertices, indices Buffer, layout VertexLayout) (VertexArray, error)
+	NewTexture(img *image.RGBA, s Sampler) (Texture, error)
+	NewProgram(vertexSource, fragmentSource string) (Program, error)
+	// NewFramebuffer creates a framebuffer with a color and a depth
+	// attachment and leaves the current framebuffer bindings as they were.
+	NewFramebuffer(width, height int) (Framebuffer, error)
+
+	// BindFramebuffer directs rendering to fb, or to the window when fb is
+	// nil. It replaces both the draw and the read binding.
+	BindFramebuffer(fb Framebuffer)
+	Viewport(x, y, width, height int)
+	Clear(c glm.Vec4)
+	Draw(d DrawCall)
+}
+
+type Buffer interface {
+	Delete()
+}
+
+type VertexArray interface {
+	Delete()
+}
+
+type Texture interface {
+	Delete()
+}
+
+type Program interface {
+	Delete()
+}
+
+type Framebuffer interface {
+	Delete()
+	// Read copies the color attachment into an image, top row first. The
+	// read binding and the pack alignment are restored afterwards.
+	Read() *image.RGBA
+}
+
+type BufferKind int
+
+const (
+	VertexBuffer BufferKind = iota
+	IndexBuffer
+)
+
+// The sampler state is shared with the texture package.
+type (
+	Wrap    = texture.Wrap
+	Filter  = texture.Filter
+	Sampler = texture.Sampler
+)
+
+const (
+	Repeat         = texture.Repeat
+	ClampToEdge    = texture.ClampToEdge
+	MirroredRepeat = texture.MirroredRepeat
+
+	Linear  = texture.Linear
+	Nearest = texture.Nearest
+)
+
+type Primitive int
+
+const (
+	Triangles Primitive = iota
+	Lines
+	Points
+)
+
+// Uniform is a named value set on the program before drawing. Value may be an
+// int32, float32, glm.Vec2, glm.Vec3, glm.Vec4, glm.Mat3 or glm.Mat4.
+type Uniform struct {
+	Name  string
+	Value interface{}
+}
+
+type DrawCall struct {
+	Program  Program
+	Vertices VertexArray
+	// Textures are bound to units 0, 1, ... in order.
+	Textures  []Texture
+	Uniforms  []Uniform
+	Primitive Primitive
+	// Count is the number of indices, or vertices for a layout without an
+	// index buffer.
+	Count     int
+	DepthTest bool
+}
+
+// Float32s returns the bytes of fs without copying.
+func Float32s(fs []float32) []byte {
+	if len(fs) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&fs[0])), 4*len(fs))
+}
+
+// Uint32s returns the bytes of us without copying.
+func Uint32s(us []uint32) []byte {
+	if len(us) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&us[0])), 4*len(us))
+}
@@ -0,0 +1,285 @@
+package device
+
+import (
+	"fmt"
+	"image"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/shader"
+	"github.com/pgeowng/rende/draft/texturing/texture"
+)
+
+// GL is the OpenGL 3.3 core device. gl.Init must have been called with a
+// current context, and every method must run on the thread owning it.
+type GL struct {
+	// locations caches uniform locations per program.
+	locations map[uint32]map[string]int32
+}
+
+func NewGL() *GL {
+	return &GL{locations: make(map[uint32]map[string]int32)}
+}
+
+type glBuffer struct {
+	id     uint32
+	target uint32
+}
+
+func (b *glBuffer) Delete() {
+	gl.DeleteBuffers(1, &b.id)
+}
+
+func (d *GL) NewBuffer(kind BufferKind, data []byte) (Buffer, error) {
+	b := &glBuffer{target: gl.ARRAY_BUFFER}
+	if kind == IndexBuffer {
+		b.target = gl.ELEMENT_ARRAY_BUFFER
+	}
+	gl.GenBuffers(1, &b.id)
+	gl.BindBuffer(b.target, b.id)
+	var ptr unsafe.Pointer
+	if len(data) > 0 {
+		ptr = gl.Ptr(data)
+	}
+	gl.BufferData(b.target, len(data), ptr, gl.STATIC_DRAW)
+	gl.BindBuffer(b.target, 0)
+	return b, nil
+}
+
+type glVertexArray struct {
+	vao     uint32
+	indexed bool
+}
+
+func (a *glVertexArray) Delete() {
+	gl.DeleteVertexArrays(1, &a.vao)
+}
+
+// Enable points the attributes of l at the buffer bound to GL_ARRAY_BUFFER
+// and enables them in the bound vertex array.
+func (l VertexLayout) Enable() {
+	for _, a := range l.Attributes {
+		gl.VertexAttribPointerWithOffset(a.Location, int32(a.Size), gl.FLOAT, false, int32(l.Stride), uintptr(a.Offset))
+		gl.EnableVertexAttribArray(a.Location)
+	}
+}
+
+func (d *GL) NewVertexArray(vertices, indices Buffer, layout VertexLayout) (VertexArray, error) {
+	vb, ok := vertices.(*glBuffer)
+	if !ok || vb.target != gl.ARRAY_BUFFER {
+		return nil, fmt.Errorf("vertex array: %T is not a GL vertex buffer", vertices)
+	}
+	a := &glVertexArray{}
+	gl.GenVertexArrays(1, &a.vao)
+	gl.BindVertexArray(a.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vb.id)
+	layout.Enable()
+	if indices != nil {
+		ib, ok := indices.(*glBuffer)
+		if !ok || ib.target != gl.ELEMENT_ARRAY_BUFFER {
+			gl.BindVertexArray(0)
+			gl.DeleteVertexArrays(1, &a.vao)
+			return nil, fmt.Errorf("vertex array: %T is not a GL index buffer", indices)
+		}
+		// the element buffer binding is part of the VAO state
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ib.id)
+		a.indexed = true
+	}
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return a, nil
+}
+
+type glTexture struct {
+	id uint32
+}
+
+func (t *glTexture) Delete() {
+	gl.DeleteTextures(1, &t.id)
+}
+
+func (d *GL) NewTexture(img *image.RGBA, s Sampler) (Texture, error) {
+	b := img.Bounds()
+	if img.Stride != 4*b.Dx() {
+		return nil, fmt.Errorf("texture: unsupported stride %d for width %d", img.Stride, b.Dx())
+	}
+
+	m, err := texture.FromRGBA(img)
+	if err != nil {
+		return nil, err
+	}
+	id, err := texture.Upload(m, s)
+	if err != nil {
+		return nil, err
+	}
+	return &glTexture{id: id}, nil
+}
+
+type glProgram struct {
+	id uint32
+	gl *GL
+}
+
+func (p *glProgram) Delete() {
+	gl.DeleteProgram(p.id)
+	delete(p.gl.locations, p.id)
+}
+
+func (d *GL) NewProgram(vertexSource, fragmentSource string) (Program, error) {
+	id, err := shader.Link(vertexSource, fragmentSource)
+	if err != nil {
+		return nil, err
+	}
+	d.locations[id] = make(map[string]int32)
+	return &glProgram{id: id, gl: d}, nil
+}
+
+type glFramebuffer struct {
+	fbo, color, depth uint32
+	width, height     int
+}
+
+func (f *glFramebuffer) Delete() {
+	gl.DeleteFramebuffers(1, &f.fbo)
+	gl.DeleteTextures(1, &f.color)
+	gl.DeleteRenderbuffers(1, &f.depth)
+}
+
+func (f *glFramebuffer) Read() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, f.width, f.height))
+	var bound, align int32
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &bound)
+	gl.GetIntegerv(gl.PACK_ALIGNMENT, &align)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, f.fbo)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, int32(f.width), int32(f.height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	gl.PixelStorei(gl.PACK_ALIGNMENT, align)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(bound))
+
+	// GL rows start at the bottom
+	row := make([]byte, img.Stride)
+	for y := 0; y < f.height/2; y++ {
+		a := img.Pix[y*img.Stride : (y+1)*img.Stride]
+		b := img.Pix[(f.height-1-y)*img.Stride : (f.height-y)*img.Stride]
+		copy(row, a)
+		copy(a, b)
+		copy(b, row)
+	}
+	return img
+}
+
+func (d *GL) NewFramebuffer(width, height int) (Framebuffer, error) {
+	// only the draw binding is used, so reads from another framebuffer
+	// are not disturbed
+	var fbo, tex, rbo int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &fbo)
+	gl.GetIntegerv(gl.TEXTURE_BINDING_2D, &tex)
+	gl.GetIntegerv(gl.RENDERBUFFER_BINDING, &rbo)
+
+	f := &glFramebuffer{width: width, height: height}
+	gl.GenFramebuffers(1, &f.fbo)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, f.fbo)
+
+	gl.GenTextures(1, &f.color)
+	gl.BindTexture(gl.TEXTURE_2D, f.color)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.FramebufferTexture2D(gl.DRAW_FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, f.color, 0)
+	gl.BindTexture(gl.TEXTURE_2D, uint32(tex))
+
+	gl.GenRenderbuffers(1, &f.depth)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, f.depth)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT24, int32(width), int32(height))
+	gl.FramebufferRenderbuffer(gl.DRAW_FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, f.depth)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, uint32(rbo))
+
+	status := gl.CheckFramebufferStatus(gl.DRAW_FRAMEBUFFER)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, uint32(fbo))
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		f.Delete()
+		return nil, fmt.Errorf("framebuffer: incomplete, status 0x%x", status)
+	}
+	return f, nil
+}
+
+func (d *GL) BindFramebuffer(fb Framebuffer) {
+	if fb == nil {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		return
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fb.(*glFramebuffer).fbo)
+}
+
+func (d *GL) Viewport(x, y, width, height int) {
+	gl.Viewport(int32(x), int32(y), int32(width), int32(height))
+}
+
+func (d *GL) Clear(c glm.Vec4) {
+	gl.ClearColor(c[0], c[1], c[2], c[3])
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+}
+
+var glPrimitive = map[Primitive]uint32{
+	Triangles: gl.TRIANGLES,
+	Lines:     gl.LINES,
+	Points:    gl.POINTS,
+}
+
+func (d *GL) uniform(p *glProgram, name string) int32 {
+	cache := d.locations[p.id]
+	loc, ok := cache[name]
+	if !ok {
+		loc = gl.GetUniformLocation(p.id, gl.Str(name+"\x00"))
+		cache[name] = loc
+	}
+	return loc
+}
+
+func (d *GL) Draw(dc DrawCall) {
+	p := dc.Program.(*glProgram)
+	va := dc.Vertices.(*glVertexArray)
+
+	if dc.DepthTest {
+		gl.Enable(gl.DEPTH_TEST)
+	} else {
+		gl.Disable(gl.DEPTH_TEST)
+	}
+
+	gl.UseProgram(p.id)
+	for _, u := range dc.Uniforms {
+		loc := d.uniform(p, u.Name)
+		switch v := u.Value.(type) {
+		case int32:
+			gl.Uniform1i(loc, v)
+		case float32:
+			gl.Uniform1f(loc, v)
+		case glm.Vec2:
+			gl.Uniform2fv(loc, 1, &v[0])
+		case glm.Vec3:
+			gl.Uniform3fv(loc, 1, &v[0])
+		case glm.Vec4:
+			gl.Uniform4fv(loc, 1, &v[0])
+		case glm.Mat3:
+			gl.UniformMatrix3fv(loc, 1, false, &v[0])
+		case glm.Mat4:
+			gl.UniformMatrix4fv(loc, 1, false, &v[0])
+		default:
+			panic(fmt.Sprintf("device: unsupported uniform %s of type %T", u.Name, u.Value))
+		}
+	}
+	for i, t := range dc.Textures {
+		gl.ActiveTexture(gl.TEXTURE0 + uint32(i))
+		gl.BindTexture(gl.TEXTURE_2D, t.(*glTexture).id)
+	}
+
+	gl.BindVertexArray(va.vao)
+	if va.indexed {
+		gl.DrawElements(glPrimitive[dc.Primitive], int32(dc.Count), gl.UNSIGNED_INT, nil)
+	} else {
+		gl.DrawArrays(glPrimitive[dc.Primitive], 0, int32(dc.Count))
+	}
+	gl.BindVertexArray(0)
+}
@@ -0,0 +1,227 @@
+package device
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Attribute is one float vertex attribute. Size is the number of floats and
+// Offset is in bytes from the start of the vertex. Name is optional; when set
+// it must match the shader input at Location.
+type Attribute struct {
+	Name     string
+	Location uint32
+	Size     int
+	Offset   int
+}
+
+// VertexLayout describes an interleaved vertex of float attributes.
+type VertexLayout struct {
+	Attributes []Attribute
+	// Stride is the size of one vertex in bytes.
+	Stride int
+}
+
+const f32size = 4
+
+// NewVertexLayout packs attrs one after another in the given order, ignoring
+// their Offset.
+func NewVertexLayout(attrs ...Attribute) VertexLayout {
+	l := VertexLayout{Attributes: make([]Attribute, len(attrs))}
+	for i, a := range attrs {
+		a.Offset = l.Stride
+		l.Attributes[i] = a
+		l.Stride += f32size * a.Size
+	}
+	return l
+}
+
+// PositionColor is an untextured vertex of a position and a color.
+var PositionColor = NewVertexLayout(
+	Attribute{Name: "aPos", Location: 0, Size: 3},
+	Attribute{Name: "aColor", Location: 1, Size: 3},
+)
+
+// PositionColorTexCoord is the vertex of draft/texturing and draft/proj.
+var PositionColorTexCoord = NewVertexLayout(
+	Attribute{Name: "aPos", Location: 0, Size: 3},
+	Attribute{Name: "aColor", Location: 1, Size: 3},
+	Attribute{Name: "aTexCoord", Location: 2, Size: 2},
+)
+
+// LayoutOf builds the layout of a struct from its exported fields tagged
+// `vertex:"location"` or `vertex:"location,name"`. Fields may be float32, an
+// array of float32 or a named type of either, like glm.Vec3. The offsets are
+// those of the Go struct, so PackStructs can copy it field by field.
+func LayoutOf(v interface{}) (VertexLayout, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return VertexLayout{}, fmt.Errorf("vertex layout: %v is not a struct", t)
+	}
+
+	l := VertexLayout{Stride: int(t.Size())}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("vertex")
+		if !ok {
+			continue
+		}
+		locTag, name, _ := strings.Cut(tag, ",")
+		loc, err := strconv.ParseUint(locTag, 10, 32)
+		if err != nil {
+			return VertexLayout{}, fmt.Errorf("vertex layout: %s.%s: bad location %q", t.Name(), f.Name, locTag)
+		}
+		size, ok := floats(f.Type)
+		if !ok {
+			return VertexLayout{}, fmt.Errorf("vertex layout: %s.%s: %v is not float32 or an array of float32", t.Name(), f.Name, f.Type)
+		}
+		l.Attributes = append(l.Attributes, Attribute{
+			Name:     name,
+			Location: uint32(loc),
+			Size:     size,
+			Offset:   int(f.Offset),
+		})
+	}
+	if len(l.Attributes) == 0 {
+		return VertexLayout{}, fmt.Errorf("vertex layout: %s has no vertex fields", t.Name())
+	}
+	return l, nil
+}
+
+func floats(t reflect.Type) (int, bool) {
+	switch t.Kind() {
+	case reflect.Float32:
+		return 1, true
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Float32 && t.Len() > 0 {
+			return t.Len(), true
+		}
+	}
+	return 0, false
+}
+
+// Pack checks that vertices holds whole vertices of l, with no padding
+// between attributes, and returns their bytes.
+func (l VertexLayout) Pack(vertices []float32) ([]byte, error) {
+	n := l.Stride / f32size
+	if n == 0 || l.Stride%f32size != 0 {
+		return nil, fmt.Errorf("vertex layout: stride %d is not a whole number of floats", l.Stride)
+	}
+	if len(vertices)%n != 0 {
+		return nil, fmt.Errorf("vertex layout: %d floats are not a multiple of %d per vertex", len(vertices), n)
+	}
+	b := make([]byte, f32size*len(vertices))
+	copy(b, Float32s(vertices))
+	return b, nil
+}
+
+// PackStructs writes the tagged fields of a slice of structs at the offsets of
+// l, which should come from LayoutOf on the same struct type. Bytes not
+// covered by an attribute are zero.
+func (l VertexLayout) PackStructs(vertices interface{}) ([]byte, error) {
+	v := reflect.ValueOf(vertices)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("vertex layout: %T is not a slice of structs", vertices)
+	}
+	et := v.Type().Elem()
+	if int(et.Size()) != l.Stride {
+		return nil, fmt.Errorf("vertex layout: %v is %d bytes, stride is %d", et, et.Size(), l.Stride)
+	}
+
+	// map each attribute back to its field
+	fields := make([]int, len(l.Attributes))
+	for i, a := range l.Attributes {
+		fields[i] = -1
+		for j := 0; j < et.NumField(); j++ {
+			f := et.Field(j)
+			if size, ok := floats(f.Type); ok && int(f.Offset) == a.Offset && size == a.Size {
+				fields[i] = j
+				break
+			}
+		}
+		if fields[i] < 0 {
+			return nil, fmt.Errorf("vertex layout: %v has no field of %d floats at offset %d", et, a.Size, a.Offset)
+		}
+	}
+
+	b := make([]byte, l.Stride*v.Len())
+	for i := 0; i < v.Len(); i++ {
+		vertex := b[i*l.Stride:]
+		for ai, a := range l.Attributes {
+			f := v.Index(i).Field(fields[ai])
+			for k := 0; k < a.Size; k++ {
+				x := f
+				if f.Kind() == reflect.Array {
+					x = f.Index(k)
+				}
+				binary.LittleEndian.PutUint32(vertex[a.Offset+f32size*k:], math.Float32bits(float32(x.Float())))
+			}
+		}
+	}
+	return b, nil
+}
+
+// shaderInputs matches `layout (location = N) in type name;`.
+var shaderInputs = regexp.MustCompile(`layout\s*\(\s*location\s*=\s*(\d+)\s*\)\s*in\s+(\w+)\s+(\w+)\s*;`)
+
+var comments = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+
+var glslSize = map[string]int{
+	"float": 1,
+	"vec2":  2,
+	"vec3":  3,
+	"vec4":  4,
+}
+
+// Validate checks l against the `layout (location = N) in` declarations of a
+// vertex shader: every input needs an attribute of the same size at its
+// location, and every attribute needs an input.
+func (l VertexLayout) Validate(vertexSource string) error {
+	src := comments.ReplaceAllString(vertexSource, "")
+
+	attrs := make(map[uint32]Attribute)
+	for _, a := range l.Attributes {
+		if _, ok := attrs[a.Location]; ok {
+			return fmt.Errorf("vertex layout: location %d used twice", a.Location)
+		}
+		attrs[a.Location] = a
+	}
+
+	inputs := make(map[uint32]bool)
+	for _, m := range shaderInputs.FindAllStringSubmatch(src, -1) {
+		loc64, err := strconv.ParseUint(m[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("vertex layout: bad location %q", m[1])
+		}
+		loc, typ, name := uint32(loc64), m[2], m[3]
+		inputs[loc] = true
+
+		a, ok := attrs[loc]
+		if !ok {
+			return fmt.Errorf("vertex layout: no attribute for %s %s at location %d", typ, name, loc)
+		}
+		if size, ok := glslSize[typ]; !ok {
+			return fmt.Errorf("vertex layout: unsupported input type %s of %s", typ, name)
+		} else if size != a.Size {
+			return fmt.Errorf("vertex layout: %s %s at location %d needs %d floats, attribute has %d", typ, name, loc, size, a.Size)
+		}
+		if a.Name != "" && a.Name != name {
+			return fmt.Errorf("vertex layout: location %d is %s in the shader, attribute is %s", loc, name, a.Name)
+		}
+	}
+
+	for _, a := range l.Attributes {
+		if !inputs[a.Location] {
+			return fmt.Errorf("vertex layout: shader has no input at location %d for attribute %s", a.Location, a.Name)
+		}
+	}
+	return nil
+}
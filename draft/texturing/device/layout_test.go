@@ -0,0 +1,168 @@
+package device
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+func TestNewVertexLayout(t *testing.T) {
+	l := PositionColorTexCoord
+	if l.Stride != 32 {
+		t.Fatal(l.Stride)
+	}
+	res := fmt.Sprint(l.Attributes)
+	if res != "[{aPos 0 3 0} {aColor 1 3 12} {aTexCoord 2 2 24}]" {
+		t.Fatal(res)
+	}
+}
+
+type texturedVertex struct {
+	Pos      glm.Vec3 `vertex:"0,aPos"`
+	Color    glm.Vec3 `vertex:"1,aColor"`
+	TexCoord glm.Vec2 `vertex:"2,aTexCoord"`
+}
+
+func TestLayoutOf(t *testing.T) {
+	l, err := LayoutOf(texturedVertex{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(l) != fmt.Sprint(PositionColorTexCoord) {
+		t.Fatal(l)
+	}
+
+	type padded struct {
+		Pos    [3]float32 `vertex:"0"`
+		ID     uint8
+		Weight float32 `vertex:"3"`
+	}
+	l, err = LayoutOf(&padded{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := fmt.Sprint(l); res != "{[{ 0 3 0} { 3 1 16}] 20}" {
+		t.Fatal(res)
+	}
+
+	bad := []interface{}{
+		1,
+		struct{ X float32 }{},
+		struct {
+			X float64 `vertex:"0"`
+		}{},
+		struct {
+			X float32 `vertex:"zero"`
+		}{},
+	}
+	for _, v := range bad {
+		if _, err := LayoutOf(v); err == nil {
+			t.Fatalf("%T: expected error", v)
+		}
+	}
+}
+
+func TestPack(t *testing.T) {
+	points := []float32{
+		+.5, +.5, 0, 0.0, 1.0, 0.0, 1.0, 0.0,
+		+.5, -.5, 0, 0.0, 1.0, 1.0, 1.0, 1.0,
+	}
+	b, err := PositionColorTexCoord.Pack(points)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vertices := []texturedVertex{
+		{glm.Vec3{+.5, +.5, 0}, glm.Vec3{0, 1, 0}, glm.Vec2{1, 0}},
+		{glm.Vec3{+.5, -.5, 0}, glm.Vec3{0, 1, 1}, glm.Vec2{1, 1}},
+	}
+	l, _ := LayoutOf(texturedVertex{})
+	s, err := l.PackStructs(vertices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != string(s) || len(b) != 64 {
+		t.Fatal(b, s)
+	}
+
+	if _, err := PositionColorTexCoord.Pack(points[:7]); err == nil {
+		t.Fatal("expected partial vertex error")
+	}
+	if _, err := PositionColor.PackStructs(vertices); err == nil {
+		t.Fatal("expected stride error")
+	}
+	if _, err := l.PackStructs(points); err == nil {
+		t.Fatal("expected type error")
+	}
+}
+
+func TestPackStructsPadding(t *testing.T) {
+	type padded struct {
+		Pos    [3]float32 `vertex:"0"`
+		ID     uint8
+		Weight float32 `vertex:"3"`
+	}
+	l, _ := LayoutOf(padded{})
+	b, err := l.PackStructs([]padded{{[3]float32{1, 2, 3}, 7, -1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ID is not an attribute and stays zero
+	if len(b) != 20 || b[12] != 0 || b[16+3] != 0xbf || b[8+3] != 0x40 {
+		t.Fatal(b)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, path := range []string{"../vertex.glsl", "../../proj/vertex.glsl"} {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := PositionColorTexCoord.Validate(string(src)); err != nil {
+			t.Fatal(path, err)
+		}
+	}
+
+	src := `#version 330 core
+layout (location = 0) in vec3 aPos;
+layout(location=1) in vec3 aColor; // trailing
+/* layout (location = 5) in vec4 commented; */
+void main() {}
+`
+	if err := PositionColor.Validate(src); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		layout VertexLayout
+		err    string
+	}{
+		{"missing attribute", NewVertexLayout(Attribute{Location: 0, Size: 3}), "no attribute for vec3 aColor"},
+		{"wrong size", NewVertexLayout(
+			Attribute{Location: 0, Size: 3},
+			Attribute{Location: 1, Size: 4},
+		), "needs 3 floats, attribute has 4"},
+		{"wrong name", NewVertexLayout(
+			Attribute{Name: "aColor", Location: 0, Size: 3},
+			Attribute{Location: 1, Size: 3},
+		), "is aPos in the shader"},
+		{"extra attribute", PositionColorTexCoord, "no input at location 2"},
+		{"duplicate location", NewVertexLayout(
+			Attribute{Location: 0, Size: 3},
+			Attribute{Location: 0, Size: 3},
+		), "used twice"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.layout.Validate(src)
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// Recorder is a Device that needs no GL context. It logs every call in Calls
+// and tracks which resources are alive, so tests can assert what a renderer
+// would have sent to the GPU and that it cleans up after itself.
+type Recorder struct {
+	Calls []string
+	// Live counts created but not yet deleted resources by kind.
+	Live map[string]int
+
+	// FailProgram, when set, makes NewProgram fail with it as the error.
+	FailProgram error
+
+	next int
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{Live: make(map[string]int)}
+}
+
+// Resource is the handle type returned by a Recorder. Kind is "buffer",
+// "vertexarray", "texture", "program" or "framebuffer".
+type Resource struct {
+	Kind string
+	ID   int
+
+	r       *Recorder
+	deleted bool
+	width   int
+	height  int
+}
+
+func (res *Resource) String() string {
+	return fmt.Sprintf("%s%d", res.Kind, res.ID)
+}
+
+func (res *Resource) Delete() {
+	if res.deleted {
+		res.r.logf("Delete %v twice", res)
+		return
+	}
+	res.deleted = true
+	res.r.Live[res.Kind]--
+	res.r.logf("Delete %v", res)
+}
+
+// Read returns a transparent image of the framebuffer size; a Recorder does
+// not render.
+func (res *Resource) Read() *image.RGBA {
+	res.r.logf("Read %v", res)
+	return image.NewRGBA(image.Rect(0, 0, res.width, res.height))
+}
+
+func (r *Recorder) logf(format string, args ...interface{}) {
+	r.Calls = append(r.Calls, fmt.Sprintf(format, args...))
+}
+
+func (r *Recorder) create(kind string) *Resource {
+	r.next++
+	r.Live[kind]++
+	return &Resource{Kind: kind, ID: r.next, r: r}
+}
+
+// String returns the calls one per line.
+func (r *Recorder) String() string {
+	return strings.Join(r.Calls, "\n")
+}
+
+func (r *Recorder) NewBuffer(kind BufferKind, data []byte) (Buffer, error) {
+	b := r.create("buffer")
+	name := "vertex"
+	if kind == IndexBuffer {
+		name = "index"
+	}
+	r.logf("NewBuffer %v %s %d bytes", b, name, len(data))
+	return b, nil
+}
+
+func (r *Recorder) NewVertexArray(vertices, indices Buffer, layout VertexLayout) (VertexArray, error) {
+	if vertices == nil {
+		return nil, errors.New("vertex array: no vertex buffer")
+	}
+	a := r.create("vertexarray")
+	var b strings.Builder
+	fmt.Fprintf(&b, "NewVertexArray %v vertices %v", a, vertices)
+	if indices != nil {
+		fmt.Fprintf(&b, " indices %v", indices)
+	}
+	fmt.Fprintf(&b, " stride %d", layout.Stride)
+	for _, at := range layout.Attributes {
+		fmt.Fprintf(&b, " %d:%d@%d", at.Location, at.Size, at.Offset)
+	}
+	r.Calls = append(r.Calls, b.String())
+	return a, nil
+}
+
+func (r *Recorder) NewTexture(img *image.RGBA, s Sampler) (Texture, error) {
+	b := img.Bounds()
+	if img.Stride != 4*b.Dx() {
+		return nil, fmt.Errorf("texture: unsupported stride %d for width %d", img.Stride, b.Dx())
+	}
+	t := r.create("texture")
+	r.logf("NewTexture %v %dx%d %+v", t, b.Dx(), b.Dy(), s)
+	return t, nil
+}
+
+func (r *Recorder) NewProgram(vertexSource, fragmentSource string) (Program, error) {
+	if r.FailProgram != nil {
+		r.logf("NewProgram failed")
+		return nil, r.FailProgram
+	}
+	p := r.create("program")
+	r.logf("NewProgram %v", p)
+	return p, nil
+}
+
+func (r *Recorder) NewFramebuffer(width, height int) (Framebuffer, error) {
+	f := r.create("framebuffer")
+	f.width, f.height = width, height
+	r.logf("NewFramebuffer %v %dx%d", f, width, height)
+	return f, nil
+}
+
+func (r *Recorder) BindFramebuffer(fb Framebuffer) {
+	if fb == nil {
+		r.logf("BindFramebuffer default")
+		return
+	}
+	r.logf("BindFramebuffer %v", fb)
+}
+
+func (r *Recorder) Viewport(x, y, width, height int) {
+	r.logf("Viewport %d %d %d %d", x, y, width, height)
+}
+
+func (r *Recorder) Clear(c glm.Vec4) {
+	r.logf("Clear %v", c)
+}
+
+func (r *Recorder) Draw(d DrawCall) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Draw %v %v", d.Program, d.Vertices)
+	for i, t := range d.Textures {
+		fmt.Fprintf(&b, " unit%d=%v", i, t)
+	}
+	for _, u := range d.Uniforms {
+		v := u.Value
+		// keep matrices on one line instead of their grid format
+		switch m := v.(type) {
+		case glm.Mat3:
+			v = [9]float32(m)
+		case glm.Mat4:
+			v = [16]float32(m)
+		}
+		fmt.Fprintf(&b, " %s=%v", u.Name, v)
+	}
+	fmt.Fprintf(&b, " count %d", d.Count)
+	if d.DepthTest {
+		b.WriteString(" depth")
+	}
+	r.Calls = append(r.Calls, b.String())
+}
@@ -0,0 +1,148 @@
+package device
+
+import (
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// drawQuad sets up and draws the textured quad of draft/texturing the way the
+// demo does.
+func drawQuad(d Device) error {
+	points := []float32{
+		+.5, +.5, 0, 0.0, 1.0, 0.0, 1.0, 0.0,
+		+.5, -.5, 0, 0.0, 1.0, 1.0, 1.0, 1.0,
+		-.5, -.5, 0, 0.0, 0.0, 1.0, 0.0, 1.0,
+		-.5, +.5, 0, 1.0, 0.0, 0.0, 0.0, 0.0,
+	}
+	indices := []uint32{0, 1, 3, 1, 2, 3}
+
+	vbo, err := d.NewBuffer(VertexBuffer, Float32s(points))
+	if err != nil {
+		return err
+	}
+	defer vbo.Delete()
+	ebo, err := d.NewBuffer(IndexBuffer, Uint32s(indices))
+	if err != nil {
+		return err
+	}
+	defer ebo.Delete()
+
+	vao, err := d.NewVertexArray(vbo, ebo, PositionColorTexCoord)
+	if err != nil {
+		return err
+	}
+	defer vao.Delete()
+
+	tex, err := d.NewTexture(image.NewRGBA(image.Rect(0, 0, 2, 2)), Sampler{Mipmaps: true})
+	if err != nil {
+		return err
+	}
+	defer tex.Delete()
+
+	prog, err := d.NewProgram("vertex", "fragment")
+	if err != nil {
+		return err
+	}
+	defer prog.Delete()
+
+	d.Clear(glm.Vec4{0.2, 0.3, 0.3, 1.0})
+	d.Draw(DrawCall{
+		Program:  prog,
+		Vertices: vao,
+		Textures: []Texture{tex},
+		Uniforms: []Uniform{
+			{"texture1", int32(0)},
+			{"mixValue", float32(.5)},
+		},
+		Count: len(indices),
+	})
+	return nil
+}
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+	if err := drawQuad(r); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"NewBuffer buffer1 vertex 128 bytes",
+		"NewBuffer buffer2 index 24 bytes",
+		"NewVertexArray vertexarray3 vertices buffer1 indices buffer2 stride 32 0:3@0 1:3@12 2:2@24",
+		"NewTexture texture4 2x2 {WrapS:0 WrapT:0 MinFilter:0 MagFilter:0 Mipmaps:true Anisotropy:0}",
+		"NewProgram program5",
+		"Clear [0.2 0.3 0.3 1]",
+		"Draw program5 vertexarray3 unit0=texture4 texture1=0 mixValue=0.5 count 6",
+		"Delete program5",
+		"Delete texture4",
+		"Delete vertexarray3",
+		"Delete buffer2",
+		"Delete buffer1",
+	}
+	if len(r.Calls) != len(want) {
+		t.Fatalf("got\n%v", r)
+	}
+	for i := range want {
+		if r.Calls[i] != want[i] {
+			t.Fatalf("call %d: got %q want %q", i, r.Calls[i], want[i])
+		}
+	}
+
+	for kind, n := range r.Live {
+		if n != 0 {
+			t.Fatal("leaked", n, kind)
+		}
+	}
+}
+
+func TestRecorderProgramError(t *testing.T) {
+	r := NewRecorder()
+	r.FailProgram = errors.New("failed to link")
+	if err := drawQuad(r); err != r.FailProgram {
+		t.Fatal(err)
+	}
+	for kind, n := range r.Live {
+		if n != 0 {
+			t.Fatal("leaked", n, kind)
+		}
+	}
+}
+
+func TestRecorderDoubleDelete(t *testing.T) {
+	r := NewRecorder()
+	b, _ := r.NewBuffer(VertexBuffer, nil)
+	b.Delete()
+	b.Delete()
+	if r.Live["buffer"] != 0 {
+		t.Fatal(r.Live)
+	}
+	if last := r.Calls[len(r.Calls)-1]; last != "Delete buffer1 twice" {
+		t.Fatal(last)
+	}
+}
+
+func TestRecorderTextureStride(t *testing.T) {
+	r := NewRecorder()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4)).SubImage(image.Rect(0, 0, 2, 2)).(*image.RGBA)
+	if _, err := r.NewTexture(img, Sampler{}); err == nil {
+		t.Fatal("expected stride error")
+	}
+}
+
+func TestBytes(t *testing.T) {
+	if b := Float32s([]float32{1}); len(b) != 4 || b[3] != 0x3f || b[2] != 0x80 {
+		t.Fatal(b)
+	}
+	if b := Uint32s([]uint32{0x01020304}); len(b) != 4 || b[0] != 4 {
+		t.Fatal(b)
+	}
+	if Float32s(nil) != nil || Uint32s(nil) != nil {
+		t.Fatal("empty")
+	}
+}
+
+var _ Device = (*GL)(nil)
+var _ Device = (*Recorder)(nil)
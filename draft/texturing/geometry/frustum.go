@@ -0,0 +1,81 @@
+package geometry
+
+import (
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+const (
+	PlaneLeft = iota
+	PlaneRight
+	PlaneBottom
+	PlaneTop
+	PlaneNear
+	PlaneFar
+)
+
+// Frustum is six planes with normals pointing inside.
+type Frustum struct {
+	Planes [6]Plane
+}
+
+// FrustumFromMatrix extracts the clip planes from projection*view (or
+// projection*view*model for object space planes) using the Gribb-Hartmann
+// method for OpenGL's [-1, 1] clip range.
+func FrustumFromMatrix(m glm.Mat4) (f Frustum) {
+	row := func(r int) glm.Vec4 {
+		return glm.Vec4{m[r], m[4+r], m[8+r], m[12+r]}
+	}
+	r0, r1, r2, r3 := row(0), row(1), row(2), row(3)
+
+	planes := [6]glm.Vec4{
+		PlaneLeft:   r3.Add(r0),
+		PlaneRight:  r3.Sub(r0),
+		PlaneBottom: r3.Add(r1),
+		PlaneTop:    r3.Sub(r1),
+		PlaneNear:   r3.Add(r2),
+		PlaneFar:    r3.Sub(r2),
+	}
+	for i, p := range planes {
+		f.Planes[i] = Plane{p.Vec3(), p[3]}.normalize()
+	}
+	return
+}
+
+func (f Frustum) ContainsPoint(p glm.Vec3) bool {
+	for _, pl := range f.Planes {
+		if pl.Distance(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Sphere reports whether s is at least partially inside f.
+func (f Frustum) Sphere(s Sphere) bool {
+	for _, pl := range f.Planes {
+		if pl.Distance(s.Center) < -s.Radius {
+			return false
+		}
+	}
+	return true
+}
+
+// AABB reports whether b is at least partially inside f. It is conservative:
+// large boxes near a frustum corner may be reported visible.
+func (f Frustum) AABB(b AABB) bool {
+	for _, pl := range f.Planes {
+		// the box corner furthest along the plane normal
+		var p glm.Vec3
+		for i := 0; i < 3; i++ {
+			if pl.Normal[i] >= 0 {
+				p[i] = b.Max[i]
+			} else {
+				p[i] = b.Min[i]
+			}
+		}
+		if pl.Distance(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package geometry
+
+import (
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+type Ray struct {
+	Origin glm.Vec3
+	Dir    glm.Vec3
+}
+
+// Plane holds the points p with Normal·p + D = 0. The normal points to the
+// positive half-space.
+type Plane struct {
+	Normal glm.Vec3
+	D      float32
+}
+
+type Sphere struct {
+	Center glm.Vec3
+	Radius float32
+}
+
+type AABB struct {
+	Min glm.Vec3
+	Max glm.Vec3
+}
+
+// OBB is a box with half extents measured along three orthonormal axes.
+type OBB struct {
+	Center      glm.Vec3
+	Axes        [3]glm.Vec3
+	HalfExtents glm.Vec3
+}
+
+func (r Ray) At(t float32) glm.Vec3 {
+	return r.Origin.Add(r.Dir.Scale(t))
+}
+
+// ScreenRay unprojects a point in normalized device coordinates (x and y in
+// [-1, 1]) through the inverse of projection*view into a world space ray.
+func ScreenRay(ndcX, ndcY float32, invViewProj glm.Mat4) Ray {
+	near := invViewProj.Mulv(glm.Vec4{ndcX, ndcY, -1, 1})
+	far := invViewProj.Mulv(glm.Vec4{ndcX, ndcY, 1, 1})
+	n := near.Vec3().Scale(1 / near[3])
+	f := far.Vec3().Scale(1 / far[3])
+	return Ray{n, f.Sub(n).Normalize()}
+}
+
+func PlaneFromPoints(a, b, c glm.Vec3) Plane {
+	n := b.Sub(a).Cross(c.Sub(a)).Normalize()
+	return Plane{n, -n.Dot(a)}
+}
+
+func PlaneFromNormalPoint(n, p glm.Vec3) Plane {
+	n = n.Normalize()
+	return Plane{n, -n.Dot(p)}
+}
+
+// Distance returns the signed distance from p to the plane.
+func (p Plane) Distance(v glm.Vec3) float32 {
+	return p.Normal.Dot(v) + p.D
+}
+
+func (p Plane) normalize() Plane {
+	l := p.Normal.Len()
+	if l == 0 {
+		return p
+	}
+	return Plane{p.Normal.Scale(1 / l), p.D / l}
+}
+
+func AABBFromPoints(points []glm.Vec3) (b AABB) {
+	if len(points) == 0 {
+		return
+	}
+	b = AABB{points[0], points[0]}
+	for _, p := range points[1:] {
+		b.Min = b.Min.Min(p)
+		b.Max = b.Max.Max(p)
+	}
+	return
+}
+
+func (b AABB) Center() glm.Vec3 {
+	return b.Min.Add(b.Max).Scale(.5)
+}
+
+func (b AABB) Extents() glm.Vec3 {
+	return b.Max.Sub(b.Min).Scale(.5)
+}
+
+func (b AABB) Contains(p glm.Vec3) bool {
+	return p[0] >= b.Min[0] && p[0] <= b.Max[0] &&
+		p[1] >= b.Min[1] && p[1] <= b.Max[1] &&
+		p[2] >= b.Min[2] && p[2] <= b.Max[2]
+}
+
+func (b AABB) Intersects(o AABB) bool {
+	return b.Min[0] <= o.Max[0] && b.Max[0] >= o.Min[0] &&
+		b.Min[1] <= o.Max[1] && b.Max[1] >= o.Min[1] &&
+		b.Min[2] <= o.Max[2] && b.Max[2] >= o.Min[2]
+}
+
+// Transform returns the box enclosing b after m is applied.
+func (b AABB) Transform(m glm.Mat4) AABB {
+	c := m.Mulv(b.Center().Vec4(1)).Vec3()
+	e := b.Extents()
+	var r glm.Vec3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r[i] += m32.Abs(m[4*j+i]) * e[j]
+		}
+	}
+	return AABB{c.Sub(r), c.Add(r)}
+}
+
+// OBBFromTransform builds the box covering the unit cube [-1, 1]^3 under m.
+func OBBFromTransform(m glm.Mat4) (o OBB) {
+	o.Center = glm.Vec3{m[12], m[13], m[14]}
+	for i := 0; i < 3; i++ {
+		axis := glm.Vec3{m[4*i], m[4*i+1], m[4*i+2]}
+		o.HalfExtents[i] = axis.Len()
+		o.Axes[i] = axis.Normalize()
+	}
+	return
+}
+
+func (o OBB) Contains(p glm.Vec3) bool {
+	d := p.Sub(o.Center)
+	for i := 0; i < 3; i++ {
+		if m32.Abs(d.Dot(o.Axes[i])) > o.HalfExtents[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,302 @@
+package geometry
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+func TestRayTriangle(t *testing.T) {
+	a, b, c := glm.Vec3{-1, -1, 0}, glm.Vec3{1, -1, 0}, glm.Vec3{0, 1, 0}
+
+	cases := []struct {
+		name string
+		ray  Ray
+		hit  bool
+		t    float32
+	}{
+		{"hit front", Ray{glm.Vec3{0, 0, 5}, glm.Vec3{0, 0, -1}}, true, 5},
+		{"hit back", Ray{glm.Vec3{0, 0, -2}, glm.Vec3{0, 0, 1}}, true, 2},
+		{"hit vertex", Ray{glm.Vec3{1, -1, 1}, glm.Vec3{0, 0, -1}}, true, 1},
+		{"miss outside", Ray{glm.Vec3{2, 0, 5}, glm.Vec3{0, 0, -1}}, false, 0},
+		{"miss behind", Ray{glm.Vec3{0, 0, 5}, glm.Vec3{0, 0, 1}}, false, 0},
+		{"miss parallel", Ray{glm.Vec3{0, 0, 1}, glm.Vec3{1, 0, 0}}, false, 0},
+	}
+
+	for _, c2 := range cases {
+		t.Run(c2.name, func(t *testing.T) {
+			d, _, _, hit := c2.ray.Triangle(a, b, c)
+			if hit != c2.hit || (hit && !glm.FloatEqual(d, c2.t)) {
+				t.Fatal(hit, d)
+			}
+		})
+	}
+
+	_, u, v, _ := Ray{glm.Vec3{0, 1, 1}, glm.Vec3{0, 0, -1}}.Triangle(a, b, c)
+	if res := fmt.Sprint(u, v); res != "0 1" {
+		t.Fatal(res)
+	}
+}
+
+func TestRayAABB(t *testing.T) {
+	box := AABB{glm.Vec3{-1, -1, -1}, glm.Vec3{1, 1, 1}}
+
+	cases := []struct {
+		name string
+		ray  Ray
+		hit  bool
+		t    float32
+	}{
+		{"hit axis", Ray{glm.Vec3{-5, 0, 0}, glm.Vec3{1, 0, 0}}, true, 4},
+		{"hit diagonal", Ray{glm.Vec3{3, 3, 3}, glm.Vec3{-1, -1, -1}.Normalize()}, true, 2 * glm.Vec3{1, 1, 1}.Len()},
+		{"hit inside", Ray{glm.Vec3{0, 0, 0}, glm.Vec3{0, 1, 0}}, true, 0},
+		{"hit parallel", Ray{glm.Vec3{0, .5, -3}, glm.Vec3{0, 0, 1}}, true, 2},
+		{"miss parallel", Ray{glm.Vec3{0, 2, -3}, glm.Vec3{0, 0, 1}}, false, 0},
+		{"miss behind", Ray{glm.Vec3{5, 0, 0}, glm.Vec3{1, 0, 0}}, false, 0},
+		{"miss offset", Ray{glm.Vec3{-5, 1.5, 0}, glm.Vec3{1, .1, 0}.Normalize()}, false, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d, hit := c.ray.AABB(box)
+			if hit != c.hit || (hit && !glm.FloatEqual(d, c.t)) {
+				t.Fatal(hit, d)
+			}
+		})
+	}
+}
+
+func TestRayOBB(t *testing.T) {
+	box := OBBFromTransform(glm.Identity().Translate(glm.Vec3{0, 0, -5}).Times(glm.RotationZ(glm.Rad(45))))
+
+	cases := []struct {
+		name string
+		ray  Ray
+		hit  bool
+		t    float32
+	}{
+		{"hit face", Ray{glm.Vec3{0, 0, 0}, glm.Vec3{0, 0, -1}}, true, 4},
+		{"hit rotated corner", Ray{glm.Vec3{1.3, 0, 0}, glm.Vec3{0, 0, -1}}, true, 4},
+		{"miss", Ray{glm.Vec3{1.5, 0, 0}, glm.Vec3{0, 0, -1}}, false, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d, hit := c.ray.OBB(box)
+			if hit != c.hit || (hit && !glm.FloatEqual(d, c.t)) {
+				t.Fatal(hit, d)
+			}
+		})
+	}
+}
+
+func TestRaySphere(t *testing.T) {
+	s := Sphere{glm.Vec3{0, 0, -10}, 2}
+
+	cases := []struct {
+		name string
+		ray  Ray
+		hit  bool
+		t    float32
+	}{
+		{"hit", Ray{glm.Vec3{}, glm.Vec3{0, 0, -1}}, true, 8},
+		{"hit tangent", Ray{glm.Vec3{2, 0, 0}, glm.Vec3{0, 0, -1}}, true, 10},
+		{"hit inside", Ray{glm.Vec3{0, 0, -10}, glm.Vec3{1, 0, 0}}, true, 2},
+		{"miss", Ray{glm.Vec3{}, glm.Vec3{0, 1, 0}}, false, 0},
+		{"miss behind", Ray{glm.Vec3{0, 0, -20}, glm.Vec3{0, 0, -1}}, false, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d, hit := c.ray.Sphere(s)
+			if hit != c.hit || (hit && !glm.FloatEqual(d, c.t)) {
+				t.Fatal(hit, d)
+			}
+		})
+	}
+}
+
+func TestRayPlane(t *testing.T) {
+	p := PlaneFromNormalPoint(glm.Vec3{0, 1, 0}, glm.Vec3{0, -1, 0})
+
+	if d, hit := (Ray{glm.Vec3{0, 3, 0}, glm.Vec3{0, -1, 0}}).Plane(p); !hit || d != 4 {
+		t.Fatal(hit, d)
+	}
+	if _, hit := (Ray{glm.Vec3{0, 3, 0}, glm.Vec3{0, 1, 0}}).Plane(p); hit {
+		t.Fatal("hit behind")
+	}
+	if _, hit := (Ray{glm.Vec3{0, 3, 0}, glm.Vec3{1, 0, 0}}).Plane(p); hit {
+		t.Fatal("hit parallel")
+	}
+}
+
+func TestAABBIntersects(t *testing.T) {
+	box := AABB{glm.Vec3{0, 0, 0}, glm.Vec3{2, 2, 2}}
+
+	cases := []struct {
+		name  string
+		other AABB
+		hit   bool
+	}{
+		{"overlap", AABB{glm.Vec3{1, 1, 1}, glm.Vec3{3, 3, 3}}, true},
+		{"inside", AABB{glm.Vec3{.5, .5, .5}, glm.Vec3{1, 1, 1}}, true},
+		{"touching", AABB{glm.Vec3{2, 0, 0}, glm.Vec3{3, 2, 2}}, true},
+		{"miss x", AABB{glm.Vec3{2.1, 0, 0}, glm.Vec3{3, 2, 2}}, false},
+		{"miss z", AABB{glm.Vec3{0, 0, -3}, glm.Vec3{2, 2, -.1}}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if hit := box.Intersects(c.other); hit != c.hit {
+				t.Fatal(hit)
+			}
+			if hit := c.other.Intersects(box); hit != c.hit {
+				t.Fatal(hit)
+			}
+		})
+	}
+}
+
+func TestAABBTransform(t *testing.T) {
+	box := AABB{glm.Vec3{-1, -1, -1}, glm.Vec3{1, 1, 1}}
+	res := box.Transform(glm.Identity().Translate(glm.Vec3{5, 0, 0}).Times(glm.RotationZ(glm.Rad(45))))
+	s := glm.Vec3{1, 1, 0}.Len()
+	want := AABB{glm.Vec3{5 - s, -s, -1}, glm.Vec3{5 + s, s, 1}}
+	if !res.Min.ApproxEqual(want.Min) || !res.Max.ApproxEqual(want.Max) {
+		t.Fatal(res)
+	}
+
+	if res := fmt.Sprint(AABBFromPoints([]glm.Vec3{{1, 2, 3}, {-1, 5, 0}, {0, 0, 4}})); res != "{[-1 0 0] [1 5 4]}" {
+		t.Fatal(res)
+	}
+}
+
+func testFrustum() Frustum {
+	proj := glm.Perspect(glm.Rad(90), 1, 1, 100)
+	view := glm.LookAt(glm.Vec3{0, 0, 10}, glm.Vec3{0, 0, 0}, glm.Vec3{0, 1, 0})
+	return FrustumFromMatrix(proj.Times(view))
+}
+
+func TestFrustumFromMatrix(t *testing.T) {
+	f := testFrustum()
+
+	cases := []struct {
+		name  string
+		point glm.Vec3
+		in    bool
+	}{
+		{"center", glm.Vec3{0, 0, 0}, true},
+		{"near", glm.Vec3{0, 0, 8.9}, true},
+		{"before near", glm.Vec3{0, 0, 9.1}, false},
+		{"far", glm.Vec3{0, 0, -89}, true},
+		{"after far", glm.Vec3{0, 0, -91}, false},
+		{"left edge", glm.Vec3{-9.9, 0, 0}, true},
+		{"left out", glm.Vec3{-10.1, 0, 0}, false},
+		{"top out", glm.Vec3{0, 10.1, 0}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if in := f.ContainsPoint(c.point); in != c.in {
+				t.Fatal(in)
+			}
+		})
+	}
+
+	if d := f.Planes[PlaneNear].Distance(glm.Vec3{0, 0, 0}); !glm.FloatEqual(d, 9) {
+		t.Fatal(d)
+	}
+}
+
+func TestFrustumSphere(t *testing.T) {
+	f := testFrustum()
+
+	cases := []struct {
+		name   string
+		sphere Sphere
+		in     bool
+	}{
+		{"inside", Sphere{glm.Vec3{0, 0, 0}, 1}, true},
+		{"straddling left", Sphere{glm.Vec3{-11, 0, 0}, 1}, true},
+		{"outside left", Sphere{glm.Vec3{-12, 0, 0}, 1}, false},
+		{"behind camera", Sphere{glm.Vec3{0, 0, 12}, 1}, false},
+		{"beyond far", Sphere{glm.Vec3{0, 0, -200}, 50}, false},
+		{"containing frustum", Sphere{glm.Vec3{0, 0, 0}, 1000}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if in := f.Sphere(c.sphere); in != c.in {
+				t.Fatal(in)
+			}
+		})
+	}
+}
+
+func TestFrustumAABB(t *testing.T) {
+	f := testFrustum()
+
+	cases := []struct {
+		name string
+		box  AABB
+		in   bool
+	}{
+		{"inside", AABB{glm.Vec3{-1, -1, -1}, glm.Vec3{1, 1, 1}}, true},
+		{"straddling top", AABB{glm.Vec3{-1, 9, -1}, glm.Vec3{1, 12, 1}}, true},
+		{"outside top", AABB{glm.Vec3{-1, 11.5, -1}, glm.Vec3{1, 12, 1}}, false},
+		{"behind camera", AABB{glm.Vec3{-1, -1, 11}, glm.Vec3{1, 1, 12}}, false},
+		{"containing frustum", AABB{glm.Vec3{-500, -500, -500}, glm.Vec3{500, 500, 500}}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if in := f.AABB(c.box); in != c.in {
+				t.Fatal(in)
+			}
+		})
+	}
+}
+
+func TestSphereIntersects(t *testing.T) {
+	s := Sphere{glm.Vec3{0, 0, 0}, 1}
+
+	if !s.Intersects(Sphere{glm.Vec3{1.5, 0, 0}, 1}) {
+		t.Fatal("sphere miss")
+	}
+	if s.Intersects(Sphere{glm.Vec3{2.5, 0, 0}, 1}) {
+		t.Fatal("sphere hit")
+	}
+	if !s.IntersectsAABB(AABB{glm.Vec3{.5, .5, -1}, glm.Vec3{2, 2, 1}}) {
+		t.Fatal("box miss")
+	}
+	if s.IntersectsAABB(AABB{glm.Vec3{.8, .8, .8}, glm.Vec3{2, 2, 2}}) {
+		t.Fatal("box hit")
+	}
+}
+
+func TestScreenRay(t *testing.T) {
+	proj := glm.Perspect(glm.Rad(45), 4./3, .1, 100)
+	view := glm.LookAt(glm.Vec3{0, 0, 3}, glm.Vec3{0, 0, 0}, glm.Vec3{0, 1, 0})
+	inv, ok := proj.Times(view).Invert()
+	if !ok {
+		t.Fatal("singular view projection")
+	}
+
+	r := ScreenRay(0, 0, inv)
+	if !r.Dir.ApproxEqual(glm.Vec3{0, 0, -1}) {
+		t.Fatal(r)
+	}
+
+	// the textured quad from draft/texturing, facing the camera
+	quad := []glm.Vec3{{.5, .5, 0}, {.5, -.5, 0}, {-.5, -.5, 0}, {-.5, .5, 0}}
+	if _, _, _, hit := r.Triangle(quad[0], quad[1], quad[3]); !hit {
+		t.Fatal("center miss")
+	}
+	r = ScreenRay(.9, .9, inv)
+	_, _, _, hit0 := r.Triangle(quad[0], quad[1], quad[3])
+	_, _, _, hit1 := r.Triangle(quad[1], quad[2], quad[3])
+	if hit0 || hit1 {
+		t.Fatal("corner hit")
+	}
+}
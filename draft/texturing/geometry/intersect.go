@@ -0,0 +1,116 @@
+package geometry
+
+import (
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+const epsilon = 1e-7
+
+// Triangle intersects r with the triangle abc using Möller–Trumbore and
+// returns the distance along the ray and the barycentric u, v of the hit
+// (weights of b and c). Both faces are hit.
+func (r Ray) Triangle(a, b, c glm.Vec3) (t, u, v float32, hit bool) {
+	e1 := b.Sub(a)
+	e2 := c.Sub(a)
+	p := r.Dir.Cross(e2)
+	det := e1.Dot(p)
+	if m32.Abs(det) < epsilon {
+		return
+	}
+	inv := 1 / det
+
+	s := r.Origin.Sub(a)
+	u = s.Dot(p) * inv
+	if u < 0 || u > 1 {
+		return
+	}
+
+	q := s.Cross(e1)
+	v = r.Dir.Dot(q) * inv
+	if v < 0 || u+v > 1 {
+		return
+	}
+
+	t = e2.Dot(q) * inv
+	return t, u, v, t >= 0
+}
+
+// Plane returns the distance along r to the plane. Rays parallel to the plane
+// never hit.
+func (r Ray) Plane(p Plane) (t float32, hit bool) {
+	denom := p.Normal.Dot(r.Dir)
+	if m32.Abs(denom) < epsilon {
+		return
+	}
+	t = -p.Distance(r.Origin) / denom
+	return t, t >= 0
+}
+
+// AABB returns the entry distance of r into b using the slab method. A ray
+// starting inside the box hits at t = 0.
+func (r Ray) AABB(b AABB) (t float32, hit bool) {
+	tmin, tmax := float32(0), m32.Inf(1)
+	for i := 0; i < 3; i++ {
+		if m32.Abs(r.Dir[i]) < epsilon {
+			if r.Origin[i] < b.Min[i] || r.Origin[i] > b.Max[i] {
+				return
+			}
+			continue
+		}
+		inv := 1 / r.Dir[i]
+		t0 := (b.Min[i] - r.Origin[i]) * inv
+		t1 := (b.Max[i] - r.Origin[i]) * inv
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tmin = m32.Max(tmin, t0)
+		tmax = m32.Min(tmax, t1)
+		if tmin > tmax {
+			return
+		}
+	}
+	return tmin, true
+}
+
+// OBB is AABB in the box's local frame.
+func (r Ray) OBB(o OBB) (t float32, hit bool) {
+	d := r.Origin.Sub(o.Center)
+	local := Ray{
+		Origin: glm.Vec3{d.Dot(o.Axes[0]), d.Dot(o.Axes[1]), d.Dot(o.Axes[2])},
+		Dir:    glm.Vec3{r.Dir.Dot(o.Axes[0]), r.Dir.Dot(o.Axes[1]), r.Dir.Dot(o.Axes[2])},
+	}
+	return local.AABB(AABB{o.HalfExtents.Scale(-1), o.HalfExtents})
+}
+
+// Sphere returns the nearest non-negative distance along r to s. A ray
+// starting inside the sphere hits at its exit point.
+func (r Ray) Sphere(s Sphere) (t float32, hit bool) {
+	oc := r.Origin.Sub(s.Center)
+	a := r.Dir.Dot(r.Dir)
+	b := oc.Dot(r.Dir)
+	c := oc.Dot(oc) - s.Radius*s.Radius
+	disc := b*b - a*c
+	if disc < 0 || a == 0 {
+		return
+	}
+	sq := m32.Sqrt(disc)
+	t = (-b - sq) / a
+	if t < 0 {
+		t = (-b + sq) / a
+	}
+	return t, t >= 0
+}
+
+func (s Sphere) Intersects(o Sphere) bool {
+	r := s.Radius + o.Radius
+	d := s.Center.Sub(o.Center)
+	return d.Dot(d) <= r*r
+}
+
+func (s Sphere) IntersectsAABB(b AABB) bool {
+	closest := s.Center.Max(b.Min).Min(b.Max)
+	d := closest.Sub(s.Center)
+	return d.Dot(d) <= s.Radius*s.Radius
+}
@@ -0,0 +1,89 @@
+package glm
+
+// Batch versions of Mulv and Times for transforming many values with one
+// matrix. The loops are unrolled and hold the matrix in locals; every product
+// is wrapped in an explicit conversion so the compiler never fuses it into a
+// multiply-add, which keeps the results bit-identical to the scalar Mulv and
+// Times.
+
+// TransformPoints writes m * (p, 1) for every p in src to dst, dropping w.
+// dst and src may be the same slice. It panics if dst is shorter than src.
+func (m *Mat4) TransformPoints(dst, src []Vec3) {
+	transformPoints(*m, dst, src)
+}
+
+// TransformVec4s writes m * v for every v in src to dst. dst and src may be
+// the same slice. It panics if dst is shorter than src.
+func (m *Mat4) TransformVec4s(dst, src []Vec4) {
+	transformVec4s(*m, dst, src)
+}
+
+// MulMany replaces every o in ms with m.Times(o), e.g. to bring a batch of
+// local joint matrices into the parent space.
+func (m *Mat4) MulMany(ms []Mat4) {
+	mulMany(*m, ms)
+}
+
+func (m *Mat4d) TransformPoints(dst, src []Vec3d) {
+	transformPoints(*m, dst, src)
+}
+
+func (m *Mat4d) TransformVec4s(dst, src []Vec4d) {
+	transformVec4s(*m, dst, src)
+}
+
+func (m *Mat4d) MulMany(ms []Mat4d) {
+	mulMany(*m, ms)
+}
+
+func transformPoints[M ~[16]T, V ~[3]T, T Float](m M, dst, src []V) {
+	dst = dst[:len(src)]
+
+	m00, m10, m20 := m[0], m[1], m[2]
+	m01, m11, m21 := m[4], m[5], m[6]
+	m02, m12, m22 := m[8], m[9], m[10]
+	m03, m13, m23 := m[12], m[13], m[14]
+
+	for i, p := range src {
+		x, y, z := p[0], p[1], p[2]
+		dst[i] = V{
+			0 + T(m00*x) + T(m01*y) + T(m02*z) + m03,
+			0 + T(m10*x) + T(m11*y) + T(m12*z) + m13,
+			0 + T(m20*x) + T(m21*y) + T(m22*z) + m23,
+		}
+	}
+}
+
+func transformVec4s[M ~[16]T, V ~[4]T, T Float](m M, dst, src []V) {
+	dst = dst[:len(src)]
+
+	m00, m10, m20, m30 := m[0], m[1], m[2], m[3]
+	m01, m11, m21, m31 := m[4], m[5], m[6], m[7]
+	m02, m12, m22, m32 := m[8], m[9], m[10], m[11]
+	m03, m13, m23, m33 := m[12], m[13], m[14], m[15]
+
+	for i, v := range src {
+		x, y, z, w := v[0], v[1], v[2], v[3]
+		dst[i] = V{
+			0 + T(m00*x) + T(m01*y) + T(m02*z) + T(m03*w),
+			0 + T(m10*x) + T(m11*y) + T(m12*z) + T(m13*w),
+			0 + T(m20*x) + T(m21*y) + T(m22*z) + T(m23*w),
+			0 + T(m30*x) + T(m31*y) + T(m32*z) + T(m33*w),
+		}
+	}
+}
+
+func mulMany[M ~[16]T, T Float](m M, ms []M) {
+	for i := range ms {
+		o := ms[i]
+		var n M
+		for c := 0; c < 4; c++ {
+			x, y, z, w := o[4*c], o[4*c+1], o[4*c+2], o[4*c+3]
+			n[4*c+0] = 0 + T(m[0]*x) + T(m[4]*y) + T(m[8]*z) + T(m[12]*w)
+			n[4*c+1] = 0 + T(m[1]*x) + T(m[5]*y) + T(m[9]*z) + T(m[13]*w)
+			n[4*c+2] = 0 + T(m[2]*x) + T(m[6]*y) + T(m[10]*z) + T(m[14]*w)
+			n[4*c+3] = 0 + T(m[3]*x) + T(m[7]*y) + T(m[11]*z) + T(m[15]*w)
+		}
+		ms[i] = n
+	}
+}
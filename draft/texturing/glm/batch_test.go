@@ -0,0 +1,154 @@
+package glm
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randMat4(r *rand.Rand) (m Mat4) {
+	for i := range m {
+		m[i] = r.Float32()*20 - 10
+	}
+	return
+}
+
+func sameBits(a, b []float32) bool {
+	for i := range a {
+		if math.Float32bits(a[i]) != math.Float32bits(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTransformPoints(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	m := randMat4(r)
+
+	src := make([]Vec3, 1000)
+	for i := range src {
+		src[i] = Vec3{r.Float32()*2 - 1, r.Float32() * 100, -r.Float32()}
+	}
+	src[0] = Vec3{}
+
+	dst := make([]Vec3, len(src))
+	m.TransformPoints(dst, src)
+	for i, p := range src {
+		want := m.Mulv(p.Vec4(1)).Vec3()
+		if !sameBits(dst[i][:], want[:]) {
+			t.Fatal(i, dst[i], want)
+		}
+	}
+
+	m.TransformPoints(src, src)
+	if !sameBits(src[10][:], dst[10][:]) {
+		t.Fatal(src[10], dst[10])
+	}
+}
+
+func TestTransformVec4s(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	m := randMat4(r)
+
+	src := make([]Vec4, 1000)
+	for i := range src {
+		src[i] = Vec4{r.Float32(), r.Float32() - .5, r.Float32() * 3, float32(i % 2)}
+	}
+
+	dst := make([]Vec4, len(src))
+	m.TransformVec4s(dst, src)
+	for i, v := range src {
+		want := m.Mulv(v)
+		if !sameBits(dst[i][:], want[:]) {
+			t.Fatal(i, dst[i], want)
+		}
+	}
+}
+
+func TestMulMany(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	m := randMat4(r)
+
+	ms := make([]Mat4, 100)
+	for i := range ms {
+		ms[i] = randMat4(r)
+	}
+	ms[0] = Identity()
+
+	want := make([]Mat4, len(ms))
+	for i := range ms {
+		want[i] = m.Times(ms[i])
+	}
+
+	m.MulMany(ms)
+	for i := range ms {
+		if !sameBits(ms[i][:], want[i][:]) {
+			t.Fatal(i, ms[i], want[i])
+		}
+	}
+}
+
+func TestTransformPointsShortDst(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	m := Identity()
+	m.TransformPoints(make([]Vec3, 1), make([]Vec3, 2))
+}
+
+var (
+	benchPoints = make([]Vec3, 4096)
+	benchVec4s  = make([]Vec4, 4096)
+	benchMats   = make([]Mat4, 256)
+)
+
+func BenchmarkTransformPointsMulv(b *testing.B) {
+	m := RotationX(.3).Translate(Vec3{1, 2, 3})
+	for n := 0; n < b.N; n++ {
+		for i, p := range benchPoints {
+			benchPoints[i] = m.Mulv(p.Vec4(1)).Vec3()
+		}
+	}
+}
+
+func BenchmarkTransformPoints(b *testing.B) {
+	m := RotationX(.3).Translate(Vec3{1, 2, 3})
+	for n := 0; n < b.N; n++ {
+		m.TransformPoints(benchPoints, benchPoints)
+	}
+}
+
+func BenchmarkTransformVec4sMulv(b *testing.B) {
+	m := RotationX(.3).Translate(Vec3{1, 2, 3})
+	for n := 0; n < b.N; n++ {
+		for i, v := range benchVec4s {
+			benchVec4s[i] = m.Mulv(v)
+		}
+	}
+}
+
+func BenchmarkTransformVec4s(b *testing.B) {
+	m := RotationX(.3).Translate(Vec3{1, 2, 3})
+	for n := 0; n < b.N; n++ {
+		m.TransformVec4s(benchVec4s, benchVec4s)
+	}
+}
+
+func BenchmarkMulManyTimes(b *testing.B) {
+	m := RotationX(.3).Translate(Vec3{1, 2, 3})
+	for n := 0; n < b.N; n++ {
+		for i := range benchMats {
+			benchMats[i] = m.Times(benchMats[i])
+		}
+	}
+}
+
+func BenchmarkMulMany(b *testing.B) {
+	m := RotationX(.3).Translate(Vec3{1, 2, 3})
+	for n := 0; n < b.N; n++ {
+		m.MulMany(benchMats)
+	}
+}
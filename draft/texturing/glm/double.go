@@ -0,0 +1,477 @@
+package glm
+
+import (
+	"fmt"
+	"math"
+)
+
+// Double precision counterparts of the float32 types for large worlds and
+// offline tools. Widening with Vec3.Vec3d or Mat4.Mat4d is always lossless;
+// narrowing with Vec3d.Vec3 rounds to nearest, and the Exact variants report
+// whether any precision was lost.
+//
+// The float64 side covers positions and the camera: the vectors and Mat4d
+// with every Mat4 method and builder, mostly shared through generic.go. Mat2,
+// Mat3, Quat and Transform, and so Mat4.Mat3 and NormalMatrix, stay float32:
+// they hold rotations and scales, which lose nothing there. Narrow a Mat4d
+// with Mat4 to use them, or to encode it.
+
+type Vec2d [2]float64
+type Vec3d [3]float64
+type Vec4d [4]float64
+type Mat4d [4 * 4]float64
+
+// Epsilon64 is the tolerance used by the float64 ApproxEqual helpers.
+var Epsilon64 float64 = 1e-12
+
+func FloatEqual64(a, b float64) bool {
+	return FloatEqualThreshold64(a, b, Epsilon64)
+}
+
+func FloatEqualThreshold64(a, b, eps float64) bool {
+	if a == b {
+		return true
+	}
+	diff := math.Abs(a - b)
+	if diff <= eps {
+		return true
+	}
+	return diff <= eps*math.Max(math.Abs(a), math.Abs(b))
+}
+
+func narrow(x float64) (float32, bool) {
+	f := float32(x)
+	return f, float64(f) == x || math.IsNaN(x)
+}
+
+// Vec2d
+
+func (v Vec2) Vec2d() Vec2d {
+	return Vec2d{float64(v[0]), float64(v[1])}
+}
+
+func (v Vec2d) Vec2() Vec2 {
+	n, _ := v.Vec2Exact()
+	return n
+}
+
+func (v Vec2d) Vec2Exact() (n Vec2, ok bool) {
+	ok = true
+	for i := range v {
+		var e bool
+		n[i], e = narrow(v[i])
+		ok = ok && e
+	}
+	return
+}
+
+func (v Vec2d) Add(o Vec2d) Vec2d {
+	return Vec2d{v[0] + o[0], v[1] + o[1]}
+}
+
+func (v Vec2d) Sub(o Vec2d) Vec2d {
+	return Vec2d{v[0] - o[0], v[1] - o[1]}
+}
+
+func (v Vec2d) Mul(o Vec2d) Vec2d {
+	return Vec2d{v[0] * o[0], v[1] * o[1]}
+}
+
+func (v Vec2d) Scale(s float64) Vec2d {
+	return Vec2d{v[0] * s, v[1] * s}
+}
+
+func (v Vec2d) Dot(o Vec2d) float64 {
+	return v[0]*o[0] + v[1]*o[1]
+}
+
+func (v Vec2d) Len() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+func (v Vec2d) Normalize() Vec2d {
+	l := v.Len()
+	if l == 0 {
+		return v
+	}
+	return v.Scale(1 / l)
+}
+
+func (v Vec2d) Cross(o Vec2d) float64 {
+	return v[0]*o[1] - v[1]*o[0]
+}
+
+func (v Vec2d) Lerp(o Vec2d, t float64) Vec2d {
+	return v.Add(o.Sub(v).Scale(t))
+}
+
+func (v Vec2d) Min(o Vec2d) Vec2d {
+	return Vec2d{math.Min(v[0], o[0]), math.Min(v[1], o[1])}
+}
+
+func (v Vec2d) Max(o Vec2d) Vec2d {
+	return Vec2d{math.Max(v[0], o[0]), math.Max(v[1], o[1])}
+}
+
+func (v Vec2d) Abs() Vec2d {
+	return Vec2d{math.Abs(v[0]), math.Abs(v[1])}
+}
+
+func (v Vec2d) ApproxEqual(o Vec2d) bool {
+	return FloatEqual64(v[0], o[0]) && FloatEqual64(v[1], o[1])
+}
+
+func (v Vec2d) Vec3d(z float64) Vec3d {
+	return Vec3d{v[0], v[1], z}
+}
+
+func (v Vec2d) Vec4d(z, w float64) Vec4d {
+	return Vec4d{v[0], v[1], z, w}
+}
+
+// Vec3d
+
+func (v Vec3) Vec3d() Vec3d {
+	return Vec3d{float64(v[0]), float64(v[1]), float64(v[2])}
+}
+
+func (v Vec3d) Vec3() Vec3 {
+	n, _ := v.Vec3Exact()
+	return n
+}
+
+func (v Vec3d) Vec3Exact() (n Vec3, ok bool) {
+	ok = true
+	for i := range v {
+		var e bool
+		n[i], e = narrow(v[i])
+		ok = ok && e
+	}
+	return
+}
+
+func (v Vec3d) Add(o Vec3d) Vec3d {
+	return Vec3d{v[0] + o[0], v[1] + o[1], v[2] + o[2]}
+}
+
+func (v Vec3d) Sub(o Vec3d) Vec3d {
+	return Vec3d{v[0] - o[0], v[1] - o[1], v[2] - o[2]}
+}
+
+func (v Vec3d) Mul(o Vec3d) Vec3d {
+	return Vec3d{v[0] * o[0], v[1] * o[1], v[2] * o[2]}
+}
+
+func (v Vec3d) Scale(s float64) Vec3d {
+	return Vec3d{v[0] * s, v[1] * s, v[2] * s}
+}
+
+func (v Vec3d) Dot(o Vec3d) float64 {
+	return v[0]*o[0] + v[1]*o[1] + v[2]*o[2]
+}
+
+func (v Vec3d) Cross(o Vec3d) Vec3d {
+	return Vec3d{
+		v[1]*o[2] - v[2]*o[1],
+		v[2]*o[0] - v[0]*o[2],
+		v[0]*o[1] - v[1]*o[0],
+	}
+}
+
+func (v Vec3d) Len() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+func (v Vec3d) Normalize() Vec3d {
+	l := v.Len()
+	if l == 0 {
+		return v
+	}
+	return v.Scale(1 / l)
+}
+
+func (v Vec3d) Lerp(o Vec3d, t float64) Vec3d {
+	return v.Add(o.Sub(v).Scale(t))
+}
+
+func (v Vec3d) Min(o Vec3d) Vec3d {
+	return Vec3d{math.Min(v[0], o[0]), math.Min(v[1], o[1]), math.Min(v[2], o[2])}
+}
+
+func (v Vec3d) Max(o Vec3d) Vec3d {
+	return Vec3d{math.Max(v[0], o[0]), math.Max(v[1], o[1]), math.Max(v[2], o[2])}
+}
+
+func (v Vec3d) Abs() Vec3d {
+	return Vec3d{math.Abs(v[0]), math.Abs(v[1]), math.Abs(v[2])}
+}
+
+func (v Vec3d) ApproxEqual(o Vec3d) bool {
+	return FloatEqual64(v[0], o[0]) && FloatEqual64(v[1], o[1]) && FloatEqual64(v[2], o[2])
+}
+
+func (v Vec3d) Vec2d() Vec2d {
+	return Vec2d{v[0], v[1]}
+}
+
+func (v Vec3d) Vec4d(w float64) Vec4d {
+	return Vec4d{v[0], v[1], v[2], w}
+}
+
+// Vec4d
+
+func (v Vec4) Vec4d() Vec4d {
+	return Vec4d{float64(v[0]), float64(v[1]), float64(v[2]), float64(v[3])}
+}
+
+func (v Vec4d) Vec4() Vec4 {
+	n, _ := v.Vec4Exact()
+	return n
+}
+
+func (v Vec4d) Vec4Exact() (n Vec4, ok bool) {
+	ok = true
+	for i := range v {
+		var e bool
+		n[i], e = narrow(v[i])
+		ok = ok && e
+	}
+	return
+}
+
+func (v Vec4d) Add(o Vec4d) Vec4d {
+	return Vec4d{v[0] + o[0], v[1] + o[1], v[2] + o[2], v[3] + o[3]}
+}
+
+func (v Vec4d) Sub(o Vec4d) Vec4d {
+	return Vec4d{v[0] - o[0], v[1] - o[1], v[2] - o[2], v[3] - o[3]}
+}
+
+func (v Vec4d) Mul(o Vec4d) Vec4d {
+	return Vec4d{v[0] * o[0], v[1] * o[1], v[2] * o[2], v[3] * o[3]}
+}
+
+func (v Vec4d) Scale(s float64) Vec4d {
+	return Vec4d{v[0] * s, v[1] * s, v[2] * s, v[3] * s}
+}
+
+func (v Vec4d) Dot(o Vec4d) float64 {
+	return v[0]*o[0] + v[1]*o[1] + v[2]*o[2] + v[3]*o[3]
+}
+
+func (v Vec4d) Len() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+func (v Vec4d) Normalize() Vec4d {
+	l := v.Len()
+	if l == 0 {
+		return v
+	}
+	return v.Scale(1 / l)
+}
+
+func (v Vec4d) Lerp(o Vec4d, t float64) Vec4d {
+	return v.Add(o.Sub(v).Scale(t))
+}
+
+func (v Vec4d) Min(o Vec4d) Vec4d {
+	return Vec4d{
+		math.Min(v[0], o[0]),
+		math.Min(v[1], o[1]),
+		math.Min(v[2], o[2]),
+		math.Min(v[3], o[3]),
+	}
+}
+
+func (v Vec4d) Max(o Vec4d) Vec4d {
+	return Vec4d{
+		math.Max(v[0], o[0]),
+		math.Max(v[1], o[1]),
+		math.Max(v[2], o[2]),
+		math.Max(v[3], o[3]),
+	}
+}
+
+func (v Vec4d) Abs() Vec4d {
+	return Vec4d{math.Abs(v[0]), math.Abs(v[1]), math.Abs(v[2]), math.Abs(v[3])}
+}
+
+func (v Vec4d) ApproxEqual(o Vec4d) bool {
+	return FloatEqual64(v[0], o[0]) && FloatEqual64(v[1], o[1]) &&
+		FloatEqual64(v[2], o[2]) && FloatEqual64(v[3], o[3])
+}
+
+func (v Vec4d) Vec2d() Vec2d {
+	return Vec2d{v[0], v[1]}
+}
+
+func (v Vec4d) Vec3d() Vec3d {
+	return Vec3d{v[0], v[1], v[2]}
+}
+
+// Mat4d
+
+func IdentityD() Mat4d {
+	return identity[Mat4d]()
+}
+
+func (m Mat4) Mat4d() (n Mat4d) {
+	for i := range m {
+		n[i] = float64(m[i])
+	}
+	return
+}
+
+func (m Mat4d) Mat4() Mat4 {
+	n, _ := m.Mat4Exact()
+	return n
+}
+
+func (m Mat4d) Mat4Exact() (n Mat4, ok bool) {
+	ok = true
+	for i := range m {
+		var e bool
+		n[i], e = narrow(m[i])
+		ok = ok && e
+	}
+	return
+}
+
+func (m Mat4d) Translate(offset Vec3d) Mat4d {
+	return translate(m, offset)
+}
+
+// Scale scales m along the world axes, like Translate it is applied after m.
+func (m Mat4d) Scale(s Vec3d) Mat4d {
+	return scale(m, s)
+}
+
+func (m Mat4d) Times(o Mat4d) Mat4d {
+	return times(m, o)
+}
+
+func (m Mat4d) Mulv(v Vec4d) Vec4d {
+	return mulv(m, v)
+}
+
+func (m Mat4d) Transpose() Mat4d {
+	return transpose(m)
+}
+
+func (m Mat4d) Det() float64 {
+	_, det := cofactors(m)
+	return det
+}
+
+// Invert returns the inverse of m and false if m is singular.
+func (m Mat4d) Invert() (Mat4d, bool) {
+	return invert(m)
+}
+
+func (m Mat4d) ApproxEqual(o Mat4d) bool {
+	for i := range m {
+		if !FloatEqual64(m[i], o[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ApproxEqualThreshold is ApproxEqual with an explicit tolerance.
+func (m Mat4d) ApproxEqualThreshold(o Mat4d, eps float64) bool {
+	for i := range m {
+		if !FloatEqualThreshold64(m[i], o[i], eps) {
+			return false
+		}
+	}
+	return true
+}
+
+// At returns the element in row r and column c, see Mat4.At.
+func (m Mat4d) At(r, c int) float64 {
+	return m[i4(r, c)]
+}
+
+func (m *Mat4d) Set(r, c int, v float64) {
+	m[i4(r, c)] = v
+}
+
+func (m Mat4d) Row(r int) Vec4d {
+	return row[Mat4d, Vec4d](m, r)
+}
+
+func (m Mat4d) Col(c int) Vec4d {
+	return col[Mat4d, Vec4d](m, c)
+}
+
+func (m *Mat4d) Ptr() *float64 {
+	return &m[0]
+}
+
+// String prints m as a 4x4 grid in row order, as Mat4.String does.
+func (m Mat4d) String() string {
+	return fmt.Sprint(m)
+}
+
+// Format prints m as a 4x4 grid, as Mat4.Format does.
+func (m Mat4d) Format(f fmt.State, verb rune) {
+	formatMat(f, verb, m, "glm.Mat4d")
+}
+
+func ScaleMatD(s Vec3d) Mat4d {
+	return scaleMat[Mat4d](s)
+}
+
+func RadD(deg float64) float64 {
+	return deg / 180 * math.Pi
+}
+
+func RotationXD(r float64) Mat4d {
+	return rotationX[Mat4d](r)
+}
+
+func RotationYD(r float64) Mat4d {
+	return rotationY[Mat4d](r)
+}
+
+func RotationZD(r float64) Mat4d {
+	return rotationZ[Mat4d](r)
+}
+
+// AxisAngleD returns the rotation by r radians around axis.
+func AxisAngleD(axis Vec3d, r float64) Mat4d {
+	return axisAngle[Mat4d](axis, r)
+}
+
+func PerspectiveD(fov, aspect, near, far float64) Mat4d {
+	return perspective[Mat4d](fov, aspect, near, far)
+}
+
+func PerspectD(fovy, aspect, near, far float64) Mat4d {
+	return perspect[Mat4d](fovy, aspect, near, far)
+}
+
+func FrustumD(left, right, bottom, top, near, far float64) Mat4d {
+	return frustum[Mat4d](left, right, bottom, top, near, far)
+}
+
+func PerspectiveInfiniteD(fovy, aspect, near float64) Mat4d {
+	return perspectiveInfinite[Mat4d](fovy, aspect, near)
+}
+
+func PerspectiveReversedZD(fovy, aspect, near, far float64) Mat4d {
+	return perspectiveReversedZ[Mat4d](fovy, aspect, near, far)
+}
+
+func PerspectiveInfiniteReversedZD(fovy, aspect, near float64) Mat4d {
+	return perspectiveInfiniteReversedZ[Mat4d](fovy, aspect, near)
+}
+
+func OrthoD(left, right, bottom, top, near, far float64) Mat4d {
+	return ortho[Mat4d](left, right, bottom, top, near, far)
+}
+
+func LookAtD(eye, center, up Vec3d) Mat4d {
+	return lookAt[Mat4d](eye, center, up)
+}
@@ -0,0 +1,189 @@
+package glm
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMat4dSharedAlgorithms(t *testing.T) {
+	a := Mat4{
+		1, 2, 3, 4,
+		5, 6, 7, 8,
+		9, 10, 11, 12,
+		13, 14, 15, 16,
+	}
+	b := Mat4{
+		20, -24, 28, -32,
+		-21, 25, -29, 33,
+		22, -26, 30, -34,
+		23, 27, -31, 35,
+	}
+
+	cases := []struct {
+		name   string
+		single Mat4
+		double Mat4d
+	}{
+		{"times", a.Times(b), a.Mat4d().Times(b.Mat4d())},
+		{"translate", a.Translate(Vec3{1, 2, 3}), a.Mat4d().Translate(Vec3d{1, 2, 3})},
+		{"transpose", a.Transpose(), a.Mat4d().Transpose()},
+		{"rotation x", RotationX(.5), RotationXD(.5)},
+		{"rotation y", RotationY(-1), RotationYD(-1)},
+		{"rotation z", RotationZ(2), RotationZD(2)},
+		{"perspective", Perspective(Rad(45), 4./3, .1, 100), PerspectiveD(RadD(45), 4./3, .1, 100)},
+		{"perspect", Perspect(Rad(60), 16./9, 1, 1000), PerspectD(RadD(60), 16./9, 1, 1000)},
+		{"frustum", Frustum(-1, 2, -1, 1, 1, 10), FrustumD(-1, 2, -1, 1, 1, 10)},
+		{"ortho", Ortho(0, 640, 0, 480, -1, 1), OrthoD(0, 640, 0, 480, -1, 1)},
+		{"look at", LookAt(Vec3{1, 2, 3}, Vec3{0, 0, 0}, Vec3{0, 1, 0}),
+			LookAtD(Vec3d{1, 2, 3}, Vec3d{0, 0, 0}, Vec3d{0, 1, 0})},
+		{"scale", a.Scale(Vec3{2, -1, .5}), a.Mat4d().Scale(Vec3d{2, -1, .5})},
+		{"scale mat", ScaleMat(Vec3{2, 3, 4}), ScaleMatD(Vec3d{2, 3, 4})},
+		{"axis angle", AxisAngle(Vec3{1, 2, 3}, .7), AxisAngleD(Vec3d{1, 2, 3}, .7)},
+		{"infinite", PerspectiveInfinite(1, 1.5, .1), PerspectiveInfiniteD(1, 1.5, .1)},
+		{"reversed z", PerspectiveReversedZ(1, 1.5, .1, 50), PerspectiveReversedZD(1, 1.5, .1, 50)},
+		{"infinite reversed z", PerspectiveInfiniteReversedZ(1, 1.5, .1), PerspectiveInfiniteReversedZD(1, 1.5, .1)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if res := c.double.Mat4(); !res.ApproxEqual(c.single) {
+				t.Fatal(res, c.single)
+			}
+		})
+	}
+
+	if res := fmt.Sprint(a.Mat4d().Mulv(Vec4d{1, 2, 3, 4})); res != "[90 100 110 120]" {
+		t.Fatal(res)
+	}
+}
+
+func TestMat4dLargeWorld(t *testing.T) {
+	// A point a few thousand kilometres from the origin, viewed from 1m away.
+	origin := Vec3d{4e6, 1e3, -3e6}
+	view := LookAtD(origin.Add(Vec3d{0, 0, 1}), origin, Vec3d{0, 1, 0})
+
+	p := view.Mulv(origin.Add(Vec3d{1e-3, 0, 0}).Vec4d(1))
+	if d := p.Vec3d().Sub(Vec3d{1e-3, 0, -1}).Len(); d > 1e-6 {
+		t.Fatal(p)
+	}
+
+	// the same in single precision loses the millimetre entirely
+	view32 := view.Mat4()
+	p32 := view32.Mulv(origin.Add(Vec3d{1e-3, 0, 0}).Vec4d(1).Vec4())
+	if d := p32.Vec3().Sub(Vec3{1e-3, 0, -1}).Len(); d < 1e-3 {
+		t.Fatal(p32)
+	}
+
+	if inv, ok := view.Invert(); !ok || !view.Times(inv).ApproxEqual(IdentityD()) {
+		t.Fatal(inv, ok)
+	}
+}
+
+func TestPrecisionConversions(t *testing.T) {
+	v := Vec3{.1, -2.5, 1e30}
+	if res, ok := v.Vec3d().Vec3Exact(); !ok || res != v {
+		t.Fatal(res, ok)
+	}
+	if _, ok := (Vec3d{.1, 0, 0}).Vec3Exact(); ok {
+		t.Fatal("0.1 is not exact in float32")
+	}
+	if res := (Vec3d{.1, 0, 0}).Vec3(); res != (Vec3{.1, 0, 0}) {
+		t.Fatal(res)
+	}
+
+	m := Perspect(Rad(45), 4./3, .1, 100)
+	if res, ok := m.Mat4d().Mat4Exact(); !ok || res != m {
+		t.Fatal(res, ok)
+	}
+
+	if res, ok := (Vec2{1, 2}).Vec2d().Vec2Exact(); !ok || res != (Vec2{1, 2}) {
+		t.Fatal(res, ok)
+	}
+	if res, ok := (Vec4{1, 2, 3, 4}).Vec4d().Vec4Exact(); !ok || res != (Vec4{1, 2, 3, 4}) {
+		t.Fatal(res, ok)
+	}
+}
+
+func TestVecDoubleMatchesFloat(t *testing.T) {
+	// the float64 vectors work as drop-ins for the float32 ones
+	a, b := Vec4{1, -2, 3, -4}, Vec4{-.5, 4, 2, 8}
+	ad, bd := a.Vec4d(), b.Vec4d()
+	for _, c := range []struct {
+		name string
+		f    Vec4
+		d    Vec4d
+	}{
+		{"mul", a.Mul(b), ad.Mul(bd)},
+		{"normalize", a.Normalize(), ad.Normalize()},
+		{"lerp", a.Lerp(b, .25), ad.Lerp(bd, .25)},
+		{"min", a.Min(b), ad.Min(bd)},
+		{"max", a.Max(b), ad.Max(bd)},
+		{"abs", a.Abs(), ad.Abs()},
+	} {
+		if !c.d.Vec4().ApproxEqual(c.f) {
+			t.Errorf("Vec4d %s = %v, want %v", c.name, c.d, c.f)
+		}
+	}
+
+	a3, b3 := a.Vec3(), b.Vec3()
+	if got := a3.Vec3d().Min(b3.Vec3d()).Vec3(); got != a3.Min(b3) {
+		t.Errorf("Vec3d min = %v", got)
+	}
+	if got := a3.Vec3d().Max(b3.Vec3d()).Abs().Vec3(); got != a3.Max(b3).Abs() {
+		t.Errorf("Vec3d max abs = %v", got)
+	}
+
+	a2, b2 := a.Vec2(), b.Vec2()
+	a2d, b2d := a2.Vec2d(), b2.Vec2d()
+	if got := a2d.Cross(b2d); float32(got) != a2.Cross(b2) {
+		t.Errorf("Vec2d cross = %v", got)
+	}
+	if got := a2d.Lerp(b2d, .5).Min(b2d).Max(a2d.Abs()).Vec2(); !got.ApproxEqual(a2.Lerp(b2, .5).Min(b2).Max(a2.Abs())) {
+		t.Errorf("Vec2d lerp min max = %v", got)
+	}
+}
+
+func TestMat4dMethods(t *testing.T) {
+	m := Identity().Translate(Vec3{1, 2, 3}).Scale(Vec3{2, 2, 2})
+	d := m.Mat4d()
+	if d.At(0, 3) != 2 || d.At(2, 3) != 6 || d.Row(1) != m.Row(1).Vec4d() || d.Col(3) != m.Col(3).Vec4d() {
+		t.Errorf("At %v, Row %v, Col %v", d.At(0, 3), d.Row(1), d.Col(3))
+	}
+	d.Set(3, 0, 1e-3)
+	if d[3] != 1e-3 {
+		t.Errorf("Set wrote %v", d)
+	}
+	if !d.ApproxEqualThreshold(m.Mat4d(), 1e-2) || d.ApproxEqualThreshold(m.Mat4d(), 1e-4) {
+		t.Error("ApproxEqualThreshold")
+	}
+	if got, want := fmt.Sprintf("%.1f", m.Mat4d()), fmt.Sprintf("%.1f", m); got != want {
+		t.Errorf("Format\n%s\nwant\n%s", got, want)
+	}
+	if got := fmt.Sprintf("%#v", IdentityD()); got[:len("glm.Mat4d{1, 0")] != "glm.Mat4d{1, 0" {
+		t.Errorf("%%#v = %s", got)
+	}
+
+	ps := []Vec3d{{1, 0, 0}, {0, 1, 2}}
+	d = m.Mat4d()
+	d.TransformPoints(ps, ps)
+	if ps[1] != (Vec3d{2, 6, 10}) {
+		t.Errorf("TransformPoints %v", ps)
+	}
+	vs := []Vec4d{{1, 0, 0, 0}}
+	d.TransformVec4s(vs, vs)
+	if vs[0] != (Vec4d{2, 0, 0, 0}) {
+		t.Errorf("TransformVec4s %v", vs)
+	}
+	ms := []Mat4d{IdentityD(), d}
+	d.MulMany(ms)
+	if ms[0] != d || ms[1] != d.Times(d) {
+		t.Errorf("MulMany %v", ms)
+	}
+
+	if v := (Vec2d{1, 2}).Vec4d(3, 4).Vec3d().Vec2d(); v != (Vec2d{1, 2}) {
+		t.Errorf("conversions %v", v)
+	}
+	if v := (Vec2d{1, 2}).Vec3d(3).Vec4d(4).Vec2d(); v != (Vec2d{1, 2}) {
+		t.Errorf("conversions %v", v)
+	}
+}
@@ -0,0 +1,150 @@
+package glm
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// All types encode their backing array in memory order, so matrices are
+// column major everywhere: binary is little-endian float32, text is the
+// elements separated by spaces and JSON is a flat array of numbers. Numbers use
+// the shortest representation that round-trips through float32, which keeps
+// the output stable for fixtures under version control.
+
+func marshalBinary(fs []float32) ([]byte, error) {
+	b := make([]byte, 4*len(fs))
+	for i, f := range fs {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b, nil
+}
+
+func unmarshalBinary(fs []float32, data []byte, name string) error {
+	if len(data) != 4*len(fs) {
+		return fmt.Errorf("glm: %s: binary length %d, want %d", name, len(data), 4*len(fs))
+	}
+	for i := range fs {
+		fs[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return nil
+}
+
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
+
+func marshalText(fs []float32) ([]byte, error) {
+	b := make([]byte, 0, 8*len(fs))
+	for i, f := range fs {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = append(b, formatFloat(f)...)
+	}
+	return b, nil
+}
+
+func unmarshalText(fs []float32, data []byte, name string) error {
+	fields := strings.Fields(string(data))
+	if len(fields) != len(fs) {
+		return fmt.Errorf("glm: %s: got %d values, want %d", name, len(fields), len(fs))
+	}
+	for i, s := range fields {
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return fmt.Errorf("glm: %s: %w", name, err)
+		}
+		fs[i] = float32(f)
+	}
+	return nil
+}
+
+func marshalJSON(fs []float32, name string) ([]byte, error) {
+	b := make([]byte, 0, 2+8*len(fs))
+	b = append(b, '[')
+	for i, f := range fs {
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			return nil, fmt.Errorf("glm: %s: unsupported value %v", name, f)
+		}
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, formatFloat(f)...)
+	}
+	return append(b, ']'), nil
+}
+
+func unmarshalJSON(fs []float32, data []byte, name string) error {
+	if strings.TrimSpace(string(data)) == "null" {
+		return nil
+	}
+	// a slice rather than the array itself, which json fills or truncates
+	// to fit without an error
+	var vs []float32
+	if err := json.Unmarshal(data, &vs); err != nil {
+		return fmt.Errorf("glm: %s: %w", name, err)
+	}
+	if len(vs) != len(fs) {
+		return fmt.Errorf("glm: %s: got %d values, want %d", name, len(vs), len(fs))
+	}
+	copy(fs, vs)
+	return nil
+}
+
+// Vec2
+
+func (v Vec2) MarshalBinary() ([]byte, error)     { return marshalBinary(v[:]) }
+func (v *Vec2) UnmarshalBinary(data []byte) error { return unmarshalBinary(v[:], data, "Vec2") }
+func (v Vec2) MarshalText() ([]byte, error)       { return marshalText(v[:]) }
+func (v *Vec2) UnmarshalText(data []byte) error   { return unmarshalText(v[:], data, "Vec2") }
+func (v Vec2) MarshalJSON() ([]byte, error)       { return marshalJSON(v[:], "Vec2") }
+func (v *Vec2) UnmarshalJSON(data []byte) error   { return unmarshalJSON(v[:], data, "Vec2") }
+
+// Vec3
+
+func (v Vec3) MarshalBinary() ([]byte, error)     { return marshalBinary(v[:]) }
+func (v *Vec3) UnmarshalBinary(data []byte) error { return unmarshalBinary(v[:], data, "Vec3") }
+func (v Vec3) MarshalText() ([]byte, error)       { return marshalText(v[:]) }
+func (v *Vec3) UnmarshalText(data []byte) error   { return unmarshalText(v[:], data, "Vec3") }
+func (v Vec3) MarshalJSON() ([]byte, error)       { return marshalJSON(v[:], "Vec3") }
+func (v *Vec3) UnmarshalJSON(data []byte) error   { return unmarshalJSON(v[:], data, "Vec3") }
+
+// Vec4
+
+func (v Vec4) MarshalBinary() ([]byte, error)     { return marshalBinary(v[:]) }
+func (v *Vec4) UnmarshalBinary(data []byte) error { return unmarshalBinary(v[:], data, "Vec4") }
+func (v Vec4) MarshalText() ([]byte, error)       { return marshalText(v[:]) }
+func (v *Vec4) UnmarshalText(data []byte) error   { return unmarshalText(v[:], data, "Vec4") }
+func (v Vec4) MarshalJSON() ([]byte, error)       { return marshalJSON(v[:], "Vec4") }
+func (v *Vec4) UnmarshalJSON(data []byte) error   { return unmarshalJSON(v[:], data, "Vec4") }
+
+// Mat3
+
+func (m Mat3) MarshalBinary() ([]byte, error)     { return marshalBinary(m[:]) }
+func (m *Mat3) UnmarshalBinary(data []byte) error { return unmarshalBinary(m[:], data, "Mat3") }
+func (m Mat3) MarshalText() ([]byte, error)       { return marshalText(m[:]) }
+func (m *Mat3) UnmarshalText(data []byte) error   { return unmarshalText(m[:], data, "Mat3") }
+func (m Mat3) MarshalJSON() ([]byte, error)       { return marshalJSON(m[:], "Mat3") }
+func (m *Mat3) UnmarshalJSON(data []byte) error   { return unmarshalJSON(m[:], data, "Mat3") }
+
+// Mat4
+
+func (m Mat4) MarshalBinary() ([]byte, error)     { return marshalBinary(m[:]) }
+func (m *Mat4) UnmarshalBinary(data []byte) error { return unmarshalBinary(m[:], data, "Mat4") }
+func (m Mat4) MarshalText() ([]byte, error)       { return marshalText(m[:]) }
+func (m *Mat4) UnmarshalText(data []byte) error   { return unmarshalText(m[:], data, "Mat4") }
+func (m Mat4) MarshalJSON() ([]byte, error)       { return marshalJSON(m[:], "Mat4") }
+func (m *Mat4) UnmarshalJSON(data []byte) error   { return unmarshalJSON(m[:], data, "Mat4") }
+
+// Quat
+
+func (q Quat) MarshalBinary() ([]byte, error)     { return marshalBinary(q[:]) }
+func (q *Quat) UnmarshalBinary(data []byte) error { return unmarshalBinary(q[:], data, "Quat") }
+func (q Quat) MarshalText() ([]byte, error)       { return marshalText(q[:]) }
+func (q *Quat) UnmarshalText(data []byte) error   { return unmarshalText(q[:], data, "Quat") }
+func (q Quat) MarshalJSON() ([]byte, error)       { return marshalJSON(q[:], "Quat") }
+func (q *Quat) UnmarshalJSON(data []byte) error   { return unmarshalJSON(q[:], data, "Quat") }
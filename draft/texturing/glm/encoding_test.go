@@ -0,0 +1,162 @@
+package glm
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type codec interface {
+	encoding.BinaryMarshaler
+	encoding.TextMarshaler
+	json.Marshaler
+}
+
+func TestEncodingOutput(t *testing.T) {
+	cases := []struct {
+		name   string
+		in     codec
+		binary string
+		text   string
+		json   string
+	}{
+		{"vec2", Vec2{1, -.5}, "0000803f000000bf", "1 -0.5", "[1,-0.5]"},
+		{"vec3", Vec3{.1, 0, 1e10}, "cdcccc3d00000000f9021550", "0.1 0 1e+10", "[0.1,0,1e+10]"},
+		{"vec4", Vec4{0, 0, 0, 1}, "000000000000000000000000" + "0000803f", "0 0 0 1", "[0,0,0,1]"},
+		{"quat", QuatIdent(), "000000000000000000000000" + "0000803f", "0 0 0 1", "[0,0,0,1]"},
+		{"mat3", Identity3(), "", "1 0 0 0 1 0 0 0 1", "[1,0,0,0,1,0,0,0,1]"},
+		{"mat4", Identity().Translate(Vec3{1, 2, 3}), "", "1 0 0 0 0 1 0 0 0 0 1 0 1 2 3 1",
+			"[1,0,0,0,0,1,0,0,0,0,1,0,1,2,3,1]"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := c.in.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.binary != "" && fmt.Sprintf("%x", b) != c.binary {
+				t.Fatalf("%x", b)
+			}
+
+			text, err := c.in.MarshalText()
+			if err != nil || string(text) != c.text {
+				t.Fatal(string(text), err)
+			}
+
+			js, err := json.Marshal(c.in)
+			if err != nil || string(js) != c.json {
+				t.Fatal(string(js), err)
+			}
+		})
+	}
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	v2 := Vec2{math.MaxFloat32, -math.SmallestNonzeroFloat32}
+	v3 := Vec3{1. / 3, -2. / 7, 123456.789}
+	v4 := Vec4{.1, .2, .3, .4}
+	q := QuatFromEuler(.1, .2, .3)
+	m3 := RotationZ(1).Mat3()
+	m4 := Perspect(Rad(45), 4./3, .1, 100).Times(LookAt(Vec3{1, 2, 3}, Vec3{}, Vec3{0, 1, 0}))
+
+	cases := []struct {
+		name string
+		in   codec
+		out  interface {
+			encoding.BinaryUnmarshaler
+			encoding.TextUnmarshaler
+			json.Unmarshaler
+		}
+	}{
+		{"vec2", v2, new(Vec2)},
+		{"vec3", v3, new(Vec3)},
+		{"vec4", v4, new(Vec4)},
+		{"quat", q, new(Quat)},
+		{"mat3", m3, new(Mat3)},
+		{"mat4", m4, new(Mat4)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, _ := c.in.MarshalBinary()
+			if err := c.out.UnmarshalBinary(b); err != nil {
+				t.Fatal(err)
+			}
+			if res := reflect.ValueOf(c.out).Elem().Interface(); res != c.in {
+				t.Fatal("binary", res)
+			}
+
+			text, _ := c.in.MarshalText()
+			if err := c.out.UnmarshalText(text); err != nil {
+				t.Fatal(err)
+			}
+			if res := reflect.ValueOf(c.out).Elem().Interface(); res != c.in {
+				t.Fatal("text", res)
+			}
+
+			js, _ := json.Marshal(c.in)
+			if err := json.Unmarshal(js, c.out); err != nil {
+				t.Fatal(err)
+			}
+			if res := reflect.ValueOf(c.out).Elem().Interface(); res != c.in {
+				t.Fatal("json", res)
+			}
+		})
+	}
+}
+
+func TestEncodingTransformJSON(t *testing.T) {
+	in := Transform{Vec3{1, 2, 3}, QuatFromAxisAngle(Vec3{0, 1, 0}, Rad(90)), Vec3{1, 1, 1}}
+
+	js, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Translation":[1,2,3],"Rotation":[0,0.7071068,0,0.70710677],"Scale":[1,1,1]}`
+	if string(js) != want {
+		t.Fatal(string(js))
+	}
+
+	var out Transform
+	if err := json.Unmarshal(js, &out); err != nil || out != in {
+		t.Fatal(out, err)
+	}
+}
+
+func TestEncodingErrors(t *testing.T) {
+	var v Vec3
+	var m Mat4
+
+	cases := []struct {
+		name string
+		err  error
+	}{
+		{"binary short", v.UnmarshalBinary(make([]byte, 8))},
+		{"binary long", m.UnmarshalBinary(make([]byte, 65))},
+		{"text count", v.UnmarshalText([]byte("1 2"))},
+		{"text number", v.UnmarshalText([]byte("1 2 x"))},
+		{"json object", json.Unmarshal([]byte(`{"x":1}`), &v)},
+		{"json count", json.Unmarshal([]byte(`[1,2,3,4]`), &v)},
+		{"json nested", json.Unmarshal([]byte(`[[1],2,3]`), &v)},
+		{"json empty element", v.UnmarshalJSON([]byte(`[1,,2,3]`))},
+		{"json space separated", v.UnmarshalJSON([]byte(`[1 2,3]`))},
+		{"json string", v.UnmarshalJSON([]byte(`["1",2,3]`))},
+		{"json overflow", v.UnmarshalJSON([]byte(`[1e39,2,3]`))},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	if _, err := json.Marshal(Vec3{float32(math.NaN()), 0, 0}); err == nil {
+		t.Fatal("NaN encoded")
+	}
+}
@@ -0,0 +1,61 @@
+package glm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String prints m as a 4x4 grid in row order, e.g. a translation shows its
+// offset in the right column.
+func (m Mat4) String() string {
+	return fmt.Sprint(m)
+}
+
+// Format prints m as a 4x4 grid with columns aligned. The verb and flags are
+// applied to every element, so %.2f works as for a float32. %#v prints the
+// Go syntax of the backing array instead.
+func (m Mat4) Format(f fmt.State, verb rune) {
+	formatMat(f, verb, m, "glm.Mat4")
+}
+
+// formatMat is Format for Mat4 and Mat4d, name is the type for %#v.
+func formatMat[M ~[16]T, T Float](f fmt.State, verb rune, m M, name string) {
+	if verb == 'v' && f.Flag('#') {
+		s := fmt.Sprintf("%#v", [16]T(m))
+		fmt.Fprint(f, name+s[strings.IndexByte(s, '{'):])
+		return
+	}
+
+	elem := "%" + string(verb)
+	if p, ok := f.Precision(); ok {
+		elem = fmt.Sprintf("%%.%d%c", p, verb)
+	}
+
+	var cells [4][4]string
+	var width [4]int
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			cells[r][c] = fmt.Sprintf(elem, m[i4(r, c)])
+			if l := len(cells[r][c]); l > width[c] {
+				width[c] = l
+			}
+		}
+	}
+
+	var b strings.Builder
+	for r := 0; r < 4; r++ {
+		if r > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteByte('[')
+		for c := 0; c < 4; c++ {
+			if c > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(strings.Repeat(" ", width[c]-len(cells[r][c])))
+			b.WriteString(cells[r][c])
+		}
+		b.WriteByte(']')
+	}
+	f.Write([]byte(b.String()))
+}
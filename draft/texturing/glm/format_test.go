@@ -0,0 +1,85 @@
+package glm
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMat4Accessors(t *testing.T) {
+	m := FromRows(
+		Vec4{1, 2, 3, 4},
+		Vec4{5, 6, 7, 8},
+		Vec4{9, 10, 11, 12},
+		Vec4{13, 14, 15, 16},
+	)
+
+	cases := []struct {
+		name string
+		res  interface{}
+		out  string
+	}{
+		{"at", m.At(0, 3), "4"},
+		{"at last row", m.At(3, 0), "13"},
+		{"row", m.Row(1), "[5 6 7 8]"},
+		{"col", m.Col(1), "[2 6 10 14]"},
+		{"backing array", [16]float32(m), "[1 5 9 13 2 6 10 14 3 7 11 15 4 8 12 16]"},
+		{"from cols", FromCols(m.Col(0), m.Col(1), m.Col(2), m.Col(3)) == m, "true"},
+		{"translate column", Identity().Translate(Vec3{7, 8, 9}).Col(3), "[7 8 9 1]"},
+		{"times uses rows", m.Times(Identity()).Row(2), "[9 10 11 12]"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := fmt.Sprint(c.res)
+			if res != c.out {
+				t.Fatal(res)
+			}
+		})
+	}
+
+	m.Set(2, 1, -1)
+	if res := m.At(2, 1); res != -1 {
+		t.Fatal(res)
+	}
+	if res := m[i4(2, 1)]; res != -1 {
+		t.Fatal(res)
+	}
+}
+
+func TestMat4Format(t *testing.T) {
+	m := Identity().Translate(Vec3{10, -2.5, 0})
+
+	cases := []struct {
+		format string
+		out    string
+	}{
+		{"%v", "[1 0 0   10]\n[0 1 0 -2.5]\n[0 0 1    0]\n[0 0 0    1]"},
+		{"%.1f", "[1.0 0.0 0.0 10.0]\n[0.0 1.0 0.0 -2.5]\n[0.0 0.0 1.0  0.0]\n[0.0 0.0 0.0  1.0]"},
+		{"%#v", "glm.Mat4{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 10, -2.5, 0, 1}"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.format, func(t *testing.T) {
+			res := fmt.Sprintf(c.format, m)
+			if res != c.out {
+				t.Fatalf("%q", res)
+			}
+		})
+	}
+
+	if res := m.String(); res != fmt.Sprint(m) {
+		t.Fatal(res)
+	}
+}
+
+func TestMat4ApproxEqualThreshold(t *testing.T) {
+	a := Identity()
+	b := Identity().Translate(Vec3{1e-3, 0, 0})
+
+	if a.ApproxEqual(b) {
+		t.Fatal("default epsilon too loose")
+	}
+	if !a.ApproxEqualThreshold(b, 1e-2) {
+		t.Fatal("threshold ignored")
+	}
+}
@@ -0,0 +1,339 @@
+package glm
+
+import (
+	"math"
+
+	m32 "github.com/chewxy/math32"
+)
+
+// Float is the element type of the float32 and float64 (the "d" suffixed)
+// types. The matrix algorithms below are written once over it and shared by
+// Mat4 and Mat4d.
+type Float interface {
+	~float32 | ~float64
+}
+
+func sin[T Float](x T) T {
+	if v, ok := any(x).(float32); ok {
+		return T(m32.Sin(v))
+	}
+	return T(math.Sin(float64(x)))
+}
+
+func cos[T Float](x T) T {
+	if v, ok := any(x).(float32); ok {
+		return T(m32.Cos(v))
+	}
+	return T(math.Cos(float64(x)))
+}
+
+func tan[T Float](x T) T {
+	if v, ok := any(x).(float32); ok {
+		return T(m32.Tan(v))
+	}
+	return T(math.Tan(float64(x)))
+}
+
+func sincos[T Float](x T) (T, T) {
+	if v, ok := any(x).(float32); ok {
+		s, c := m32.Sincos(v)
+		return T(s), T(c)
+	}
+	s, c := math.Sincos(float64(x))
+	return T(s), T(c)
+}
+
+func sqrt[T Float](x T) T {
+	if v, ok := any(x).(float32); ok {
+		return T(m32.Sqrt(v))
+	}
+	return T(math.Sqrt(float64(x)))
+}
+
+// Vector helpers for the matrix builders, the same operations as the Vec3
+// and Vec3d methods.
+
+func sub3[V ~[3]T, T Float](a, b V) V {
+	return V{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func dot3[V ~[3]T, T Float](a, b V) T {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross3[V ~[3]T, T Float](a, b V) V {
+	return V{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func normalize3[V ~[3]T, T Float](v V) V {
+	l := sqrt(dot3(v, v))
+	if l == 0 {
+		return v
+	}
+	s := 1 / l
+	return V{v[0] * s, v[1] * s, v[2] * s}
+}
+
+func identity[M ~[16]T, T Float]() (m M) {
+	m[i4(0, 0)] = 1
+	m[i4(1, 1)] = 1
+	m[i4(2, 2)] = 1
+	m[i4(3, 3)] = 1
+	return
+}
+
+func translate[M ~[16]T, V ~[3]T, T Float](m M, offset V) M {
+	m[12] += offset[0]
+	m[13] += offset[1]
+	m[14] += offset[2]
+	return m
+}
+
+func scale[M ~[16]T, V ~[3]T, T Float](m M, s V) M {
+	for c := 0; c < 4; c++ {
+		m[i4(0, c)] *= s[0]
+		m[i4(1, c)] *= s[1]
+		m[i4(2, c)] *= s[2]
+	}
+	return m
+}
+
+func scaleMat[M ~[16]T, V ~[3]T, T Float](s V) (m M) {
+	m[i4(0, 0)] = s[0]
+	m[i4(1, 1)] = s[1]
+	m[i4(2, 2)] = s[2]
+	m[i4(3, 3)] = 1
+	return
+}
+
+func rotationX[M ~[16]T, T Float](r T) (m M) {
+	m = identity[M]()
+	m[i4(1, 1)] = cos(r)
+	m[i4(1, 2)] = -sin(r)
+	m[i4(2, 1)] = sin(r)
+	m[i4(2, 2)] = cos(r)
+	return
+}
+
+func rotationY[M ~[16]T, T Float](r T) (m M) {
+	m = identity[M]()
+	m[i4(0, 0)] = cos(r)
+	m[i4(0, 2)] = sin(r)
+	m[i4(2, 0)] = -sin(r)
+	m[i4(2, 2)] = cos(r)
+	return
+}
+
+func rotationZ[M ~[16]T, T Float](r T) (m M) {
+	m = identity[M]()
+	m[i4(0, 0)] = cos(r)
+	m[i4(0, 1)] = -sin(r)
+	m[i4(1, 0)] = sin(r)
+	m[i4(1, 1)] = cos(r)
+	return
+}
+
+func axisAngle[M ~[16]T, V ~[3]T, T Float](axis V, r T) (m M) {
+	a := normalize3(axis)
+	x, y, z := a[0], a[1], a[2]
+	s, c := sincos(r)
+	k := 1 - c
+
+	m = identity[M]()
+	m[i4(0, 0)] = c + x*x*k
+	m[i4(0, 1)] = x*y*k - z*s
+	m[i4(0, 2)] = x*z*k + y*s
+	m[i4(1, 0)] = y*x*k + z*s
+	m[i4(1, 1)] = c + y*y*k
+	m[i4(1, 2)] = y*z*k - x*s
+	m[i4(2, 0)] = z*x*k - y*s
+	m[i4(2, 1)] = z*y*k + x*s
+	m[i4(2, 2)] = c + z*z*k
+	return
+}
+
+func row[M ~[16]T, V ~[4]T, T Float](m M, r int) V {
+	return V{m[i4(r, 0)], m[i4(r, 1)], m[i4(r, 2)], m[i4(r, 3)]}
+}
+
+func col[M ~[16]T, V ~[4]T, T Float](m M, c int) V {
+	return V{m[i4(0, c)], m[i4(1, c)], m[i4(2, c)], m[i4(3, c)]}
+}
+
+func times[M ~[16]T, T Float](m, o M) (n M) {
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			for i := 0; i < 4; i++ {
+				n[i4(r, c)] += m[i4(r, i)] * o[i4(i, c)]
+			}
+		}
+	}
+	return
+}
+
+func mulv[M ~[16]T, V ~[4]T, T Float](m M, v V) (n V) {
+	for c := 0; c < 4; c++ {
+		for r := 0; r < 4; r++ {
+			n[r] += m[i4(r, c)] * v[c]
+		}
+	}
+	return
+}
+
+func transpose[M ~[16]T, T Float](m M) (n M) {
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			n[i4(r, c)] = m[i4(c, r)]
+		}
+	}
+	return
+}
+
+// cofactors returns the adjugate of m (unscaled inverse) and the determinant.
+func cofactors[M ~[16]T, T Float](m M) (inv M, det T) {
+	inv[0] = m[5]*m[10]*m[15] - m[5]*m[11]*m[14] - m[9]*m[6]*m[15] +
+		m[9]*m[7]*m[14] + m[13]*m[6]*m[11] - m[13]*m[7]*m[10]
+	inv[4] = -m[4]*m[10]*m[15] + m[4]*m[11]*m[14] + m[8]*m[6]*m[15] -
+		m[8]*m[7]*m[14] - m[12]*m[6]*m[11] + m[12]*m[7]*m[10]
+	inv[8] = m[4]*m[9]*m[15] - m[4]*m[11]*m[13] - m[8]*m[5]*m[15] +
+		m[8]*m[7]*m[13] + m[12]*m[5]*m[11] - m[12]*m[7]*m[9]
+	inv[12] = -m[4]*m[9]*m[14] + m[4]*m[10]*m[13] + m[8]*m[5]*m[14] -
+		m[8]*m[6]*m[13] - m[12]*m[5]*m[10] + m[12]*m[6]*m[9]
+	inv[1] = -m[1]*m[10]*m[15] + m[1]*m[11]*m[14] + m[9]*m[2]*m[15] -
+		m[9]*m[3]*m[14] - m[13]*m[2]*m[11] + m[13]*m[3]*m[10]
+	inv[5] = m[0]*m[10]*m[15] - m[0]*m[11]*m[14] - m[8]*m[2]*m[15] +
+		m[8]*m[3]*m[14] + m[12]*m[2]*m[11] - m[12]*m[3]*m[10]
+	inv[9] = -m[0]*m[9]*m[15] + m[0]*m[11]*m[13] + m[8]*m[1]*m[15] -
+		m[8]*m[3]*m[13] - m[12]*m[1]*m[11] + m[12]*m[3]*m[9]
+	inv[13] = m[0]*m[9]*m[14] - m[0]*m[10]*m[13] - m[8]*m[1]*m[14] +
+		m[8]*m[2]*m[13] + m[12]*m[1]*m[10] - m[12]*m[2]*m[9]
+	inv[2] = m[1]*m[6]*m[15] - m[1]*m[7]*m[14] - m[5]*m[2]*m[15] +
+		m[5]*m[3]*m[14] + m[13]*m[2]*m[7] - m[13]*m[3]*m[6]
+	inv[6] = -m[0]*m[6]*m[15] + m[0]*m[7]*m[14] + m[4]*m[2]*m[15] -
+		m[4]*m[3]*m[14] - m[12]*m[2]*m[7] + m[12]*m[3]*m[6]
+	inv[10] = m[0]*m[5]*m[15] - m[0]*m[7]*m[13] - m[4]*m[1]*m[15] +
+		m[4]*m[3]*m[13] + m[12]*m[1]*m[7] - m[12]*m[3]*m[5]
+	inv[14] = -m[0]*m[5]*m[14] + m[0]*m[6]*m[13] + m[4]*m[1]*m[14] -
+		m[4]*m[2]*m[13] - m[12]*m[1]*m[6] + m[12]*m[2]*m[5]
+	inv[3] = -m[1]*m[6]*m[11] + m[1]*m[7]*m[10] + m[5]*m[2]*m[11] -
+		m[5]*m[3]*m[10] - m[9]*m[2]*m[7] + m[9]*m[3]*m[6]
+	inv[7] = m[0]*m[6]*m[11] - m[0]*m[7]*m[10] - m[4]*m[2]*m[11] +
+		m[4]*m[3]*m[10] + m[8]*m[2]*m[7] - m[8]*m[3]*m[6]
+	inv[11] = -m[0]*m[5]*m[11] + m[0]*m[7]*m[9] + m[4]*m[1]*m[11] -
+		m[4]*m[3]*m[9] - m[8]*m[1]*m[7] + m[8]*m[3]*m[5]
+	inv[15] = m[0]*m[5]*m[10] - m[0]*m[6]*m[9] - m[4]*m[1]*m[10] +
+		m[4]*m[2]*m[9] + m[8]*m[1]*m[6] - m[8]*m[2]*m[5]
+
+	det = m[0]*inv[0] + m[1]*inv[4] + m[2]*inv[8] + m[3]*inv[12]
+	return
+}
+
+func invert[M ~[16]T, T Float](m M) (n M, ok bool) {
+	inv, det := cofactors(m)
+	if det == 0 {
+		return
+	}
+	det = 1 / det
+	for i := range inv {
+		n[i] = inv[i] * det
+	}
+	return n, true
+}
+
+func perspective[M ~[16]T, T Float](fov, aspect, near, far T) (n M) {
+	heightViewport2 := near * tan(fov/2)
+	widthViewport2 := heightViewport2 * aspect
+
+	n[i4(0, 0)] = near / widthViewport2
+	n[i4(1, 1)] = near / heightViewport2
+	n[i4(2, 2)] = -(far + near) / (far - near)
+	n[i4(3, 2)] = -1
+	n[i4(2, 3)] = -2 * near * far / (far - near)
+	return
+}
+
+func perspect[M ~[16]T, T Float](fovy, aspect, near, far T) M {
+	nmf, f := near-far, 1./tan(fovy/2)
+
+	return M{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, (near + far) / nmf, -1,
+		0, 0, 2. * far * near / nmf, 0,
+	}
+}
+
+func frustum[M ~[16]T, T Float](left, right, bottom, top, near, far T) (n M) {
+	n[i4(0, 0)] = 2 * near / (right - left)
+	n[i4(1, 1)] = 2 * near / (top - bottom)
+	n[i4(0, 2)] = (right + left) / (right - left)
+	n[i4(1, 2)] = (top + bottom) / (top - bottom)
+	n[i4(2, 2)] = -(far + near) / (far - near)
+	n[i4(3, 2)] = -1
+	n[i4(2, 3)] = -2 * near * far / (far - near)
+	return
+}
+
+func ortho[M ~[16]T, T Float](left, right, bottom, top, near, far T) (n M) {
+	n[i4(0, 0)] = 2 / (right - left)
+	n[i4(1, 1)] = 2 / (top - bottom)
+	n[i4(2, 2)] = -2 / (far - near)
+	n[i4(0, 3)] = -(right + left) / (right - left)
+	n[i4(1, 3)] = -(top + bottom) / (top - bottom)
+	n[i4(2, 3)] = -(far + near) / (far - near)
+	n[i4(3, 3)] = 1
+	return
+}
+
+func perspectiveInfinite[M ~[16]T, T Float](fovy, aspect, near T) (n M) {
+	f := 1 / tan(fovy/2)
+
+	n[i4(0, 0)] = f / aspect
+	n[i4(1, 1)] = f
+	n[i4(2, 2)] = -1
+	n[i4(3, 2)] = -1
+	n[i4(2, 3)] = -2 * near
+	return
+}
+
+func perspectiveReversedZ[M ~[16]T, T Float](fovy, aspect, near, far T) (n M) {
+	f := 1 / tan(fovy/2)
+
+	n[i4(0, 0)] = f / aspect
+	n[i4(1, 1)] = f
+	n[i4(2, 2)] = near / (far - near)
+	n[i4(3, 2)] = -1
+	n[i4(2, 3)] = far * near / (far - near)
+	return
+}
+
+func perspectiveInfiniteReversedZ[M ~[16]T, T Float](fovy, aspect, near T) (n M) {
+	f := 1 / tan(fovy/2)
+
+	n[i4(0, 0)] = f / aspect
+	n[i4(1, 1)] = f
+	n[i4(3, 2)] = -1
+	n[i4(2, 3)] = near
+	return
+}
+
+func lookAt[M ~[16]T, V ~[3]T, T Float](eye, center, up V) (n M) {
+	f := normalize3(sub3(center, eye))
+	s := normalize3(cross3(f, up))
+	u := cross3(s, f)
+
+	for c := 0; c < 3; c++ {
+		n[i4(0, c)] = s[c]
+		n[i4(1, c)] = u[c]
+		n[i4(2, c)] = -f[c]
+	}
+	n[i4(0, 3)] = -dot3(s, eye)
+	n[i4(1, 3)] = -dot3(u, eye)
+	n[i4(2, 3)] = dot3(f, eye)
+	n[i4(3, 3)] = 1
+	return
+}
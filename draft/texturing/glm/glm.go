@@ -24,41 +24,41 @@ func Identity() Mat4 {
 }
 
 func (m Mat4) Translate(offset Vec3) Mat4 {
-	m[12] += offset[0]
-	m[13] += offset[1]
-	m[14] += offset[2]
-	return m
+	return translate(m, offset)
+}
+
+// Scale scales m along the world axes, like Translate it is applied after m.
+func (m Mat4) Scale(s Vec3) Mat4 {
+	return scale(m, s)
+}
+
+func ScaleMat(s Vec3) Mat4 {
+	return scaleMat[Mat4](s)
 }
 
 func Rad(deg float32) float32 {
 	return deg / 180 * m32.Pi
 }
 
-func RotationX(r float32) (m Mat4) {
-	m = mat4id
-	m[i4(1, 1)] = m32.Cos(r)
-	m[i4(1, 2)] = -m32.Sin(r)
-	m[i4(2, 1)] = m32.Sin(r)
-	m[i4(2, 2)] = m32.Cos(r)
-	return
+func Deg(rad float32) float32 {
+	return rad / m32.Pi * 180
+}
+
+func RotationX(r float32) Mat4 {
+	return rotationX[Mat4](r)
 }
 
-func RotationY(r float32) (m Mat4) {
-	m = mat4id
-	m[i4(0, 0)] = m32.Cos(r)
-	m[i4(0, 3)] = m32.Sin(r)
-	m[i4(3, 0)] = -m32.Sin(r)
-	m[i4(3, 3)] = m32.Cos(r)
-	return
+func RotationY(r float32) Mat4 {
+	return rotationY[Mat4](r)
 }
 
-func RotationZ(r float32) (m Mat4) {
-	m = mat4id
-	m[i4(0, 0)] = m32.Cos(r)
-	m[i4(0, 1)] = -m32.Sin(r)
-	m[i4(1, 0)] = m32.Sin(r)
-	m[i4(1, 1)] = m32.Cos(r)
-	return m
+func RotationZ(r float32) Mat4 {
+	return rotationZ[Mat4](r)
+}
+
+// AxisAngle returns the rotation by r radians around axis.
+func AxisAngle(axis Vec3, r float32) Mat4 {
+	return axisAngle[Mat4](axis, r)
 }
 
 func j4(r, c int) int {
@@ -69,50 +69,58 @@ func i4(r, c int) int {
 	return 4*c + r
 }
 
-func (m Mat4) Times(o Mat4) (n Mat4) {
-	for r := 0; r < 4; r++ {
-		for c := 0; c < 4; c++ {
-			for i := 0; i < 4; i++ {
-				n[i4(r, c)] += m[i4(r, i)] * o[i4(i, c)]
-			}
-		}
-	}
-	return
+func (m Mat4) Times(o Mat4) Mat4 {
+	return times(m, o)
 }
 
-func (m Mat4) Mulv(v Vec4) (n Vec4) {
-	for c := 0; c < 4; c++ {
-		for r := 0; r < 4; r++ {
-			n[r] += m[i4(r, c)] * v[c]
-		}
-	}
-	return
+func (m Mat4) Mulv(v Vec4) Vec4 {
+	return mulv(m, v)
 }
 
 func (m *Mat4) Ptr() *float32 {
 	return &m[0]
 }
 
-func Perspective(fov float32, aspect float32, near float32, far float32) (n Mat4) {
+func Perspective(fov float32, aspect float32, near float32, far float32) Mat4 {
 	fmt.Println("perspective", fov, aspect, near, far)
-	heightViewport2 := near * m32.Tan(fov/2)
-	widthViewport2 := heightViewport2 * aspect
-
-	n[i4(0, 0)] = near / widthViewport2
-	n[i4(1, 1)] = near / heightViewport2
-	n[i4(2, 2)] = -(far + near) / (far - near)
-	n[i4(3, 2)] = -1
-	n[i4(2, 3)] = -2 * near * far / (far - near)
-	return
+	return perspective[Mat4](fov, aspect, near, far)
 }
 
 func Perspect(fovy, aspect, near, far float32) Mat4 {
-	nmf, f := near-far, 1./m32.Tan(fovy/2)
-
-	return Mat4{
-		f / aspect, 0, 0, 0,
-		0, f, 0, 0,
-		0, 0, (near + far) / nmf, -1,
-		0, 0, 2. * far * near / nmf, 0,
-	}
+	return perspect[Mat4](fovy, aspect, near, far)
+}
+
+// Frustum builds a perspective projection from the near plane rectangle,
+// matching glFrustum.
+func Frustum(left, right, bottom, top, near, far float32) Mat4 {
+	return frustum[Mat4](left, right, bottom, top, near, far)
+}
+
+// PerspectiveInfinite is Perspect with the far plane pushed to infinity.
+func PerspectiveInfinite(fovy, aspect, near float32) Mat4 {
+	return perspectiveInfinite[Mat4](fovy, aspect, near)
+}
+
+// PerspectiveReversedZ maps near to depth 1 and far to depth 0. It expects a
+// [0, 1] clip depth range (glClipControl with GL_ZERO_TO_ONE) and a GREATER
+// depth test.
+func PerspectiveReversedZ(fovy, aspect, near, far float32) Mat4 {
+	return perspectiveReversedZ[Mat4](fovy, aspect, near, far)
+}
+
+// PerspectiveInfiniteReversedZ is PerspectiveReversedZ with the far plane at
+// infinity.
+func PerspectiveInfiniteReversedZ(fovy, aspect, near float32) Mat4 {
+	return perspectiveInfiniteReversedZ[Mat4](fovy, aspect, near)
+}
+
+// Ortho builds an orthographic projection, matching glOrtho.
+func Ortho(left, right, bottom, top, near, far float32) Mat4 {
+	return ortho[Mat4](left, right, bottom, top, near, far)
+}
+
+// LookAt builds a right-handed view matrix for a camera at eye looking at
+// center.
+func LookAt(eye, center, up Vec3) Mat4 {
+	return lookAt[Mat4](eye, center, up)
 }
@@ -3,6 +3,8 @@ package glm
 import (
 	"fmt"
 	"testing"
+
+	m32 "github.com/chewxy/math32"
 )
 
 func TestMulv(t *testing.T) {
@@ -80,7 +82,7 @@ func TestTimes(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.result, func(t *testing.T) {
-			res := fmt.Sprint(c.left.Times(c.right))
+			res := fmt.Sprint([16]float32(c.left.Times(c.right)))
 			if res != c.result {
 				t.Fatal(res)
 			}
@@ -97,7 +99,7 @@ func TestPerspective(t *testing.T) {
 		out    string
 	}{
 		{
-			Deg2Rad(45),
+			Rad(45),
 			float32(640) / 480,
 			0.1,
 			100,
@@ -108,7 +110,7 @@ func TestPerspective(t *testing.T) {
 	for _, c := range cases {
 		t.Run(fmt.Sprintf("%f %f %f %f", c.fov, c.aspect, c.near, c.far), func(t *testing.T) {
 
-			res := fmt.Sprint(Perspective(c.fov, c.aspect, c.near, c.far))
+			res := fmt.Sprint([16]float32(Perspective(c.fov, c.aspect, c.near, c.far)))
 
 			if res != c.out {
 				t.Fatal(res)
@@ -117,3 +119,73 @@ func TestPerspective(t *testing.T) {
 		})
 	}
 }
+
+func project(m Mat4, p Vec3) Vec3 {
+	v := m.Mulv(p.Vec4(1))
+	return v.Vec3().Scale(1 / v[3])
+}
+
+func TestLookAt(t *testing.T) {
+	cases := []struct {
+		name            string
+		eye, center, up Vec3
+		in, out         Vec3
+	}{
+		{"eye maps to origin", Vec3{1, 2, 3}, Vec3{4, -1, 0}, Vec3{0, 1, 0}, Vec3{1, 2, 3}, Vec3{0, 0, 0}},
+		{"center on -z", Vec3{0, 0, 5}, Vec3{0, 0, 0}, Vec3{0, 1, 0}, Vec3{0, 0, 0}, Vec3{0, 0, -5}},
+		{"up on +y", Vec3{0, 0, 5}, Vec3{0, 0, 0}, Vec3{0, 1, 0}, Vec3{0, 1, 5}, Vec3{0, 1, 0}},
+		{"looking down +x", Vec3{0, 0, 0}, Vec3{1, 0, 0}, Vec3{0, 1, 0}, Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := project(LookAt(c.eye, c.center, c.up), c.in)
+			if !res.ApproxEqual(c.out) {
+				t.Fatal(res)
+			}
+		})
+	}
+
+	view := LookAt(Vec3{0, .2, 0}, Vec3{0, .2, -1}, Vec3{0, 1, 0})
+	if !view.ApproxEqual(Identity().Translate(Vec3{0, -.2, 0})) {
+		t.Fatal(view)
+	}
+}
+
+func TestProjections(t *testing.T) {
+	fovy, aspect := Rad(60), float32(16)/9
+	top := .1 * m32.Tan(fovy/2)
+	right := top * aspect
+
+	cases := []struct {
+		name string
+		mat  Mat4
+		in   Vec3
+		out  Vec3
+	}{
+		{"ortho min", Ortho(-2, 2, -1, 1, .5, 10), Vec3{-2, -1, -.5}, Vec3{-1, -1, -1}},
+		{"ortho max", Ortho(-2, 2, -1, 1, .5, 10), Vec3{2, 1, -10}, Vec3{1, 1, 1}},
+		{"ortho offset", Ortho(0, 640, 0, 480, -1, 1), Vec3{320, 240, 0}, Vec3{0, 0, 0}},
+		{"frustum near corner", Frustum(-1, 3, -2, 2, 1, 10), Vec3{3, 2, -1}, Vec3{1, 1, -1}},
+		{"frustum far corner", Frustum(-1, 3, -2, 2, 1, 10), Vec3{-10, -20, -10}, Vec3{-1, -1, 1}},
+		{"infinite near", PerspectiveInfinite(fovy, aspect, .1), Vec3{0, 0, -.1}, Vec3{0, 0, -1}},
+		{"infinite far", PerspectiveInfinite(fovy, aspect, .1), Vec3{0, 0, -1e6}, Vec3{0, 0, 1}},
+		{"reversed near", PerspectiveReversedZ(fovy, aspect, .1, 100), Vec3{right, top, -.1}, Vec3{1, 1, 1}},
+		{"reversed far", PerspectiveReversedZ(fovy, aspect, .1, 100), Vec3{0, 0, -100}, Vec3{0, 0, 0}},
+		{"infinite reversed near", PerspectiveInfiniteReversedZ(fovy, aspect, .1), Vec3{0, 0, -.1}, Vec3{0, 0, 1}},
+		{"infinite reversed far", PerspectiveInfiniteReversedZ(fovy, aspect, .1), Vec3{0, 0, -1e6}, Vec3{0, 0, 0}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := project(c.mat, c.in)
+			if !res.ApproxEqual(c.out) {
+				t.Fatal(res)
+			}
+		})
+	}
+
+	if res := Frustum(-right, right, -top, top, .1, 100); !res.ApproxEqual(Perspect(fovy, aspect, .1, 100)) {
+		t.Fatal(res)
+	}
+}
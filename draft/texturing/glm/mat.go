@@ -0,0 +1,264 @@
+package glm
+
+type Mat2 [2 * 2]float32
+type Mat3 [3 * 3]float32
+
+var mat2id Mat2 = Mat2{
+	1, 0,
+	0, 1,
+}
+
+var mat3id Mat3 = Mat3{
+	1, 0, 0,
+	0, 1, 0,
+	0, 0, 1,
+}
+
+func Identity2() Mat2 {
+	return mat2id
+}
+
+func Identity3() Mat3 {
+	return mat3id
+}
+
+func i2(r, c int) int {
+	return 2*c + r
+}
+
+func i3(r, c int) int {
+	return 3*c + r
+}
+
+// Mat2
+
+func (m Mat2) Times(o Mat2) (n Mat2) {
+	for r := 0; r < 2; r++ {
+		for c := 0; c < 2; c++ {
+			for i := 0; i < 2; i++ {
+				n[i2(r, c)] += m[i2(r, i)] * o[i2(i, c)]
+			}
+		}
+	}
+	return
+}
+
+func (m Mat2) Mulv(v Vec2) (n Vec2) {
+	for c := 0; c < 2; c++ {
+		for r := 0; r < 2; r++ {
+			n[r] += m[i2(r, c)] * v[c]
+		}
+	}
+	return
+}
+
+func (m Mat2) Transpose() Mat2 {
+	return Mat2{
+		m[0], m[2],
+		m[1], m[3],
+	}
+}
+
+func (m Mat2) Det() float32 {
+	return m[0]*m[3] - m[1]*m[2]
+}
+
+// Invert returns the inverse of m and false if m is singular.
+func (m Mat2) Invert() (n Mat2, ok bool) {
+	det := m.Det()
+	if det == 0 {
+		return
+	}
+	inv := 1 / det
+	return Mat2{
+		m[3] * inv, -m[1] * inv,
+		-m[2] * inv, m[0] * inv,
+	}, true
+}
+
+func (m Mat2) ApproxEqual(o Mat2) bool {
+	for i := range m {
+		if !FloatEqual(m[i], o[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Mat2) Ptr() *float32 {
+	return &m[0]
+}
+
+// Mat3
+
+func (m Mat3) Times(o Mat3) (n Mat3) {
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			for i := 0; i < 3; i++ {
+				n[i3(r, c)] += m[i3(r, i)] * o[i3(i, c)]
+			}
+		}
+	}
+	return
+}
+
+func (m Mat3) Mulv(v Vec3) (n Vec3) {
+	for c := 0; c < 3; c++ {
+		for r := 0; r < 3; r++ {
+			n[r] += m[i3(r, c)] * v[c]
+		}
+	}
+	return
+}
+
+func (m Mat3) Transpose() (n Mat3) {
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			n[i3(r, c)] = m[i3(c, r)]
+		}
+	}
+	return
+}
+
+func (m Mat3) Det() float32 {
+	return m[0]*(m[4]*m[8]-m[7]*m[5]) -
+		m[3]*(m[1]*m[8]-m[7]*m[2]) +
+		m[6]*(m[1]*m[5]-m[4]*m[2])
+}
+
+// Invert returns the inverse of m and false if m is singular.
+func (m Mat3) Invert() (n Mat3, ok bool) {
+	det := m.Det()
+	if det == 0 {
+		return
+	}
+	inv := 1 / det
+	n = Mat3{
+		(m[4]*m[8] - m[5]*m[7]) * inv,
+		(m[2]*m[7] - m[1]*m[8]) * inv,
+		(m[1]*m[5] - m[2]*m[4]) * inv,
+
+		(m[5]*m[6] - m[3]*m[8]) * inv,
+		(m[0]*m[8] - m[2]*m[6]) * inv,
+		(m[2]*m[3] - m[0]*m[5]) * inv,
+
+		(m[3]*m[7] - m[4]*m[6]) * inv,
+		(m[1]*m[6] - m[0]*m[7]) * inv,
+		(m[0]*m[4] - m[1]*m[3]) * inv,
+	}
+	return n, true
+}
+
+func (m Mat3) Mat4() Mat4 {
+	return Mat4{
+		m[0], m[1], m[2], 0,
+		m[3], m[4], m[5], 0,
+		m[6], m[7], m[8], 0,
+		0, 0, 0, 1,
+	}
+}
+
+func (m Mat3) ApproxEqual(o Mat3) bool {
+	for i := range m {
+		if !FloatEqual(m[i], o[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Mat3) Ptr() *float32 {
+	return &m[0]
+}
+
+// Mat4
+
+func (m Mat4) Transpose() Mat4 {
+	return transpose(m)
+}
+
+func (m Mat4) Det() float32 {
+	_, det := cofactors(m)
+	return det
+}
+
+// Invert returns the inverse of m and false if m is singular.
+func (m Mat4) Invert() (Mat4, bool) {
+	return invert(m)
+}
+
+// Mat3 returns the upper-left 3x3 part of m.
+func (m Mat4) Mat3() Mat3 {
+	return Mat3{
+		m[0], m[1], m[2],
+		m[4], m[5], m[6],
+		m[8], m[9], m[10],
+	}
+}
+
+// NormalMatrix returns the inverse-transpose of the upper-left 3x3 part of m,
+// used to bring normals into world space under non-uniform scale.
+func (m Mat4) NormalMatrix() (Mat3, bool) {
+	inv, ok := m.Mat3().Invert()
+	if !ok {
+		return Mat3{}, false
+	}
+	return inv.Transpose(), true
+}
+
+func (m Mat4) ApproxEqual(o Mat4) bool {
+	for i := range m {
+		if !FloatEqual(m[i], o[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ApproxEqualThreshold is ApproxEqual with an explicit tolerance.
+func (m Mat4) ApproxEqualThreshold(o Mat4, eps float32) bool {
+	for i := range m {
+		if !FloatEqualThreshold(m[i], o[i], eps) {
+			return false
+		}
+	}
+	return true
+}
+
+// At returns the element in row r and column c. The backing array is column
+// major, so m[i] is not the same as reading the matrix left to right.
+func (m Mat4) At(r, c int) float32 {
+	return m[i4(r, c)]
+}
+
+func (m *Mat4) Set(r, c int, v float32) {
+	m[i4(r, c)] = v
+}
+
+func (m Mat4) Row(r int) Vec4 {
+	return row[Mat4, Vec4](m, r)
+}
+
+func (m Mat4) Col(c int) Vec4 {
+	return col[Mat4, Vec4](m, c)
+}
+
+// FromRows builds a matrix the way it is written on paper.
+func FromRows(r0, r1, r2, r3 Vec4) (m Mat4) {
+	for r, row := range [4]Vec4{r0, r1, r2, r3} {
+		for c := 0; c < 4; c++ {
+			m[i4(r, c)] = row[c]
+		}
+	}
+	return
+}
+
+// FromCols builds a matrix from its columns, which is also its memory layout.
+func FromCols(c0, c1, c2, c3 Vec4) (m Mat4) {
+	for c, col := range [4]Vec4{c0, c1, c2, c3} {
+		for r := 0; r < 4; r++ {
+			m[i4(r, c)] = col[r]
+		}
+	}
+	return
+}
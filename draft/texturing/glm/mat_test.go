@@ -0,0 +1,181 @@
+package glm
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMat4Inverse(t *testing.T) {
+	cases := []struct {
+		name string
+		mat  Mat4
+	}{
+		{"identity", Identity()},
+		{"translate", Identity().Translate(Vec3{1, -2, 3})},
+		{"rotate", RotationX(Rad(30)).Times(RotationZ(Rad(-70)))},
+		{"trs", Identity().Translate(Vec3{.5, -.5, 0}).Times(RotationZ(1.2)).Times(Mat4{
+			2, 0, 0, 0,
+			0, 3, 0, 0,
+			0, 0, .5, 0,
+			0, 0, 0, 1,
+		})},
+		{"perspective", Perspect(Rad(45), 4./3, .1, 100)},
+		{"generic", Mat4{
+			4, 0, 1, 2,
+			1, 3, 0, 1,
+			0, 2, 5, 1,
+			1, 1, 1, 6,
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			inv, ok := c.mat.Invert()
+			if !ok {
+				t.Fatal("singular")
+			}
+			if res := c.mat.Times(inv); !res.ApproxEqual(Identity()) {
+				t.Fatal(res)
+			}
+			if res := inv.Times(c.mat); !res.ApproxEqual(Identity()) {
+				t.Fatal(res)
+			}
+		})
+	}
+}
+
+func TestMat4Singular(t *testing.T) {
+	m := Mat4{
+		1, 2, 3, 4,
+		2, 4, 6, 8,
+		0, 1, 0, 1,
+		1, 0, 1, 0,
+	}
+	if _, ok := m.Invert(); ok {
+		t.Fatal("expected singular")
+	}
+	if _, ok := (Mat4{}).NormalMatrix(); ok {
+		t.Fatal("expected singular")
+	}
+}
+
+func TestMat4Det(t *testing.T) {
+	cases := []struct {
+		mat Mat4
+		out string
+	}{
+		{Identity(), "1"},
+		{Identity().Translate(Vec3{5, 6, 7}), "1"},
+		{Mat4{
+			2, 0, 0, 0,
+			0, 3, 0, 0,
+			0, 0, 4, 0,
+			0, 0, 0, 1,
+		}, "24"},
+		{Mat4{
+			4, 0, 1, 2,
+			1, 3, 0, 1,
+			0, 2, 5, 1,
+			1, 1, 1, 6,
+		}, "324"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.out, func(t *testing.T) {
+			res := fmt.Sprint(c.mat.Det())
+			if res != c.out {
+				t.Fatal(res)
+			}
+		})
+	}
+}
+
+func TestMat4Transpose(t *testing.T) {
+	m := Mat4{
+		1, 2, 3, 4,
+		5, 6, 7, 8,
+		9, 10, 11, 12,
+		13, 14, 15, 16,
+	}
+	res := fmt.Sprint([16]float32(m.Transpose()))
+	if res != "[1 5 9 13 2 6 10 14 3 7 11 15 4 8 12 16]" {
+		t.Fatal(res)
+	}
+}
+
+func TestNormalMatrix(t *testing.T) {
+	cases := []struct {
+		name string
+		mat  Mat4
+		in   Vec3
+		out  Vec3
+	}{
+		{"translate only", Identity().Translate(Vec3{1, 2, 3}), Vec3{0, 1, 0}, Vec3{0, 1, 0}},
+		{"rotation", RotationZ(Rad(90)), Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{"non-uniform scale", Mat4{
+			2, 0, 0, 0,
+			0, 1, 0, 0,
+			0, 0, 1, 0,
+			0, 0, 0, 1,
+		}, Vec3{1, 1, 0}, Vec3{.5, 1, 0}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			n, ok := c.mat.NormalMatrix()
+			if !ok {
+				t.Fatal("singular")
+			}
+			if res := n.Mulv(c.in); !res.ApproxEqual(c.out) {
+				t.Fatal(res)
+			}
+		})
+	}
+}
+
+func TestMat3(t *testing.T) {
+	m := Mat3{
+		2, 0, 1,
+		1, 3, 2,
+		1, 1, 2,
+	}
+
+	if res := fmt.Sprint(m.Det()); res != "6" {
+		t.Fatal(res)
+	}
+	if inv, ok := m.Invert(); !ok || !m.Times(inv).ApproxEqual(Identity3()) {
+		t.Fatal(inv, ok)
+	}
+	if res := fmt.Sprint(m.Transpose()); res != "[2 1 1 0 3 1 1 2 2]" {
+		t.Fatal(res)
+	}
+	if res := fmt.Sprint(m.Mulv(Vec3{1, 0, 0})); res != "[2 0 1]" {
+		t.Fatal(res)
+	}
+	if _, ok := (Mat3{1, 2, 3, 2, 4, 6, 0, 0, 1}).Invert(); ok {
+		t.Fatal("expected singular")
+	}
+}
+
+func TestMat2(t *testing.T) {
+	m := Mat2{
+		4, 2,
+		7, 6,
+	}
+
+	if res := fmt.Sprint(m.Det()); res != "10" {
+		t.Fatal(res)
+	}
+	if inv, ok := m.Invert(); !ok || !m.Times(inv).ApproxEqual(Identity2()) {
+		t.Fatal(inv, ok)
+	}
+	if res := fmt.Sprint(m.Transpose()); res != "[4 7 2 6]" {
+		t.Fatal(res)
+	}
+	if res := fmt.Sprint(m.Mulv(Vec2{0, 1})); res != "[7 6]" {
+		t.Fatal(res)
+	}
+	if _, ok := (Mat2{1, 2, 2, 4}).Invert(); ok {
+		t.Fatal("expected singular")
+	}
+}
@@ -0,0 +1,176 @@
+package glm
+
+import (
+	m32 "github.com/chewxy/math32"
+)
+
+// Quat is a rotation quaternion stored as {x, y, z, w}.
+type Quat [4]float32
+
+func QuatIdent() Quat {
+	return Quat{0, 0, 0, 1}
+}
+
+// QuatFromAxisAngle returns the rotation by angle radians around axis.
+func QuatFromAxisAngle(axis Vec3, angle float32) Quat {
+	s, c := m32.Sincos(angle / 2)
+	a := axis.Normalize().Scale(s)
+	return Quat{a[0], a[1], a[2], c}
+}
+
+// QuatFromEuler returns the rotation applying x first, then y, then z, the
+// same as RotationZ(z).Times(RotationY(y)).Times(RotationX(x)).
+func QuatFromEuler(x, y, z float32) Quat {
+	qx := QuatFromAxisAngle(Vec3{1, 0, 0}, x)
+	qy := QuatFromAxisAngle(Vec3{0, 1, 0}, y)
+	qz := QuatFromAxisAngle(Vec3{0, 0, 1}, z)
+	return qz.Mul(qy).Mul(qx)
+}
+
+// QuatFromMat4 extracts the rotation from the upper 3x3 part of m, which must
+// be orthonormal.
+func QuatFromMat4(m Mat4) (q Quat) {
+	m00, m01, m02 := m[i4(0, 0)], m[i4(0, 1)], m[i4(0, 2)]
+	m10, m11, m12 := m[i4(1, 0)], m[i4(1, 1)], m[i4(1, 2)]
+	m20, m21, m22 := m[i4(2, 0)], m[i4(2, 1)], m[i4(2, 2)]
+
+	trace := m00 + m11 + m22
+	switch {
+	case trace > 0:
+		s := 0.5 / m32.Sqrt(trace+1)
+		q = Quat{(m21 - m12) * s, (m02 - m20) * s, (m10 - m01) * s, 0.25 / s}
+	case m00 > m11 && m00 > m22:
+		s := 2 * m32.Sqrt(1+m00-m11-m22)
+		q = Quat{0.25 * s, (m01 + m10) / s, (m02 + m20) / s, (m21 - m12) / s}
+	case m11 > m22:
+		s := 2 * m32.Sqrt(1+m11-m00-m22)
+		q = Quat{(m01 + m10) / s, 0.25 * s, (m12 + m21) / s, (m02 - m20) / s}
+	default:
+		s := 2 * m32.Sqrt(1+m22-m00-m11)
+		q = Quat{(m02 + m20) / s, (m12 + m21) / s, 0.25 * s, (m10 - m01) / s}
+	}
+	return q.Normalize()
+}
+
+func (q Quat) V() Vec3 {
+	return Vec3{q[0], q[1], q[2]}
+}
+
+func (q Quat) W() float32 {
+	return q[3]
+}
+
+// Mul composes two rotations: the result applies o first, then q.
+func (q Quat) Mul(o Quat) Quat {
+	v := o.V().Scale(q[3]).Add(q.V().Scale(o[3])).Add(q.V().Cross(o.V()))
+	return Quat{v[0], v[1], v[2], q[3]*o[3] - q.V().Dot(o.V())}
+}
+
+func (q Quat) Scale(s float32) Quat {
+	return Quat{q[0] * s, q[1] * s, q[2] * s, q[3] * s}
+}
+
+func (q Quat) Add(o Quat) Quat {
+	return Quat{q[0] + o[0], q[1] + o[1], q[2] + o[2], q[3] + o[3]}
+}
+
+func (q Quat) Dot(o Quat) float32 {
+	return q[0]*o[0] + q[1]*o[1] + q[2]*o[2] + q[3]*o[3]
+}
+
+func (q Quat) Len() float32 {
+	return m32.Sqrt(q.Dot(q))
+}
+
+func (q Quat) Normalize() Quat {
+	l := q.Len()
+	if l == 0 {
+		return QuatIdent()
+	}
+	return q.Scale(1 / l)
+}
+
+func (q Quat) Conjugate() Quat {
+	return Quat{-q[0], -q[1], -q[2], q[3]}
+}
+
+func (q Quat) Inverse() Quat {
+	d := q.Dot(q)
+	if d == 0 {
+		return QuatIdent()
+	}
+	return q.Conjugate().Scale(1 / d)
+}
+
+// Rotate applies the rotation to v. q must be a unit quaternion.
+func (q Quat) Rotate(v Vec3) Vec3 {
+	u := q.V()
+	t := u.Cross(v).Scale(2)
+	return v.Add(t.Scale(q[3])).Add(u.Cross(t))
+}
+
+// AxisAngle returns the rotation axis and angle in radians of a unit
+// quaternion.
+func (q Quat) AxisAngle() (Vec3, float32) {
+	if q[3] < 0 {
+		q = q.Scale(-1)
+	}
+	s := m32.Sqrt(1 - Clamp(q[3]*q[3], 0, 1))
+	angle := 2 * m32.Acos(Clamp(q[3], -1, 1))
+	if s < 1e-6 {
+		return Vec3{1, 0, 0}, angle
+	}
+	return q.V().Scale(1 / s), angle
+}
+
+func (q Quat) ToMat4() Mat4 {
+	x, y, z, w := q[0], q[1], q[2], q[3]
+
+	m := mat4id
+	m[i4(0, 0)] = 1 - 2*(y*y+z*z)
+	m[i4(0, 1)] = 2 * (x*y - w*z)
+	m[i4(0, 2)] = 2 * (x*z + w*y)
+	m[i4(1, 0)] = 2 * (x*y + w*z)
+	m[i4(1, 1)] = 1 - 2*(x*x+z*z)
+	m[i4(1, 2)] = 2 * (y*z - w*x)
+	m[i4(2, 0)] = 2 * (x*z - w*y)
+	m[i4(2, 1)] = 2 * (y*z + w*x)
+	m[i4(2, 2)] = 1 - 2*(x*x+y*y)
+	return m
+}
+
+// Nlerp interpolates linearly along the shortest path and renormalizes.
+func (q Quat) Nlerp(o Quat, t float32) Quat {
+	if q.Dot(o) < 0 {
+		o = o.Scale(-1)
+	}
+	return q.Scale(1 - t).Add(o.Scale(t)).Normalize()
+}
+
+// Slerp interpolates at constant angular velocity along the shortest path.
+func (q Quat) Slerp(o Quat, t float32) Quat {
+	cos := q.Dot(o)
+	if cos < 0 {
+		o, cos = o.Scale(-1), -cos
+	}
+	if cos > 1-Epsilon {
+		return q.Nlerp(o, t)
+	}
+
+	theta := m32.Acos(cos)
+	sin := m32.Sin(theta)
+	a := m32.Sin((1-t)*theta) / sin
+	b := m32.Sin(t*theta) / sin
+	return q.Scale(a).Add(o.Scale(b))
+}
+
+func (q Quat) ApproxEqual(o Quat) bool {
+	return FloatEqual(q[0], o[0]) && FloatEqual(q[1], o[1]) &&
+		FloatEqual(q[2], o[2]) && FloatEqual(q[3], o[3])
+}
+
+// ApproxEqualRotation reports whether q and o describe the same rotation,
+// treating q and -q as equal.
+func (q Quat) ApproxEqualRotation(o Quat) bool {
+	return q.ApproxEqual(o) || q.ApproxEqual(o.Scale(-1))
+}
@@ -0,0 +1,94 @@
+package glm
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestQuatRotationBuilders(t *testing.T) {
+	cases := []struct {
+		name string
+		quat Quat
+		mat  Mat4
+	}{
+		{"x 30", QuatFromAxisAngle(Vec3{1, 0, 0}, Rad(30)), RotationX(Rad(30))},
+		{"x -120", QuatFromAxisAngle(Vec3{1, 0, 0}, Rad(-120)), RotationX(Rad(-120))},
+		{"y 45", QuatFromAxisAngle(Vec3{0, 1, 0}, Rad(45)), RotationY(Rad(45))},
+		{"y 170", QuatFromAxisAngle(Vec3{0, 2, 0}, Rad(170)), RotationY(Rad(170))},
+		{"z 90", QuatFromAxisAngle(Vec3{0, 0, 1}, Rad(90)), RotationZ(Rad(90))},
+		{"z 200", QuatFromAxisAngle(Vec3{0, 0, 1}, Rad(200)), RotationZ(Rad(200))},
+		{"euler", QuatFromEuler(Rad(-55), Rad(10), Rad(90)),
+			RotationZ(Rad(90)).Times(RotationY(Rad(10))).Times(RotationX(Rad(-55)))},
+		{"euler gimbal", QuatFromEuler(Rad(20), Rad(90), Rad(-30)),
+			RotationZ(Rad(-30)).Times(RotationY(Rad(90))).Times(RotationX(Rad(20)))},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if res := c.quat.ToMat4(); !res.ApproxEqual(c.mat) {
+				t.Fatal(res)
+			}
+			if res := QuatFromMat4(c.mat); !res.ApproxEqualRotation(c.quat) {
+				t.Fatal(res)
+			}
+			v := Vec3{.3, -1, 2}
+			if res := c.quat.Rotate(v); !res.ApproxEqual(c.mat.Mulv(v.Vec4(0)).Vec3()) {
+				t.Fatal(res)
+			}
+		})
+	}
+}
+
+func TestQuatAlgebra(t *testing.T) {
+	a := QuatFromAxisAngle(Vec3{1, 2, 3}, .7)
+	b := QuatFromAxisAngle(Vec3{-1, 0, 1}, 2.1)
+
+	if res := a.Mul(b).ToMat4(); !res.ApproxEqual(a.ToMat4().Times(b.ToMat4())) {
+		t.Fatal(res)
+	}
+	if res := a.Mul(a.Inverse()); !res.ApproxEqual(QuatIdent()) {
+		t.Fatal(res)
+	}
+	if res := a.Conjugate(); !res.ApproxEqual(a.Inverse()) {
+		t.Fatal(res)
+	}
+	if res := fmt.Sprint(Quat{0, 3, 0, 4}.Normalize()); res != "[0 0.6 0 0.8]" {
+		t.Fatal(res)
+	}
+	if res := (Quat{0, 0, 2, 0}).Inverse(); !res.ApproxEqual(Quat{0, 0, -.5, 0}) {
+		t.Fatal(res)
+	}
+
+	axis, angle := b.AxisAngle()
+	if !axis.ApproxEqual(Vec3{-1, 0, 1}.Normalize()) || !FloatEqual(angle, 2.1) {
+		t.Fatal(axis, angle)
+	}
+}
+
+func TestQuatInterpolation(t *testing.T) {
+	from := QuatIdent()
+	to := QuatFromAxisAngle(Vec3{0, 0, 1}, Rad(90))
+
+	cases := []struct {
+		name string
+		res  Quat
+		out  Quat
+	}{
+		{"slerp start", from.Slerp(to, 0), from},
+		{"slerp end", from.Slerp(to, 1), to},
+		{"slerp half", from.Slerp(to, .5), QuatFromAxisAngle(Vec3{0, 0, 1}, Rad(45))},
+		{"slerp quarter", from.Slerp(to, .25), QuatFromAxisAngle(Vec3{0, 0, 1}, Rad(22.5))},
+		{"slerp shortest", from.Slerp(to.Scale(-1), .5), QuatFromAxisAngle(Vec3{0, 0, 1}, Rad(45))},
+		{"slerp same", to.Slerp(to, .3), to},
+		{"nlerp half", from.Nlerp(to, .5), QuatFromAxisAngle(Vec3{0, 0, 1}, Rad(45))},
+		{"nlerp end", from.Nlerp(to, 1), to},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if !c.res.ApproxEqualRotation(c.out) {
+				t.Fatal(c.res)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package glm
+
+import (
+	m32 "github.com/chewxy/math32"
+)
+
+// Transform is a translation, rotation and scale applied in TRS order: scale
+// first, then rotation, then translation.
+type Transform struct {
+	Translation Vec3
+	Rotation    Quat
+	Scale       Vec3
+}
+
+func TransformIdent() Transform {
+	return Transform{
+		Rotation: QuatIdent(),
+		Scale:    Vec3{1, 1, 1},
+	}
+}
+
+func (t Transform) Matrix() Mat4 {
+	m := t.Rotation.ToMat4()
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			m[i4(r, c)] *= t.Scale[c]
+		}
+	}
+	m[i4(0, 3)] = t.Translation[0]
+	m[i4(1, 3)] = t.Translation[1]
+	m[i4(2, 3)] = t.Translation[2]
+	return m
+}
+
+// Decompose splits an affine matrix into translation, rotation and scale. It
+// returns false when m is projective, degenerate (a zero scale axis) or
+// contains shear, since none of those can be represented by a Transform.
+// A negative determinant is folded into the x scale.
+func Decompose(m Mat4) (t Transform, ok bool) {
+	if !FloatEqual(m[i4(3, 0)], 0) || !FloatEqual(m[i4(3, 1)], 0) ||
+		!FloatEqual(m[i4(3, 2)], 0) || !FloatEqual(m[i4(3, 3)], 1) {
+		return
+	}
+
+	t.Translation = Vec3{m[i4(0, 3)], m[i4(1, 3)], m[i4(2, 3)]}
+
+	var cols [3]Vec3
+	for c := 0; c < 3; c++ {
+		cols[c] = Vec3{m[i4(0, c)], m[i4(1, c)], m[i4(2, c)]}
+		t.Scale[c] = cols[c].Len()
+		if t.Scale[c] < Epsilon {
+			return
+		}
+		cols[c] = cols[c].Scale(1 / t.Scale[c])
+	}
+
+	for i := 0; i < 3; i++ {
+		if m32.Abs(cols[i].Dot(cols[(i+1)%3])) > 1e-4 {
+			return
+		}
+	}
+
+	if cols[0].Cross(cols[1]).Dot(cols[2]) < 0 {
+		t.Scale[0] = -t.Scale[0]
+		cols[0] = cols[0].Scale(-1)
+	}
+
+	rot := mat4id
+	for c := 0; c < 3; c++ {
+		for r := 0; r < 3; r++ {
+			rot[i4(r, c)] = cols[c][r]
+		}
+	}
+	t.Rotation = QuatFromMat4(rot)
+	return t, true
+}
+
+func (t Transform) ApproxEqual(o Transform) bool {
+	return t.Translation.ApproxEqual(o.Translation) &&
+		t.Rotation.ApproxEqualRotation(o.Rotation) &&
+		t.Scale.ApproxEqual(o.Scale)
+}
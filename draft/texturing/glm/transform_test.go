@@ -0,0 +1,112 @@
+package glm
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTransformRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		in   Transform
+	}{
+		{"identity", TransformIdent()},
+		{"translate", Transform{Vec3{1, -2, 3}, QuatIdent(), Vec3{1, 1, 1}}},
+		{"uniform", Transform{Vec3{0, 5, 0}, QuatFromAxisAngle(Vec3{0, 1, 0}, 1), Vec3{2, 2, 2}}},
+		{"non-uniform", Transform{Vec3{.5, -.5, 0}, QuatFromEuler(.3, -1.1, 2), Vec3{.5, 3, 1.5}}},
+		{"mirrored", Transform{Vec3{}, QuatFromAxisAngle(Vec3{1, 1, 0}, .4), Vec3{-2, 1, 1}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res, ok := Decompose(c.in.Matrix())
+			if !ok {
+				t.Fatal("not decomposable")
+			}
+			if !res.ApproxEqual(c.in) {
+				t.Fatal(res)
+			}
+		})
+	}
+}
+
+func TestTransformMatrix(t *testing.T) {
+	tr := Transform{Vec3{1, 2, 3}, QuatFromAxisAngle(Vec3{0, 0, 1}, Rad(90)), Vec3{2, 1, 1}}
+	want := Identity().Translate(Vec3{1, 2, 3}).Times(RotationZ(Rad(90))).Times(ScaleMat(Vec3{2, 1, 1}))
+
+	if res := tr.Matrix(); !res.ApproxEqual(want) {
+		t.Fatal(res)
+	}
+
+	// scale, then rotate, then translate
+	want = AxisAngle(Vec3{0, 0, 1}, Rad(90)).Times(ScaleMat(Vec3{2, 1, 1})).Translate(Vec3{1, 2, 3})
+	if res := tr.Matrix(); !res.ApproxEqual(want) {
+		t.Fatal(res)
+	}
+}
+
+func TestDecomposeRejects(t *testing.T) {
+	shear := Identity()
+	shear[i4(0, 1)] = .5
+
+	cases := []struct {
+		name string
+		mat  Mat4
+	}{
+		{"shear", shear},
+		{"shear after rotation", RotationZ(.3).Times(shear).Translate(Vec3{1, 1, 1})},
+		{"zero scale", ScaleMat(Vec3{1, 0, 1})},
+		{"zero", Mat4{}},
+		{"projective", Perspect(Rad(45), 1, .1, 100)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if res, ok := Decompose(c.mat); ok {
+				t.Fatal(res)
+			}
+		})
+	}
+}
+
+func TestMat4Scale(t *testing.T) {
+	cases := []struct {
+		name string
+		mat  Mat4
+		out  string
+	}{
+		{"identity", Identity().Scale(Vec3{2, 3, 4}), "[2 0 0 0 0 3 0 0 0 0 4 0 0 0 0 1]"},
+		{"after translate", Identity().Translate(Vec3{1, 1, 1}).Scale(Vec3{2, 3, 4}), "[2 0 0 0 0 3 0 0 0 0 4 0 2 3 4 1]"},
+		{"builder", ScaleMat(Vec3{2, 3, 4}), "[2 0 0 0 0 3 0 0 0 0 4 0 0 0 0 1]"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := fmt.Sprint([16]float32(c.mat))
+			if res != c.out {
+				t.Fatal(res)
+			}
+		})
+	}
+}
+
+func TestAxisAngle(t *testing.T) {
+	cases := []struct {
+		name string
+		res  Mat4
+		out  Mat4
+	}{
+		{"x", AxisAngle(Vec3{1, 0, 0}, .8), RotationX(.8)},
+		{"y", AxisAngle(Vec3{0, 3, 0}, -1.3), RotationY(-1.3)},
+		{"z", AxisAngle(Vec3{0, 0, 1}, 2.5), RotationZ(2.5)},
+		{"quat", AxisAngle(Vec3{1, -2, .5}, 1), QuatFromAxisAngle(Vec3{1, -2, .5}, 1).ToMat4()},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if !c.res.ApproxEqual(c.out) {
+				t.Fatal(c.res)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package glm
+
+import (
+	m32 "github.com/chewxy/math32"
+)
+
+type Vec2 [2]float32
+
+// Epsilon is the tolerance used by the ApproxEqual helpers.
+var Epsilon float32 = 1e-5
+
+func FloatEqual(a, b float32) bool {
+	return FloatEqualThreshold(a, b, Epsilon)
+}
+
+func FloatEqualThreshold(a, b, eps float32) bool {
+	if a == b {
+		return true
+	}
+	diff := m32.Abs(a - b)
+	if diff <= eps {
+		return true
+	}
+	return diff <= eps*m32.Max(m32.Abs(a), m32.Abs(b))
+}
+
+func Lerp(a, b, t float32) float32 {
+	return a + (b-a)*t
+}
+
+func Clamp(v, lo, hi float32) float32 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Vec2
+
+func (v Vec2) Add(o Vec2) Vec2 {
+	return Vec2{v[0] + o[0], v[1] + o[1]}
+}
+
+func (v Vec2) Sub(o Vec2) Vec2 {
+	return Vec2{v[0] - o[0], v[1] - o[1]}
+}
+
+func (v Vec2) Mul(o Vec2) Vec2 {
+	return Vec2{v[0] * o[0], v[1] * o[1]}
+}
+
+func (v Vec2) Scale(s float32) Vec2 {
+	return Vec2{v[0] * s, v[1] * s}
+}
+
+func (v Vec2) Dot(o Vec2) float32 {
+	return v[0]*o[0] + v[1]*o[1]
+}
+
+// Cross returns the z component of the cross product of v and o lifted to 3D.
+func (v Vec2) Cross(o Vec2) float32 {
+	return v[0]*o[1] - v[1]*o[0]
+}
+
+func (v Vec2) Len() float32 {
+	return m32.Sqrt(v.Dot(v))
+}
+
+func (v Vec2) Normalize() Vec2 {
+	l := v.Len()
+	if l == 0 {
+		return v
+	}
+	return v.Scale(1 / l)
+}
+
+func (v Vec2) Lerp(o Vec2, t float32) Vec2 {
+	return Vec2{Lerp(v[0], o[0], t), Lerp(v[1], o[1], t)}
+}
+
+func (v Vec2) Min(o Vec2) Vec2 {
+	return Vec2{m32.Min(v[0], o[0]), m32.Min(v[1], o[1])}
+}
+
+func (v Vec2) Max(o Vec2) Vec2 {
+	return Vec2{m32.Max(v[0], o[0]), m32.Max(v[1], o[1])}
+}
+
+func (v Vec2) Abs() Vec2 {
+	return Vec2{m32.Abs(v[0]), m32.Abs(v[1])}
+}
+
+func (v Vec2) ApproxEqual(o Vec2) bool {
+	return FloatEqual(v[0], o[0]) && FloatEqual(v[1], o[1])
+}
+
+func (v Vec2) Vec3(z float32) Vec3 {
+	return Vec3{v[0], v[1], z}
+}
+
+func (v Vec2) Vec4(z, w float32) Vec4 {
+	return Vec4{v[0], v[1], z, w}
+}
+
+// Vec3
+
+func (v Vec3) Add(o Vec3) Vec3 {
+	return Vec3{v[0] + o[0], v[1] + o[1], v[2] + o[2]}
+}
+
+func (v Vec3) Sub(o Vec3) Vec3 {
+	return Vec3{v[0] - o[0], v[1] - o[1], v[2] - o[2]}
+}
+
+func (v Vec3) Mul(o Vec3) Vec3 {
+	return Vec3{v[0] * o[0], v[1] * o[1], v[2] * o[2]}
+}
+
+func (v Vec3) Scale(s float32) Vec3 {
+	return Vec3{v[0] * s, v[1] * s, v[2] * s}
+}
+
+func (v Vec3) Dot(o Vec3) float32 {
+	return v[0]*o[0] + v[1]*o[1] + v[2]*o[2]
+}
+
+func (v Vec3) Cross(o Vec3) Vec3 {
+	return Vec3{
+		v[1]*o[2] - v[2]*o[1],
+		v[2]*o[0] - v[0]*o[2],
+		v[0]*o[1] - v[1]*o[0],
+	}
+}
+
+func (v Vec3) Len() float32 {
+	return m32.Sqrt(v.Dot(v))
+}
+
+func (v Vec3) Normalize() Vec3 {
+	l := v.Len()
+	if l == 0 {
+		return v
+	}
+	return v.Scale(1 / l)
+}
+
+func (v Vec3) Lerp(o Vec3, t float32) Vec3 {
+	return Vec3{Lerp(v[0], o[0], t), Lerp(v[1], o[1], t), Lerp(v[2], o[2], t)}
+}
+
+func (v Vec3) Min(o Vec3) Vec3 {
+	return Vec3{m32.Min(v[0], o[0]), m32.Min(v[1], o[1]), m32.Min(v[2], o[2])}
+}
+
+func (v Vec3) Max(o Vec3) Vec3 {
+	return Vec3{m32.Max(v[0], o[0]), m32.Max(v[1], o[1]), m32.Max(v[2], o[2])}
+}
+
+func (v Vec3) Abs() Vec3 {
+	return Vec3{m32.Abs(v[0]), m32.Abs(v[1]), m32.Abs(v[2])}
+}
+
+func (v Vec3) ApproxEqual(o Vec3) bool {
+	return FloatEqual(v[0], o[0]) && FloatEqual(v[1], o[1]) && FloatEqual(v[2], o[2])
+}
+
+func (v Vec3) Vec2() Vec2 {
+	return Vec2{v[0], v[1]}
+}
+
+func (v Vec3) Vec4(w float32) Vec4 {
+	return Vec4{v[0], v[1], v[2], w}
+}
+
+// Vec4
+
+func (v Vec4) Add(o Vec4) Vec4 {
+	return Vec4{v[0] + o[0], v[1] + o[1], v[2] + o[2], v[3] + o[3]}
+}
+
+func (v Vec4) Sub(o Vec4) Vec4 {
+	return Vec4{v[0] - o[0], v[1] - o[1], v[2] - o[2], v[3] - o[3]}
+}
+
+func (v Vec4) Mul(o Vec4) Vec4 {
+	return Vec4{v[0] * o[0], v[1] * o[1], v[2] * o[2], v[3] * o[3]}
+}
+
+func (v Vec4) Scale(s float32) Vec4 {
+	return Vec4{v[0] * s, v[1] * s, v[2] * s, v[3] * s}
+}
+
+func (v Vec4) Dot(o Vec4) float32 {
+	return v[0]*o[0] + v[1]*o[1] + v[2]*o[2] + v[3]*o[3]
+}
+
+func (v Vec4) Len() float32 {
+	return m32.Sqrt(v.Dot(v))
+}
+
+func (v Vec4) Normalize() Vec4 {
+	l := v.Len()
+	if l == 0 {
+		return v
+	}
+	return v.Scale(1 / l)
+}
+
+func (v Vec4) Lerp(o Vec4, t float32) Vec4 {
+	return Vec4{
+		Lerp(v[0], o[0], t),
+		Lerp(v[1], o[1], t),
+		Lerp(v[2], o[2], t),
+		Lerp(v[3], o[3], t),
+	}
+}
+
+func (v Vec4) Min(o Vec4) Vec4 {
+	return Vec4{
+		m32.Min(v[0], o[0]),
+		m32.Min(v[1], o[1]),
+		m32.Min(v[2], o[2]),
+		m32.Min(v[3], o[3]),
+	}
+}
+
+func (v Vec4) Max(o Vec4) Vec4 {
+	return Vec4{
+		m32.Max(v[0], o[0]),
+		m32.Max(v[1], o[1]),
+		m32.Max(v[2], o[2]),
+		m32.Max(v[3], o[3]),
+	}
+}
+
+func (v Vec4) Abs() Vec4 {
+	return Vec4{m32.Abs(v[0]), m32.Abs(v[1]), m32.Abs(v[2]), m32.Abs(v[3])}
+}
+
+func (v Vec4) ApproxEqual(o Vec4) bool {
+	return FloatEqual(v[0], o[0]) && FloatEqual(v[1], o[1]) &&
+		FloatEqual(v[2], o[2]) && FloatEqual(v[3], o[3])
+}
+
+func (v Vec4) Vec2() Vec2 {
+	return Vec2{v[0], v[1]}
+}
+
+func (v Vec4) Vec3() Vec3 {
+	return Vec3{v[0], v[1], v[2]}
+}
@@ -0,0 +1,132 @@
+package glm
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestVec2(t *testing.T) {
+	a, b := Vec2{3, 4}, Vec2{-1, 2}
+
+	cases := []struct {
+		name string
+		res  interface{}
+		out  string
+	}{
+		{"add", a.Add(b), "[2 6]"},
+		{"sub", a.Sub(b), "[4 2]"},
+		{"mul", a.Mul(b), "[-3 8]"},
+		{"scale", a.Scale(2), "[6 8]"},
+		{"dot", a.Dot(b), "5"},
+		{"cross", a.Cross(b), "10"},
+		{"len", a.Len(), "5"},
+		{"normalize", a.Normalize(), "[0.6 0.8]"},
+		{"normalize zero", Vec2{}.Normalize(), "[0 0]"},
+		{"lerp", a.Lerp(b, .5), "[1 3]"},
+		{"min", a.Min(b), "[-1 2]"},
+		{"max", a.Max(b), "[3 4]"},
+		{"abs", b.Abs(), "[1 2]"},
+		{"vec3", a.Vec3(5), "[3 4 5]"},
+		{"vec4", a.Vec4(5, 1), "[3 4 5 1]"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := fmt.Sprint(c.res)
+			if res != c.out {
+				t.Fatal(res)
+			}
+		})
+	}
+}
+
+func TestVec3(t *testing.T) {
+	a, b := Vec3{1, 2, 3}, Vec3{4, -5, 6}
+
+	cases := []struct {
+		name string
+		res  interface{}
+		out  string
+	}{
+		{"add", a.Add(b), "[5 -3 9]"},
+		{"sub", a.Sub(b), "[-3 7 -3]"},
+		{"mul", a.Mul(b), "[4 -10 18]"},
+		{"scale", a.Scale(-1), "[-1 -2 -3]"},
+		{"dot", a.Dot(b), "12"},
+		{"cross", a.Cross(b), "[27 6 -13]"},
+		{"cross xy", Vec3{1, 0, 0}.Cross(Vec3{0, 1, 0}), "[0 0 1]"},
+		{"len", Vec3{2, 3, 6}.Len(), "7"},
+		{"normalize", Vec3{0, 0, -4}.Normalize(), "[0 0 -1]"},
+		{"lerp", a.Lerp(b, .5), "[2.5 -1.5 4.5]"},
+		{"min", a.Min(b), "[1 -5 3]"},
+		{"max", a.Max(b), "[4 2 6]"},
+		{"abs", b.Abs(), "[4 5 6]"},
+		{"vec2", a.Vec2(), "[1 2]"},
+		{"vec4", a.Vec4(1), "[1 2 3 1]"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := fmt.Sprint(c.res)
+			if res != c.out {
+				t.Fatal(res)
+			}
+		})
+	}
+}
+
+func TestVec4(t *testing.T) {
+	a, b := Vec4{1, 2, 3, 4}, Vec4{-4, 3, -2, 1}
+
+	cases := []struct {
+		name string
+		res  interface{}
+		out  string
+	}{
+		{"add", a.Add(b), "[-3 5 1 5]"},
+		{"sub", a.Sub(b), "[5 -1 5 3]"},
+		{"mul", a.Mul(b), "[-4 6 -6 4]"},
+		{"scale", a.Scale(.5), "[0.5 1 1.5 2]"},
+		{"dot", a.Dot(b), "0"},
+		{"len", Vec4{1, 1, 1, 1}.Len(), "2"},
+		{"normalize", Vec4{0, 3, 0, 4}.Normalize(), "[0 0.6 0 0.8]"},
+		{"lerp", a.Lerp(b, 1), "[-4 3 -2 1]"},
+		{"min", a.Min(b), "[-4 2 -2 1]"},
+		{"max", a.Max(b), "[1 3 3 4]"},
+		{"abs", b.Abs(), "[4 3 2 1]"},
+		{"vec2", a.Vec2(), "[1 2]"},
+		{"vec3", a.Vec3(), "[1 2 3]"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := fmt.Sprint(c.res)
+			if res != c.out {
+				t.Fatal(res)
+			}
+		})
+	}
+}
+
+func TestVecApproxEqual(t *testing.T) {
+	cases := []struct {
+		name string
+		res  bool
+		out  bool
+	}{
+		{"vec2 equal", Vec2{1, 2}.ApproxEqual(Vec2{1, 2.000001}), true},
+		{"vec2 differ", Vec2{1, 2}.ApproxEqual(Vec2{1, 2.1}), false},
+		{"vec3 equal", Vec3{.1, .2, .3}.ApproxEqual(Vec3{.1, .2, .3000001}), true},
+		{"vec3 differ", Vec3{.1, .2, .3}.ApproxEqual(Vec3{.1, .2, .31}), false},
+		{"vec4 equal", Vec4{1e6, 0, 0, 1}.ApproxEqual(Vec4{1e6 + 1, 0, 0, 1}), true},
+		{"vec4 differ", Vec4{0, 0, 0, 1}.ApproxEqual(Vec4{0, 0, 0, -1}), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.res != c.out {
+				t.Fatal(c.res)
+			}
+		})
+	}
+}
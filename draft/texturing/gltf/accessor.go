@@ -0,0 +1,229 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Accessor component types.
+const (
+	componentByte          = 5120
+	componentUnsignedByte  = 5121
+	componentShort         = 5122
+	componentUnsignedShort = 5123
+	componentUnsignedInt   = 5125
+	componentFloat         = 5126
+)
+
+var componentSize = map[int]int{
+	componentByte:          1,
+	componentUnsignedByte:  1,
+	componentShort:         2,
+	componentUnsignedShort: 2,
+	componentUnsignedInt:   4,
+	componentFloat:         4,
+}
+
+var typeComponents = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+	"MAT2":   4,
+	"MAT3":   9,
+	"MAT4":   16,
+}
+
+// maxZeroElements caps the count of an accessor without a buffer view,
+// whose zeros take no room in the file.
+const maxZeroElements = 1 << 24
+
+// view returns the bytes of a buffer view and its stride, 0 when tightly
+// packed.
+func (l *loader) view(i int) ([]byte, int, error) {
+	if i < 0 || i >= len(l.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("buffer view %d out of range", i)
+	}
+	v := l.doc.BufferViews[i]
+	if v.Buffer < 0 || v.Buffer >= len(l.buffers) {
+		return nil, 0, fmt.Errorf("buffer view %d: buffer %d out of range", i, v.Buffer)
+	}
+	b := l.buffers[v.Buffer]
+	if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteOffset > len(b) || v.ByteLength > len(b)-v.ByteOffset {
+		return nil, 0, fmt.Errorf("buffer view %d: bytes %d+%d past the end of buffer %d (%d bytes)", i, v.ByteOffset, v.ByteLength, v.Buffer, len(b))
+	}
+	if v.ByteStride < 0 {
+		return nil, 0, fmt.Errorf("buffer view %d: negative byte stride %d", i, v.ByteStride)
+	}
+	return b[v.ByteOffset : v.ByteOffset+v.ByteLength], v.ByteStride, nil
+}
+
+// component reads one component of the given type as a float64, which holds
+// every type exactly.
+func component(b []byte, typ int) float64 {
+	switch typ {
+	case componentByte:
+		return float64(int8(b[0]))
+	case componentUnsignedByte:
+		return float64(b[0])
+	case componentShort:
+		return float64(int16(binary.LittleEndian.Uint16(b)))
+	case componentUnsignedShort:
+		return float64(binary.LittleEndian.Uint16(b))
+	case componentUnsignedInt:
+		return float64(binary.LittleEndian.Uint32(b))
+	}
+	return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+}
+
+// readElements reads count elements of n components from a view, starting at
+// offset. The view must hold them all before anything is allocated, so a
+// small file cannot ask for a large slice.
+func readElements(b []byte, stride, offset, count, n, typ int) ([]float64, error) {
+	size, ok := componentSize[typ]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("unknown component type %d", typ)
+	case offset < 0:
+		return nil, fmt.Errorf("negative byte offset %d", offset)
+	case count < 0:
+		return nil, fmt.Errorf("negative count %d", count)
+	case stride < 0:
+		return nil, fmt.Errorf("negative byte stride %d", stride)
+	}
+	if stride == 0 {
+		stride = n * size
+	}
+	// the last element ends at offset+(count-1)*stride+n*size, which is
+	// compared without overflowing
+	if count > 0 && (offset > len(b) || n*size > len(b)-offset || count-1 > (len(b)-offset-n*size)/stride) {
+		return nil, fmt.Errorf("%d elements at offset %d with stride %d overrun %d bytes", count, offset, stride, len(b))
+	}
+	out := make([]float64, count*n)
+	for e := 0; e < count; e++ {
+		for c := 0; c < n; c++ {
+			out[e*n+c] = component(b[offset+e*stride+c*size:], typ)
+		}
+	}
+	return out, nil
+}
+
+// read returns the raw values of accessor i, count times its number of
+// components, with sparse substitutions applied.
+func (l *loader) read(i int) ([]float64, int, error) {
+	if i < 0 || i >= len(l.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d out of range", i)
+	}
+	a := l.doc.Accessors[i]
+	n, ok := typeComponents[a.Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("accessor %d: unknown type %q", i, a.Type)
+	}
+	if _, ok := componentSize[a.ComponentType]; !ok {
+		return nil, 0, fmt.Errorf("accessor %d: unknown component type %d", i, a.ComponentType)
+	}
+	if a.Count < 0 {
+		return nil, 0, fmt.Errorf("accessor %d: negative count", i)
+	}
+
+	var out []float64
+	if a.BufferView != nil {
+		b, stride, err := l.view(*a.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", i, err)
+		}
+		if out, err = readElements(b, stride, a.ByteOffset, a.Count, n, a.ComponentType); err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", i, err)
+		}
+	} else {
+		// without a buffer view the accessor is all zeros, and nothing in
+		// the file bounds its size
+		if a.Count > maxZeroElements {
+			return nil, 0, fmt.Errorf("accessor %d: %d elements without a buffer view, more than %d", i, a.Count, maxZeroElements)
+		}
+		out = make([]float64, a.Count*n)
+	}
+
+	if s := a.Sparse; s != nil {
+		switch s.Indices.ComponentType {
+		case componentUnsignedByte, componentUnsignedShort, componentUnsignedInt:
+		default:
+			return nil, 0, fmt.Errorf("accessor %d: sparse index component type %d is not an unsigned integer", i, s.Indices.ComponentType)
+		}
+		ib, _, err := l.view(s.Indices.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: sparse indices: %w", i, err)
+		}
+		indices, err := readElements(ib, 0, s.Indices.ByteOffset, s.Count, 1, s.Indices.ComponentType)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: sparse indices: %w", i, err)
+		}
+		vb, _, err := l.view(s.Values.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: sparse values: %w", i, err)
+		}
+		values, err := readElements(vb, 0, s.Values.ByteOffset, s.Count, n, a.ComponentType)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: sparse values: %w", i, err)
+		}
+		for k, idx := range indices {
+			e := int(idx)
+			if e < 0 || e >= a.Count {
+				return nil, 0, fmt.Errorf("accessor %d: sparse index %d out of range of %d", i, e, a.Count)
+			}
+			copy(out[e*n:(e+1)*n], values[k*n:(k+1)*n])
+		}
+	}
+	return out, n, nil
+}
+
+// floats reads accessor i as floats, mapping normalized integers to [0, 1]
+// or [-1, 1].
+func (l *loader) floats(i int) ([]float32, int, error) {
+	raw, n, err := l.read(i)
+	if err != nil {
+		return nil, 0, err
+	}
+	a := l.doc.Accessors[i]
+	scale := 1.0
+	if a.Normalized {
+		switch a.ComponentType {
+		case componentByte:
+			scale = 127
+		case componentUnsignedByte:
+			scale = 255
+		case componentShort:
+			scale = 32767
+		case componentUnsignedShort:
+			scale = 65535
+		}
+	}
+	out := make([]float32, len(raw))
+	for k, v := range raw {
+		out[k] = float32(v / scale)
+		// the most negative signed value maps to -1 too
+		if a.Normalized && out[k] < -1 {
+			out[k] = -1
+		}
+	}
+	return out, n, nil
+}
+
+// uints reads accessor i, which must have an integer component type.
+func (l *loader) uints(i int) ([]uint32, int, error) {
+	raw, n, err := l.read(i)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch l.doc.Accessors[i].ComponentType {
+	case componentUnsignedByte, componentUnsignedShort, componentUnsignedInt:
+	default:
+		return nil, 0, fmt.Errorf("accessor %d: component type %d is not an unsigned integer", i, l.doc.Accessors[i].ComponentType)
+	}
+	out := make([]uint32, len(raw))
+	for k, v := range raw {
+		out[k] = uint32(v)
+	}
+	return out, n, nil
+}
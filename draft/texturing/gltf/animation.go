@@ -0,0 +1,213 @@
+package gltf
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+type Path string
+
+const (
+	PathTranslation Path = "translation"
+	PathRotation    Path = "rotation"
+	PathScale       Path = "scale"
+	PathWeights     Path = "weights"
+)
+
+type Interpolation string
+
+const (
+	Linear      Interpolation = "LINEAR"
+	Step        Interpolation = "STEP"
+	CubicSpline Interpolation = "CUBICSPLINE"
+)
+
+type Animation struct {
+	Name     string
+	Channels []*Channel
+}
+
+// Channel animates one property of a node. Values holds Components floats
+// per keyframe, or three such groups for CUBICSPLINE: in-tangent, value,
+// out-tangent.
+type Channel struct {
+	Node          *Node
+	Path          Path
+	Interpolation Interpolation
+	Times         []float32
+	Values        []float32
+	Components    int
+}
+
+func (l *loader) animation(i int, nodes []*Node) (*Animation, error) {
+	da := l.doc.Animations[i]
+	a := &Animation{Name: da.Name}
+	for k, dc := range da.Channels {
+		// channels without a node belong to extensions
+		if dc.Target.Node == nil {
+			continue
+		}
+		n := *dc.Target.Node
+		if n < 0 || n >= len(nodes) {
+			return nil, fmt.Errorf("channel %d: node %d out of range", k, n)
+		}
+		if dc.Sampler < 0 || dc.Sampler >= len(da.Samplers) {
+			return nil, fmt.Errorf("channel %d: sampler %d out of range", k, dc.Sampler)
+		}
+		s := da.Samplers[dc.Sampler]
+
+		c := &Channel{
+			Node:          nodes[n],
+			Path:          Path(dc.Target.Path),
+			Interpolation: Interpolation(s.Interpolation),
+		}
+		if c.Interpolation == "" {
+			c.Interpolation = Linear
+		}
+		switch c.Interpolation {
+		case Linear, Step, CubicSpline:
+		default:
+			return nil, fmt.Errorf("channel %d: unknown interpolation %q", k, c.Interpolation)
+		}
+
+		var err error
+		var n1 int
+		if c.Times, n1, err = l.floats(s.Input); err != nil {
+			return nil, fmt.Errorf("channel %d: input: %w", k, err)
+		}
+		if n1 != 1 {
+			return nil, fmt.Errorf("channel %d: input has %d components", k, n1)
+		}
+		if !sort.SliceIsSorted(c.Times, func(a, b int) bool { return c.Times[a] < c.Times[b] }) {
+			return nil, fmt.Errorf("channel %d: input times are not increasing", k)
+		}
+		if c.Values, c.Components, err = l.floats(s.Output); err != nil {
+			return nil, fmt.Errorf("channel %d: output: %w", k, err)
+		}
+
+		want := map[Path]int{PathTranslation: 3, PathRotation: 4, PathScale: 3}[c.Path]
+		if c.Path == PathWeights {
+			want = 1
+		}
+		if want == 0 {
+			return nil, fmt.Errorf("channel %d: unknown path %q", k, c.Path)
+		}
+		if c.Components != want {
+			return nil, fmt.Errorf("channel %d: %s output has %d components", k, c.Path, c.Components)
+		}
+		groups := 1
+		if c.Interpolation == CubicSpline {
+			groups = 3
+		}
+		keys := len(c.Values) / (groups * c.Components)
+		if c.Path == PathWeights {
+			// one value per morph target and keyframe
+			if len(c.Times) == 0 || keys%len(c.Times) != 0 {
+				return nil, fmt.Errorf("channel %d: %d weights for %d keyframes", k, keys, len(c.Times))
+			}
+			c.Components = keys / len(c.Times)
+		} else if keys != len(c.Times) {
+			return nil, fmt.Errorf("channel %d: %d values for %d keyframes", k, keys, len(c.Times))
+		}
+		a.Channels = append(a.Channels, c)
+	}
+	return a, nil
+}
+
+// Duration is the time of the last keyframe of any channel.
+func (a *Animation) Duration() float32 {
+	var d float32
+	for _, c := range a.Channels {
+		if n := len(c.Times); n > 0 && c.Times[n-1] > d {
+			d = c.Times[n-1]
+		}
+	}
+	return d
+}
+
+// Apply samples every channel at time t and sets the node transforms.
+// Weight channels and nodes with a matrix are left alone.
+func (a *Animation) Apply(t float32) {
+	for _, c := range a.Channels {
+		if c.Node.Matrix != nil || c.Path == PathWeights {
+			continue
+		}
+		v := c.Sample(t)
+		tr := &c.Node.Transform
+		switch c.Path {
+		case PathTranslation:
+			tr.Translation = glm.Vec3{v[0], v[1], v[2]}
+		case PathRotation:
+			tr.Rotation = glm.Quat{v[0], v[1], v[2], v[3]}
+		case PathScale:
+			tr.Scale = glm.Vec3{v[0], v[1], v[2]}
+		}
+	}
+}
+
+// value returns the keyframe value k, skipping the tangents of cubic
+// splines.
+func (c *Channel) value(k int) []float32 {
+	n := c.Components
+	if c.Interpolation == CubicSpline {
+		return c.Values[(3*k+1)*n : (3*k+2)*n]
+	}
+	return c.Values[k*n : (k+1)*n]
+}
+
+// Sample returns the value of the channel at time t, clamped to the first
+// and last keyframes. Rotations are slerped and come out normalized.
+func (c *Channel) Sample(t float32) []float32 {
+	out := make([]float32, c.Components)
+	if len(c.Times) == 0 {
+		return out
+	}
+	last := len(c.Times) - 1
+	if t <= c.Times[0] {
+		copy(out, c.value(0))
+		return out
+	}
+	if t >= c.Times[last] {
+		copy(out, c.value(last))
+		return out
+	}
+
+	k := sort.Search(len(c.Times), func(i int) bool { return c.Times[i] > t }) - 1
+	t0, t1 := c.Times[k], c.Times[k+1]
+	dt := t1 - t0
+	s := (t - t0) / dt
+	a, b := c.value(k), c.value(k+1)
+
+	switch c.Interpolation {
+	case Step:
+		copy(out, a)
+	case Linear:
+		if c.Path == PathRotation {
+			q := glm.Quat{a[0], a[1], a[2], a[3]}.Slerp(glm.Quat{b[0], b[1], b[2], b[3]}, s)
+			copy(out, q[:])
+			break
+		}
+		for i := range out {
+			out[i] = a[i] + (b[i]-a[i])*s
+		}
+	case CubicSpline:
+		n := c.Components
+		outTan := c.Values[(3*k+2)*n : (3*k+3)*n]
+		inTan := c.Values[3*(k+1)*n : (3*(k+1)+1)*n]
+		s2, s3 := s*s, s*s*s
+		h00 := 2*s3 - 3*s2 + 1
+		h10 := s3 - 2*s2 + s
+		h01 := -2*s3 + 3*s2
+		h11 := s3 - s2
+		for i := range out {
+			out[i] = h00*a[i] + h10*dt*outTan[i] + h01*b[i] + h11*dt*inTan[i]
+		}
+		if c.Path == PathRotation {
+			q := glm.Quat{out[0], out[1], out[2], out[3]}.Normalize()
+			copy(out, q[:])
+		}
+	}
+	return out
+}
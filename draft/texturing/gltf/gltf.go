@@ -0,0 +1,803 @@
+// Package gltf loads glTF 2.0 assets, .gltf with external or data URI
+// buffers and binary .glb, into meshes, PBR materials, a node hierarchy,
+// skins and animations.
+//
+// glTF shares the conventions of the project: counter-clockwise front faces,
+// texture coordinate (0, 0) at the first image row and column-major
+// matrices, so data is taken over unchanged.
+package gltf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"neilpa.me/go-stbi"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/mesh"
+)
+
+// Scene is a loaded asset. The slices follow the indices of the file.
+type Scene struct {
+	Name string
+	// Roots are the root nodes of the default scene, or of every tree when
+	// the asset names no scene.
+	Roots      []*Node
+	Nodes      []*Node
+	Meshes     []*Mesh
+	Materials  []*Material
+	Textures   []*Texture
+	Skins      []*Skin
+	Animations []*Animation
+}
+
+type Node struct {
+	Name     string
+	Parent   *Node
+	Children []*Node
+	Mesh     *Mesh
+	Skin     *Skin
+	// Matrix is set when the node has a matrix instead of TRS properties.
+	// Such nodes are not animated.
+	Matrix    *glm.Mat4
+	Transform glm.Transform
+}
+
+// Local returns the transform of n relative to its parent.
+func (n *Node) Local() glm.Mat4 {
+	if n.Matrix != nil {
+		return *n.Matrix
+	}
+	return n.Transform.Matrix()
+}
+
+// World returns the transform of n relative to the scene.
+func (n *Node) World() glm.Mat4 {
+	m := n.Local()
+	for p := n.Parent; p != nil; p = p.Parent {
+		m = p.Local().Times(m)
+	}
+	return m
+}
+
+// Walk calls f for n and its descendants, parents first.
+func (n *Node) Walk(f func(*Node)) {
+	f(n)
+	for _, c := range n.Children {
+		c.Walk(f)
+	}
+}
+
+type Mesh struct {
+	Name       string
+	Primitives []*Primitive
+}
+
+// Primitive is one draw of a mesh. Strips and fans are converted to triangle
+// lists; point and line primitives are skipped.
+type Primitive struct {
+	Mesh *mesh.Mesh
+	// Material is nil for the default material, see DefaultMaterial.
+	Material *Material
+	// Joints and Weights are JOINTS_0 and WEIGHTS_0 of skinned meshes.
+	Joints  [][4]uint32
+	Weights []glm.Vec4
+}
+
+type AlphaMode string
+
+const (
+	Opaque AlphaMode = "OPAQUE"
+	Mask   AlphaMode = "MASK"
+	Blend  AlphaMode = "BLEND"
+)
+
+// Material is a metallic-roughness PBR material. Texture values multiply the
+// factors.
+type Material struct {
+	Name string
+
+	BaseColorFactor  glm.Vec4
+	BaseColorTexture *TextureRef
+	MetallicFactor   float32
+	RoughnessFactor  float32
+	// MetallicRoughnessTexture holds roughness in green and metalness in blue.
+	MetallicRoughnessTexture *TextureRef
+
+	NormalTexture     *TextureRef
+	NormalScale       float32
+	OcclusionTexture  *TextureRef
+	OcclusionStrength float32
+	EmissiveTexture   *TextureRef
+	EmissiveFactor    glm.Vec3
+
+	AlphaMode   AlphaMode
+	AlphaCutoff float32
+	DoubleSided bool
+}
+
+// DefaultMaterial is the material of primitives that do not name one.
+func DefaultMaterial() *Material {
+	return &Material{
+		BaseColorFactor:   glm.Vec4{1, 1, 1, 1},
+		MetallicFactor:    1,
+		RoughnessFactor:   1,
+		NormalScale:       1,
+		OcclusionStrength: 1,
+		AlphaMode:         Opaque,
+		AlphaCutoff:       .5,
+	}
+}
+
+type TextureRef struct {
+	Texture *Texture
+	// TexCoord is the TEXCOORD_n set; only set 0 is loaded into meshes.
+	TexCoord int
+}
+
+// Texture is an image with its sampler. Textures sharing an image share the
+// *image.RGBA.
+type Texture struct {
+	Name    string
+	Image   *image.RGBA
+	Sampler Sampler
+}
+
+// Sampler holds GL enum values. A zero filter leaves the choice to the
+// renderer.
+type Sampler struct {
+	MagFilter int
+	MinFilter int
+	WrapS     int
+	WrapT     int
+}
+
+const glRepeat = 10497
+
+type Skin struct {
+	Name   string
+	Joints []*Node
+	// InverseBindMatrices has one matrix per joint, identity when the file
+	// has none.
+	InverseBindMatrices []glm.Mat4
+	Skeleton            *Node
+}
+
+// JointMatrices returns world(joint) * inverseBind for each joint. As the
+// spec asks, the transform of the skinned mesh's node is not applied to its
+// vertices.
+func (s *Skin) JointMatrices() []glm.Mat4 {
+	out := make([]glm.Mat4, len(s.Joints))
+	for i, j := range s.Joints {
+		out[i] = j.World().Times(s.InverseBindMatrices[i])
+	}
+	return out
+}
+
+// OpenFunc opens a file referenced by URI, relative to the asset.
+type OpenFunc func(uri string) (io.ReadCloser, error)
+
+// Load reads a .gltf or .glb file; external files are opened next to it.
+func Load(path string) (*Scene, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+	s, err := Decode(data, func(uri string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, filepath.FromSlash(uri)))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Decode reads a glTF JSON document or a GLB container. open resolves
+// external buffers and images and may be nil for self-contained assets.
+func Decode(data []byte, open OpenFunc) (*Scene, error) {
+	l := &loader{open: open}
+
+	var bin []byte
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		var err error
+		if data, bin, err = splitGLB(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := json.Unmarshal(data, &l.doc); err != nil {
+		return nil, fmt.Errorf("gltf: %w", err)
+	}
+	if !strings.HasPrefix(l.doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("gltf: unsupported version %q", l.doc.Asset.Version)
+	}
+	if len(l.doc.ExtensionsRequired) > 0 {
+		return nil, fmt.Errorf("gltf: unsupported required extensions %v", l.doc.ExtensionsRequired)
+	}
+
+	if err := l.loadBuffers(bin); err != nil {
+		return nil, fmt.Errorf("gltf: %w", err)
+	}
+	s, err := l.scene()
+	if err != nil {
+		return nil, fmt.Errorf("gltf: %w", err)
+	}
+	return s, nil
+}
+
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+// splitGLB returns the JSON and binary chunks of a GLB container.
+func splitGLB(data []byte) (js, bin []byte, err error) {
+	if len(data) < 12 {
+		return nil, nil, errors.New("glb: short header")
+	}
+	if v := binary.LittleEndian.Uint32(data[4:]); v != 2 {
+		return nil, nil, fmt.Errorf("glb: unsupported version %d", v)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) || length < 12 {
+		return nil, nil, fmt.Errorf("glb: length %d, file has %d bytes", length, len(data))
+	}
+	data = data[12:length]
+
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, nil, errors.New("glb: short chunk header")
+		}
+		n := int(binary.LittleEndian.Uint32(data))
+		typ := binary.LittleEndian.Uint32(data[4:])
+		if n > len(data)-8 {
+			return nil, nil, fmt.Errorf("glb: chunk of %d bytes past the end", n)
+		}
+		chunk := data[8 : 8+n]
+		switch {
+		case typ == glbChunkJSON && js == nil:
+			js = chunk
+		case typ == glbChunkBIN && bin == nil && js != nil:
+			bin = chunk
+		}
+		// unknown chunks are skipped
+		data = data[8+n:]
+	}
+	if js == nil {
+		return nil, nil, errors.New("glb: no JSON chunk")
+	}
+	return js, bin, nil
+}
+
+type loader struct {
+	doc     document
+	open    OpenFunc
+	buffers [][]byte
+	images  []*image.RGBA
+}
+
+func (l *loader) loadBuffers(bin []byte) error {
+	for i, b := range l.doc.Buffers {
+		var data []byte
+		switch {
+		case b.URI == "" && i == 0 && bin != nil:
+			data = bin
+		case b.URI == "":
+			return fmt.Errorf("buffer %d: no uri", i)
+		default:
+			var err error
+			if data, err = l.fetch(b.URI); err != nil {
+				return fmt.Errorf("buffer %d: %w", i, err)
+			}
+		}
+		if b.ByteLength < 0 || len(data) < b.ByteLength {
+			return fmt.Errorf("buffer %d: %d bytes, want %d", i, len(data), b.ByteLength)
+		}
+		l.buffers = append(l.buffers, data[:b.ByteLength])
+	}
+	return nil
+}
+
+// fetch returns the contents of a data URI or an external file.
+func (l *loader) fetch(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		i := strings.IndexByte(uri, ',')
+		if i < 0 || !strings.HasSuffix(uri[:i], ";base64") {
+			return nil, errors.New("only base64 data URIs are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[i+1:])
+	}
+	if l.open == nil {
+		return nil, fmt.Errorf("no way to open %q", uri)
+	}
+	name, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+	f, err := l.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// image decodes image i through stbi, once.
+func (l *loader) image(i int) (*image.RGBA, error) {
+	if i < 0 || i >= len(l.doc.Images) {
+		return nil, fmt.Errorf("image %d out of range", i)
+	}
+	if l.images == nil {
+		l.images = make([]*image.RGBA, len(l.doc.Images))
+	}
+	if l.images[i] != nil {
+		return l.images[i], nil
+	}
+
+	im := l.doc.Images[i]
+	var data []byte
+	var err error
+	if im.BufferView != nil {
+		data, _, err = l.view(*im.BufferView)
+	} else {
+		data, err = l.fetch(im.URI)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", i, err)
+	}
+	rgba, err := stbi.LoadMemory(data)
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", i, err)
+	}
+	// go-stbi reports a stride of 4 whatever the width; the pixels are
+	// tightly packed
+	rgba.Stride = 4 * rgba.Rect.Dx()
+	l.images[i] = rgba
+	return rgba, nil
+}
+
+func (l *loader) scene() (*Scene, error) {
+	s := &Scene{}
+	var err error
+
+	for i, t := range l.doc.Textures {
+		tex := &Texture{Name: t.Name, Sampler: Sampler{WrapS: glRepeat, WrapT: glRepeat}}
+		if t.Sampler != nil {
+			if *t.Sampler < 0 || *t.Sampler >= len(l.doc.Samplers) {
+				return nil, fmt.Errorf("texture %d: sampler %d out of range", i, *t.Sampler)
+			}
+			ds := l.doc.Samplers[*t.Sampler]
+			tex.Sampler = Sampler{MagFilter: ds.MagFilter, MinFilter: ds.MinFilter, WrapS: ds.WrapS, WrapT: ds.WrapT}
+			if ds.WrapS == 0 {
+				tex.Sampler.WrapS = glRepeat
+			}
+			if ds.WrapT == 0 {
+				tex.Sampler.WrapT = glRepeat
+			}
+		}
+		if t.Source != nil {
+			if tex.Image, err = l.image(*t.Source); err != nil {
+				return nil, fmt.Errorf("texture %d: %w", i, err)
+			}
+		}
+		s.Textures = append(s.Textures, tex)
+	}
+
+	for i := range l.doc.Materials {
+		m, err := l.material(i, s.Textures)
+		if err != nil {
+			return nil, err
+		}
+		s.Materials = append(s.Materials, m)
+	}
+
+	for i, dm := range l.doc.Meshes {
+		m := &Mesh{Name: dm.Name}
+		for j, p := range dm.Primitives {
+			prim, err := l.primitive(p, s.Materials)
+			if err != nil {
+				return nil, fmt.Errorf("mesh %d primitive %d: %w", i, j, err)
+			}
+			if prim != nil {
+				m.Primitives = append(m.Primitives, prim)
+			}
+		}
+		s.Meshes = append(s.Meshes, m)
+	}
+
+	if err := l.nodes(s); err != nil {
+		return nil, err
+	}
+
+	for i, ds := range l.doc.Skins {
+		skin, err := l.skin(ds, s.Nodes)
+		if err != nil {
+			return nil, fmt.Errorf("skin %d: %w", i, err)
+		}
+		s.Skins = append(s.Skins, skin)
+	}
+	for i, dn := range l.doc.Nodes {
+		if dn.Skin != nil {
+			if *dn.Skin < 0 || *dn.Skin >= len(s.Skins) {
+				return nil, fmt.Errorf("node %d: skin %d out of range", i, *dn.Skin)
+			}
+			s.Nodes[i].Skin = s.Skins[*dn.Skin]
+		}
+	}
+
+	for i := range l.doc.Animations {
+		a, err := l.animation(i, s.Nodes)
+		if err != nil {
+			return nil, fmt.Errorf("animation %d: %w", i, err)
+		}
+		s.Animations = append(s.Animations, a)
+	}
+	return s, nil
+}
+
+func (l *loader) material(i int, textures []*Texture) (*Material, error) {
+	dm := l.doc.Materials[i]
+	m := DefaultMaterial()
+	m.Name = dm.Name
+	m.EmissiveFactor = dm.EmissiveFactor
+	m.DoubleSided = dm.DoubleSided
+	if dm.AlphaMode != "" {
+		m.AlphaMode = AlphaMode(dm.AlphaMode)
+	}
+	if dm.AlphaCutoff != nil {
+		m.AlphaCutoff = *dm.AlphaCutoff
+	}
+
+	ref := func(what string, ti *docTextureInfo) (*TextureRef, error) {
+		if ti == nil {
+			return nil, nil
+		}
+		if ti.Index < 0 || ti.Index >= len(textures) {
+			return nil, fmt.Errorf("material %d: %s texture %d out of range", i, what, ti.Index)
+		}
+		return &TextureRef{Texture: textures[ti.Index], TexCoord: ti.TexCoord}, nil
+	}
+
+	var err error
+	if pbr := dm.PBRMetallicRoughness; pbr != nil {
+		if pbr.BaseColorFactor != nil {
+			m.BaseColorFactor = *pbr.BaseColorFactor
+		}
+		if pbr.MetallicFactor != nil {
+			m.MetallicFactor = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			m.RoughnessFactor = *pbr.RoughnessFactor
+		}
+		if m.BaseColorTexture, err = ref("base color", pbr.BaseColorTexture); err != nil {
+			return nil, err
+		}
+		if m.MetallicRoughnessTexture, err = ref("metallic roughness", pbr.MetallicRoughnessTexture); err != nil {
+			return nil, err
+		}
+	}
+	if m.NormalTexture, err = ref("normal", dm.NormalTexture); err != nil {
+		return nil, err
+	}
+	if dm.NormalTexture != nil && dm.NormalTexture.Scale != nil {
+		m.NormalScale = *dm.NormalTexture.Scale
+	}
+	if m.OcclusionTexture, err = ref("occlusion", dm.OcclusionTexture); err != nil {
+		return nil, err
+	}
+	if dm.OcclusionTexture != nil && dm.OcclusionTexture.Strength != nil {
+		m.OcclusionStrength = *dm.OcclusionTexture.Strength
+	}
+	if m.EmissiveTexture, err = ref("emissive", dm.EmissiveTexture); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Primitive modes.
+const (
+	modeTriangles     = 4
+	modeTriangleStrip = 5
+	modeTriangleFan   = 6
+)
+
+func (l *loader) primitive(p docPrimitive, materials []*Material) (*Primitive, error) {
+	mode := modeTriangles
+	if p.Mode != nil {
+		mode = *p.Mode
+	}
+	if mode != modeTriangles && mode != modeTriangleStrip && mode != modeTriangleFan {
+		return nil, nil
+	}
+
+	if _, ok := p.Attributes["POSITION"]; !ok {
+		return nil, errors.New("no POSITION attribute")
+	}
+
+	prim := &Primitive{Mesh: &mesh.Mesh{}}
+	m := prim.Mesh
+
+	vec := func(name string, want ...int) ([]float32, int, bool, error) {
+		i, ok := p.Attributes[name]
+		if !ok {
+			return nil, 0, false, nil
+		}
+		f, n, err := l.floats(i)
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, w := range want {
+			if n == w {
+				return f, n, true, nil
+			}
+		}
+		return nil, 0, false, fmt.Errorf("%s: %d components, want %v", name, n, want)
+	}
+
+	f, _, _, err := vec("POSITION", 3)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(f); i += 3 {
+		m.Positions = append(m.Positions, glm.Vec3{f[i], f[i+1], f[i+2]})
+	}
+	count := len(m.Positions)
+
+	check := func(name string, n int) error {
+		if n != count {
+			return fmt.Errorf("%s: %d elements for %d positions", name, n, count)
+		}
+		return nil
+	}
+
+	if f, _, ok, err := vec("NORMAL", 3); err != nil {
+		return nil, err
+	} else if ok {
+		for i := 0; i < len(f); i += 3 {
+			m.Normals = append(m.Normals, glm.Vec3{f[i], f[i+1], f[i+2]})
+		}
+		if err := check("NORMAL", len(m.Normals)); err != nil {
+			return nil, err
+		}
+	}
+	if f, _, ok, err := vec("TANGENT", 4); err != nil {
+		return nil, err
+	} else if ok {
+		for i := 0; i < len(f); i += 4 {
+			m.Tangents = append(m.Tangents, glm.Vec4{f[i], f[i+1], f[i+2], f[i+3]})
+		}
+		if err := check("TANGENT", len(m.Tangents)); err != nil {
+			return nil, err
+		}
+	}
+	if f, _, ok, err := vec("TEXCOORD_0", 2); err != nil {
+		return nil, err
+	} else if ok {
+		for i := 0; i < len(f); i += 2 {
+			m.UVs = append(m.UVs, glm.Vec2{f[i], f[i+1]})
+		}
+		if err := check("TEXCOORD_0", len(m.UVs)); err != nil {
+			return nil, err
+		}
+	}
+	if f, n, ok, err := vec("COLOR_0", 3, 4); err != nil {
+		return nil, err
+	} else if ok {
+		for i := 0; i < len(f); i += n {
+			c := glm.Vec4{f[i], f[i+1], f[i+2], 1}
+			if n == 4 {
+				c[3] = f[i+3]
+			}
+			m.Colors = append(m.Colors, c)
+		}
+		if err := check("COLOR_0", len(m.Colors)); err != nil {
+			return nil, err
+		}
+	}
+	if i, ok := p.Attributes["JOINTS_0"]; ok {
+		j, n, err := l.uints(i)
+		if err != nil {
+			return nil, fmt.Errorf("JOINTS_0: %w", err)
+		}
+		if n != 4 {
+			return nil, fmt.Errorf("JOINTS_0: %d components, want 4", n)
+		}
+		for k := 0; k < len(j); k += 4 {
+			prim.Joints = append(prim.Joints, [4]uint32{j[k], j[k+1], j[k+2], j[k+3]})
+		}
+		if err := check("JOINTS_0", len(prim.Joints)); err != nil {
+			return nil, err
+		}
+	}
+	if f, _, ok, err := vec("WEIGHTS_0", 4); err != nil {
+		return nil, err
+	} else if ok {
+		for i := 0; i < len(f); i += 4 {
+			prim.Weights = append(prim.Weights, glm.Vec4{f[i], f[i+1], f[i+2], f[i+3]})
+		}
+		if err := check("WEIGHTS_0", len(prim.Weights)); err != nil {
+			return nil, err
+		}
+	}
+
+	var indices []uint32
+	if p.Indices != nil {
+		var n int
+		if indices, n, err = l.uints(*p.Indices); err != nil {
+			return nil, fmt.Errorf("indices: %w", err)
+		}
+		if n != 1 {
+			return nil, fmt.Errorf("indices: %d components, want 1", n)
+		}
+		for _, i := range indices {
+			if int(i) >= count {
+				return nil, fmt.Errorf("indices: %d out of range of %d vertices", i, count)
+			}
+		}
+	} else if mode != modeTriangles {
+		indices = make([]uint32, count)
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+	m.Indices = triangleList(mode, indices)
+	if m.Indices == nil && count%3 != 0 {
+		return nil, fmt.Errorf("%d vertices do not make whole triangles", count)
+	}
+
+	if p.Material != nil {
+		if *p.Material < 0 || *p.Material >= len(materials) {
+			return nil, fmt.Errorf("material %d out of range", *p.Material)
+		}
+		prim.Material = materials[*p.Material]
+	}
+	return prim, nil
+}
+
+// triangleList converts strip and fan indices to a list with the winding of
+// the first triangle.
+func triangleList(mode int, indices []uint32) []uint32 {
+	switch mode {
+	case modeTriangleStrip:
+		var out []uint32
+		for i := 0; i+2 < len(indices); i++ {
+			if i%2 == 0 {
+				out = append(out, indices[i], indices[i+1], indices[i+2])
+			} else {
+				out = append(out, indices[i+1], indices[i], indices[i+2])
+			}
+		}
+		return out
+	case modeTriangleFan:
+		var out []uint32
+		for i := 1; i+1 < len(indices); i++ {
+			out = append(out, indices[0], indices[i], indices[i+1])
+		}
+		return out
+	}
+	if indices != nil {
+		indices = indices[:len(indices)/3*3]
+	}
+	return indices
+}
+
+func (l *loader) nodes(s *Scene) error {
+	for i, dn := range l.doc.Nodes {
+		n := &Node{Name: dn.Name, Transform: glm.TransformIdent()}
+		if dn.Matrix != nil {
+			m := glm.Mat4(*dn.Matrix)
+			n.Matrix = &m
+		}
+		if dn.Translation != nil {
+			n.Transform.Translation = *dn.Translation
+		}
+		if dn.Rotation != nil {
+			n.Transform.Rotation = *dn.Rotation
+		}
+		if dn.Scale != nil {
+			n.Transform.Scale = *dn.Scale
+		}
+		if dn.Mesh != nil {
+			if *dn.Mesh < 0 || *dn.Mesh >= len(s.Meshes) {
+				return fmt.Errorf("node %d: mesh %d out of range", i, *dn.Mesh)
+			}
+			n.Mesh = s.Meshes[*dn.Mesh]
+		}
+		s.Nodes = append(s.Nodes, n)
+	}
+
+	for i, dn := range l.doc.Nodes {
+		for _, c := range dn.Children {
+			if c < 0 || c >= len(s.Nodes) {
+				return fmt.Errorf("node %d: child %d out of range", i, c)
+			}
+			child := s.Nodes[c]
+			if child.Parent != nil {
+				return fmt.Errorf("node %d: child %d already has a parent", i, c)
+			}
+			child.Parent = s.Nodes[i]
+			s.Nodes[i].Children = append(s.Nodes[i].Children, child)
+		}
+	}
+	// a cycle has no root above it
+	for i, n := range s.Nodes {
+		steps := 0
+		for p := n.Parent; p != nil; p = p.Parent {
+			if steps++; steps > len(s.Nodes) {
+				return fmt.Errorf("node %d: cycle in the hierarchy", i)
+			}
+		}
+	}
+
+	sc := l.doc.Scene
+	if sc == nil && len(l.doc.Scenes) > 0 {
+		zero := 0
+		sc = &zero
+	}
+	if sc == nil {
+		for _, n := range s.Nodes {
+			if n.Parent == nil {
+				s.Roots = append(s.Roots, n)
+			}
+		}
+		return nil
+	}
+	if *sc < 0 || *sc >= len(l.doc.Scenes) {
+		return fmt.Errorf("scene %d out of range", *sc)
+	}
+	s.Name = l.doc.Scenes[*sc].Name
+	for _, i := range l.doc.Scenes[*sc].Nodes {
+		if i < 0 || i >= len(s.Nodes) {
+			return fmt.Errorf("scene %d: node %d out of range", *sc, i)
+		}
+		s.Roots = append(s.Roots, s.Nodes[i])
+	}
+	return nil
+}
+
+func (l *loader) skin(ds docSkin, nodes []*Node) (*Skin, error) {
+	s := &Skin{Name: ds.Name}
+	for _, j := range ds.Joints {
+		if j < 0 || j >= len(nodes) {
+			return nil, fmt.Errorf("joint %d out of range", j)
+		}
+		s.Joints = append(s.Joints, nodes[j])
+	}
+	if ds.Skeleton != nil {
+		if *ds.Skeleton < 0 || *ds.Skeleton >= len(nodes) {
+			return nil, fmt.Errorf("skeleton %d out of range", *ds.Skeleton)
+		}
+		s.Skeleton = nodes[*ds.Skeleton]
+	}
+
+	if ds.InverseBindMatrices == nil {
+		for range s.Joints {
+			s.InverseBindMatrices = append(s.InverseBindMatrices, glm.Identity())
+		}
+		return s, nil
+	}
+	f, n, err := l.floats(*ds.InverseBindMatrices)
+	if err != nil {
+		return nil, fmt.Errorf("inverse bind matrices: %w", err)
+	}
+	if n != 16 || len(f)/16 < len(s.Joints) {
+		return nil, fmt.Errorf("inverse bind matrices: %d matrices for %d joints", len(f)/16, len(s.Joints))
+	}
+	for i := range s.Joints {
+		var m glm.Mat4
+		copy(m[:], f[16*i:])
+		s.InverseBindMatrices = append(s.InverseBindMatrices, m)
+	}
+	return s, nil
+}
@@ -0,0 +1,377 @@
+package gltf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// The assets in testdata are written by testdata/gen.go.
+
+func TestTriangle(t *testing.T) {
+	s, err := Load("testdata/triangle.gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Roots) != 1 || s.Roots[0].Mesh == nil {
+		t.Fatalf("roots %v, want the one node with a mesh", s.Roots)
+	}
+	prim := s.Meshes[0].Primitives[0]
+	want := []glm.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+	if fmt.Sprint(prim.Mesh.Positions) != fmt.Sprint(want) {
+		t.Errorf("positions %v, want %v", prim.Mesh.Positions, want)
+	}
+	if prim.Mesh.Indices != nil {
+		t.Errorf("indices %v, want none", prim.Mesh.Indices)
+	}
+	if prim.Material != nil {
+		t.Errorf("material %+v, want the default", prim.Material)
+	}
+	if err := prim.Mesh.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBoxGLB(t *testing.T) {
+	s, err := Load("testdata/box.glb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "boxes" || len(s.Roots) != 1 || s.Roots[0].Name != "root" {
+		t.Fatalf("scene %q roots %v", s.Name, s.Roots)
+	}
+
+	root := s.Roots[0]
+	if len(root.Children) != 2 {
+		t.Fatalf("root has %d children, want 2", len(root.Children))
+	}
+	box, offset := root.Children[0], root.Children[1]
+	if box.Parent != root || box.Mesh != s.Meshes[0] {
+		t.Errorf("box node %+v", box)
+	}
+
+	// the box is rotated 90 degrees about y and scaled by 2 before moving
+	// back by 5, so +x maps to -2z
+	w := box.World()
+	p := w.Mulv(glm.Vec4{1, 0, 0, 1})
+	if !p.ApproxEqual(glm.Vec4{0, 0, -7, 1}) {
+		t.Errorf("box world * +x = %v, want [0 0 -7 1]", p)
+	}
+	if offset.Matrix == nil {
+		t.Fatal("offset node has no matrix")
+	}
+	p = offset.World().Mulv(glm.Vec4{0, 0, 0, 1})
+	if !p.ApproxEqual(glm.Vec4{3, 0, -5, 1}) {
+		t.Errorf("offset world origin = %v, want [3 0 -5 1]", p)
+	}
+
+	m := s.Meshes[0].Primitives[0].Mesh
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Positions) != 24 || m.TriangleCount() != 12 || m.Normals == nil || m.UVs == nil {
+		t.Errorf("cube has %d vertices, %d triangles, normals %v, uvs %v",
+			len(m.Positions), m.TriangleCount(), m.Normals != nil, m.UVs != nil)
+	}
+	if b := m.Bounds(); !b.Min.ApproxEqual(glm.Vec3{-.5, -.5, -.5}) || !b.Max.ApproxEqual(glm.Vec3{.5, .5, .5}) {
+		t.Errorf("bounds %v", b)
+	}
+
+	mat := s.Meshes[0].Primitives[0].Material
+	if mat == nil || mat != s.Materials[0] {
+		t.Fatal("primitive does not use material 0")
+	}
+	if mat.Name != "checker" || mat.BaseColorFactor != (glm.Vec4{1, .5, .5, 1}) ||
+		mat.MetallicFactor != 0 || mat.RoughnessFactor != 1 ||
+		mat.AlphaMode != Mask || mat.AlphaCutoff != .25 || !mat.DoubleSided {
+		t.Errorf("material %+v", mat)
+	}
+
+	tex := mat.BaseColorTexture
+	if tex == nil || tex.Texture.Image == nil {
+		t.Fatal("no base color image")
+	}
+	if got := tex.Texture.Sampler; got != (Sampler{MagFilter: 9728, MinFilter: 9728, WrapS: 33071, WrapT: glRepeat}) {
+		t.Errorf("sampler %+v", got)
+	}
+	img := tex.Texture.Image
+	if img.Bounds().Dx() != 2 || img.Bounds().Dy() != 2 {
+		t.Fatalf("image is %v", img.Bounds())
+	}
+	if c := img.RGBAAt(0, 0); c.R != 255 || c.G != 0 || c.B != 0 {
+		t.Errorf("top left texel %v, want red", c)
+	}
+	if c := img.RGBAAt(0, 1); c.B != 255 || c.R != 0 {
+		t.Errorf("bottom left texel %v, want blue", c)
+	}
+}
+
+func TestSkin(t *testing.T) {
+	s, err := Load("testdata/skin.gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Roots) != 2 {
+		t.Fatalf("%d roots, want 2", len(s.Roots))
+	}
+
+	prim := s.Meshes[0].Primitives[0]
+	m := prim.Mesh
+	// the strip of 6 vertices makes 4 triangles, all facing +z
+	if m.TriangleCount() != 4 {
+		t.Fatalf("%d triangles, want 4", m.TriangleCount())
+	}
+	for i := 0; i < m.TriangleCount(); i++ {
+		a, b, c := m.Triangle(i)
+		n := m.Positions[b].Sub(m.Positions[a]).Cross(m.Positions[c].Sub(m.Positions[a]))
+		if n[2] <= 0 {
+			t.Errorf("triangle %d (%d %d %d) faces %v", i, a, b, c, n)
+		}
+	}
+	// sparse substitution lifts the last two vertices
+	if m.Positions[4] != (glm.Vec3{0, 2, 0}) || m.Positions[5] != (glm.Vec3{1, 2, 0}) {
+		t.Errorf("positions %v", m.Positions)
+	}
+
+	if prim.Joints[2] != ([4]uint32{0, 1, 0, 0}) || prim.Joints[4] != ([4]uint32{1, 0, 0, 0}) {
+		t.Errorf("joints %v", prim.Joints)
+	}
+	if w := prim.Weights[2]; !glm.FloatEqual(w[0]+w[1], 1) || !glm.FloatEqual(w[0], 128./255) {
+		t.Errorf("weights %v", w)
+	}
+
+	strip := s.Nodes[0]
+	skin := strip.Skin
+	if skin == nil || len(skin.Joints) != 2 || skin.Skeleton != s.Nodes[1] {
+		t.Fatalf("skin %+v", skin)
+	}
+	// in the bind pose every joint matrix is the identity
+	for i, jm := range skin.JointMatrices() {
+		if !jm.ApproxEqual(glm.Identity()) {
+			t.Errorf("joint %d matrix %v, want identity", i, [16]float32(jm))
+		}
+	}
+
+	mat := prim.Material
+	if mat.NormalScale != .5 || mat.NormalTexture.Texture != mat.BaseColorTexture.Texture {
+		t.Errorf("material %+v", mat)
+	}
+	if mat.BaseColorTexture.Texture.Image.Bounds().Dx() != 2 {
+		t.Error("external image not loaded")
+	}
+}
+
+func TestAnimation(t *testing.T) {
+	s, err := Load("testdata/skin.gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := s.Animations[0]
+	if a.Name != "bend" || len(a.Channels) != 3 || a.Duration() != 2 {
+		t.Fatalf("animation %q with %d channels, %v long", a.Name, len(a.Channels), a.Duration())
+	}
+	hip, knee := s.Nodes[1], s.Nodes[2]
+
+	a.Apply(.5)
+	// half way to 90 degrees about z
+	want := glm.QuatFromAxisAngle(glm.Vec3{0, 0, 1}, math.Pi/4)
+	if !knee.Transform.Rotation.ApproxEqualRotation(want) {
+		t.Errorf("rotation at .5 = %v, want %v", knee.Transform.Rotation, want)
+	}
+	if hip.Transform.Translation != (glm.Vec3{0, 1, 0}) {
+		t.Errorf("step translation at .5 = %v, want the first key", hip.Transform.Translation)
+	}
+	// flat tangents ease in and out: the midpoint is the midpoint
+	if !knee.Transform.Scale.ApproxEqual(glm.Vec3{1.5, 1.5, 1.5}) {
+		t.Errorf("cubic scale at .5 = %v", knee.Transform.Scale)
+	}
+	if sc := a.Channels[2].Sample(.25)[0]; !glm.FloatEqual(sc, 1.15625) {
+		t.Errorf("cubic scale at .25 = %v, want 1.15625", sc)
+	}
+
+	a.Apply(1.5)
+	if hip.Transform.Translation != (glm.Vec3{0, 2, 0}) {
+		t.Errorf("step translation at 1.5 = %v", hip.Transform.Translation)
+	}
+
+	// clamped past the ends
+	a.Apply(10)
+	if !knee.Transform.Rotation.ApproxEqualRotation(glm.QuatFromAxisAngle(glm.Vec3{0, 0, 1}, math.Pi)) {
+		t.Errorf("rotation at 10 = %v", knee.Transform.Rotation)
+	}
+	if hip.Transform.Translation != (glm.Vec3{0, 3, 0}) {
+		t.Errorf("translation at 10 = %v", hip.Transform.Translation)
+	}
+	a.Apply(-1)
+	if !knee.Transform.Rotation.ApproxEqual(glm.QuatIdent()) {
+		t.Errorf("rotation at -1 = %v", knee.Transform.Rotation)
+	}
+
+	// the knee moves with the hip
+	a.Apply(2)
+	p := knee.World().Mulv(glm.Vec4{0, 0, 0, 1})
+	if !p.ApproxEqual(glm.Vec4{0, 4, 0, 1}) {
+		t.Errorf("knee at %v, want [0 4 0 1]", p)
+	}
+}
+
+func TestTriangleList(t *testing.T) {
+	tests := []struct {
+		mode    int
+		indices []uint32
+		want    string
+	}{
+		{modeTriangles, []uint32{0, 1, 2, 3}, "[0 1 2]"},
+		{modeTriangleStrip, []uint32{0, 1, 2, 3, 4}, "[0 1 2 2 1 3 2 3 4]"},
+		{modeTriangleFan, []uint32{0, 1, 2, 3}, "[0 1 2 0 2 3]"},
+		{modeTriangleFan, []uint32{0, 1}, "[]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(triangleList(tt.mode, tt.indices)); got != tt.want {
+			t.Errorf("mode %d %v = %s, want %s", tt.mode, tt.indices, got, tt.want)
+		}
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	var buf []byte
+	buf = append(buf, 0x80, 0x7f, 0xff, 0) // bytes -128 127 -1, padding
+	buf = binary.LittleEndian.AppendUint16(buf, 65535)
+	buf = binary.LittleEndian.AppendUint16(buf, 0)
+	// interleaved: two (float, ubyte) elements with stride 8
+	buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(1.5))
+	buf = append(buf, 7, 0, 0, 0)
+	buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(-2))
+	buf = append(buf, 9, 0, 0, 0)
+
+	zero, one, two := 0, 1, 2
+	l := &loader{buffers: [][]byte{buf}}
+	l.doc.BufferViews = []docBufferView{
+		{ByteLength: 3},
+		{ByteOffset: 4, ByteLength: 4},
+		{ByteOffset: 8, ByteLength: 16, ByteStride: 8},
+	}
+	l.doc.Accessors = []docAccessor{
+		{BufferView: &zero, ComponentType: componentByte, Normalized: true, Count: 3, Type: "SCALAR"},
+		{BufferView: &one, ComponentType: componentUnsignedShort, Normalized: true, Count: 1, Type: "VEC2"},
+		{BufferView: &two, ComponentType: componentFloat, Count: 2, Type: "SCALAR"},
+		{BufferView: &two, ByteOffset: 4, ComponentType: componentUnsignedByte, Count: 2, Type: "SCALAR"},
+		{ComponentType: componentFloat, Count: 2, Type: "VEC2"},
+		{BufferView: &one, ComponentType: componentFloat, Count: 2, Type: "SCALAR"},
+	}
+
+	tests := []struct {
+		i    int
+		want string
+	}{
+		{0, "[-1 1 -0.007874016]"},
+		{1, "[1 0]"},
+		{2, "[1.5 -2]"},
+		{3, "[7 9]"},
+		{4, "[0 0 0 0]"},
+	}
+	for _, tt := range tests {
+		f, _, err := l.floats(tt.i)
+		if err != nil {
+			t.Errorf("accessor %d: %v", tt.i, err)
+			continue
+		}
+		if got := fmt.Sprint(f); got != tt.want {
+			t.Errorf("accessor %d = %s, want %s", tt.i, got, tt.want)
+		}
+	}
+
+	if _, _, err := l.floats(5); err == nil {
+		t.Error("accessor 5 overruns its view but read without error")
+	}
+
+	if u, _, err := l.uints(3); err != nil || fmt.Sprint(u) != "[7 9]" {
+		t.Errorf("uints(3) = %v, %v", u, err)
+	}
+	if _, _, err := l.uints(2); err == nil {
+		t.Error("uints of a float accessor did not fail")
+	}
+
+	// malformed accessors fail rather than panic or allocate what the
+	// buffer cannot hold
+	sparse := func(count, indexType int) *docSparse {
+		s := &docSparse{Count: count}
+		s.Indices.BufferView, s.Indices.ComponentType = 2, indexType
+		s.Values.BufferView = 2
+		return s
+	}
+	three, four := 3, 4
+	l.doc.BufferViews = append(l.doc.BufferViews,
+		docBufferView{ByteLength: 8, ByteStride: -4},
+		docBufferView{ByteOffset: 1 << 62, ByteLength: 1 << 62})
+	bad := []struct {
+		a    docAccessor
+		want string
+	}{
+		{docAccessor{BufferView: &zero, ByteOffset: -4, ComponentType: componentByte, Count: 1, Type: "SCALAR"}, "negative byte offset"},
+		{docAccessor{BufferView: &zero, ComponentType: componentByte, Count: math.MaxInt / 2, Type: "VEC4"}, "overrun"},
+		{docAccessor{BufferView: &three, ComponentType: componentByte, Count: 1, Type: "SCALAR"}, "negative byte stride"},
+		{docAccessor{BufferView: &four, ComponentType: componentByte, Count: 1, Type: "SCALAR"}, "past the end"},
+		{docAccessor{BufferView: &zero, ByteOffset: math.MaxInt - 2, ComponentType: componentFloat, Count: 1, Type: "VEC4"}, "overrun"},
+		{docAccessor{ComponentType: componentFloat, Count: 400000000, Type: "MAT4"}, "without a buffer view"},
+		{docAccessor{ComponentType: componentFloat, Count: 2, Type: "SCALAR", Sparse: sparse(-1, componentUnsignedByte)}, "negative count"},
+		{docAccessor{ComponentType: componentFloat, Count: 2, Type: "SCALAR", Sparse: sparse(1, componentFloat)}, "not an unsigned integer"},
+		{docAccessor{ComponentType: componentFloat, Count: 2, Type: "SCALAR", Sparse: sparse(1, 1)}, "not an unsigned integer"},
+		{docAccessor{ComponentType: componentFloat, Count: 2, Type: "SCALAR", Sparse: sparse(1<<40, componentUnsignedByte)}, "overrun"},
+	}
+	for k, tt := range bad {
+		l.doc.Accessors = []docAccessor{tt.a}
+		if _, _, err := l.read(0); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("malformed accessor %d: error %v, want %q", k, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	triangle, err := os.ReadFile("testdata/triangle.gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	box, err := os.ReadFile("testdata/box.glb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	glb := func(version uint32) []byte {
+		b := append([]byte(nil), box...)
+		binary.LittleEndian.PutUint32(b[4:], version)
+		return b
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"json", []byte("{"), "unexpected end"},
+		{"version", []byte(`{"asset":{"version":"1.0"}}`), "unsupported version"},
+		{"extensions", []byte(`{"asset":{"version":"2.0"},"extensionsRequired":["KHR_draco_mesh_compression"]}`), "KHR_draco"},
+		{"glb version", glb(1), "unsupported version 1"},
+		{"glb short", box[:10], "short header"},
+		{"external buffer", []byte(`{"asset":{"version":"2.0"},"buffers":[{"uri":"a.bin","byteLength":4}]}`), "no way to open"},
+		{"short buffer", []byte(`{"asset":{"version":"2.0"},"buffers":[{"uri":"data:application/octet-stream;base64,` +
+			base64.StdEncoding.EncodeToString([]byte{1, 2}) + `","byteLength":4}]}`), "2 bytes, want 4"},
+		{"negative buffer", []byte(`{"asset":{"version":"2.0"},"buffers":[{"uri":"data:application/octet-stream;base64,AAAA","byteLength":-1}]}`), "want -1"},
+		{"two parents", []byte(`{"asset":{"version":"2.0"},"nodes":[{"children":[2]},{"children":[2]},{}]}`), "already has a parent"},
+		{"cycle", []byte(`{"asset":{"version":"2.0"},"nodes":[{"children":[1]},{"children":[0]}]}`), "cycle"},
+		{"self cycle", []byte(`{"asset":{"version":"2.0"},"nodes":[{"children":[0]}]}`), "cycle"},
+		{"no position", []byte(`{"asset":{"version":"2.0"},"meshes":[{"primitives":[{"attributes":{}}]}]}`), "no POSITION"},
+		{"bad index", []byte(strings.Replace(string(triangle), `"mesh": 0`, `"mesh": 3`, 1)), "mesh 3 out of range"},
+	}
+	for _, tt := range tests {
+		_, err := Decode(tt.data, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+//go:build ignore
+
+// gen writes the sample assets of the gltf tests:
+//
+//	triangle.gltf  one unindexed triangle in a data URI buffer, no scene
+//	box.glb        a textured cube under a two node hierarchy, the PNG in the
+//	               binary chunk
+//	skin.gltf      a skinned triangle strip with an animation, sparse
+//	               positions, skin.bin and checker.png next to it
+//
+// Run it from this directory with go run gen.go.
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"math"
+	"os"
+
+	"github.com/pgeowng/rende/draft/texturing/mesh"
+)
+
+// builder collects buffer views and accessors over one buffer.
+type builder struct {
+	buf         bytes.Buffer
+	bufferViews []map[string]interface{}
+	accessors   []map[string]interface{}
+}
+
+func (b *builder) view(data interface{}) int {
+	for b.buf.Len()%4 != 0 {
+		b.buf.WriteByte(0)
+	}
+	off := b.buf.Len()
+	if err := binary.Write(&b.buf, binary.LittleEndian, data); err != nil {
+		log.Fatal(err)
+	}
+	b.bufferViews = append(b.bufferViews, map[string]interface{}{
+		"buffer": 0, "byteOffset": off, "byteLength": b.buf.Len() - off,
+	})
+	return len(b.bufferViews) - 1
+}
+
+func (b *builder) accessor(data interface{}, componentType, count int, typ string, extra map[string]interface{}) int {
+	a := map[string]interface{}{
+		"bufferView": b.view(data), "componentType": componentType, "count": count, "type": typ,
+	}
+	for k, v := range extra {
+		a[k] = v
+	}
+	b.accessors = append(b.accessors, a)
+	return len(b.accessors) - 1
+}
+
+func flatten3(v [][3]float32) []float32 {
+	var out []float32
+	for _, x := range v {
+		out = append(out, x[:]...)
+	}
+	return out
+}
+
+func checker() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	img.Set(1, 0, color.RGBA{0, 255, 0, 255})
+	img.Set(0, 1, color.RGBA{0, 0, 255, 255})
+	img.Set(1, 1, color.RGBA{255, 255, 255, 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		log.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeJSON(name string, doc map[string]interface{}) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(name, append(data, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func triangle() {
+	var b builder
+	b.accessor([]float32{0, 0, 0, 1, 0, 0, 0, 1, 0}, 5126, 3, "VEC3",
+		map[string]interface{}{"min": []float32{0, 0, 0}, "max": []float32{1, 1, 0}})
+	writeJSON("triangle.gltf", map[string]interface{}{
+		"asset":       map[string]interface{}{"version": "2.0"},
+		"nodes":       []interface{}{map[string]interface{}{"mesh": 0}},
+		"meshes":      []interface{}{map[string]interface{}{"primitives": []interface{}{map[string]interface{}{"attributes": map[string]int{"POSITION": 0}}}}},
+		"accessors":   b.accessors,
+		"bufferViews": b.bufferViews,
+		"buffers": []interface{}{map[string]interface{}{
+			"byteLength": b.buf.Len(),
+			"uri":        "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(b.buf.Bytes()),
+		}},
+	})
+}
+
+func box() {
+	var b builder
+	cube := mesh.Cube(1)
+	var pos, nrm [][3]float32
+	var uv []float32
+	for i := range cube.Positions {
+		pos = append(pos, cube.Positions[i])
+		nrm = append(nrm, cube.Normals[i])
+		uv = append(uv, cube.UVs[i][0], cube.UVs[i][1])
+	}
+	idx := make([]uint16, len(cube.Indices))
+	for i, v := range cube.Indices {
+		idx[i] = uint16(v)
+	}
+	n := len(pos)
+	b.accessor(flatten3(pos), 5126, n, "VEC3", map[string]interface{}{"min": []float32{-.5, -.5, -.5}, "max": []float32{.5, .5, .5}})
+	b.accessor(flatten3(nrm), 5126, n, "VEC3", nil)
+	b.accessor(uv, 5126, n, "VEC2", nil)
+	b.accessor(idx, 5123, len(idx), "SCALAR", nil)
+	img := b.view(checker())
+
+	doc := map[string]interface{}{
+		"asset":  map[string]interface{}{"version": "2.0", "generator": "rende gen.go"},
+		"scene":  0,
+		"scenes": []interface{}{map[string]interface{}{"name": "boxes", "nodes": []int{0}}},
+		"nodes": []interface{}{
+			map[string]interface{}{"name": "root", "translation": []float32{0, 0, -5}, "children": []int{1, 2}},
+			map[string]interface{}{
+				"name": "box", "mesh": 0,
+				// 90 degrees about y
+				"rotation": []float32{0, float32(math.Sqrt2 / 2), 0, float32(math.Sqrt2 / 2)},
+				"scale":    []float32{2, 2, 2},
+			},
+			map[string]interface{}{
+				"name":   "offset",
+				"matrix": []float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 3, 0, 0, 1},
+			},
+		},
+		"meshes": []interface{}{map[string]interface{}{
+			"name": "cube",
+			"primitives": []interface{}{map[string]interface{}{
+				"attributes": map[string]int{"POSITION": 0, "NORMAL": 1, "TEXCOORD_0": 2},
+				"indices":    3,
+				"material":   0,
+			}},
+		}},
+		"materials": []interface{}{map[string]interface{}{
+			"name": "checker",
+			"pbrMetallicRoughness": map[string]interface{}{
+				"baseColorFactor":  []float32{1, .5, .5, 1},
+				"baseColorTexture": map[string]int{"index": 0},
+				"metallicFactor":   0,
+			},
+			"alphaMode":   "MASK",
+			"alphaCutoff": .25,
+			"doubleSided": true,
+		}},
+		"textures":    []interface{}{map[string]int{"sampler": 0, "source": 0}},
+		"samplers":    []interface{}{map[string]int{"magFilter": 9728, "minFilter": 9728, "wrapS": 33071}},
+		"images":      []interface{}{map[string]interface{}{"bufferView": img, "mimeType": "image/png"}},
+		"accessors":   b.accessors,
+		"bufferViews": b.bufferViews,
+		"buffers":     []interface{}{map[string]int{"byteLength": b.buf.Len()}},
+	}
+	js, err := json.Marshal(doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+	bin := b.buf.Bytes()
+	for len(bin)%4 != 0 {
+		bin = append(bin, 0)
+	}
+
+	var out bytes.Buffer
+	w := func(v interface{}) { binary.Write(&out, binary.LittleEndian, v) }
+	w(uint32(0x46546C67))
+	w(uint32(2))
+	w(uint32(12 + 8 + len(js) + 8 + len(bin)))
+	w(uint32(len(js)))
+	w(uint32(0x4E4F534A))
+	out.Write(js)
+	w(uint32(len(bin)))
+	w(uint32(0x004E4942))
+	out.Write(bin)
+	if err := os.WriteFile("box.glb", out.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func skin() {
+	var b builder
+	// a strip of two quads along y; the upper row is lifted by the sparse
+	// accessor from y = 0 to y = 2
+	pos := []float32{
+		0, 0, 0, 1, 0, 0,
+		0, 1, 0, 1, 1, 0,
+		0, 0, 0, 1, 0, 0,
+	}
+	b.accessors = append(b.accessors, map[string]interface{}{
+		"bufferView": b.view(pos), "componentType": 5126, "count": 6, "type": "VEC3",
+		"sparse": map[string]interface{}{
+			"count":   2,
+			"indices": map[string]int{"bufferView": b.view([]uint8{4, 5}), "componentType": 5121},
+			"values":  map[string]int{"bufferView": b.view([]float32{0, 2, 0, 1, 2, 0})},
+		},
+	})
+	b.accessor([]float32{0, 0, 1, 0, 0, 1, 0, 1, 1, 1, 1, 1}, 5126, 6, "VEC2", nil)
+	b.accessor([]uint8{0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0}, 5121, 6, "VEC4", nil)
+	b.accessor([]uint8{255, 0, 0, 0, 255, 0, 0, 0, 128, 127, 0, 0, 128, 127, 0, 0, 255, 0, 0, 0, 255, 0, 0, 0}, 5121, 6, "VEC4",
+		map[string]interface{}{"normalized": true})
+	// inverse bind matrices: joint 1 sits at y = 1
+	b.accessor([]float32{
+		1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1,
+		1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, -1, 0, 1,
+	}, 5126, 2, "MAT4", nil)
+
+	times := b.accessor([]float32{0, 1, 2}, 5126, 3, "SCALAR", map[string]interface{}{"min": []float32{0}, "max": []float32{2}})
+	s := float32(math.Sqrt2 / 2)
+	rot := b.accessor([]float32{0, 0, 0, 1, 0, 0, s, s, 0, 0, 1, 0}, 5126, 3, "VEC4", nil)
+	tr := b.accessor([]float32{0, 1, 0, 0, 2, 0, 0, 3, 0}, 5126, 3, "VEC3", nil)
+	// in-tangent, value, out-tangent per key
+	sc := b.accessor([]float32{
+		0, 0, 0, 1, 1, 1, 0, 0, 0,
+		0, 0, 0, 2, 2, 2, 0, 0, 0,
+		0, 0, 0, 2, 2, 2, 0, 0, 0,
+	}, 5126, 9, "VEC3", nil)
+
+	if err := os.WriteFile("skin.bin", b.buf.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("checker.png", checker(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+
+	writeJSON("skin.gltf", map[string]interface{}{
+		"asset":  map[string]interface{}{"version": "2.0"},
+		"scenes": []interface{}{map[string]interface{}{"nodes": []int{0, 1}}},
+		"nodes": []interface{}{
+			map[string]interface{}{"name": "strip", "mesh": 0, "skin": 0},
+			map[string]interface{}{"name": "hip", "children": []int{2}},
+			map[string]interface{}{"name": "knee", "translation": []float32{0, 1, 0}},
+		},
+		"meshes": []interface{}{map[string]interface{}{"primitives": []interface{}{map[string]interface{}{
+			"attributes": map[string]int{"POSITION": 0, "TEXCOORD_0": 1, "JOINTS_0": 2, "WEIGHTS_0": 3},
+			"mode":       5,
+			"material":   0,
+		}}}},
+		"materials": []interface{}{map[string]interface{}{
+			"pbrMetallicRoughness": map[string]interface{}{"baseColorTexture": map[string]int{"index": 0}},
+			"normalTexture":        map[string]interface{}{"index": 0, "scale": .5},
+		}},
+		"textures": []interface{}{map[string]int{"source": 0}},
+		"images":   []interface{}{map[string]string{"uri": "checker.png"}},
+		"skins": []interface{}{map[string]interface{}{
+			"joints": []int{1, 2}, "inverseBindMatrices": 4, "skeleton": 1,
+		}},
+		"animations": []interface{}{map[string]interface{}{
+			"name": "bend",
+			"channels": []interface{}{
+				map[string]interface{}{"sampler": 0, "target": map[string]interface{}{"node": 2, "path": "rotation"}},
+				map[string]interface{}{"sampler": 1, "target": map[string]interface{}{"node": 1, "path": "translation"}},
+				map[string]interface{}{"sampler": 2, "target": map[string]interface{}{"node": 2, "path": "scale"}},
+			},
+			"samplers": []interface{}{
+				map[string]interface{}{"input": times, "output": rot},
+				map[string]interface{}{"input": times, "output": tr, "interpolation": "STEP"},
+				map[string]interface{}{"input": times, "output": sc, "interpolation": "CUBICSPLINE"},
+			},
+		}},
+		"accessors":   b.accessors,
+		"bufferViews": b.bufferViews,
+		"buffers":     []interface{}{map[string]interface{}{"uri": "skin.bin", "byteLength": b.buf.Len()}},
+	})
+}
+
+func main() {
+	triangle()
+	box()
+	skin()
+}
@@ -0,0 +1,264 @@
+// Package golden compares rendered frames with reference images stored as
+// PNG files in a test's testdata directory.
+//
+// Run the tests with -golden.update to write the current frames as the new
+// goldens. The flag is named after the package so it does not clash with an
+// -update flag of the test importing it.
+package golden
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("golden.update", false, "write rendered frames to testdata as the new golden images")
+
+// Dir is where goldens are read and written, relative to the test's package.
+// Failed comparisons write <name>.got.png and <name>.diff.png to Dir/failures.
+var Dir = "testdata"
+
+// Options decide when a frame matches its golden. A pixel is bad when any
+// channel differs by more than Tolerance; the frame passes when at most
+// MaxBadPixels are bad.
+type Options struct {
+	Tolerance    uint8
+	MaxBadPixels int
+}
+
+// Result describes how far a frame is from its golden.
+type Result struct {
+	BadPixels int
+	// MaxDiff is the largest difference of any channel.
+	MaxDiff uint8
+	// PSNR is in dB over the RGB channels, +Inf for identical images.
+	PSNR float64
+	// SSIM is the mean structural similarity of the luma, 1 for identical
+	// images.
+	SSIM float64
+	// Diff shows the golden dimmed with the bad pixels in red.
+	Diff *image.RGBA
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%d bad pixels, max diff %d, PSNR %.2f dB, SSIM %.4f", r.BadPixels, r.MaxDiff, r.PSNR, r.SSIM)
+}
+
+// RGBA converts img to *image.RGBA with bounds starting at the origin,
+// returning it unchanged when it already is one.
+func RGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	return rgba
+}
+
+// Compare measures got against want, which must be the same size.
+func Compare(got, want *image.RGBA, opt Options) (Result, error) {
+	if got.Rect.Size() != want.Rect.Size() {
+		return Result{}, fmt.Errorf("golden: size %v, want %v", got.Rect.Size(), want.Rect.Size())
+	}
+	w, h := got.Rect.Dx(), got.Rect.Dy()
+
+	res := Result{Diff: image.NewRGBA(image.Rect(0, 0, w, h))}
+	var sse float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			g := got.RGBAAt(got.Rect.Min.X+x, got.Rect.Min.Y+y)
+			e := want.RGBAAt(want.Rect.Min.X+x, want.Rect.Min.Y+y)
+
+			var max uint8
+			for i, d := range [4]uint8{absDiff(g.R, e.R), absDiff(g.G, e.G), absDiff(g.B, e.B), absDiff(g.A, e.A)} {
+				if d > max {
+					max = d
+				}
+				if i < 3 {
+					sse += float64(d) * float64(d)
+				}
+			}
+			if max > res.MaxDiff {
+				res.MaxDiff = max
+			}
+
+			if max > opt.Tolerance {
+				res.BadPixels++
+				res.Diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				l := uint8(luma(e) / 3)
+				res.Diff.SetRGBA(x, y, color.RGBA{l, l, l, 255})
+			}
+		}
+	}
+
+	res.PSNR = PSNR(sse / float64(3*w*h))
+	res.SSIM = SSIM(got, want)
+	return res, nil
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func luma(c color.RGBA) float64 {
+	return .299*float64(c.R) + .587*float64(c.G) + .114*float64(c.B)
+}
+
+// PSNR converts a mean squared error of 8-bit values to peak signal to noise
+// ratio in dB.
+func PSNR(mse float64) float64 {
+	if mse == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(255*255/mse)
+}
+
+// ssimWindow is the side of the square windows SSIM is averaged over, placed
+// every ssimWindow/2 pixels.
+const ssimWindow = 8
+
+// SSIM is the mean structural similarity of the luma of a and b, which must
+// be the same size. Images smaller than a window are treated as one window.
+func SSIM(a, b *image.RGBA) float64 {
+	const (
+		c1 = (.01 * 255) * (.01 * 255)
+		c2 = (.03 * 255) * (.03 * 255)
+	)
+	w, h := a.Rect.Dx(), a.Rect.Dy()
+	la, lb := make([]float64, w*h), make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			la[y*w+x] = luma(a.RGBAAt(a.Rect.Min.X+x, a.Rect.Min.Y+y))
+			lb[y*w+x] = luma(b.RGBAAt(b.Rect.Min.X+x, b.Rect.Min.Y+y))
+		}
+	}
+
+	win := func(n int) (size, step int) {
+		if n < ssimWindow {
+			return n, n
+		}
+		return ssimWindow, ssimWindow / 2
+	}
+	ww, sx := win(w)
+	wh, sy := win(h)
+
+	var sum float64
+	var count int
+	for y0 := 0; y0+wh <= h; y0 += sy {
+		for x0 := 0; x0+ww <= w; x0 += sx {
+			var ma, mb float64
+			for y := y0; y < y0+wh; y++ {
+				for x := x0; x < x0+ww; x++ {
+					ma += la[y*w+x]
+					mb += lb[y*w+x]
+				}
+			}
+			n := float64(ww * wh)
+			ma, mb = ma/n, mb/n
+
+			var va, vb, cov float64
+			for y := y0; y < y0+wh; y++ {
+				for x := x0; x < x0+ww; x++ {
+					da, db := la[y*w+x]-ma, lb[y*w+x]-mb
+					va += da * da
+					vb += db * db
+					cov += da * db
+				}
+			}
+			va, vb, cov = va/(n-1), vb/(n-1), cov/(n-1)
+			if n == 1 {
+				va, vb, cov = 0, 0, 0
+			}
+
+			sum += (2*ma*mb + c1) * (2*cov + c2) / ((ma*ma + mb*mb + c1) * (va + vb + c2))
+			count++
+		}
+	}
+	if count == 0 {
+		return 1
+	}
+	return sum / float64(count)
+}
+
+// Load reads a PNG file.
+func Load(path string) (*image.RGBA, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("golden: %s: %w", path, err)
+	}
+	return RGBA(img), nil
+}
+
+// Save writes img as a PNG file, creating its directory.
+func Save(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Check compares got with the golden Dir/<name>.png and fails t when they do
+// not match. With -golden.update it writes got as the golden instead.
+func Check(t testing.TB, name string, got *image.RGBA, opt Options) {
+	t.Helper()
+	path := filepath.Join(Dir, name+".png")
+
+	if *update {
+		if err := Save(path, got); err != nil {
+			t.Fatal(err)
+		}
+		t.Log("updated", path)
+		return
+	}
+
+	want, err := Load(path)
+	if err != nil {
+		t.Fatalf("%v (run with -golden.update to create it)", err)
+	}
+
+	res, err := Compare(got, want, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%s: %v", name, res)
+	if res.BadPixels <= opt.MaxBadPixels {
+		return
+	}
+
+	failures := filepath.Join(Dir, "failures")
+	gotPath := filepath.Join(failures, name+".got.png")
+	diffPath := filepath.Join(failures, name+".diff.png")
+	if err := Save(gotPath, got); err != nil {
+		t.Error(err)
+	}
+	if err := Save(diffPath, res.Diff); err != nil {
+		t.Error(err)
+	}
+	t.Fatalf("%s does not match golden: %v; wrote %s and %s", name, res, gotPath, diffPath)
+}
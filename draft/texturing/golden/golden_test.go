@@ -0,0 +1,101 @@
+package golden
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func gradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x * 255 / w), uint8(y * 255 / h), 128, 255})
+		}
+	}
+	return img
+}
+
+func TestCompareIdentical(t *testing.T) {
+	a := gradient(32, 16)
+	res, err := Compare(a, a, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BadPixels != 0 || res.MaxDiff != 0 || !math.IsInf(res.PSNR, 1) || math.Abs(res.SSIM-1) > 1e-9 {
+		t.Fatal(res)
+	}
+}
+
+func TestCompareTolerance(t *testing.T) {
+	a, b := gradient(32, 16), gradient(32, 16)
+	b.SetRGBA(3, 4, color.RGBA{0, 0, 0, 255})
+	c := b.RGBAAt(10, 10)
+	c.G += 2
+	b.SetRGBA(10, 10, c)
+
+	cases := []struct {
+		tolerance uint8
+		bad       int
+	}{
+		{0, 2},
+		{2, 1},
+		{255, 0},
+	}
+	for _, c := range cases {
+		t.Run(fmt.Sprint(c.tolerance), func(t *testing.T) {
+			res, err := Compare(b, a, Options{Tolerance: c.tolerance})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.BadPixels != c.bad {
+				t.Fatal(res)
+			}
+			if res.MaxDiff != 128 {
+				t.Fatal(res.MaxDiff)
+			}
+			if res.PSNR <= 20 || res.PSNR >= 50 || res.SSIM >= 1 || res.SSIM < .5 {
+				t.Fatal(res)
+			}
+		})
+	}
+
+	res, _ := Compare(b, a, Options{})
+	if got := res.Diff.RGBAAt(3, 4); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Fatal(got)
+	}
+	if got := res.Diff.RGBAAt(0, 0); got.R != got.G {
+		t.Fatal(got)
+	}
+}
+
+func TestCompareSize(t *testing.T) {
+	if _, err := Compare(gradient(4, 4), gradient(4, 5), Options{}); err == nil {
+		t.Fatal("expected size error")
+	}
+}
+
+func TestPSNR(t *testing.T) {
+	if p := PSNR(255 * 255); p != 0 {
+		t.Fatal(p)
+	}
+	if p := PSNR(255 * 255 / 100.); math.Abs(p-20) > 1e-9 {
+		t.Fatal(p)
+	}
+}
+
+func TestSSIMNoise(t *testing.T) {
+	a := gradient(64, 64)
+	b := gradient(64, 64)
+	for i := 0; i < len(b.Pix); i += 4 {
+		if i/4%2 == 0 {
+			b.Pix[i] ^= 0x40
+		}
+	}
+	small, large := SSIM(a, a), SSIM(a, b)
+	if small != 1 || large >= .9 {
+		t.Fatal(small, large)
+	}
+}
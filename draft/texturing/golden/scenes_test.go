@@ -0,0 +1,150 @@
+package golden
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"testing"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/raster"
+)
+
+// The scenes render the demos at a quarter or tenth of their window size to
+// keep the goldens small.
+
+var opts = Options{Tolerance: 2, MaxBadPixels: 16}
+
+var background = glm.Vec4{0.2, 0.3, 0.3, 1.0}
+
+var quadIndices = []uint32{
+	0, 1, 3,
+	1, 2, 3,
+}
+
+// texturedQuad is the position/color/texcoord quad of draft/texturing and
+// draft/proj.
+var texturedQuad = []float32{
+	+.5, +.5, 0, 0.0, 1.0, 0.0, 1.0, 0.0,
+	+.5, -.5, 0, 0.0, 1.0, 1.0, 1.0, 1.0,
+	-.5, -.5, 0, 0.0, 0.0, 1.0, 0.0, 1.0,
+	-.5, +.5, 0, 1.0, 0.0, 0.0, 0.0, 0.0,
+}
+
+// loadTexture reads an image the way stbi.Load does for the demos: straight
+// alpha, first row first.
+func loadTexture(t *testing.T, path string) *image.RGBA {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatal(path, err)
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	if n, ok := img.(*image.NRGBA); ok {
+		for y := 0; y < b.Dy(); y++ {
+			copy(rgba.Pix[y*rgba.Stride:(y+1)*rgba.Stride], n.Pix[n.PixOffset(b.Min.X, b.Min.Y+y):])
+		}
+		return rgba
+	}
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	return rgba
+}
+
+func TestBrian(t *testing.T) {
+	target := raster.NewTarget(160, 120)
+	target.Clear(background)
+	err := target.Draw(raster.DrawCall{
+		Vertices: []float32{
+			+.5, +.5, 0, 0.0, 1.0, 0.0,
+			+.5, -.5, 0, 0.0, 1.0, 1.0,
+			-.5, -.5, 0, 0.0, 0.0, 1.0,
+			-.5, +.5, 0, 1.0, 0.0, 0.0,
+		},
+		Layout:     raster.ColorLayout,
+		Indices:    quadIndices,
+		Model:      glm.Identity(),
+		View:       glm.Identity(),
+		Projection: glm.Identity(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Check(t, "brian", target.Color, opts)
+}
+
+func TestTexturing(t *testing.T) {
+	textures := []*image.RGBA{
+		loadTexture(t, "../tex.png"),
+		loadTexture(t, "../lumi.jpg"),
+	}
+
+	// fragment.glsl
+	fragment := func(f raster.Fragment, tex []*image.RGBA) glm.Vec4 {
+		return raster.Mix(raster.Sample(tex[0], f.TexCoord), raster.Sample(tex[1], f.TexCoord), 0.2)
+	}
+
+	// glfw.GetTime() in seconds
+	for _, time := range []float32{0, 1, 2.5} {
+		name := fmt.Sprintf("texturing_%g", time)
+		t.Run(name, func(t *testing.T) {
+			translation := glm.Identity().Translate(glm.Vec3{.5, -.5, 0})
+			rotation := glm.RotationZ(time)
+
+			target := raster.NewTarget(160, 120)
+			target.Clear(background)
+			err := target.Draw(raster.DrawCall{
+				Vertices:   texturedQuad,
+				Layout:     raster.DefaultLayout,
+				Indices:    quadIndices,
+				Model:      translation.Times(rotation),
+				View:       glm.Identity(),
+				Projection: glm.Identity(),
+				Textures:   textures,
+				Fragment:   fragment,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			Check(t, name, target.Color, opts)
+		})
+	}
+}
+
+func TestProj(t *testing.T) {
+	const width, height = 192, 108
+	textures := []*image.RGBA{
+		loadTexture(t, "../tex.png"),
+		loadTexture(t, "../lumi.jpg"),
+	}
+
+	target := raster.NewTarget(width, height)
+	target.Clear(background)
+	err := target.Draw(raster.DrawCall{
+		Vertices:   texturedQuad,
+		Layout:     raster.DefaultLayout,
+		Indices:    quadIndices,
+		Model:      glm.RotationX(glm.Rad(-55)),
+		View:       glm.LookAt(glm.Vec3{0, .2, 0}, glm.Vec3{0, .2, -1}, glm.Vec3{0, 1, 0}),
+		Projection: glm.Perspect(glm.Rad(45), float32(width)/float32(height), 0.1, 100.0),
+		Textures:   textures,
+		// draft/proj/fragment.glsl
+		Fragment: func(f raster.Fragment, tex []*image.RGBA) glm.Vec4 {
+			c := raster.Mix(raster.Sample(tex[0], f.TexCoord), raster.Sample(tex[1], f.TexCoord), 0.2)
+			return raster.Mix(c, f.Color.Vec4(1), 0.2)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Check(t, "proj", target.Color, opts)
+}
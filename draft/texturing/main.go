@@ -2,17 +2,15 @@ package main
 
 import (
 	"fmt"
-	"image"
+	"os"
 	"runtime"
 
-	"neilpa.me/go-stbi"
-	_ "neilpa.me/go-stbi/jpeg"
-	_ "neilpa.me/go-stbi/png"
-
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/pgeowng/rende/draft/texturing/device"
 	"github.com/pgeowng/rende/draft/texturing/glm"
 	"github.com/pgeowng/rende/draft/texturing/shader"
+	"github.com/pgeowng/rende/draft/texturing/texture"
 )
 
 func init() {
@@ -49,11 +47,19 @@ func run() (err error) {
 		return
 	}
 
-	sh := shader.New("./vertex.glsl", "./fragment.glsl")
-	prog, err := sh.Compile()
+	layout := device.PositionColorTexCoord
+	vertexSource, err := os.ReadFile("./vertex.glsl")
 	if err != nil {
 		return
 	}
+	if err = layout.Validate(string(vertexSource)); err != nil {
+		return
+	}
+
+	sh := shader.New("./vertex.glsl", "./fragment.glsl")
+	if _, err = sh.Compile(); err != nil {
+		return
+	}
 
 	points := []float32{
 		// positions // colors     // texture coords
@@ -62,8 +68,11 @@ func run() (err error) {
 		-.5, -.5, 0, 0.0, 0.0, 1.0, 0.0, 1.0,
 		-.5, +.5, 0, 1.0, 0.0, 0.0, 0.0, 0.0,
 	}
-	const vertexSize = 8
-	const f32size = 4
+	var data []byte
+	data, err = layout.Pack(points)
+	if err != nil {
+		return
+	}
 	var vbo, vao uint32
 	{
 		gl.GenVertexArrays(1, &vao)
@@ -71,19 +80,9 @@ func run() (err error) {
 		gl.BindVertexArray(vao)
 
 		gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-		gl.BufferData(gl.ARRAY_BUFFER, f32size*len(points), gl.Ptr(points), gl.STATIC_DRAW)
-
-		// position attribute
-		gl.VertexAttribPointer(0, 3, gl.FLOAT, false, f32size*vertexSize, nil)
-		gl.EnableVertexAttribArray(0)
-
-		// color attribute
-		gl.VertexAttribPointer(1, 3, gl.FLOAT, false, f32size*vertexSize, gl.PtrOffset(f32size*3))
-		gl.EnableVertexAttribArray(1)
+		gl.BufferData(gl.ARRAY_BUFFER, len(data), gl.Ptr(data), gl.STATIC_DRAW)
 
-		// tex attribute
-		gl.VertexAttribPointer(2, 2, gl.FLOAT, false, f32size*vertexSize, gl.PtrOffset(f32size*6))
-		gl.EnableVertexAttribArray(2)
+		layout.Enable()
 
 		defer gl.DeleteVertexArrays(1, &vao)
 		defer gl.DeleteBuffers(1, &vbo)
@@ -104,75 +103,57 @@ func run() (err error) {
 
 	var texture1 uint32
 	{
-		gl.GenTextures(1, &texture1)
-		gl.BindTexture(gl.TEXTURE_2D, texture1)
-
-		// repeat texture1
-		gl.TexParameteri(texture1, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(texture1, gl.TEXTURE_WRAP_T, gl.REPEAT)
-
-		// texture1 interpolation
-		gl.TexParameteri(texture1, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-		gl.TexParameteri(texture1, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-		var filePath string = "./tex.png"
-
-		var rgba *image.RGBA
-		rgba, err = stbi.Load(filePath)
+		var img *texture.Image
+		img, err = texture.Load("./tex.png", texture.Options{})
 		if err != nil {
 			return
 		}
-		// if rgba.Stride != rgba.Rect.Size().X*4 {
-		// 	return fmt.Errorf("image: unsupported stride: %d", rgba.Stride)
-		// }
 
-		fmt.Println(rgba.Rect.Size())
+		fmt.Println(img.Width, img.Height)
 
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-		gl.GenerateMipmap(gl.TEXTURE_2D)
+		// repeat, linear with mipmaps built on the CPU
+		texture1, err = texture.UploadLevels(img.Mipmaps(texture.Box, texture.Repeat, 0), texture.Sampler{})
+		if err != nil {
+			return
+		}
 		defer gl.DeleteTextures(1, &texture1)
 	}
 
 	var texture2 uint32
 	{
-		gl.GenTextures(1, &texture2)
-		gl.BindTexture(gl.TEXTURE_2D, texture2)
-
-		// repeat texture2
-		gl.TexParameteri(texture2, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(texture2, gl.TEXTURE_WRAP_T, gl.REPEAT)
-
-		// texture2 interpolation
-		gl.TexParameteri(texture2, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-		gl.TexParameteri(texture2, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-		var filePath string = "./lumi.jpg"
-
-		var rgba *image.RGBA
-		rgba, err = stbi.Load(filePath)
+		var img *texture.Image
+		img, err = texture.Load("./lumi.jpg", texture.Options{})
 		if err != nil {
 			return
 		}
-		// if rgba.Stride != rgba.Rect.Size().X*4 {
-		// 	return fmt.Errorf("image: unsupported stride: %d", rgba.Stride)
-		// }
 
-		fmt.Println(rgba.Rect.Size())
+		fmt.Println(img.Width, img.Height)
 
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-		gl.GenerateMipmap(gl.TEXTURE_2D)
+		// repeat, linear with mipmaps built on the CPU
+		texture2, err = texture.UploadLevels(img.Mipmaps(texture.Box, texture.Repeat, 0), texture.Sampler{})
+		if err != nil {
+			return
+		}
 		defer gl.DeleteTextures(1, &texture2)
 	}
 
-	gl.UseProgram(prog)
-
-	gl.Uniform1i(gl.GetUniformLocation(prog, gl.Str("texture1"+"\x00")), 0)
-	gl.Uniform1i(gl.GetUniformLocation(prog, gl.Str("texture2"+"\x00")), 1)
+	samplers := func() {
+		sh.UseProgram()
+		gl.Uniform1i(sh.Uniform("texture1"), 0)
+		gl.Uniform1i(sh.Uniform("texture2"), 1)
+	}
+	samplers()
 
 	// vertexColorLocation := gl.GetUniformLocation(shaderProgram, gl.Str("ourColor"+"\x00"))
 	for !window.ShouldClose() {
 		processInput(window)
 
+		// edits to the shader files show up without a restart; a broken
+		// edit is logged and the last good program stays
+		if ok, _ := sh.Reload(); ok {
+			samplers()
+		}
+
 		gl.ClearColor(0.2, 0.3, 0.3, 1.0)
 		gl.Clear(gl.COLOR_BUFFER_BIT)
 
@@ -185,8 +166,8 @@ func run() (err error) {
 		rotation := glm.RotationZ(float32(glfw.GetTime()))
 		transform := translation.Times(rotation)
 
-		gl.UseProgram(prog)
-		gl.UniformMatrix4fv(gl.GetUniformLocation(prog, gl.Str("transform"+"\x00")), 1, false, &transform[0])
+		sh.UseProgram()
+		gl.UniformMatrix4fv(sh.Uniform("transform"), 1, false, &transform[0])
 		gl.BindVertexArray(vao)
 
 		// timeValue := glfw.GetTime()
@@ -0,0 +1,267 @@
+// Package mesh holds indexed triangle geometry on the CPU and generates
+// primitive shapes. Front faces are counter-clockwise, as GL expects by
+// default, and texture coordinate (0, 0) is the first row of the image.
+package mesh
+
+import (
+	"fmt"
+
+	"github.com/pgeowng/rende/draft/texturing/geometry"
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// Mesh is a triangle list. Every attribute slice is either nil or has one
+// entry per position. Indices are read three at a time; nil draws the
+// positions in order.
+type Mesh struct {
+	Positions []glm.Vec3
+	Normals   []glm.Vec3
+	// Tangents point along increasing u. w is the handedness: the bitangent
+	// cross(normal, tangent) * w points along decreasing v, up in the image,
+	// as in glTF.
+	Tangents []glm.Vec4
+	UVs      []glm.Vec2
+	Colors   []glm.Vec4
+	Indices  []uint32
+}
+
+// Validate reports attributes whose length differs from the positions and
+// indices out of range.
+func (m *Mesh) Validate() error {
+	n := len(m.Positions)
+	lens := []struct {
+		name string
+		len  int
+	}{
+		{"normals", len(m.Normals)},
+		{"tangents", len(m.Tangents)},
+		{"uvs", len(m.UVs)},
+		{"colors", len(m.Colors)},
+	}
+	for _, l := range lens {
+		if l.len != 0 && l.len != n {
+			return fmt.Errorf("mesh: %d %s for %d positions", l.len, l.name, n)
+		}
+	}
+
+	count := n
+	if m.Indices != nil {
+		count = len(m.Indices)
+	}
+	if count%3 != 0 {
+		return fmt.Errorf("mesh: %d vertices do not make whole triangles", count)
+	}
+	for i, idx := range m.Indices {
+		if int(idx) >= n {
+			return fmt.Errorf("mesh: index %d at %d is out of range of %d positions", idx, i, n)
+		}
+	}
+	return nil
+}
+
+// TriangleCount returns the number of triangles drawn.
+func (m *Mesh) TriangleCount() int {
+	if m.Indices != nil {
+		return len(m.Indices) / 3
+	}
+	return len(m.Positions) / 3
+}
+
+// Triangle returns the vertex indices of triangle i.
+func (m *Mesh) Triangle(i int) (a, b, c uint32) {
+	if m.Indices != nil {
+		return m.Indices[3*i], m.Indices[3*i+1], m.Indices[3*i+2]
+	}
+	return uint32(3 * i), uint32(3*i + 1), uint32(3*i + 2)
+}
+
+func (m *Mesh) Bounds() geometry.AABB {
+	return geometry.AABBFromPoints(m.Positions)
+}
+
+// faceNormal is the normal of the triangle scaled by twice its area.
+func (m *Mesh) faceNormal(a, b, c uint32) glm.Vec3 {
+	pa := m.Positions[a]
+	return m.Positions[b].Sub(pa).Cross(m.Positions[c].Sub(pa))
+}
+
+// SmoothNormals sets each normal to the area weighted average of the faces
+// sharing the vertex. Vertices are shared by index only, so seams where
+// positions are duplicated stay visible.
+func (m *Mesh) SmoothNormals() {
+	m.Normals = make([]glm.Vec3, len(m.Positions))
+	for i := 0; i < m.TriangleCount(); i++ {
+		a, b, c := m.Triangle(i)
+		n := m.faceNormal(a, b, c)
+		m.Normals[a] = m.Normals[a].Add(n)
+		m.Normals[b] = m.Normals[b].Add(n)
+		m.Normals[c] = m.Normals[c].Add(n)
+	}
+	for i := range m.Normals {
+		m.Normals[i] = m.Normals[i].Normalize()
+	}
+}
+
+// FlatNormals gives every triangle its own three vertices with the face
+// normal. The mesh stays indexed, with indices 0, 1, 2, ...
+func (m *Mesh) FlatNormals() {
+	count := m.TriangleCount()
+	flat := Mesh{
+		Positions: make([]glm.Vec3, 0, 3*count),
+		Normals:   make([]glm.Vec3, 0, 3*count),
+		Indices:   make([]uint32, 3*count),
+	}
+	if m.Tangents != nil {
+		flat.Tangents = make([]glm.Vec4, 0, 3*count)
+	}
+	if m.UVs != nil {
+		flat.UVs = make([]glm.Vec2, 0, 3*count)
+	}
+	if m.Colors != nil {
+		flat.Colors = make([]glm.Vec4, 0, 3*count)
+	}
+
+	for i := 0; i < count; i++ {
+		a, b, c := m.Triangle(i)
+		n := m.faceNormal(a, b, c).Normalize()
+		for _, v := range [3]uint32{a, b, c} {
+			flat.Positions = append(flat.Positions, m.Positions[v])
+			flat.Normals = append(flat.Normals, n)
+			if m.Tangents != nil {
+				flat.Tangents = append(flat.Tangents, m.Tangents[v])
+			}
+			if m.UVs != nil {
+				flat.UVs = append(flat.UVs, m.UVs[v])
+			}
+			if m.Colors != nil {
+				flat.Colors = append(flat.Colors, m.Colors[v])
+			}
+		}
+	}
+	for i := range flat.Indices {
+		flat.Indices[i] = uint32(i)
+	}
+	*m = flat
+}
+
+// ComputeTangents derives tangents from the normals and UVs, which must be
+// set. Triangles without a usable UV mapping do not contribute; vertices
+// left without a tangent get an arbitrary one perpendicular to the normal.
+func (m *Mesh) ComputeTangents() {
+	n := len(m.Positions)
+	tan := make([]glm.Vec3, n)
+	bitan := make([]glm.Vec3, n)
+
+	for i := 0; i < m.TriangleCount(); i++ {
+		a, b, c := m.Triangle(i)
+		e1 := m.Positions[b].Sub(m.Positions[a])
+		e2 := m.Positions[c].Sub(m.Positions[a])
+		d1 := m.UVs[b].Sub(m.UVs[a])
+		d2 := m.UVs[c].Sub(m.UVs[a])
+
+		det := d1[0]*d2[1] - d2[0]*d1[1]
+		if glm.FloatEqual(det, 0) {
+			continue
+		}
+		r := 1 / det
+		t := e1.Scale(d2[1]).Sub(e2.Scale(d1[1])).Scale(r)
+		bt := e2.Scale(d1[0]).Sub(e1.Scale(d2[0])).Scale(r)
+		for _, v := range [3]uint32{a, b, c} {
+			tan[v] = tan[v].Add(t)
+			bitan[v] = bitan[v].Add(bt)
+		}
+	}
+
+	m.Tangents = make([]glm.Vec4, n)
+	for i := range m.Tangents {
+		nrm := m.Normals[i]
+		// Gram-Schmidt
+		t := tan[i].Sub(nrm.Scale(nrm.Dot(tan[i])))
+		if t.Len() < 1e-6 {
+			t = perpendicular(nrm)
+		}
+		t = t.Normalize()
+
+		// bitan points along increasing v
+		w := float32(1)
+		if nrm.Cross(t).Dot(bitan[i]) > 0 {
+			w = -1
+		}
+		m.Tangents[i] = t.Vec4(w)
+	}
+}
+
+func perpendicular(n glm.Vec3) glm.Vec3 {
+	axis := glm.Vec3{1, 0, 0}
+	if n.Abs()[0] > .9 {
+		axis = glm.Vec3{0, 1, 0}
+	}
+	return axis.Sub(n.Scale(n.Dot(axis)))
+}
+
+// Interleave returns position, color and texture coordinate per vertex, the
+// vertex of device.PositionColorTexCoord and raster.DefaultLayout. Missing
+// colors are white and missing UVs zero.
+func (m *Mesh) Interleave() []float32 {
+	out := make([]float32, 0, 8*len(m.Positions))
+	for i, p := range m.Positions {
+		c := glm.Vec4{1, 1, 1, 1}
+		if m.Colors != nil {
+			c = m.Colors[i]
+		}
+		var uv glm.Vec2
+		if m.UVs != nil {
+			uv = m.UVs[i]
+		}
+		out = append(out, p[0], p[1], p[2], c[0], c[1], c[2], uv[0], uv[1])
+	}
+	return out
+}
+
+// Append adds the vertices and triangles of o to m. Attributes missing on one
+// side are filled with zero values.
+func (m *Mesh) Append(o *Mesh) {
+	base := uint32(len(m.Positions))
+	n, on := len(m.Positions), len(o.Positions)
+
+	// two unindexed meshes stay unindexed
+	if m.Indices != nil || o.Indices != nil {
+		if m.Indices == nil {
+			m.Indices = sequence(n)
+		}
+		oi := o.Indices
+		if oi == nil {
+			oi = sequence(on)
+		}
+		for _, i := range oi {
+			m.Indices = append(m.Indices, base+i)
+		}
+	}
+
+	m.Positions = append(m.Positions, o.Positions...)
+	m.Normals = appendAttr(m.Normals, o.Normals, n, on)
+	m.Tangents = appendAttr(m.Tangents, o.Tangents, n, on)
+	m.UVs = appendAttr(m.UVs, o.UVs, n, on)
+	m.Colors = appendAttr(m.Colors, o.Colors, n, on)
+}
+
+func sequence(n int) []uint32 {
+	s := make([]uint32, n)
+	for i := range s {
+		s[i] = uint32(i)
+	}
+	return s
+}
+
+func appendAttr[T any](a, b []T, n, on int) []T {
+	if a == nil && b == nil {
+		return nil
+	}
+	if a == nil {
+		a = make([]T, n, n+on)
+	}
+	if b == nil {
+		return append(a, make([]T, on)...)
+	}
+	return append(a, b...)
+}
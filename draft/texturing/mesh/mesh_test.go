@@ -0,0 +1,219 @@
+package mesh
+
+import (
+	"fmt"
+	"testing"
+
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+var primitives = []struct {
+	name      string
+	mesh      *Mesh
+	triangles int
+	closed    bool
+	bounds    string
+}{
+	{"plane", Plane(2, 4, 3, 2), 12, false, "{[-1 0 -2] [1 0 2]}"},
+	{"quad", Quad(), 2, false, "{[-0.5 -0.5 0] [0.5 0.5 0]}"},
+	{"cube", Cube(2), 12, true, "{[-1 -1 -1] [1 1 1]}"},
+	{"uv sphere", UVSphere(1, 16, 8), 2 * 16 * 7, true, "{[-1 -1 -1] [1 1 1]}"},
+	{"icosphere", Icosphere(2, 2), 20 * 16, true, "{[-2 -2 -2] [2 2 2]}"},
+	{"cylinder", Cylinder(1, 2, 12), 4 * 12, true, "{[-1 -1 -1] [1 1 1]}"},
+	{"cone", Cone(1, 2, 12), 2 * 12, true, "{[-1 -1 -1] [1 1 1]}"},
+	{"torus", Torus(2, .5, 24, 12), 2 * 24 * 12, true, "{[-2.5 -0.5 -2.5] [2.5 0.5 2.5]}"},
+	{"capsule", Capsule(1, 2, 16, 4), 16 * 4 * 4, true, "{[-1 -2 -1] [1 2 1]}"},
+}
+
+func round(v glm.Vec3) [3]int {
+	return [3]int{int(m32.Round(v[0] * 1e4)), int(m32.Round(v[1] * 1e4)), int(m32.Round(v[2] * 1e4))}
+}
+
+func roundBounds(m *Mesh) string {
+	b := m.Bounds()
+	r := func(v glm.Vec3) glm.Vec3 {
+		for i := range v {
+			v[i] = m32.Round(v[i]*1e4) / 1e4
+		}
+		return v
+	}
+	return fmt.Sprint(r(b.Min), r(b.Max))
+}
+
+func TestPrimitives(t *testing.T) {
+	for _, c := range primitives {
+		t.Run(c.name, func(t *testing.T) {
+			m := c.mesh
+			if err := m.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if n := m.TriangleCount(); n != c.triangles {
+				t.Fatal("triangles", n)
+			}
+			if b := "{" + roundBounds(m) + "}"; b != c.bounds {
+				t.Fatal(b)
+			}
+
+			for i := range m.Positions {
+				if !glm.FloatEqual(m.Normals[i].Len(), 1) {
+					t.Fatal("normal", i, m.Normals[i])
+				}
+				uv := m.UVs[i]
+				if uv[0] < 0 || uv[0] > 1.5 || uv[1] < 0 || uv[1] > 1 {
+					t.Fatal("uv", i, uv)
+				}
+				tan := m.Tangents[i]
+				if !glm.FloatEqual(tan.Vec3().Len(), 1) || m32.Abs(tan.Vec3().Dot(m.Normals[i])) > 1e-4 || m32.Abs(tan[3]) != 1 {
+					t.Fatal("tangent", i, tan)
+				}
+			}
+
+			// counter-clockwise seen from the side the normals point to
+			for i := 0; i < m.TriangleCount(); i++ {
+				a, b, cc := m.Triangle(i)
+				n := m.Normals[a].Add(m.Normals[b]).Add(m.Normals[cc])
+				if m.faceNormal(a, b, cc).Dot(n) <= 0 {
+					t.Fatal("winding", i, m.Positions[a], m.Positions[b], m.Positions[cc])
+				}
+			}
+
+			if c.closed {
+				// every edge, with duplicated vertices welded, is shared by
+				// exactly two triangles running it in opposite directions
+				edges := make(map[[2][3]int]int)
+				for i := 0; i < m.TriangleCount(); i++ {
+					a, b, cc := m.Triangle(i)
+					p := [3][3]int{round(m.Positions[a]), round(m.Positions[b]), round(m.Positions[cc])}
+					for k := 0; k < 3; k++ {
+						edges[[2][3]int{p[k], p[(k+1)%3]}]++
+					}
+				}
+				for e, n := range edges {
+					if n != 1 || edges[[2][3]int{e[1], e[0]}] != 1 {
+						t.Fatal("edge", e, n, edges[[2][3]int{e[1], e[0]}])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTangentsFollowUV(t *testing.T) {
+	m := Quad()
+	for i, tan := range m.Tangents {
+		if !tan.ApproxEqual(glm.Vec4{1, 0, 0, 1}) {
+			t.Fatal(i, tan)
+		}
+		// v runs down the quad, the bitangent up
+		bitan := m.Normals[i].Cross(tan.Vec3()).Scale(tan[3])
+		if !bitan.ApproxEqual(glm.Vec3{0, 1, 0}) {
+			t.Fatal(i, bitan)
+		}
+	}
+
+	// mirrored UVs flip the handedness
+	for i := range m.UVs {
+		m.UVs[i][0] = 1 - m.UVs[i][0]
+	}
+	m.ComputeTangents()
+	for i, tan := range m.Tangents {
+		if !tan.ApproxEqual(glm.Vec4{-1, 0, 0, -1}) {
+			t.Fatal(i, tan)
+		}
+	}
+}
+
+func TestQuadMatchesDemo(t *testing.T) {
+	// the vertices of draft/texturing with colors left white
+	want := map[string]string{
+		"[0.5 0.5 0]":   "[1 0]",
+		"[0.5 -0.5 0]":  "[1 1]",
+		"[-0.5 -0.5 0]": "[0 1]",
+		"[-0.5 0.5 0]":  "[0 0]",
+	}
+	m := Quad()
+	v := m.Interleave()
+	if len(v) != 4*8 {
+		t.Fatal(len(v))
+	}
+	for i := 0; i < 4; i++ {
+		p, uv := fmt.Sprint(v[8*i:8*i+3]), fmt.Sprint(v[8*i+6:8*i+8])
+		if want[p] != uv || fmt.Sprint(v[8*i+3:8*i+6]) != "[1 1 1]" {
+			t.Fatal(p, uv)
+		}
+	}
+}
+
+func TestSmoothNormals(t *testing.T) {
+	m := UVSphere(3, 24, 12)
+	analytic := m.Normals
+	m.SmoothNormals()
+	for i, n := range m.Normals {
+		// the last vertex of the pole row is not used by any triangle
+		if n == (glm.Vec3{}) {
+			continue
+		}
+		// the seam and poles only see the faces on one side
+		if n.Dot(analytic[i]) < .97 {
+			t.Fatal(i, n, analytic[i])
+		}
+	}
+}
+
+func TestFlatNormals(t *testing.T) {
+	m := Cube(1)
+	m.Colors = make([]glm.Vec4, len(m.Positions))
+	m.Colors[0] = glm.Vec4{1, 0, 0, 1}
+	m.SmoothNormals()
+	m.FlatNormals()
+
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Positions) != 36 || m.TriangleCount() != 12 {
+		t.Fatal(len(m.Positions), m.TriangleCount())
+	}
+	for i := 0; i < m.TriangleCount(); i++ {
+		a, b, c := m.Triangle(i)
+		n := m.Normals[a]
+		if n != m.Normals[b] || n != m.Normals[c] || !glm.FloatEqual(n.Abs().Dot(glm.Vec3{1, 1, 1}), 1) {
+			t.Fatal(i, n)
+		}
+	}
+	if m.Colors[0] != (glm.Vec4{1, 0, 0, 1}) {
+		t.Fatal(m.Colors[0])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		mesh Mesh
+		ok   bool
+	}{
+		{"unindexed", Mesh{Positions: make([]glm.Vec3, 3)}, true},
+		{"partial triangle", Mesh{Positions: make([]glm.Vec3, 4)}, false},
+		{"short normals", Mesh{Positions: make([]glm.Vec3, 3), Normals: make([]glm.Vec3, 2)}, false},
+		{"index out of range", Mesh{Positions: make([]glm.Vec3, 3), Indices: []uint32{0, 1, 3}}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.mesh.Validate(); (err == nil) != c.ok {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestAppend(t *testing.T) {
+	m := &Mesh{Positions: make([]glm.Vec3, 3)}
+	m.Append(Quad())
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if m.TriangleCount() != 3 || len(m.UVs) != 7 || fmt.Sprint(m.Indices[:3]) != "[0 1 2]" || m.Indices[3] < 3 {
+		t.Fatal(m.TriangleCount(), m.Indices)
+	}
+}
@@ -0,0 +1,311 @@
+package mesh
+
+import (
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// grid builds a (cols+1) x (rows+1) sheet of vertices. f gives the position
+// and normal of vertex (s, r), which gets uv (s/cols, r/rows); seen from the
+// front, s must grow to the right and r downwards. Triangles that collapse,
+// like those at the poles of a sphere, are left out.
+func grid(cols, rows int, f func(s, r int) (pos, normal glm.Vec3)) *Mesh {
+	m := &Mesh{}
+	for r := 0; r <= rows; r++ {
+		for s := 0; s <= cols; s++ {
+			p, n := f(s, r)
+			m.Positions = append(m.Positions, p)
+			m.Normals = append(m.Normals, n)
+			m.UVs = append(m.UVs, glm.Vec2{float32(s) / float32(cols), float32(r) / float32(rows)})
+		}
+	}
+
+	at := func(s, r int) uint32 { return uint32(r*(cols+1) + s) }
+	for r := 0; r < rows; r++ {
+		for s := 0; s < cols; s++ {
+			a, b := at(s, r), at(s+1, r)
+			c, d := at(s, r+1), at(s+1, r+1)
+			m.addTriangle(a, c, d)
+			m.addTriangle(a, d, b)
+		}
+	}
+	return m
+}
+
+// addTriangle appends the triangle unless two of its corners coincide.
+func (m *Mesh) addTriangle(a, b, c uint32) {
+	pa, pb, pc := m.Positions[a], m.Positions[b], m.Positions[c]
+	if pa.ApproxEqual(pb) || pb.ApproxEqual(pc) || pc.ApproxEqual(pa) {
+		return
+	}
+	m.Indices = append(m.Indices, a, b, c)
+}
+
+// face is a square of cols x rows quads with the given center and half
+// extents along right and down; its normal is down x right.
+func face(center, right, down glm.Vec3, cols, rows int) *Mesh {
+	normal := down.Cross(right).Normalize()
+	return grid(cols, rows, func(s, r int) (glm.Vec3, glm.Vec3) {
+		u := 2*float32(s)/float32(cols) - 1
+		v := 2*float32(r)/float32(rows) - 1
+		return center.Add(right.Scale(u)).Add(down.Scale(v)), normal
+	})
+}
+
+// disk is a triangle fan facing +y, or -y when down is set.
+func disk(center glm.Vec3, radius float32, segments int, down bool) *Mesh {
+	normal := glm.Vec3{0, 1, 0}
+	if down {
+		normal = glm.Vec3{0, -1, 0}
+	}
+	m := &Mesh{
+		Positions: []glm.Vec3{center},
+		Normals:   []glm.Vec3{normal},
+		UVs:       []glm.Vec2{{.5, .5}},
+	}
+	for s := 0; s <= segments; s++ {
+		sin, cos := m32.Sincos(2 * m32.Pi * float32(s) / float32(segments))
+		m.Positions = append(m.Positions, center.Add(glm.Vec3{sin, 0, cos}.Scale(radius)))
+		m.Normals = append(m.Normals, normal)
+		if down {
+			m.UVs = append(m.UVs, glm.Vec2{.5 + .5*sin, .5 - .5*cos})
+		} else {
+			m.UVs = append(m.UVs, glm.Vec2{.5 + .5*sin, .5 + .5*cos})
+		}
+	}
+	for s := uint32(1); s <= uint32(segments); s++ {
+		if down {
+			m.Indices = append(m.Indices, 0, s+1, s)
+		} else {
+			m.Indices = append(m.Indices, 0, s, s+1)
+		}
+	}
+	return m
+}
+
+func finish(m *Mesh) *Mesh {
+	m.ComputeTangents()
+	return m
+}
+
+// Plane is a width x depth rectangle in the xz plane facing +y, centered on
+// the origin, split into segX x segZ quads. u runs along +x and v along +z.
+func Plane(width, depth float32, segX, segZ int) *Mesh {
+	return finish(face(glm.Vec3{}, glm.Vec3{width / 2, 0, 0}, glm.Vec3{0, 0, depth / 2}, segX, segZ))
+}
+
+// Quad is the unit quad of the demos: the xy plane from -0.5 to 0.5 facing
+// +z, with u along +x and v along -y.
+func Quad() *Mesh {
+	return finish(face(glm.Vec3{}, glm.Vec3{.5, 0, 0}, glm.Vec3{0, -.5, 0}, 1, 1))
+}
+
+// Cube is an axis aligned cube centered on the origin. Each face has its own
+// vertices and the full [0, 1] UV square.
+func Cube(size float32) *Mesh {
+	h := size / 2
+	x, y, z := glm.Vec3{h, 0, 0}, glm.Vec3{0, h, 0}, glm.Vec3{0, 0, h}
+	neg := func(v glm.Vec3) glm.Vec3 { return v.Scale(-1) }
+
+	m := &Mesh{}
+	for _, f := range [6][3]glm.Vec3{
+		{z, x, neg(y)},
+		{neg(z), neg(x), neg(y)},
+		{x, neg(z), neg(y)},
+		{neg(x), z, neg(y)},
+		{y, x, z},
+		{neg(y), x, neg(z)},
+	} {
+		m.Append(face(f[0], f[1], f[2], 1, 1))
+	}
+	return finish(m)
+}
+
+// spherical is the point of the unit sphere at azimuth theta from +z towards
+// +x and polar angle phi from +y.
+func spherical(theta, phi float32) glm.Vec3 {
+	st, ct := m32.Sincos(theta)
+	sp, cp := m32.Sincos(phi)
+	return glm.Vec3{sp * st, cp, sp * ct}
+}
+
+// UVSphere is a latitude-longitude sphere centered on the origin with u
+// around the equator and v from the north pole (+y) to the south pole.
+func UVSphere(radius float32, segments, rings int) *Mesh {
+	return finish(grid(segments, rings, func(s, r int) (glm.Vec3, glm.Vec3) {
+		n := spherical(2*m32.Pi*float32(s)/float32(segments), m32.Pi*float32(r)/float32(rings))
+		return n.Scale(radius), n
+	}))
+}
+
+// Icosphere subdivides an icosahedron, splitting every triangle into four
+// per level, and projects it onto the sphere. UVs are spherical like those of
+// UVSphere, with vertices duplicated along the seam.
+func Icosphere(radius float32, subdivisions int) *Mesh {
+	t := (1 + m32.Sqrt(5)) / 2
+	points := []glm.Vec3{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+	}
+	for i := range points {
+		points[i] = points[i].Normalize()
+	}
+	tris := []uint32{
+		0, 11, 5, 0, 5, 1, 0, 1, 7, 0, 7, 10, 0, 10, 11,
+		1, 5, 9, 5, 11, 4, 11, 10, 2, 10, 7, 6, 7, 1, 8,
+		3, 9, 4, 3, 4, 2, 3, 2, 6, 3, 6, 8, 3, 8, 9,
+		4, 9, 5, 2, 4, 11, 6, 2, 10, 8, 6, 7, 9, 8, 1,
+	}
+
+	for level := 0; level < subdivisions; level++ {
+		mid := make(map[[2]uint32]uint32)
+		midpoint := func(a, b uint32) uint32 {
+			key := [2]uint32{a, b}
+			if a > b {
+				key = [2]uint32{b, a}
+			}
+			if i, ok := mid[key]; ok {
+				return i
+			}
+			points = append(points, points[a].Add(points[b]).Normalize())
+			i := uint32(len(points) - 1)
+			mid[key] = i
+			return i
+		}
+
+		next := make([]uint32, 0, 4*len(tris))
+		for i := 0; i < len(tris); i += 3 {
+			a, b, c := tris[i], tris[i+1], tris[i+2]
+			ab, bc, ca := midpoint(a, b), midpoint(b, c), midpoint(c, a)
+			next = append(next, a, ab, ca, b, bc, ab, c, ca, bc, ab, bc, ca)
+		}
+		tris = next
+	}
+
+	m := &Mesh{Indices: tris}
+	for _, p := range points {
+		m.Positions = append(m.Positions, p.Scale(radius))
+		m.Normals = append(m.Normals, p)
+		m.UVs = append(m.UVs, glm.Vec2{
+			m32.Atan2(p[0], p[2])/(2*m32.Pi) + .5,
+			m32.Acos(glm.Clamp(p[1], -1, 1)) / m32.Pi,
+		})
+	}
+	m.fixSphericalSeam()
+	return finish(m)
+}
+
+// fixSphericalSeam duplicates vertices of triangles that wrap around u = 0 so
+// u increases across them, and gives pole vertices the mean u of the other
+// two corners.
+func (m *Mesh) fixSphericalSeam() {
+	dup := func(i uint32, uv glm.Vec2) uint32 {
+		m.Positions = append(m.Positions, m.Positions[i])
+		m.Normals = append(m.Normals, m.Normals[i])
+		m.UVs = append(m.UVs, uv)
+		return uint32(len(m.Positions) - 1)
+	}
+	wrapped := make(map[uint32]uint32)
+
+	for t := 0; t < len(m.Indices); t += 3 {
+		tri := m.Indices[t : t+3]
+
+		var pole = -1
+		for k, i := range tri {
+			if n := m.Normals[i]; glm.FloatEqual(n[0], 0) && glm.FloatEqual(n[2], 0) {
+				pole = k
+			}
+		}
+
+		minU, maxU := float32(2), float32(-1)
+		for k, i := range tri {
+			if k != pole {
+				minU = m32.Min(minU, m.UVs[i][0])
+				maxU = m32.Max(maxU, m.UVs[i][0])
+			}
+		}
+		if maxU-minU > .5 {
+			for k, i := range tri {
+				if k == pole || m.UVs[i][0] >= .5 {
+					continue
+				}
+				w, ok := wrapped[i]
+				if !ok {
+					w = dup(i, glm.Vec2{m.UVs[i][0] + 1, m.UVs[i][1]})
+					wrapped[i] = w
+				}
+				tri[k] = w
+			}
+		}
+
+		if pole >= 0 {
+			var u float32
+			for k, i := range tri {
+				if k != pole {
+					u += m.UVs[i][0] / 2
+				}
+			}
+			tri[pole] = dup(tri[pole], glm.Vec2{u, m.UVs[tri[pole]][1]})
+		}
+	}
+}
+
+// Cylinder runs along the y axis from -height/2 to height/2 and has capped
+// ends. u wraps once around the side.
+func Cylinder(radius, height float32, segments int) *Mesh {
+	m := grid(segments, 1, func(s, r int) (glm.Vec3, glm.Vec3) {
+		n := spherical(2*m32.Pi*float32(s)/float32(segments), m32.Pi/2)
+		return n.Scale(radius).Add(glm.Vec3{0, height/2 - float32(r)*height, 0}), n
+	})
+	m.Append(disk(glm.Vec3{0, height / 2, 0}, radius, segments, false))
+	m.Append(disk(glm.Vec3{0, -height / 2, 0}, radius, segments, true))
+	return finish(m)
+}
+
+// Cone has its apex at +height/2 on the y axis and a capped base at
+// -height/2.
+func Cone(radius, height float32, segments int) *Mesh {
+	m := grid(segments, 1, func(s, r int) (glm.Vec3, glm.Vec3) {
+		theta := 2 * m32.Pi * float32(s) / float32(segments)
+		sin, cos := m32.Sincos(theta)
+		n := glm.Vec3{height * sin, radius, height * cos}.Normalize()
+		if r == 0 {
+			return glm.Vec3{0, height / 2, 0}, n
+		}
+		return glm.Vec3{radius * sin, -height / 2, radius * cos}, n
+	})
+	m.Append(disk(glm.Vec3{0, -height / 2, 0}, radius, segments, true))
+	return finish(m)
+}
+
+// Torus lies in the xz plane around the y axis. major is the distance from
+// the center to the middle of the tube and minor the tube radius. u goes
+// around the y axis and v around the tube, starting at its outer edge.
+func Torus(major, minor float32, majorSegments, minorSegments int) *Mesh {
+	return finish(grid(majorSegments, minorSegments, func(s, r int) (glm.Vec3, glm.Vec3) {
+		st, ct := m32.Sincos(2 * m32.Pi * float32(s) / float32(majorSegments))
+		sp, cp := m32.Sincos(2 * m32.Pi * float32(r) / float32(minorSegments))
+		n := glm.Vec3{cp * st, -sp, cp * ct}
+		ring := glm.Vec3{major * st, 0, major * ct}
+		return ring.Add(n.Scale(minor)), n
+	}))
+}
+
+// Capsule is a cylinder of the given height along y closed by two
+// hemispheres, so it is height + 2*radius tall. rings is the number of
+// latitude bands per hemisphere.
+func Capsule(radius, height float32, segments, rings int) *Mesh {
+	return finish(grid(segments, 2*rings+1, func(s, r int) (glm.Vec3, glm.Vec3) {
+		theta := 2 * m32.Pi * float32(s) / float32(segments)
+		center := glm.Vec3{0, height / 2, 0}
+		phi := m32.Pi / 2 * float32(r) / float32(rings)
+		if r > rings {
+			center[1] = -height / 2
+			phi = m32.Pi / 2 * float32(r-1) / float32(rings)
+		}
+		n := spherical(theta, phi)
+		return center.Add(n.Scale(radius)), n
+	}))
+}
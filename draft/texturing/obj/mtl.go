@@ -0,0 +1,189 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// Material is the subset of an MTL material the renderer understands. Map
+// paths are as written in the file, relative to it.
+type Material struct {
+	Name     string
+	Ambient  glm.Vec3 // Ka
+	Diffuse  glm.Vec3 // Kd
+	Specular glm.Vec3 // Ks
+	// Shininess is the specular exponent Ns.
+	Shininess float32
+	// Opacity is d, or 1 - Tr.
+	Opacity float32
+
+	DiffuseMap string // map_Kd
+	BumpMap    string // map_Bump or bump
+}
+
+func newMaterial(name string) *Material {
+	return &Material{
+		Name:    name,
+		Diffuse: glm.Vec3{1, 1, 1},
+		Opacity: 1,
+	}
+}
+
+// ParseMTL reads the materials of an MTL file by name.
+func ParseMTL(r io.Reader) (map[string]*Material, error) {
+	materials := make(map[string]*Material)
+	var cur *Material
+
+	err := scanLines(r, func(n int, keyword string, args []string) error {
+		if keyword == "newmtl" {
+			if len(args) == 0 {
+				return fmt.Errorf("mtl: line %d: newmtl without a name", n)
+			}
+			cur = newMaterial(strings.Join(args, " "))
+			materials[cur.Name] = cur
+			return nil
+		}
+
+		switch keyword {
+		case "Ka", "Kd", "Ks", "Ns", "d", "Tr", "map_Kd", "map_Bump", "map_bump", "bump":
+			if cur == nil {
+				return fmt.Errorf("mtl: line %d: %s before newmtl", n, keyword)
+			}
+		default:
+			// illum, Ke, Ni, map_Ks, ... are not used
+			return nil
+		}
+
+		switch keyword {
+		case "Ka", "Kd", "Ks":
+			c, err := parseColor(args)
+			if err != nil {
+				return fmt.Errorf("mtl: line %d: %s: %w", n, keyword, err)
+			}
+			switch keyword {
+			case "Ka":
+				cur.Ambient = c
+			case "Kd":
+				cur.Diffuse = c
+			case "Ks":
+				cur.Specular = c
+			}
+		case "Ns", "d", "Tr":
+			if len(args) == 0 {
+				return fmt.Errorf("mtl: line %d: %s without a value", n, keyword)
+			}
+			f, err := parseFloat(args[0])
+			if err != nil {
+				return fmt.Errorf("mtl: line %d: %s: %w", n, keyword, err)
+			}
+			switch keyword {
+			case "Ns":
+				cur.Shininess = f
+			case "d":
+				cur.Opacity = f
+			case "Tr":
+				cur.Opacity = 1 - f
+			}
+		case "map_Kd", "map_Bump", "map_bump", "bump":
+			path, err := mapPath(args)
+			if err != nil {
+				return fmt.Errorf("mtl: line %d: %s: %w", n, keyword, err)
+			}
+			if keyword == "map_Kd" {
+				cur.DiffuseMap = path
+			} else {
+				cur.BumpMap = path
+			}
+		}
+		return nil
+	})
+	return materials, err
+}
+
+// parseColor reads "r g b"; a single value is grey.
+func parseColor(args []string) (c glm.Vec3, err error) {
+	if len(args) == 1 {
+		args = []string{args[0], args[0], args[0]}
+	}
+	if len(args) != 3 {
+		return c, fmt.Errorf("want 3 components, got %d", len(args))
+	}
+	for i := range c {
+		if c[i], err = parseFloat(args[i]); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// mapArgs is the number of values following each texture map option.
+var mapArgs = map[string]int{
+	"-blendu": 1, "-blendv": 1, "-bm": 1, "-boost": 1, "-cc": 1, "-clamp": 1,
+	"-imfchan": 1, "-mm": 2, "-o": 3, "-s": 3, "-t": 3, "-texres": 1,
+}
+
+// mapPath skips the options of a texture map statement and returns the file
+// name, which may contain spaces.
+func mapPath(args []string) (string, error) {
+	for len(args) > 0 {
+		n, ok := mapArgs[args[0]]
+		if !ok {
+			break
+		}
+		if len(args) <= n {
+			return "", fmt.Errorf("option %s needs %d values", args[0], n)
+		}
+		args = args[n+1:]
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("no file name")
+	}
+	return strings.Join(args, " "), nil
+}
+
+func parseFloat(s string) (float32, error) {
+	f, err := strconv.ParseFloat(s, 32)
+	return float32(f), err
+}
+
+// scanLines calls f with the keyword and arguments of each statement, joining
+// lines ending in a backslash and dropping comments. n is the line number
+// the statement starts on.
+func scanLines(r io.Reader, f func(n int, keyword string, args []string) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+
+	n, start := 0, 0
+	var stmt strings.Builder
+	for sc.Scan() {
+		n++
+		line := sc.Text()
+		if stmt.Len() == 0 {
+			start = n
+		}
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if strings.HasSuffix(line, "\\") {
+			stmt.WriteString(line[:len(line)-1])
+			stmt.WriteByte(' ')
+			continue
+		}
+		stmt.WriteString(line)
+
+		fields := strings.Fields(stmt.String())
+		stmt.Reset()
+		if len(fields) == 0 {
+			continue
+		}
+		if err := f(start, fields[0], fields[1:]); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,400 @@
+// Package obj loads Wavefront OBJ models and their MTL materials into meshes.
+//
+// Texture coordinates are flipped to v = 1 - vt so that (0, 0) is the first
+// row of the image, as elsewhere in the project.
+package obj
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/mesh"
+)
+
+// Part is the geometry of one object, group and material combination, in the
+// order the faces appear in the file.
+type Part struct {
+	Object   string
+	Group    string
+	Material string
+	Mesh     *mesh.Mesh
+}
+
+type Model struct {
+	Parts []*Part
+	// Materials holds the materials of every loaded mtllib by name.
+	Materials map[string]*Material
+	// MaterialLibs are the mtllib file names in the order referenced.
+	MaterialLibs []string
+}
+
+// OpenFunc opens a file referenced by the model, like an mtllib.
+type OpenFunc func(name string) (io.ReadCloser, error)
+
+// Load reads an OBJ file and the material libraries next to it.
+func Load(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(path)
+	m, err := Parse(f, func(name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Parse reads an OBJ model. open resolves mtllib statements; with a nil open
+// the libraries are only recorded in MaterialLibs.
+func Parse(r io.Reader, open OpenFunc) (*Model, error) {
+	p := &parser{
+		model: &Model{Materials: make(map[string]*Material)},
+		open:  open,
+		group: "default",
+	}
+	if err := scanLines(r, p.statement); err != nil {
+		return nil, err
+	}
+	p.finishPart()
+	return p.model, nil
+}
+
+// corner is one v/vt/vn reference of a face as 0-based indices, -1 when
+// absent.
+type corner struct {
+	v, vt, vn int
+}
+
+// vertexKey identifies an output vertex. normal is the smoothing key used
+// when the corner has no vn: the smoothing group, or a unique negative value
+// per face for flat shading.
+type vertexKey struct {
+	corner
+	normal int
+}
+
+type parser struct {
+	model *Model
+	open  OpenFunc
+
+	positions []glm.Vec3
+	colors    []glm.Vec3
+	uvs       []glm.Vec2
+	normals   []glm.Vec3
+
+	object, group, material string
+	smooth                  int
+	faces                   int
+
+	part     *Part
+	vertices map[vertexKey]uint32
+	// smoothing accumulates face normals per position and smoothing key for
+	// the vertices without vn.
+	smoothing map[[2]int]glm.Vec3
+	computed  map[uint32][2]int
+}
+
+func (p *parser) statement(n int, keyword string, args []string) error {
+	fail := func(format string, a ...interface{}) error {
+		return fmt.Errorf("obj: line %d: %s", n, fmt.Sprintf(format, a...))
+	}
+
+	switch keyword {
+	case "v":
+		if len(args) != 3 && len(args) != 4 && len(args) != 6 {
+			return fail("v needs 3 coordinates, got %d values", len(args))
+		}
+		f, err := parseFloats(args)
+		if err != nil {
+			return fail("v: %v", err)
+		}
+		p.positions = append(p.positions, glm.Vec3{f[0], f[1], f[2]})
+		// the common vertex color extension: v x y z r g b
+		if len(f) == 6 {
+			for len(p.colors) < len(p.positions)-1 {
+				p.colors = append(p.colors, glm.Vec3{1, 1, 1})
+			}
+			p.colors = append(p.colors, glm.Vec3{f[3], f[4], f[5]})
+		}
+	case "vt":
+		if len(args) < 1 || len(args) > 3 {
+			return fail("vt needs 1 to 3 coordinates, got %d", len(args))
+		}
+		f, err := parseFloats(args)
+		if err != nil {
+			return fail("vt: %v", err)
+		}
+		uv := glm.Vec2{f[0], 1}
+		if len(f) > 1 {
+			uv[1] = 1 - f[1]
+		}
+		p.uvs = append(p.uvs, uv)
+	case "vn":
+		if len(args) != 3 {
+			return fail("vn needs 3 coordinates, got %d", len(args))
+		}
+		f, err := parseFloats(args)
+		if err != nil {
+			return fail("vn: %v", err)
+		}
+		p.normals = append(p.normals, glm.Vec3{f[0], f[1], f[2]}.Normalize())
+	case "f":
+		if len(args) < 3 {
+			return fail("face needs 3 vertices, got %d", len(args))
+		}
+		corners := make([]corner, len(args))
+		for i, a := range args {
+			c, err := p.corner(a)
+			if err != nil {
+				return fail("f: %v", err)
+			}
+			corners[i] = c
+		}
+		p.face(corners)
+	case "o":
+		p.finishPart()
+		p.object = strings.Join(args, " ")
+	case "g":
+		p.finishPart()
+		p.group = strings.Join(args, " ")
+		if p.group == "" {
+			p.group = "default"
+		}
+	case "usemtl":
+		if len(args) == 0 {
+			return fail("usemtl without a name")
+		}
+		p.finishPart()
+		p.material = strings.Join(args, " ")
+	case "s":
+		if len(args) != 1 {
+			return fail("s needs one value")
+		}
+		if args[0] == "off" {
+			p.smooth = 0
+			break
+		}
+		s, err := strconv.Atoi(args[0])
+		if err != nil || s < 0 {
+			return fail("bad smoothing group %q", args[0])
+		}
+		p.smooth = s
+	case "mtllib":
+		if len(args) == 0 {
+			return fail("mtllib without a file name")
+		}
+		// several libraries may follow one mtllib
+		for _, name := range args {
+			p.model.MaterialLibs = append(p.model.MaterialLibs, name)
+			if p.open == nil {
+				continue
+			}
+			if err := p.loadLib(name); err != nil {
+				return fail("mtllib %s: %v", name, err)
+			}
+		}
+	}
+	// l, p, vp, curves and surfaces are not supported and skipped
+	return nil
+}
+
+func (p *parser) loadLib(name string) error {
+	f, err := p.open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	mats, err := ParseMTL(f)
+	if err != nil {
+		return err
+	}
+	for k, v := range mats {
+		p.model.Materials[k] = v
+	}
+	return nil
+}
+
+func parseFloats(args []string) ([]float32, error) {
+	f := make([]float32, len(args))
+	for i, a := range args {
+		var err error
+		if f[i], err = parseFloat(a); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// corner parses v, v/vt, v//vn or v/vt/vn. Indices are 1-based, or relative
+// to the end of the list when negative.
+func (p *parser) corner(s string) (c corner, err error) {
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 {
+		return c, fmt.Errorf("bad vertex %q", s)
+	}
+	c = corner{-1, -1, -1}
+
+	resolve := func(i int, count int, what string) (int, error) {
+		if i >= len(parts) || parts[i] == "" {
+			if i == 0 {
+				return 0, fmt.Errorf("bad vertex %q", s)
+			}
+			return -1, nil
+		}
+		idx, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, fmt.Errorf("bad %s index in %q", what, s)
+		}
+		switch {
+		case idx > 0 && idx <= count:
+			return idx - 1, nil
+		case idx < 0 && -idx <= count:
+			return count + idx, nil
+		}
+		return 0, fmt.Errorf("%s index %d out of range of %d", what, idx, count)
+	}
+
+	if c.v, err = resolve(0, len(p.positions), "position"); err != nil {
+		return
+	}
+	if c.vt, err = resolve(1, len(p.uvs), "texture coordinate"); err != nil {
+		return
+	}
+	c.vn, err = resolve(2, len(p.normals), "normal")
+	return
+}
+
+func (p *parser) face(corners []corner) {
+	if p.part == nil {
+		p.part = &Part{
+			Object:   p.object,
+			Group:    p.group,
+			Material: p.material,
+			Mesh:     &mesh.Mesh{},
+		}
+		p.vertices = make(map[vertexKey]uint32)
+		p.smoothing = make(map[[2]int]glm.Vec3)
+		p.computed = make(map[uint32][2]int)
+	}
+	p.faces++
+
+	normalKey := p.smooth
+	if normalKey == 0 {
+		normalKey = -p.faces
+	}
+
+	points := make([]glm.Vec3, len(corners))
+	for i, c := range corners {
+		points[i] = p.positions[c.v]
+	}
+	tris := triangulate(points)
+
+	m := p.part.Mesh
+	idx := make([]uint32, len(corners))
+	for i, c := range corners {
+		key := vertexKey{corner: c}
+		if c.vn < 0 {
+			key.normal = normalKey
+		}
+		v, ok := p.vertices[key]
+		if !ok {
+			v = uint32(len(m.Positions))
+			p.vertices[key] = v
+			p.addVertex(key)
+		}
+		idx[i] = v
+	}
+
+	for t := 0; t < len(tris); t += 3 {
+		m.Indices = append(m.Indices, idx[tris[t]], idx[tris[t+1]], idx[tris[t+2]])
+	}
+
+	// the whole polygon counts once towards each of its smoothed corners,
+	// however it was triangulated
+	fn := newell(points)
+	for i, v := range idx {
+		if k, ok := p.computed[v]; ok && !repeated(idx[:i], v) {
+			p.smoothing[k] = p.smoothing[k].Add(fn)
+		}
+	}
+}
+
+func repeated(idx []uint32, v uint32) bool {
+	for _, i := range idx {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) addVertex(key vertexKey) {
+	m := p.part.Mesh
+	m.Positions = append(m.Positions, p.positions[key.v])
+
+	var uv glm.Vec2
+	if key.vt >= 0 {
+		uv = p.uvs[key.vt]
+	}
+	m.UVs = append(m.UVs, uv)
+
+	c := glm.Vec4{1, 1, 1, 1}
+	if key.v < len(p.colors) {
+		c = p.colors[key.v].Vec4(1)
+	}
+	m.Colors = append(m.Colors, c)
+
+	if key.vn >= 0 {
+		m.Normals = append(m.Normals, p.normals[key.vn])
+	} else {
+		m.Normals = append(m.Normals, glm.Vec3{})
+		p.computed[uint32(len(m.Positions)-1)] = [2]int{key.v, key.normal}
+	}
+}
+
+// finishPart computes the missing normals of the current part and adds it to
+// the model. Attributes the file never mentions are dropped.
+func (p *parser) finishPart() {
+	if p.part == nil {
+		return
+	}
+	m := p.part.Mesh
+	for v, k := range p.computed {
+		m.Normals[v] = p.smoothing[k].Normalize()
+	}
+
+	var hasUV, hasColor bool
+	for k := range p.vertices {
+		hasUV = hasUV || k.vt >= 0
+		hasColor = hasColor || k.v < len(p.colors)
+	}
+	if !hasUV {
+		m.UVs = nil
+	}
+	if !hasColor {
+		m.Colors = nil
+	}
+
+	p.model.Parts = append(p.model.Parts, p.part)
+	p.part = nil
+}
+
+// Mesh merges all parts into one mesh, dropping the material assignment.
+func (m *Model) Mesh() *mesh.Mesh {
+	out := &mesh.Mesh{}
+	for _, part := range m.Parts {
+		out.Append(part.Mesh)
+	}
+	return out
+}
@@ -0,0 +1,271 @@
+package obj
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+func TestLoadCube(t *testing.T) {
+	m, err := Load("testdata/cube.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Parts) != 2 {
+		t.Fatal(len(m.Parts))
+	}
+	cases := []struct {
+		group, material   string
+		vertices, indices int
+	}{
+		{"sides", "red", 16, 24},
+		{"caps", "textured", 8, 12},
+	}
+	for i, c := range cases {
+		p := m.Parts[i]
+		if p.Object != "Cube" || p.Group != c.group || p.Material != c.material {
+			t.Fatal(p.Object, p.Group, p.Material)
+		}
+		if err := p.Mesh.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Mesh.Positions) != c.vertices || len(p.Mesh.Indices) != c.indices {
+			t.Fatal(c.group, len(p.Mesh.Positions), len(p.Mesh.Indices))
+		}
+		if p.Mesh.Colors != nil {
+			t.Fatal("colors")
+		}
+	}
+
+	// faces keep their winding, counter-clockwise seen from outside
+	mesh := m.Mesh()
+	for i := 0; i < mesh.TriangleCount(); i++ {
+		a, b, c := mesh.Triangle(i)
+		pa := mesh.Positions[a]
+		n := mesh.Positions[b].Sub(pa).Cross(mesh.Positions[c].Sub(pa)).Normalize()
+		if !n.ApproxEqual(mesh.Normals[a]) {
+			t.Fatal(i, n, mesh.Normals[a])
+		}
+	}
+
+	// vt 0 0 is the bottom left of the image
+	if uv := m.Parts[0].Mesh.UVs[0]; uv != (glm.Vec2{0, 1}) {
+		t.Fatal(uv)
+	}
+
+	if fmt.Sprint(m.MaterialLibs) != "[cube.mtl]" {
+		t.Fatal(m.MaterialLibs)
+	}
+	red := m.Materials["red"]
+	if red == nil || red.Diffuse != (glm.Vec3{.8, .1, .1}) || red.Shininess != 32 || red.Opacity != 1 || red.Ambient != (glm.Vec3{.1, .1, .1}) {
+		t.Fatalf("%+v", red)
+	}
+	tex := m.Materials["textured"]
+	if tex == nil || tex.Opacity != .5 || tex.DiffuseMap != "../tex.png" || tex.BumpMap != "normal map.png" {
+		t.Fatalf("%+v", tex)
+	}
+}
+
+func TestShapes(t *testing.T) {
+	m, err := Load("testdata/shapes.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Parts) != 2 || m.Parts[0].Object != "L" || m.Parts[1].Object != "tent" {
+		t.Fatal(m.Parts)
+	}
+
+	// the concave hexagon needs ear clipping: every triangle lies inside it,
+	// faces +z and the areas add up to 3
+	l := m.Parts[0].Mesh
+	if l.TriangleCount() != 4 {
+		t.Fatal(l.TriangleCount())
+	}
+	var area float32
+	for i := 0; i < l.TriangleCount(); i++ {
+		a, b, c := l.Triangle(i)
+		pa := l.Positions[a]
+		cross := l.Positions[b].Sub(pa).Cross(l.Positions[c].Sub(pa))
+		if cross[2] <= 0 {
+			t.Fatal("winding", i)
+		}
+		area += cross[2] / 2
+		centroid := pa.Add(l.Positions[b]).Add(l.Positions[c]).Scale(1. / 3)
+		if centroid[0] > 1 && centroid[1] > 1 {
+			t.Fatal("outside", i, centroid)
+		}
+	}
+	if !glm.FloatEqual(area, 3) {
+		t.Fatal(area)
+	}
+	for _, n := range l.Normals {
+		if n != (glm.Vec3{0, 0, 1}) {
+			t.Fatal(n)
+		}
+	}
+	if l.UVs != nil || l.Colors != nil {
+		t.Fatal("attributes")
+	}
+
+	// the shared edge of the tent is smoothed, the outer corners are not
+	tent := m.Parts[1].Mesh
+	if len(tent.Positions) != 6 {
+		t.Fatal(len(tent.Positions))
+	}
+	ridge := glm.Vec3{0, 0, 1}
+	slope := glm.Vec3{0, -1, 1}.Normalize()
+	for i, p := range tent.Positions {
+		want := slope
+		if p[1] == 1 {
+			want = ridge
+		} else if p[1] == 2 {
+			want = glm.Vec3{0, 1, 1}.Normalize()
+		}
+		if !tent.Normals[i].ApproxEqual(want) {
+			t.Fatal(p, tent.Normals[i], want)
+		}
+	}
+	// colors from the v extension, white where missing
+	if fmt.Sprint(tent.Colors[:4]) != "[[1 0 0 1] [0 1 0 1] [0 0 1 1] [1 1 1 1]]" {
+		t.Fatal(tent.Colors)
+	}
+}
+
+func TestFlatShadingSplitsVertices(t *testing.T) {
+	src := `
+v 0 0 0
+v 1 0 0
+v 1 1 1
+v 0 1 1
+v 1 2 0
+v 0 2 0
+f 1 2 3 4
+f 4 3 5 6
+`
+	m, err := Parse(strings.NewReader(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mesh := m.Parts[0].Mesh
+	if len(mesh.Positions) != 8 {
+		t.Fatal(len(mesh.Positions))
+	}
+	for i := 0; i < mesh.TriangleCount(); i++ {
+		a, _, _ := mesh.Triangle(i)
+		if m32.Abs(mesh.Normals[a][0]) > 1e-6 || m32.Abs(mesh.Normals[a].Len()-1) > 1e-6 {
+			t.Fatal(mesh.Normals[a])
+		}
+	}
+}
+
+func TestDeduplication(t *testing.T) {
+	src := `
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 1
+vn 0 0 1
+f 1/1/1 2/1/1 3/1/1
+f 1/1/1 3/1/1 4/1/1
+f 1/2/1 3/1/1 4/1/1
+`
+	m, err := Parse(strings.NewReader(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mesh := m.Parts[0].Mesh
+	// 1/2/1 differs from 1/1/1 in its texture coordinate
+	if len(mesh.Positions) != 5 || fmt.Sprint(mesh.Indices) != "[0 1 2 0 2 3 4 2 3]" {
+		t.Fatal(len(mesh.Positions), mesh.Indices)
+	}
+	if m.Parts[0].Group != "default" || m.Parts[0].Material != "" {
+		t.Fatal(m.Parts[0])
+	}
+}
+
+func TestNegativeIndices(t *testing.T) {
+	src := `
+v 0 0 0
+v 1 0 0
+v 1 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+f -3/-3 -2/-2 -1/-1
+`
+	m, err := Parse(strings.NewReader(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mesh := m.Parts[0].Mesh
+	if fmt.Sprint(mesh.Positions) != "[[0 0 0] [1 0 0] [1 1 0]]" || fmt.Sprint(mesh.UVs) != "[[0 1] [1 1] [1 0]]" {
+		t.Fatal(mesh.Positions, mesh.UVs)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	cases := []struct {
+		name, src, err string
+	}{
+		{"bad float", "v 0 0 x", "obj: line 1: v: "},
+		{"short vertex", "v 0 0 0\n\nv 1 1", "obj: line 3: v needs 3 coordinates"},
+		{"index out of range", "v 0 0 0\nv 1 0 0\nv 1 1 0\nf 1 2 4", "obj: line 4: f: position index 4 out of range of 3"},
+		{"negative out of range", "v 0 0 0\nf -1 -2 -1", "line 2: f: position index -2 out of range"},
+		{"missing uv", "v 0 0 0\nf 1/1 1/1 1/1", "texture coordinate index 1 out of range of 0"},
+		{"bad vertex", "v 0 0 0\nf 1/1/1/1 1 1", "bad vertex"},
+		{"two vertex face", "v 0 0 0\nf 1 1", "line 2: face needs 3 vertices"},
+		{"smoothing", "s smooth", "bad smoothing group"},
+		{"missing mtllib", "# comment\nmtllib missing.mtl", "obj: line 2: mtllib missing.mtl:"},
+		{"mtl error", "mtllib bad.mtl", "mtl: line 2: Kd: want 3 components"},
+		{"mtl before newmtl", "mtllib early.mtl", "mtl: line 1: Kd before newmtl"},
+	}
+
+	libs := map[string]string{
+		"bad.mtl":   "newmtl a\nKd 1 1",
+		"early.mtl": "Kd 1 1 1",
+	}
+	open := func(name string) (io.ReadCloser, error) {
+		src, ok := libs[name]
+		if !ok {
+			return nil, fmt.Errorf("%s not found", name)
+		}
+		return io.NopCloser(strings.NewReader(src)), nil
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(c.src), open)
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestTriangulate(t *testing.T) {
+	cases := []struct {
+		name   string
+		points []glm.Vec3
+		out    string
+	}{
+		{"triangle", []glm.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}, "[0 1 2]"},
+		{"square", []glm.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}, "[3 0 1 1 2 3]"},
+		// clockwise seen from +z, in the yz plane: the winding is kept
+		{"clockwise arrow", []glm.Vec3{{0, 0, 0}, {0, 1, 2}, {0, 2, 0}, {0, 1, 1}}, "[3 0 1 1 2 3]"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if res := fmt.Sprint(triangulate(c.points)); res != c.out {
+				t.Fatal(res)
+			}
+		})
+	}
+}
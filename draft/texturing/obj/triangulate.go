@@ -0,0 +1,103 @@
+package obj
+
+import (
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// triangulate splits a planar polygon into triangles by ear clipping and
+// returns them as indices into points, keeping the winding of the polygon.
+// Concave polygons are handled; if no ear can be found, as for degenerate or
+// self-intersecting polygons, the rest is split as a fan.
+func triangulate(points []glm.Vec3) []int {
+	n := len(points)
+	if n == 3 {
+		return []int{0, 1, 2}
+	}
+
+	// the normal picks the axis to drop for a 2D projection
+	axis := 0
+	abs := newell(points).Abs()
+	if abs[1] > abs[axis] {
+		axis = 1
+	}
+	if abs[2] > abs[axis] {
+		axis = 2
+	}
+	flat := make([]glm.Vec2, n)
+	for i, p := range points {
+		flat[i] = glm.Vec2{p[(axis+1)%3], p[(axis+2)%3]}
+	}
+
+	// orientation of the projected polygon, so convex corners test positive
+	var area float32
+	for i := range flat {
+		area += flat[i].Cross(flat[(i+1)%n])
+	}
+	sign := float32(1)
+	if area < 0 {
+		sign = -1
+	}
+
+	remaining := make([]int, n)
+	for i := range remaining {
+		remaining[i] = i
+	}
+	tris := make([]int, 0, 3*(n-2))
+
+	for len(remaining) > 3 {
+		ear := -1
+		for i := range remaining {
+			k := len(remaining)
+			a, b, c := remaining[(i+k-1)%k], remaining[i], remaining[(i+1)%k]
+			if isEar(flat, remaining, a, b, c, sign) {
+				ear = i
+				tris = append(tris, a, b, c)
+				break
+			}
+		}
+		if ear < 0 {
+			break
+		}
+		remaining = append(remaining[:ear], remaining[ear+1:]...)
+	}
+
+	for i := 1; i+1 < len(remaining); i++ {
+		tris = append(tris, remaining[0], remaining[i], remaining[i+1])
+	}
+	return tris
+}
+
+// newell returns the normal of a polygon scaled by twice its area, using
+// Newell's method so that slightly non-planar polygons work too.
+func newell(points []glm.Vec3) (normal glm.Vec3) {
+	n := len(points)
+	for i := range points {
+		a, b := points[i], points[(i+1)%n]
+		normal[0] += (a[1] - b[1]) * (a[2] + b[2])
+		normal[1] += (a[2] - b[2]) * (a[0] + b[0])
+		normal[2] += (a[0] - b[0]) * (a[1] + b[1])
+	}
+	return
+}
+
+func cross2(a, b, c glm.Vec2) float32 {
+	return b.Sub(a).Cross(c.Sub(a))
+}
+
+func isEar(flat []glm.Vec2, remaining []int, a, b, c int, sign float32) bool {
+	pa, pb, pc := flat[a], flat[b], flat[c]
+	if sign*cross2(pa, pb, pc) <= 0 {
+		return false
+	}
+	for _, i := range remaining {
+		if i == a || i == b || i == c {
+			continue
+		}
+		p := flat[i]
+		// inside or on the edge of the candidate triangle
+		if sign*cross2(pa, pb, p) >= 0 && sign*cross2(pb, pc, p) >= 0 && sign*cross2(pc, pa, p) >= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package raster
+
+// clipPlanes are the signed distances to the six clip planes -w <= x, y, z <= w
+// of a clip space position. A vertex is inside when all are non-negative.
+var clipPlanes = [6]func(v *vertex) float32{
+	func(v *vertex) float32 { return v.pos[3] + v.pos[0] },
+	func(v *vertex) float32 { return v.pos[3] - v.pos[0] },
+	func(v *vertex) float32 { return v.pos[3] + v.pos[1] },
+	func(v *vertex) float32 { return v.pos[3] - v.pos[1] },
+	func(v *vertex) float32 { return v.pos[3] + v.pos[2] },
+	func(v *vertex) float32 { return v.pos[3] - v.pos[2] },
+}
+
+func lerpVertex(a, b *vertex, t float32) (v vertex) {
+	v.pos = a.pos.Lerp(b.pos, t)
+	for i := range v.att {
+		v.att[i] = a.att[i] + (b.att[i]-a.att[i])*t
+	}
+	return
+}
+
+// clip cuts the polygon against every clip plane (Sutherland-Hodgman) and
+// returns the remaining convex polygon, possibly empty. scratch is reused
+// between calls to avoid allocating per triangle; both slices are returned so
+// the caller can keep them.
+func clip(poly, scratch []vertex) ([]vertex, []vertex) {
+	for _, dist := range clipPlanes {
+		if len(poly) == 0 {
+			break
+		}
+		out := scratch[:0]
+		for i := range poly {
+			a, b := &poly[i], &poly[(i+1)%len(poly)]
+			da, db := dist(a), dist(b)
+			if da >= 0 {
+				out = append(out, *a)
+			}
+			if (da >= 0) != (db >= 0) {
+				out = append(out, lerpVertex(a, b, da/(da-db)))
+			}
+		}
+		poly, scratch = out, poly
+	}
+	return poly, scratch
+}
@@ -0,0 +1,278 @@
+package raster
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// Layout describes where the attributes live in an interleaved vertex, in
+// floats. An offset of -1 means the attribute is absent. The zero value is
+// not useful; DefaultLayout matches the position/color/texcoord vertices of
+// draft/texturing and draft/proj.
+type Layout struct {
+	Stride   int
+	Position int
+	Color    int
+	TexCoord int
+}
+
+var DefaultLayout = Layout{Stride: 8, Position: 0, Color: 3, TexCoord: 6}
+
+// ColorLayout matches the position/color vertices of draft/brian.
+var ColorLayout = Layout{Stride: 6, Position: 0, Color: 3, TexCoord: -1}
+
+// Fragment holds the interpolated inputs of one pixel.
+type Fragment struct {
+	X, Y     int
+	Depth    float32
+	Color    glm.Vec3
+	TexCoord glm.Vec2
+}
+
+// FragmentFunc plays the role of the fragment shader. textures are the units
+// bound for the draw call, in order.
+type FragmentFunc func(f Fragment, textures []*image.RGBA) glm.Vec4
+
+// VertexColor is the fragment shader of draft/brian.
+func VertexColor(f Fragment, _ []*image.RGBA) glm.Vec4 {
+	return f.Color.Vec4(1)
+}
+
+type DrawCall struct {
+	Vertices []float32
+	Layout   Layout
+	// Indices are read three at a time as triangles. nil draws the vertices in
+	// order.
+	Indices []uint32
+
+	Model      glm.Mat4
+	View       glm.Mat4
+	Projection glm.Mat4
+
+	Textures []*image.RGBA
+	Fragment FragmentFunc
+
+	DepthTest bool
+}
+
+// Target is a color and depth framebuffer. Depth is stored in window space,
+// [0, 1] with 0 at the near plane.
+type Target struct {
+	Color *image.RGBA
+	Depth []float32
+}
+
+func NewTarget(width, height int) *Target {
+	return &Target{
+		Color: image.NewRGBA(image.Rect(0, 0, width, height)),
+		Depth: make([]float32, width*height),
+	}
+}
+
+func (t *Target) Clear(c glm.Vec4) {
+	px := toRGBA(c)
+	b := t.Color.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			t.Color.SetRGBA(x, y, px)
+		}
+	}
+	for i := range t.Depth {
+		t.Depth[i] = 1
+	}
+}
+
+func toRGBA(c glm.Vec4) color.RGBA {
+	conv := func(f float32) uint8 {
+		return uint8(m32.Round(glm.Clamp(f, 0, 1) * 255))
+	}
+	return color.RGBA{conv(c[0]), conv(c[1]), conv(c[2]), conv(c[3])}
+}
+
+// vertex is a vertex after the vertex stage: clip space position and the
+// attributes to interpolate (color rgb, texcoord uv).
+type vertex struct {
+	pos glm.Vec4
+	att [5]float32
+}
+
+func (d *DrawCall) fetch(i uint32, mvp *glm.Mat4) (v vertex) {
+	l := d.Layout
+	in := d.Vertices[int(i)*l.Stride:]
+
+	p := glm.Vec4{in[l.Position], in[l.Position+1], in[l.Position+2], 1}
+	v.pos = mvp.Mulv(p)
+	if l.Color >= 0 {
+		copy(v.att[0:3], in[l.Color:l.Color+3])
+	} else {
+		v.att[0], v.att[1], v.att[2] = 1, 1, 1
+	}
+	if l.TexCoord >= 0 {
+		copy(v.att[3:5], in[l.TexCoord:l.TexCoord+2])
+	}
+	return
+}
+
+// validate checks that the layout fits in a vertex and that every index
+// names a whole vertex of d.Vertices, so fetch cannot read past them.
+func (d *DrawCall) validate() error {
+	l := d.Layout
+	if l.Position < 0 {
+		return fmt.Errorf("raster: position offset %d", l.Position)
+	}
+	size := l.Position + 3
+	if l.Color >= 0 && l.Color+3 > size {
+		size = l.Color + 3
+	}
+	if l.TexCoord >= 0 && l.TexCoord+2 > size {
+		size = l.TexCoord + 2
+	}
+	if l.Stride < size {
+		return fmt.Errorf("raster: stride %d, the attributes need %d", l.Stride, size)
+	}
+	n := len(d.Vertices) / l.Stride
+	for i, v := range d.Indices {
+		if int64(v) >= int64(n) {
+			return fmt.Errorf("raster: index %d is %d, past %d vertices", i, v, n)
+		}
+	}
+	return nil
+}
+
+// Draw runs the draw call into t. It draws nothing and returns an error when
+// the layout does not fit its stride or an index is past the vertices.
+func (t *Target) Draw(d DrawCall) error {
+	if err := d.validate(); err != nil {
+		return err
+	}
+	if d.Fragment == nil {
+		d.Fragment = VertexColor
+	}
+	mvp := d.Projection.Times(d.View).Times(d.Model)
+
+	count := len(d.Vertices) / d.Layout.Stride
+	if d.Indices != nil {
+		count = len(d.Indices)
+	}
+	index := func(i int) uint32 {
+		if d.Indices != nil {
+			return d.Indices[i]
+		}
+		return uint32(i)
+	}
+
+	var poly, scratch []vertex
+	for i := 0; i+2 < count; i += 3 {
+		poly = append(poly[:0],
+			d.fetch(index(i), &mvp),
+			d.fetch(index(i+1), &mvp),
+			d.fetch(index(i+2), &mvp),
+		)
+		poly, scratch = clip(poly, scratch)
+		for j := 1; j+1 < len(poly); j++ {
+			t.triangle(&d, poly[0], poly[j], poly[j+1])
+		}
+	}
+	return nil
+}
+
+// screen is a vertex after perspective divide and viewport transform, with
+// its attributes divided by w for perspective-correct interpolation.
+type screen struct {
+	x, y, z float32
+	invW    float32
+	att     [5]float32
+}
+
+func (t *Target) toScreen(v vertex) (s screen) {
+	b := t.Color.Bounds()
+	w, h := float32(b.Dx()), float32(b.Dy())
+
+	s.invW = 1 / v.pos[3]
+	s.x = (v.pos[0]*s.invW + 1) / 2 * w
+	s.y = (1 - v.pos[1]*s.invW) / 2 * h
+	s.z = (v.pos[2]*s.invW + 1) / 2
+	for i := range v.att {
+		s.att[i] = v.att[i] * s.invW
+	}
+	return
+}
+
+func edge(a, b screen, x, y float32) float32 {
+	return (b.x-a.x)*(y-a.y) - (b.y-a.y)*(x-a.x)
+}
+
+// topLeft reports whether the edge a->b is a top or left edge of a triangle
+// with positive area, for the fill rule shared by neighbouring triangles.
+func topLeft(a, b screen) bool {
+	return (a.y == b.y && b.x < a.x) || b.y < a.y
+}
+
+func (t *Target) triangle(d *DrawCall, v0, v1, v2 vertex) {
+	a, b, c := t.toScreen(v0), t.toScreen(v1), t.toScreen(v2)
+
+	area := edge(a, b, c.x, c.y)
+	if area == 0 {
+		return
+	}
+	// GL draws both faces; make the winding positive so one fill rule works
+	if area < 0 {
+		b, c = c, b
+		area = -area
+	}
+
+	bounds := t.Color.Bounds()
+	minX := int(math.Max(math.Floor(float64(m32.Min(a.x, m32.Min(b.x, c.x)))), 0))
+	minY := int(math.Max(math.Floor(float64(m32.Min(a.y, m32.Min(b.y, c.y)))), 0))
+	maxX := int(math.Min(math.Ceil(float64(m32.Max(a.x, m32.Max(b.x, c.x)))), float64(bounds.Dx()-1)))
+	maxY := int(math.Min(math.Ceil(float64(m32.Max(a.y, m32.Max(b.y, c.y)))), float64(bounds.Dy()-1)))
+
+	tl0, tl1, tl2 := topLeft(b, c), topLeft(c, a), topLeft(a, b)
+
+	for y := minY; y <= maxY; y++ {
+		py := float32(y) + .5
+		for x := minX; x <= maxX; x++ {
+			px := float32(x) + .5
+
+			w0 := edge(b, c, px, py)
+			w1 := edge(c, a, px, py)
+			w2 := edge(a, b, px, py)
+			if w0 < 0 || w1 < 0 || w2 < 0 ||
+				(w0 == 0 && !tl0) || (w1 == 0 && !tl1) || (w2 == 0 && !tl2) {
+				continue
+			}
+			w0, w1, w2 = w0/area, w1/area, w2/area
+
+			z := w0*a.z + w1*b.z + w2*c.z
+			di := y*bounds.Dx() + x
+			if d.DepthTest && z >= t.Depth[di] {
+				continue
+			}
+
+			invW := w0*a.invW + w1*b.invW + w2*c.invW
+			var att [5]float32
+			for i := range att {
+				att[i] = (w0*a.att[i] + w1*b.att[i] + w2*c.att[i]) / invW
+			}
+
+			out := d.Fragment(Fragment{
+				X:        x,
+				Y:        y,
+				Depth:    z,
+				Color:    glm.Vec3{att[0], att[1], att[2]},
+				TexCoord: glm.Vec2{att[3], att[4]},
+			}, d.Textures)
+
+			if d.DepthTest {
+				t.Depth[di] = z
+			}
+			t.Color.SetRGBA(bounds.Min.X+x, bounds.Min.Y+y, toRGBA(out))
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package raster
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// the quad of draft/brian
+var quad = []float32{
+	+.5, +.5, 0, 0.0, 1.0, 0.0,
+	+.5, -.5, 0, 0.0, 1.0, 1.0,
+	-.5, -.5, 0, 0.0, 0.0, 1.0,
+	-.5, +.5, 0, 1.0, 0.0, 0.0,
+}
+
+var quadIndices = []uint32{
+	0, 1, 3,
+	1, 2, 3,
+}
+
+func identityCall(vertices []float32, layout Layout, indices []uint32) DrawCall {
+	return DrawCall{
+		Vertices:   vertices,
+		Layout:     layout,
+		Indices:    indices,
+		Model:      glm.Identity(),
+		View:       glm.Identity(),
+		Projection: glm.Identity(),
+	}
+}
+
+func TestDrawQuad(t *testing.T) {
+	target := NewTarget(32, 32)
+	target.Clear(glm.Vec4{0.2, 0.3, 0.3, 1.0})
+	if err := target.Draw(identityCall(quad, ColorLayout, quadIndices)); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		x, y int
+		out  string
+	}{
+		{0, 0, "{51 77 77 255}"},
+		{31, 31, "{51 77 77 255}"},
+		{7, 16, "{51 77 77 255}"},
+		{8, 8, "{247 8 8 255}"},
+		{23, 8, "{8 247 8 255}"},
+		{23, 23, "{8 247 247 255}"},
+		{8, 23, "{8 8 247 255}"},
+		{16, 16, "{120 135 135 255}"},
+		{24, 16, "{51 77 77 255}"},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprint(c.x, c.y), func(t *testing.T) {
+			res := fmt.Sprint(target.Color.RGBAAt(c.x, c.y))
+			if res != c.out {
+				t.Fatal(res)
+			}
+		})
+	}
+}
+
+func TestDrawErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		call DrawCall
+	}{
+		{"zero stride", identityCall(quad, Layout{Position: 0, Color: -1, TexCoord: -1}, nil)},
+		{"stride short of the color", identityCall(quad, Layout{Stride: 4, Position: 0, Color: 3, TexCoord: -1}, nil)},
+		{"stride short of the texcoord", identityCall(quad, Layout{Stride: 6, Position: 0, Color: 3, TexCoord: 5}, nil)},
+		{"no position", identityCall(quad, Layout{Stride: 6, Position: -1, Color: 3, TexCoord: -1}, nil)},
+		{"index past the vertices", identityCall(quad, ColorLayout, []uint32{0, 1, 4})},
+		{"huge index", identityCall(quad, ColorLayout, []uint32{0, 1, 1 << 31})},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			target := NewTarget(8, 8)
+			if err := target.Draw(c.call); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestFillRule(t *testing.T) {
+	full := []float32{
+		-1, -1, 0, 1, 1, 1,
+		1, -1, 0, 1, 1, 1,
+		1, 1, 0, 1, 1, 1,
+		-1, 1, 0, 1, 1, 1,
+		0, 0, 0, 1, 1, 1,
+	}
+
+	cases := []struct {
+		name    string
+		indices []uint32
+	}{
+		{"two triangles", []uint32{0, 1, 2, 0, 2, 3}},
+		{"fan around center", []uint32{4, 0, 1, 4, 1, 2, 4, 2, 3, 4, 3, 0}},
+		{"mixed winding", []uint32{0, 2, 1, 0, 2, 3}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			target := NewTarget(17, 13)
+			hits := make(map[image.Point]int)
+			call := identityCall(full, ColorLayout, c.indices)
+			call.Fragment = func(f Fragment, _ []*image.RGBA) glm.Vec4 {
+				hits[image.Pt(f.X, f.Y)]++
+				return glm.Vec4{1, 1, 1, 1}
+			}
+			target.Draw(call)
+
+			if len(hits) != 17*13 {
+				t.Fatal("covered", len(hits))
+			}
+			for p, n := range hits {
+				if n != 1 {
+					t.Fatal(p, n)
+				}
+			}
+		})
+	}
+}
+
+func TestDepthTest(t *testing.T) {
+	tris := []float32{
+		// near, red
+		-1, -1, -.5, 1, 0, 0,
+		1, -1, -.5, 1, 0, 0,
+		0, 1, -.5, 1, 0, 0,
+		// far, green
+		-1, -1, .5, 0, 1, 0,
+		1, -1, .5, 0, 1, 0,
+		0, 1, .5, 0, 1, 0,
+	}
+
+	cases := []struct {
+		name  string
+		depth bool
+		out   string
+	}{
+		{"depth test", true, "{255 0 0 255}"},
+		{"painter", false, "{0 255 0 255}"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			target := NewTarget(8, 8)
+			target.Clear(glm.Vec4{0, 0, 0, 1})
+			call := identityCall(tris, ColorLayout, nil)
+			call.DepthTest = c.depth
+			target.Draw(call)
+
+			if res := fmt.Sprint(target.Color.RGBAAt(4, 5)); res != c.out {
+				t.Fatal(res)
+			}
+			if c.depth && !glm.FloatEqual(target.Depth[5*8+4], .25) {
+				t.Fatal(target.Depth[5*8+4])
+			}
+		})
+	}
+}
+
+func TestClipping(t *testing.T) {
+	proj := glm.Perspect(glm.Rad(90), 1, .1, 10)
+
+	cases := []struct {
+		name  string
+		tri   []float32
+		drawn bool
+	}{
+		{"crossing near plane", []float32{
+			-1, -1, -2, 1, 1, 1,
+			1, -1, -2, 1, 1, 1,
+			0, -1, 5, 1, 1, 1,
+		}, true},
+		{"behind camera", []float32{
+			-1, -1, 2, 1, 1, 1,
+			1, -1, 2, 1, 1, 1,
+			0, 1, 2, 1, 1, 1,
+		}, false},
+		{"beyond far plane", []float32{
+			-1, -1, -20, 1, 1, 1,
+			1, -1, -20, 1, 1, 1,
+			0, 1, -20, 1, 1, 1,
+		}, false},
+		{"larger than screen", []float32{
+			-100, -100, -1, 1, 1, 1,
+			100, -100, -1, 1, 1, 1,
+			0, 100, -1, 1, 1, 1,
+		}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			target := NewTarget(16, 16)
+			target.Clear(glm.Vec4{0, 0, 0, 1})
+			call := identityCall(c.tri, ColorLayout, nil)
+			call.Projection = proj
+			call.DepthTest = true
+
+			drawn := 0
+			call.Fragment = func(f Fragment, _ []*image.RGBA) glm.Vec4 {
+				if f.Depth < 0 || f.Depth > 1 {
+					t.Fatal("depth", f.Depth)
+				}
+				drawn++
+				return f.Color.Vec4(1)
+			}
+			target.Draw(call)
+
+			if (drawn > 0) != c.drawn {
+				t.Fatal(drawn)
+			}
+		})
+	}
+}
+
+func TestPerspectiveCorrect(t *testing.T) {
+	// a quad receding to the right: x = -1 + 2u, z = -1 - 2u
+	plane := []float32{
+		-1, -1, -1, 1, 1, 1, 0, 0,
+		1, -1, -3, 1, 1, 1, 1, 0,
+		1, 1, -3, 1, 1, 1, 1, 1,
+		-1, 1, -1, 1, 1, 1, 0, 1,
+	}
+	target := NewTarget(16, 16)
+	call := identityCall(plane, DefaultLayout, []uint32{0, 1, 2, 0, 2, 3})
+	call.Projection = glm.Perspect(glm.Rad(90), 1, .1, 10)
+
+	us := make(map[int]float32)
+	call.Fragment = func(f Fragment, _ []*image.RGBA) glm.Vec4 {
+		if f.Y == 8 {
+			us[f.X] = f.TexCoord[0]
+		}
+		return glm.Vec4{}
+	}
+	target.Draw(call)
+
+	for _, x := range []int{2, 6, 9, 10} {
+		ndc := (float32(x)+.5)/8 - 1
+		want := (1 + ndc) / (2 * (1 - ndc))
+		if !glm.FloatEqualThreshold(us[x], want, 1e-4) {
+			t.Fatal(x, us[x], want)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.SetRGBA(0, 0, color.RGBA{255, 0, 0, 255})
+	img.SetRGBA(1, 0, color.RGBA{0, 255, 0, 255})
+	img.SetRGBA(0, 1, color.RGBA{0, 0, 255, 255})
+	img.SetRGBA(1, 1, color.RGBA{255, 255, 255, 255})
+
+	cases := []struct {
+		name string
+		uv   glm.Vec2
+		out  string
+	}{
+		{"texel center", glm.Vec2{.25, .25}, "[1 0 0 1]"},
+		{"second row", glm.Vec2{.25, .75}, "[0 0 1 1]"},
+		{"between", glm.Vec2{.5, .25}, "[0.5 0.5 0 1]"},
+		{"wrap", glm.Vec2{1.25, -.75}, "[1 0 0 1]"},
+		{"wrap edge", glm.Vec2{0, .25}, "[0.5 0.5 0 1]"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := fmt.Sprint(Sample(img, c.uv))
+			if res != c.out {
+				t.Fatal(res)
+			}
+		})
+	}
+
+	if res := fmt.Sprint(Sample(image.NewRGBA(image.Rect(0, 0, 0, 0)), glm.Vec2{.5, .5})); res != "[0 0 0 1]" {
+		t.Fatal("empty", res)
+	}
+}
+
+func TestTexturedQuad(t *testing.T) {
+	points := []float32{
+		+.5, +.5, 0, 0.0, 1.0, 0.0, 1.0, 0.0,
+		+.5, -.5, 0, 0.0, 1.0, 1.0, 1.0, 1.0,
+		-.5, -.5, 0, 0.0, 0.0, 1.0, 0.0, 1.0,
+		-.5, +.5, 0, 1.0, 0.0, 0.0, 0.0, 0.0,
+	}
+
+	left := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	left.SetRGBA(0, 0, color.RGBA{255, 255, 255, 255})
+	left.SetRGBA(1, 0, color.RGBA{255, 255, 255, 255})
+	left.SetRGBA(2, 0, color.RGBA{0, 0, 0, 255})
+	left.SetRGBA(3, 0, color.RGBA{0, 0, 0, 255})
+
+	target := NewTarget(40, 40)
+	call := identityCall(points, DefaultLayout, quadIndices)
+	call.Textures = []*image.RGBA{left}
+	call.Fragment = func(f Fragment, tex []*image.RGBA) glm.Vec4 {
+		return Sample(tex[0], f.TexCoord)
+	}
+	target.Draw(call)
+
+	if res := fmt.Sprint(target.Color.RGBAAt(14, 20)); res != "{255 255 255 255}" {
+		t.Fatal(res)
+	}
+	if res := fmt.Sprint(target.Color.RGBAAt(26, 20)); res != "{0 0 0 255}" {
+		t.Fatal(res)
+	}
+}
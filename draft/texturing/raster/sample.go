@@ -0,0 +1,44 @@
+package raster
+
+import (
+	"image"
+
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+)
+
+// Sample reads img at uv with bilinear filtering and repeat wrapping, the
+// sampler state the demos set up. uv (0, 0) is the first pixel row of img, as
+// it is for an image uploaded unflipped with glTexImage2D. A nil or empty
+// img reads as opaque black.
+func Sample(img *image.RGBA, uv glm.Vec2) glm.Vec4 {
+	if img == nil || img.Bounds().Empty() {
+		return glm.Vec4{0, 0, 0, 1}
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	x := uv[0]*float32(w) - .5
+	y := uv[1]*float32(h) - .5
+	x0, y0 := m32.Floor(x), m32.Floor(y)
+	fx, fy := x-x0, y-y0
+
+	at := func(x, y int) glm.Vec4 {
+		x = ((x % w) + w) % w
+		y = ((y % h) + h) % h
+		i := img.PixOffset(b.Min.X+x, b.Min.Y+y)
+		p := img.Pix[i : i+4 : i+4]
+		return glm.Vec4{float32(p[0]), float32(p[1]), float32(p[2]), float32(p[3])}.Scale(1. / 255)
+	}
+
+	ix, iy := int(x0), int(y0)
+	top := at(ix, iy).Lerp(at(ix+1, iy), fx)
+	bottom := at(ix, iy+1).Lerp(at(ix+1, iy+1), fx)
+	return top.Lerp(bottom, fy)
+}
+
+// Mix is GLSL mix().
+func Mix(a, b glm.Vec4, t float32) glm.Vec4 {
+	return a.Lerp(b, t)
+}
@@ -0,0 +1,259 @@
+package shader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Defines are macros injected after #version, one variant of a shader per
+// set. An empty value defines the name alone.
+type Defines map[string]string
+
+// Preprocessor expands #include directives in GLSL before the driver sees
+// it. It is pure Go; the driver's own preprocessor still handles #define,
+// #if and the rest, so an include inside a disabled #if branch is expanded
+// all the same.
+//
+// `#include "file"` is searched next to the including file, then in
+// IncludePaths; `#include <file>` only in IncludePaths. A file with
+// `#pragma once` is included once per output. The first #version line of
+// any file is hoisted to the top, where GLSL requires it, and the Defines
+// follow it.
+//
+// The output carries #line directives numbering each file as a source
+// string, so driver logs can be mapped back with Source.MapLog. With
+// #version 330 and later, #line n sets the number of the next line.
+type Preprocessor struct {
+	IncludePaths []string
+	// ReadFile reads a source file; nil is os.ReadFile.
+	ReadFile func(path string) ([]byte, error)
+}
+
+// Source is the output of the preprocessor.
+type Source struct {
+	Code string
+	// Files are the files read, the source string numbers of the #line
+	// directives: Files[0] is the main file.
+	Files []string
+}
+
+var (
+	includeDirective = regexp.MustCompile(`^\s*#\s*include\s*(?:"([^"]*)"|<([^>]*)>)\s*(?://.*)?$`)
+	versionDirective = regexp.MustCompile(`^\s*#\s*version\s+(.*?)\s*(?://.*)?$`)
+	onceDirective    = regexp.MustCompile(`^\s*#\s*pragma\s+once\s*(?://.*)?$`)
+	defineName       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// File preprocesses the file at path.
+func (p *Preprocessor) File(path string, defines Defines) (*Source, error) {
+	data, err := p.read(path)
+	if err != nil {
+		return nil, fmt.Errorf("shader: %w", err)
+	}
+	return p.String(path, string(data), defines)
+}
+
+// String preprocesses code as if read from the file name, which relative
+// includes are resolved against.
+func (p *Preprocessor) String(name, code string, defines Defines) (*Source, error) {
+	for _, k := range sortedKeys(defines) {
+		if !defineName.MatchString(k) {
+			return nil, fmt.Errorf("shader: bad define name %q", k)
+		}
+	}
+	st := &state{p: p, index: make(map[string]int), once: make(map[string]bool)}
+	if err := st.expand(filepath.Clean(name), code); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	if st.version != "" {
+		b.WriteString("#version " + st.version + "\n")
+	}
+	for _, k := range sortedKeys(defines) {
+		b.WriteString(strings.TrimRight("#define "+k+" "+defines[k], " ") + "\n")
+	}
+	b.WriteString(st.out.String())
+	return &Source{Code: b.String(), Files: st.files}, nil
+}
+
+func sortedKeys(d Defines) []string {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (p *Preprocessor) read(path string) ([]byte, error) {
+	if p.ReadFile != nil {
+		return p.ReadFile(path)
+	}
+	return os.ReadFile(path)
+}
+
+type state struct {
+	p     *Preprocessor
+	out   strings.Builder
+	files []string
+	// index numbers the files; stack are the files being expanded, for
+	// cycles, and once those marked #pragma once.
+	index   map[string]int
+	stack   []string
+	once    map[string]bool
+	version string
+	// versionAt is where version was found, for conflicting ones.
+	versionAt string
+}
+
+func (st *state) number(path string) int {
+	n, ok := st.index[path]
+	if !ok {
+		n = len(st.files)
+		st.index[path] = n
+		st.files = append(st.files, path)
+	}
+	return n
+}
+
+func (st *state) expand(path, code string) error {
+	if st.once[path] {
+		return nil
+	}
+	for _, s := range st.stack {
+		if s == path {
+			return fmt.Errorf("shader: include cycle %s -> %s", strings.Join(st.stack, " -> "), path)
+		}
+	}
+	st.stack = append(st.stack, path)
+	defer func() { st.stack = st.stack[:len(st.stack)-1] }()
+	n := st.number(path)
+
+	lines := strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	fmt.Fprintf(&st.out, "#line 1 %d\n", n)
+	inComment := false
+	for i, line := range lines {
+		startsInComment := inComment
+		inComment = blockComment(line, inComment)
+		if startsInComment {
+			st.out.WriteString(line + "\n")
+			continue
+		}
+
+		if m := versionDirective.FindStringSubmatch(line); m != nil {
+			at := fmt.Sprintf("%s:%d", path, i+1)
+			switch {
+			case st.version == "":
+				st.version, st.versionAt = m[1], at
+			case st.version != m[1]:
+				return fmt.Errorf("shader: %s: #version %s conflicts with #version %s at %s", at, m[1], st.version, st.versionAt)
+			}
+			// keep the numbering of the lines below
+			st.out.WriteString("\n")
+			continue
+		}
+		if onceDirective.MatchString(line) {
+			st.once[path] = true
+			st.out.WriteString("\n")
+			continue
+		}
+		m := includeDirective.FindStringSubmatch(line)
+		if m == nil {
+			if strings.HasPrefix(strings.TrimSpace(line), "#include") {
+				return fmt.Errorf("shader: %s:%d: malformed #include", path, i+1)
+			}
+			st.out.WriteString(line + "\n")
+			continue
+		}
+
+		name, quoted := m[2], false
+		if m[1] != "" {
+			name, quoted = m[1], true
+		}
+		target, data, err := st.resolve(path, name, quoted)
+		if err != nil {
+			return fmt.Errorf("shader: %s:%d: %w", path, i+1, err)
+		}
+		if err := st.expand(target, string(data)); err != nil {
+			return err
+		}
+		fmt.Fprintf(&st.out, "#line %d %d\n", i+2, n)
+	}
+	return nil
+}
+
+// resolve finds an include of from and reads it.
+func (st *state) resolve(from, name string, quoted bool) (string, []byte, error) {
+	if name == "" {
+		return "", nil, fmt.Errorf("empty #include")
+	}
+	var dirs []string
+	if quoted {
+		dirs = append(dirs, filepath.Dir(from))
+	}
+	dirs = append(dirs, st.p.IncludePaths...)
+	if filepath.IsAbs(name) {
+		dirs = []string{""}
+	}
+	var first error
+	for _, dir := range dirs {
+		path := filepath.Clean(filepath.Join(dir, name))
+		data, err := st.p.read(path)
+		if err == nil {
+			return path, data, nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if first == nil {
+		return "", nil, fmt.Errorf("%s is not in any include path", name)
+	}
+	return "", nil, fmt.Errorf("cannot include %s: %w", name, first)
+}
+
+// blockComment reports whether a line ends inside a /* */ comment when it
+// starts inside one if in is set.
+func blockComment(line string, in bool) bool {
+	for i := 0; i < len(line); i++ {
+		switch {
+		case in && strings.HasPrefix(line[i:], "*/"):
+			in = false
+			i++
+		case !in && strings.HasPrefix(line[i:], "//"):
+			return false
+		case !in && strings.HasPrefix(line[i:], "/*"):
+			in = true
+			i++
+		}
+	}
+	return in
+}
+
+// logLocation matches the places drivers put the source string and line in
+// a log: "0(12) : error" from NVIDIA, "0:12(5): error" from Mesa and
+// "ERROR: 0:12: " from AMD and others.
+var logLocation = regexp.MustCompile(`(?m)^((?:ERROR|WARNING): )?(\d+)(?:\((\d+)\)|:(\d+))`)
+
+// MapLog rewrites the source string numbers of a driver's info log into the
+// file names they stand for, so "0(12)" reads "vertex.glsl:12".
+func (s *Source) MapLog(log string) string {
+	return logLocation.ReplaceAllStringFunc(log, func(loc string) string {
+		m := logLocation.FindStringSubmatch(loc)
+		n, err := strconv.Atoi(m[2])
+		if err != nil || n >= len(s.Files) {
+			return loc
+		}
+		line := m[3] + m[4]
+		return m[1] + s.Files[n] + ":" + line
+	})
+}
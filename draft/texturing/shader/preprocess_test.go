@@ -0,0 +1,160 @@
+package shader
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// files is an in-memory file system for Preprocessor.ReadFile.
+type files map[string]string
+
+func (f files) read(path string) ([]byte, error) {
+	s, ok := f[filepath.ToSlash(path)]
+	if !ok {
+		return nil, fmt.Errorf("open %s: %w", path, fs.ErrNotExist)
+	}
+	return []byte(s), nil
+}
+
+func TestPreprocess(t *testing.T) {
+	fsys := files{
+		"shaders/main.frag": `// lighting
+#version 330 core
+#include "lib/light.glsl"
+out vec4 FragColor;
+void main() { FragColor = vec4(light(), 1.0); }
+`,
+		"shaders/lib/light.glsl": "#version 330 core\n#include <common.glsl>\nvec3 light() { return vec3(PI); }\n",
+		"include/common.glsl":    "const float PI = 3.14159;\n",
+	}
+	p := Preprocessor{IncludePaths: []string{"include"}, ReadFile: fsys.read}
+	src, err := p.File("shaders/main.frag", Defines{"SHADOWS": "", "LIGHTS": "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `#version 330 core
+#define LIGHTS 4
+#define SHADOWS
+#line 1 0
+// lighting
+
+#line 1 1
+
+#line 1 2
+const float PI = 3.14159;
+#line 3 1
+vec3 light() { return vec3(PI); }
+#line 4 0
+out vec4 FragColor;
+void main() { FragColor = vec4(light(), 1.0); }
+`
+	if src.Code != want {
+		t.Errorf("got\n%s\nwant\n%s", src.Code, want)
+	}
+	wantFiles := []string{"shaders/main.frag", "shaders/lib/light.glsl", "include/common.glsl"}
+	if strings.Join(src.Files, ",") != strings.Join(wantFiles, ",") {
+		t.Errorf("files %v, want %v", src.Files, wantFiles)
+	}
+}
+
+func TestPreprocessLines(t *testing.T) {
+	// every line of the output that is not a directive maps back to the
+	// file and line it came from
+	fsys := files{
+		"a.glsl": "#version 330\n/* #include \"missing.glsl\"\n*/\nint a;\n#include \"b.glsl\"\nint a2;\n#include \"b.glsl\"\nint a3;\n",
+		"b.glsl": "#pragma once\nint b;\n#include \"c.glsl\"\n",
+		"c.glsl": "int c;\r\nint c2;",
+	}
+	p := Preprocessor{ReadFile: fsys.read}
+	src, err := p.File("a.glsl", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, line := -1, 0
+	for _, l := range strings.Split(strings.TrimSuffix(src.Code, "\n"), "\n") {
+		if _, err := fmt.Sscanf(l, "#line %d %d", &line, &file); err == nil {
+			continue
+		}
+		if strings.HasPrefix(l, "#version") {
+			continue
+		}
+		if file < 0 {
+			t.Fatalf("line %q before any #line", l)
+		}
+		orig := strings.Split(strings.ReplaceAll(fsys[src.Files[file]], "\r\n", "\n"), "\n")[line-1]
+		if l != orig && !(l == "" && strings.HasPrefix(orig, "#")) {
+			t.Errorf("%q maps to %s:%d, which is %q", l, src.Files[file], line, orig)
+		}
+		line++
+	}
+	if n := strings.Count(src.Code, "int b;"); n != 1 {
+		t.Errorf("#pragma once file included %d times", n)
+	}
+}
+
+func TestPreprocessErrors(t *testing.T) {
+	fsys := files{
+		"cycle.glsl":    "#include \"a/one.glsl\"\n",
+		"a/one.glsl":    "#include \"../two.glsl\"\n",
+		"two.glsl":      "\n#include \"a/one.glsl\"\n",
+		"missing.glsl":  "int x;\n#include \"nowhere.glsl\"\n",
+		"angle.glsl":    "#include <two.glsl>\n",
+		"bad.glsl":      "#include two.glsl\n",
+		"versions.glsl": "#version 330\n#include \"v450.glsl\"\n",
+		"v450.glsl":     "#version 450\n",
+		"empty.glsl":    "#include \"\"\n",
+	}
+	tests := []struct {
+		path    string
+		defines Defines
+		want    string
+	}{
+		{"cycle.glsl", nil, "include cycle cycle.glsl -> a/one.glsl -> two.glsl -> a/one.glsl"},
+		{"missing.glsl", nil, "missing.glsl:2: cannot include nowhere.glsl"},
+		{"angle.glsl", nil, "two.glsl is not in any include path"},
+		{"bad.glsl", nil, "bad.glsl:1: malformed #include"},
+		{"versions.glsl", nil, "v450.glsl:1: #version 450 conflicts with #version 330 at versions.glsl:1"},
+		{"empty.glsl", nil, "empty #include"},
+		{"two.glsl", Defines{"1X": ""}, "bad define name"},
+		{"none.glsl", nil, "does not exist"},
+	}
+	p := Preprocessor{ReadFile: fsys.read}
+	for _, tt := range tests {
+		_, err := p.File(tt.path, tt.defines)
+		if err == nil || !strings.Contains(filepath.ToSlash(err.Error()), tt.want) {
+			t.Errorf("%s: got %v, want an error about %q", tt.path, err, tt.want)
+		}
+	}
+}
+
+func TestPreprocessFiles(t *testing.T) {
+	// the shaders of the demo, whose #version is indented in one of them
+	var p Preprocessor
+	for _, path := range []string{"../vertex.glsl", "../fragment.glsl", "../../proj/vertex.glsl"} {
+		src, err := p.File(path, Defines{"VARIANT": "1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(src.Code, "#version 330") || !strings.Contains(src.Code, "\n#define VARIANT 1\n#line 1 0\n") {
+			t.Errorf("%s starts with %q", path, src.Code[:60])
+		}
+	}
+}
+
+func TestMapLog(t *testing.T) {
+	src := &Source{Files: []string{"main.frag", "lib/light.glsl"}}
+	tests := []struct{ log, want string }{
+		{"1(12) : error C1008: undefined variable \"x\"", "lib/light.glsl:12 : error C1008: undefined variable \"x\""},
+		{"0:3(7): error: syntax error\n1:4(1): warning: unused", "main.frag:3(7): error: syntax error\nlib/light.glsl:4(1): warning: unused"},
+		{"ERROR: 1:9: 'x' : undeclared identifier", "ERROR: lib/light.glsl:9: 'x' : undeclared identifier"},
+		{"5(1) : error in a file we never read", "5(1) : error in a file we never read"},
+	}
+	for _, tt := range tests {
+		if got := src.MapLog(tt.log); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package shader
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 )
@@ -12,6 +16,29 @@ type Shader struct {
 	vertexPath   string
 	fragmentPath string
 	program      uint32
+
+	// Preprocessor expands the includes of both stages and Defines are
+	// injected into them, making this shader one variant.
+	Preprocessor Preprocessor
+	Defines      Defines
+	// Compiler builds the program; nil compiles with GL.
+	Compiler Compiler
+	// Log receives the errors of Reload; nil is the standard logger.
+	Log *log.Logger
+
+	// locations caches Uniform per name for the current program.
+	locations map[string]int32
+	// stamps are the modification times of the files the program was
+	// built from, as Reload last saw them.
+	stamps map[string]time.Time
+}
+
+// Compiler builds programs from preprocessed sources. The GL one is the
+// default; tests put a fake in its place.
+type Compiler interface {
+	Link(vertex, fragment *Source) (uint32, error)
+	UniformLocation(prog uint32, name string) int32
+	DeleteProgram(prog uint32)
 }
 
 func New(vertexPath, fragmentPath string) *Shader {
@@ -21,30 +48,155 @@ func New(vertexPath, fragmentPath string) *Shader {
 	}
 }
 
+func (s *Shader) compiler() Compiler {
+	if s.Compiler != nil {
+		return s.Compiler
+	}
+	return glCompiler{}
+}
+
+// Compile builds the program from the files and makes it the shader's,
+// deleting the previous one.
 func (s *Shader) Compile() (prog uint32, err error) {
+	prog, files, err := s.build()
+	if err != nil {
+		return
+	}
+	s.swap(prog, files)
+	return
+}
 
-	vertexBody, err := os.ReadFile(s.vertexPath)
+// build preprocesses and links both stages, returning the files read.
+func (s *Shader) build() (prog uint32, files []string, err error) {
+	vertex, err := s.Preprocessor.File(s.vertexPath, s.Defines)
 	if err != nil {
 		return
 	}
 
-	fragmentBody, err := os.ReadFile(s.fragmentPath)
+	fragment, err := s.Preprocessor.File(s.fragmentPath, s.Defines)
 	if err != nil {
 		return
 	}
 
-	vertexShader, err := compileShader(string(vertexBody)+"\x00", gl.VERTEX_SHADER)
+	prog, err = s.compiler().Link(vertex, fragment)
 	if err != nil {
 		return
 	}
+	files = append(append(files, vertex.Files...), fragment.Files...)
+	return
+}
+
+func (s *Shader) swap(prog uint32, files []string) {
+	if s.program != 0 {
+		s.compiler().DeleteProgram(s.program)
+	}
+	s.program = prog
+	s.locations = make(map[string]int32)
+	s.stamps = make(map[string]time.Time)
+	for _, f := range files {
+		s.stamps[f] = modTime(f)
+	}
+}
+
+// modTime is the modification time of path, zero when it cannot be read:
+// an editor may save by removing and renaming.
+func modTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+// Files are the files the current program was built from: both stages and
+// everything they include.
+func (s *Shader) Files() []string {
+	files := make([]string, 0, len(s.stamps))
+	for f := range s.stamps {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Reload is the watch mode: called once a frame, it polls the files the
+// program was built from and, when any changed on disk, rebuilds it. A new
+// program replaces the old one, which is deleted, and the uniform locations
+// are looked up again; a failed build keeps the old program and is logged
+// and returned. The change is seen once, so a broken file is not rebuilt
+// every frame but on its next save. Reload reports whether the program was
+// replaced, and must run on the thread owning the context.
+func (s *Shader) Reload() (bool, error) {
+	changed := false
+	for f, t := range s.stamps {
+		if now := modTime(f); !now.Equal(t) {
+			s.stamps[f] = now
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
 
-	fragmentShader, err := compileShader(string(fragmentBody)+"\x00", gl.FRAGMENT_SHADER)
+	prog, files, err := s.build()
+	if err != nil {
+		err = fmt.Errorf("shader: reloading %s and %s: %w", s.vertexPath, s.fragmentPath, err)
+		logger := s.Log
+		if logger == nil {
+			logger = log.Default()
+		}
+		logger.Print(err)
+		return false, err
+	}
+	s.swap(prog, files)
+	return true, nil
+}
+
+// Program is the current program, which Reload may replace.
+func (s *Shader) Program() uint32 {
+	return s.program
+}
+
+type glCompiler struct{}
+
+func (glCompiler) Link(vertex, fragment *Source) (uint32, error) {
+	prog, err := Link(vertex.Code, fragment.Code)
+	var ce *CompileError
+	if errors.As(err, &ce) {
+		// name the files in the log rather than source string numbers
+		if ce.Type == gl.VERTEX_SHADER {
+			ce.Log = vertex.MapLog(ce.Log)
+		} else {
+			ce.Log = fragment.MapLog(ce.Log)
+		}
+	}
+	return prog, err
+}
+
+func (glCompiler) UniformLocation(prog uint32, name string) int32 {
+	return gl.GetUniformLocation(prog, gl.Str(name+"\x00"))
+}
+
+func (glCompiler) DeleteProgram(prog uint32) {
+	gl.DeleteProgram(prog)
+}
+
+// Link compiles the vertex and fragment sources and links them into a new
+// program.
+func Link(vertexSource, fragmentSource string) (prog uint32, err error) {
+	vertexShader, err := compileShader(vertexSource+"\x00", gl.VERTEX_SHADER)
 	if err != nil {
 		return
 	}
+	defer gl.DeleteShader(vertexShader)
+
+	fragmentShader, err := compileShader(fragmentSource+"\x00", gl.FRAGMENT_SHADER)
+	if err != nil {
+		return
+	}
+	defer gl.DeleteShader(fragmentShader)
 
 	prog = gl.CreateProgram()
-	s.program = prog
 	gl.AttachShader(prog, vertexShader)
 	gl.AttachShader(prog, fragmentShader)
 	gl.LinkProgram(prog)
@@ -58,17 +210,29 @@ func (s *Shader) Compile() (prog uint32, err error) {
 			log := strings.Repeat("\x00", int(logLen+1))
 			gl.GetProgramInfoLog(prog, logLen, nil, gl.Str(log))
 
-			err = fmt.Errorf("failed to link: %v", log)
-			return
+			gl.DeleteProgram(prog)
+			return 0, fmt.Errorf("failed to link: %v", log)
 		}
 	}
 
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-
 	return
 }
 
+// CompileError is a stage the driver failed to compile, with its info log.
+type CompileError struct {
+	// Type is gl.VERTEX_SHADER or gl.FRAGMENT_SHADER.
+	Type uint32
+	Log  string
+}
+
+func (e *CompileError) Error() string {
+	stage := "fragment"
+	if e.Type == gl.VERTEX_SHADER {
+		stage = "vertex"
+	}
+	return fmt.Sprintf("failed to compile %s shader: %v", stage, e.Log)
+}
+
 func compileShader(source string, shaderType uint32) (shader uint32, err error) {
 	shader = gl.CreateShader(shaderType)
 
@@ -85,15 +249,26 @@ func compileShader(source string, shaderType uint32) (shader uint32, err error)
 
 		log := strings.Repeat("\x00", int(logLen+1))
 		gl.GetShaderInfoLog(shader, logLen, nil, gl.Str(log))
+		gl.DeleteShader(shader)
 
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
+		return 0, &CompileError{Type: shaderType, Log: strings.TrimRight(log, "\x00")}
 	}
 
 	return
 }
 
+// Uniform is the location of the uniform label in the current program,
+// cached until the program is replaced.
 func (s *Shader) Uniform(label string) int32 {
-	return gl.GetUniformLocation(s.program, gl.Str(label+"\x00"))
+	loc, ok := s.locations[label]
+	if !ok {
+		loc = s.compiler().UniformLocation(s.program, label)
+		if s.locations == nil {
+			s.locations = make(map[string]int32)
+		}
+		s.locations[label] = loc
+	}
+	return loc
 }
 
 func (s *Shader) UseProgram() {
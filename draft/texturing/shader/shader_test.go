@@ -0,0 +1,138 @@
+package shader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeCompiler numbers programs from 1 and fails while fail is set.
+type fakeCompiler struct {
+	next      uint32
+	fail      error
+	fragments map[uint32]string
+	deleted   []uint32
+	lookups   int
+}
+
+func (c *fakeCompiler) Link(vertex, fragment *Source) (uint32, error) {
+	if c.fail != nil {
+		return 0, c.fail
+	}
+	c.next++
+	if c.fragments == nil {
+		c.fragments = make(map[uint32]string)
+	}
+	c.fragments[c.next] = fragment.Code
+	return c.next, nil
+}
+
+func (c *fakeCompiler) UniformLocation(prog uint32, name string) int32 {
+	c.lookups++
+	return int32(prog)*100 + int32(len(name))
+}
+
+func (c *fakeCompiler) DeleteProgram(prog uint32) {
+	c.deleted = append(c.deleted, prog)
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string, at time.Time) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	at := time.Now().Add(-time.Hour)
+	write("vertex.glsl", "#version 330 core\nvoid main() {}\n", at)
+	write("fragment.glsl", "#version 330 core\n#include \"color.glsl\"\nvoid main() {}\n", at)
+	write("color.glsl", "vec3 color = vec3(1.0);\n", at)
+
+	c := &fakeCompiler{}
+	var logged bytes.Buffer
+	s := New(filepath.Join(dir, "vertex.glsl"), filepath.Join(dir, "fragment.glsl"))
+	s.Compiler = c
+	s.Log = log.New(&logged, "", 0)
+	if prog, err := s.Compile(); err != nil || prog != 1 {
+		t.Fatalf("compile: %v, %v", prog, err)
+	}
+	if got := strings.Join(s.Files(), ","); got != strings.Join([]string{
+		filepath.Join(dir, "color.glsl"), filepath.Join(dir, "fragment.glsl"), filepath.Join(dir, "vertex.glsl"),
+	}, ",") {
+		t.Errorf("watching %s", got)
+	}
+
+	if ok, err := s.Reload(); ok || err != nil {
+		t.Fatalf("reloaded unchanged files: %v, %v", ok, err)
+	}
+	loc := s.Uniform("color")
+	s.Uniform("color")
+	if c.lookups != 1 {
+		t.Errorf("%d lookups of a cached location", c.lookups)
+	}
+
+	// a change to an included file rebuilds the program
+	at = at.Add(time.Minute)
+	write("color.glsl", "vec3 color = vec3(0.5);\n", at)
+	if ok, err := s.Reload(); !ok || err != nil {
+		t.Fatalf("reload: %v, %v", ok, err)
+	}
+	if s.Program() != 2 || !strings.Contains(c.fragments[2], "vec3(0.5)") {
+		t.Errorf("program %d built from\n%s", s.Program(), c.fragments[s.Program()])
+	}
+	if fmt.Sprint(c.deleted) != "[1]" {
+		t.Errorf("deleted %v", c.deleted)
+	}
+	if got := s.Uniform("color"); got == loc || c.lookups != 2 {
+		t.Errorf("location %d after reload, was %d, with %d lookups", got, loc, c.lookups)
+	}
+
+	// a failed build keeps the program and is tried again on the next change
+	c.fail = errors.New("0(1) : error C0000: syntax error")
+	at = at.Add(time.Minute)
+	write("fragment.glsl", "#version 330 core\nvoid main() {\n", at)
+	ok, err := s.Reload()
+	if ok || err == nil || !errors.Is(err, c.fail) {
+		t.Fatalf("broken reload: %v, %v", ok, err)
+	}
+	if s.Program() != 2 || len(c.deleted) != 1 {
+		t.Errorf("program %d and deleted %v after a failed build", s.Program(), c.deleted)
+	}
+	if !strings.Contains(logged.String(), "syntax error") {
+		t.Errorf("logged %q", logged.String())
+	}
+	if ok, err := s.Reload(); ok || err != nil {
+		t.Errorf("retried a broken build without a change: %v, %v", ok, err)
+	}
+
+	c.fail = nil
+	at = at.Add(time.Minute)
+	write("fragment.glsl", "#version 330 core\nvoid main() {}\n", at)
+	if ok, err := s.Reload(); !ok || err != nil || s.Program() != 3 {
+		t.Fatalf("fixed reload: %v, %v, program %d", ok, err, s.Program())
+	}
+	// the include is gone from the fragment shader and no longer watched
+	if len(s.Files()) != 2 {
+		t.Errorf("watching %v", s.Files())
+	}
+
+	// a file removed while saving fails the build until it is back
+	os.Remove(filepath.Join(dir, "vertex.glsl"))
+	if ok, err := s.Reload(); ok || err == nil || s.Program() != 3 {
+		t.Errorf("missing file: %v, %v, program %d", ok, err, s.Program())
+	}
+	write("vertex.glsl", "#version 330 core\nvoid main() {}\n", at)
+	if ok, err := s.Reload(); !ok || err != nil || s.Program() != 4 {
+		t.Errorf("restored file: %v, %v, program %d", ok, err, s.Program())
+	}
+}
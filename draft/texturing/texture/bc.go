@@ -0,0 +1,162 @@
+package texture
+
+import "encoding/binary"
+
+// Blocks of the BCn formats are little endian. Pixel i of a block is at
+// x = i%4, y = i/4 and out receives them in that order.
+
+func expand565(c uint16) [3]int {
+	r, g, b := int(c>>11), int(c>>5&63), int(c&31)
+	return [3]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2}
+}
+
+// decodeColor decodes the 8 byte color block of BC1 to BC3. Three color
+// mode, with c0 <= c1, only exists in BC1; punchThrough makes its fourth
+// color transparent black rather than opaque black.
+func decodeColor(b, out []byte, threeColor, punchThrough bool) {
+	c0, c1 := binary.LittleEndian.Uint16(b), binary.LittleEndian.Uint16(b[2:])
+	e0, e1 := expand565(c0), expand565(c1)
+	var palette [4][4]byte
+	for c := 0; c < 3; c++ {
+		palette[0][c], palette[1][c] = byte(e0[c]), byte(e1[c])
+		if c0 > c1 || !threeColor {
+			palette[2][c] = byte((2*e0[c] + e1[c] + 1) / 3)
+			palette[3][c] = byte((e0[c] + 2*e1[c] + 1) / 3)
+		} else {
+			palette[2][c] = byte((e0[c] + e1[c] + 1) / 2)
+		}
+	}
+	palette[0][3], palette[1][3], palette[2][3], palette[3][3] = 255, 255, 255, 255
+	if c0 <= c1 && threeColor && punchThrough {
+		palette[3][3] = 0
+	}
+
+	indices := binary.LittleEndian.Uint32(b[4:])
+	for i := 0; i < 16; i++ {
+		copy(out[4*i:4*i+4], palette[indices>>(2*i)&3][:])
+	}
+}
+
+func decodeBC1(b, out []byte) {
+	decodeColor(b, out, true, false)
+}
+
+func decodeBC1A(b, out []byte) {
+	decodeColor(b, out, true, true)
+}
+
+func decodeBC2(b, out []byte) {
+	decodeColor(b[8:], out, false, false)
+	alpha := binary.LittleEndian.Uint64(b)
+	for i := 0; i < 16; i++ {
+		out[4*i+3] = byte(alpha>>(4*i)&15) * 17
+	}
+}
+
+func decodeBC3(b, out []byte) {
+	decodeColor(b[8:], out, false, false)
+	var a [16]byte
+	decodeAlpha(b, &a)
+	for i, v := range a {
+		out[4*i+3] = v
+	}
+}
+
+// alphaPalette interpolates the endpoints of a BC3 alpha or BC4 block, in
+// the unit of the endpoints. With a0 > a1 there are six steps between them,
+// otherwise four and then the extremes lo and hi.
+func alphaPalette(a0, a1, lo, hi int) [8]int {
+	p := [8]int{a0, a1}
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			p[i+1] = roundDiv((7-i)*a0+i*a1, 7)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			p[i+1] = roundDiv((5-i)*a0+i*a1, 5)
+		}
+		p[6], p[7] = lo, hi
+	}
+	return p
+}
+
+// roundDiv divides rounding half away from zero.
+func roundDiv(n, d int) int {
+	if n < 0 {
+		return -((-n + d/2) / d)
+	}
+	return (n + d/2) / d
+}
+
+// alphaIndices are the 3 bit indices of a BC3 alpha or BC4 block.
+func alphaIndices(b []byte) uint64 {
+	var v uint64
+	for i := 7; i >= 2; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
+func decodeAlpha(b []byte, out *[16]byte) {
+	p := alphaPalette(int(b[0]), int(b[1]), 0, 255)
+	idx := alphaIndices(b)
+	for i := range out {
+		out[i] = byte(p[idx>>(3*i)&7])
+	}
+}
+
+// decodeSignedAlpha decodes a BC4 signed block, mapping [-1, 1] to bytes.
+func decodeSignedAlpha(b []byte, out *[16]byte) {
+	end := func(v byte) int {
+		// -128 is another -1
+		if s := int(int8(v)); s > -128 {
+			return s
+		}
+		return -127
+	}
+	p := alphaPalette(end(b[0]), end(b[1]), -127, 127)
+	idx := alphaIndices(b)
+	for i := range out {
+		out[i] = snormByte(float32(p[idx>>(3*i)&7]) / 127)
+	}
+}
+
+// snormByte maps [-1, 1] to [0, 255].
+func snormByte(v float32) byte {
+	return Quantize((v + 1) / 2)
+}
+
+func writeChannels(out []byte, r, g *[16]byte) {
+	for i := 0; i < 16; i++ {
+		out[4*i], out[4*i+1], out[4*i+2], out[4*i+3] = r[i], 0, 0, 255
+		if g != nil {
+			out[4*i+1] = g[i]
+		}
+	}
+}
+
+func decodeBC4(b, out []byte) {
+	var r [16]byte
+	decodeAlpha(b, &r)
+	writeChannels(out, &r, nil)
+}
+
+func decodeBC4S(b, out []byte) {
+	var r [16]byte
+	decodeSignedAlpha(b, &r)
+	writeChannels(out, &r, nil)
+}
+
+func decodeBC5(b, out []byte) {
+	var r, g [16]byte
+	decodeAlpha(b, &r)
+	decodeAlpha(b[8:], &g)
+	writeChannels(out, &r, &g)
+}
+
+func decodeBC5S(b, out []byte) {
+	var r, g [16]byte
+	decodeSignedAlpha(b, &r)
+	decodeSignedAlpha(b[8:], &g)
+	writeChannels(out, &r, &g)
+}
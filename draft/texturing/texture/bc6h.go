@@ -0,0 +1,217 @@
+package texture
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	m32 "github.com/chewxy/math32"
+)
+
+// bc6hField places one bit of a BC6H block in an endpoint component.
+type bc6hField struct {
+	endpoint, channel, bit int
+}
+
+type bc6hMode struct {
+	regions int
+	// bits is the precision of the endpoints, delta that of the other
+	// endpoints when transformed, stored as differences to the first.
+	bits        int
+	delta       [3]int
+	transformed bool
+	fields      []bc6hField
+}
+
+// bc6hModes are keyed by the 2 or 5 mode bits. The layouts are in the
+// notation of the D3D11 specification: rw9:0 is bits 0 to 9 of the red of
+// endpoint w, read from 0 up; rw10:15 is read from 15 down.
+var bc6hModes = map[int]*bc6hMode{
+	0x00: bc6hLayout(2, 10, 5, 5, 5, true, "gy4 by4 bz4 rw9:0 gw9:0 bw9:0 rx4:0 gz4 gy3:0 gx4:0 bz0 gz3:0 bx4:0 bz1 by3:0 ry4:0 bz2 rz4:0 bz3"),
+	0x01: bc6hLayout(2, 7, 6, 6, 6, true, "gy5 gz4 gz5 rw6:0 bz0 bz1 by4 gw6:0 by5 bz2 gy4 bw6:0 bz3 bz5 bz4 rx5:0 gy3:0 gx5:0 gz3:0 bx5:0 by3:0 ry5:0 rz5:0"),
+	0x02: bc6hLayout(2, 11, 5, 4, 4, true, "rw9:0 gw9:0 bw9:0 rx4:0 rw10 gy3:0 gx3:0 gw10 bz0 gz3:0 bx3:0 bw10 bz1 by3:0 ry4:0 bz2 rz4:0 bz3"),
+	0x06: bc6hLayout(2, 11, 4, 5, 4, true, "rw9:0 gw9:0 bw9:0 rx3:0 rw10 gz4 gy3:0 gx4:0 gw10 gz3:0 bx3:0 bw10 bz1 by3:0 ry3:0 bz0 bz2 rz3:0 gy4 bz3"),
+	0x0a: bc6hLayout(2, 11, 4, 4, 5, true, "rw9:0 gw9:0 bw9:0 rx3:0 rw10 by4 gy3:0 gx3:0 gw10 bz0 gz3:0 bx4:0 bw10 by3:0 ry3:0 bz1 bz2 rz3:0 bz4 bz3"),
+	0x0e: bc6hLayout(2, 9, 5, 5, 5, true, "rw8:0 by4 gw8:0 gy4 bw8:0 bz4 rx4:0 gz4 gy3:0 gx4:0 bz0 gz3:0 bx4:0 bz1 by3:0 ry4:0 bz2 rz4:0 bz3"),
+	0x12: bc6hLayout(2, 8, 6, 5, 5, true, "rw7:0 gz4 by4 gw7:0 bz2 gy4 bw7:0 bz3 bz4 rx5:0 gy3:0 gx4:0 bz0 gz3:0 bx4:0 bz1 by3:0 ry5:0 rz5:0"),
+	0x16: bc6hLayout(2, 8, 5, 6, 5, true, "rw7:0 bz0 by4 gw7:0 gy5 gy4 bw7:0 gz5 bz4 rx4:0 gz4 gy3:0 gx5:0 gz3:0 bx4:0 bz1 by3:0 ry4:0 bz2 rz4:0 bz3"),
+	0x1a: bc6hLayout(2, 8, 5, 5, 6, true, "rw7:0 bz1 by4 gw7:0 by5 gy4 bw7:0 bz5 bz4 rx4:0 gz4 gy3:0 gx4:0 bz0 gz3:0 bx5:0 by3:0 ry4:0 bz2 rz4:0 bz3"),
+	0x1e: bc6hLayout(2, 6, 6, 6, 6, false, "rw5:0 gz4 bz0 bz1 by4 gw5:0 gy5 by5 bz2 gy4 bw5:0 gz5 bz3 bz5 bz4 rx5:0 gy3:0 gx5:0 gz3:0 bx5:0 by3:0 ry5:0 rz5:0"),
+	0x03: bc6hLayout(1, 10, 10, 10, 10, false, "rw9:0 gw9:0 bw9:0 rx9:0 gx9:0 bx9:0"),
+	0x07: bc6hLayout(1, 11, 9, 9, 9, true, "rw9:0 gw9:0 bw9:0 rx8:0 rw10 gx8:0 gw10 bx8:0 bw10"),
+	0x0b: bc6hLayout(1, 12, 8, 8, 8, true, "rw9:0 gw9:0 bw9:0 rx7:0 rw10:11 gx7:0 gw10:11 bx7:0 bw10:11"),
+	0x0f: bc6hLayout(1, 16, 4, 4, 4, true, "rw9:0 gw9:0 bw9:0 rx3:0 rw10:15 gx3:0 gw10:15 bx3:0 bw10:15"),
+}
+
+func bc6hLayout(regions, bits, dr, dg, db int, transformed bool, layout string) *bc6hMode {
+	m := &bc6hMode{regions: regions, bits: bits, delta: [3]int{dr, dg, db}, transformed: transformed}
+	for _, f := range strings.Fields(layout) {
+		ch := strings.IndexByte("rgb", f[0])
+		ep := strings.IndexByte("wxyz", f[1])
+		from, to := f[2:], f[2:]
+		if i := strings.IndexByte(f, ':'); i >= 0 {
+			to, from = f[2:i], f[i+1:]
+		}
+		a, err1 := strconv.Atoi(from)
+		b, err2 := strconv.Atoi(to)
+		if ch < 0 || ep < 0 || err1 != nil || err2 != nil {
+			panic("texture: bad BC6H field " + f)
+		}
+		step := 1
+		if b < a {
+			step = -1
+		}
+		for bit := a; ; bit += step {
+			m.fields = append(m.fields, bc6hField{ep, ch, bit})
+			if bit == b {
+				break
+			}
+		}
+	}
+	// the endpoints end at bit 77 with two regions and 65 with one, after 2
+	// or 5 mode bits
+	n := len(m.fields)
+	if !(regions == 2 && (n == 75 || n == 72) || regions == 1 && n == 60) {
+		panic(fmt.Sprintf("texture: BC6H layout of %d bits: %s", n, layout))
+	}
+	return m
+}
+
+func signExtend(v, bits int) int {
+	if v&(1<<(bits-1)) != 0 {
+		return v - 1<<bits
+	}
+	return v
+}
+
+func unquantizeBC6H(v, bits int, signed bool) int {
+	if !signed {
+		switch {
+		case bits >= 15:
+			return v
+		case v == 0:
+			return 0
+		case v == 1<<bits-1:
+			return 0xffff
+		}
+		return (v<<16 + 0x8000) >> bits
+	}
+	if bits >= 16 {
+		return v
+	}
+	neg := v < 0
+	if neg {
+		v = -v
+	}
+	switch {
+	case v == 0:
+	case v >= 1<<(bits-1)-1:
+		v = 0x7fff
+	default:
+		v = (v<<15 + 0x4000) >> (bits - 1)
+	}
+	if neg {
+		return -v
+	}
+	return v
+}
+
+// decodeBC6HFloat decodes a block to linear RGB. Reserved modes decode to
+// black.
+func decodeBC6HFloat(b []byte, signed bool, out *[16][3]float32) {
+	r := newBitReader(b)
+	key := r.read(2)
+	if key > 1 {
+		key |= r.read(3) << 2
+	}
+	m := bc6hModes[key]
+	if m == nil {
+		*out = [16][3]float32{}
+		return
+	}
+
+	var e [4][3]int
+	for _, f := range m.fields {
+		e[f.endpoint][f.channel] |= r.read(1) << f.bit
+	}
+	partition := 0
+	if m.regions == 2 {
+		partition = r.read(5)
+	}
+
+	n := 2 * m.regions
+	mask := 1<<m.bits - 1
+	for c := 0; c < 3; c++ {
+		if signed {
+			e[0][c] = signExtend(e[0][c], m.bits)
+		}
+		for i := 1; i < n; i++ {
+			if m.transformed {
+				e[i][c] = (e[0][c] + signExtend(e[i][c], m.delta[c])) & mask
+			}
+			if signed {
+				e[i][c] = signExtend(e[i][c], m.bits)
+			}
+		}
+		for i := 0; i < n; i++ {
+			e[i][c] = unquantizeBC6H(e[i][c], m.bits, signed)
+		}
+	}
+
+	bits := 3
+	if m.regions == 1 {
+		bits = 4
+	}
+	s, anchor := subsets(m.regions, partition)
+	idx := readIndices(r, bits, &anchor)
+	for i := range out {
+		for c := 0; c < 3; c++ {
+			v := interpolate(e[2*s[i]][c], e[2*s[i]+1][c], weights[bits][idx[i]])
+			// scale to the bit pattern of a half float
+			var h uint16
+			switch {
+			case !signed:
+				h = uint16(v * 31 >> 6)
+			case v < 0:
+				h = 0x8000 | uint16(-v*31>>5)
+			default:
+				h = uint16(v * 31 >> 5)
+			}
+			out[i][c] = HalfToFloat(h)
+		}
+	}
+}
+
+func decodeBC6HBytes(b, out []byte, signed bool) {
+	var f [16][3]float32
+	decodeBC6HFloat(b, signed, &f)
+	for i, px := range f {
+		out[4*i], out[4*i+1], out[4*i+2], out[4*i+3] = Quantize(px[0]), Quantize(px[1]), Quantize(px[2]), 255
+	}
+}
+
+func decodeBC6H(b, out []byte) {
+	decodeBC6HBytes(b, out, false)
+}
+
+func decodeBC6HS(b, out []byte) {
+	decodeBC6HBytes(b, out, true)
+}
+
+// HalfToFloat converts an IEEE 754 half precision float.
+func HalfToFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h >> 10 & 31)
+	mant := uint32(h & 1023)
+	switch {
+	case exp == 0:
+		f := float32(mant) / (1 << 24)
+		if sign != 0 {
+			return -f
+		}
+		return f
+	case exp == 31:
+		return m32.Float32frombits(sign | 0xff<<23 | mant<<13)
+	}
+	return m32.Float32frombits(sign | (exp-15+127)<<23 | mant<<13)
+}
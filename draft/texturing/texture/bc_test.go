@@ -0,0 +1,410 @@
+package texture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+// bitWriter packs the fields of a 128 bit block, least significant bit
+// first.
+type bitWriter struct {
+	lo, hi uint64
+	n      int
+}
+
+func (w *bitWriter) write(v, n int) {
+	for i := 0; i < n; i++ {
+		bit := uint64(v >> i & 1)
+		if w.n < 64 {
+			w.lo |= bit << w.n
+		} else {
+			w.hi |= bit << (w.n - 64)
+		}
+		w.n++
+	}
+}
+
+func (w *bitWriter) bytes(t *testing.T) []byte {
+	t.Helper()
+	if w.n != 128 {
+		t.Fatalf("block of %d bits", w.n)
+	}
+	b := binary.LittleEndian.AppendUint64(nil, w.lo)
+	return binary.LittleEndian.AppendUint64(b, w.hi)
+}
+
+func decodeBlock(f Format, b []byte) [16][4]byte {
+	var out [64]byte
+	formats[f].decode(b, out[:])
+	var px [16][4]byte
+	for i := range px {
+		copy(px[i][:], out[4*i:])
+	}
+	return px
+}
+
+func checkPixels(t *testing.T, name string, got [16][4]byte, want map[int][4]byte) {
+	t.Helper()
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("%s: pixel %d is %v, want %v", name, i, got[i], w)
+		}
+	}
+}
+
+func TestBC1(t *testing.T) {
+	// red and blue, pixels 1 to 3 using indices 1 to 3
+	b := []byte{0x00, 0xf8, 0x1f, 0x00, 0xe4, 0, 0, 0}
+	want := map[int][4]byte{
+		0: {255, 0, 0, 255}, 1: {0, 0, 255, 255}, 2: {170, 0, 85, 255}, 3: {85, 0, 170, 255}, 15: {255, 0, 0, 255},
+	}
+	checkPixels(t, "BC1", decodeBlock(FormatBC1, b), want)
+	checkPixels(t, "BC1A", decodeBlock(FormatBC1A, b), want)
+
+	// swapped endpoints select three colors and black
+	b = []byte{0x1f, 0x00, 0x00, 0xf8, 0xe4, 0, 0, 0}
+	checkPixels(t, "BC1 three color", decodeBlock(FormatBC1, b), map[int][4]byte{
+		0: {0, 0, 255, 255}, 2: {128, 0, 128, 255}, 3: {0, 0, 0, 255},
+	})
+	checkPixels(t, "BC1A three color", decodeBlock(FormatBC1A, b), map[int][4]byte{3: {0, 0, 0, 0}})
+
+	// BC2 and BC3 always interpolate four colors
+	bc2 := append([]byte{0x8f, 0, 0, 0, 0, 0, 0, 0}, b...)
+	checkPixels(t, "BC2", decodeBlock(FormatBC2, bc2), map[int][4]byte{
+		0: {0, 0, 255, 255}, 1: {255, 0, 0, 136}, 3: {170, 0, 85, 0},
+	})
+}
+
+func TestBC3Alpha(t *testing.T) {
+	// eight values: indices 0, 1, 2 and 7 on pixels 0 to 3
+	alpha := []byte{255, 0, 0x88, 0x0e, 0, 0, 0, 0}
+	color := []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}
+	checkPixels(t, "BC3", decodeBlock(FormatBC3, append(alpha, color...)), map[int][4]byte{
+		0: {255, 255, 255, 255}, 1: {255, 255, 255, 0}, 2: {255, 255, 255, 219}, 3: {255, 255, 255, 36},
+	})
+
+	// six values and the extremes: indices 2, 6 and 7 on pixels 0 to 2
+	alpha = []byte{0, 255, 0xf2, 0x01, 0, 0, 0, 0}
+	checkPixels(t, "BC4", decodeBlock(FormatBC4, alpha), map[int][4]byte{
+		0: {51, 0, 0, 255}, 1: {0, 0, 0, 255}, 2: {255, 0, 0, 255}, 3: {0, 0, 0, 255},
+	})
+	checkPixels(t, "BC5", decodeBlock(FormatBC5, append(alpha, 255, 0, 0, 0, 0, 0, 0, 0)), map[int][4]byte{
+		0: {51, 255, 0, 255}, 2: {255, 255, 0, 255},
+	})
+
+	// signed: 1 and -1, index 1 on pixel 1; -128 reads as -1
+	signed := []byte{0x7f, 0x81, 0x08, 0, 0, 0, 0, 0}
+	checkPixels(t, "BC4S", decodeBlock(FormatBC4S, signed), map[int][4]byte{
+		0: {255, 0, 0, 255}, 1: {0, 0, 0, 255},
+	})
+	checkPixels(t, "BC5S", decodeBlock(FormatBC5S, append(signed, 0x80, 0x80, 0, 0, 0, 0, 0, 0)), map[int][4]byte{
+		0: {255, 0, 0, 255},
+	})
+}
+
+func TestPartitions(t *testing.T) {
+	for p := 0; p < 64; p++ {
+		for n := 2; n <= 3; n++ {
+			s, anchor := subsets(n, p)
+			if s[0] != 0 {
+				t.Errorf("%d subsets, partition %d: pixel 0 in subset %d", n, p, s[0])
+			}
+			seen := make([]bool, n)
+			for i, a := range anchor {
+				if int(s[i]) >= n {
+					t.Fatalf("%d subsets, partition %d: pixel %d in subset %d", n, p, i, s[i])
+				}
+				if a {
+					if seen[s[i]] {
+						t.Errorf("%d subsets, partition %d: two anchors in subset %d", n, p, s[i])
+					}
+					seen[s[i]] = true
+				}
+			}
+			for k, ok := range seen {
+				if !ok {
+					t.Errorf("%d subsets, partition %d: no anchor in subset %d", n, p, k)
+				}
+			}
+		}
+	}
+}
+
+func TestBC7(t *testing.T) {
+	w4 := []int{0, 4, 9, 13, 17, 21, 26, 30, 34, 38, 43, 47, 51, 55, 60, 64}
+
+	// mode 6: white to transparent black, pixel i at index i
+	var w bitWriter
+	w.write(1<<6, 7)
+	for c := 0; c < 4; c++ {
+		w.write(127, 7)
+		w.write(0, 7)
+	}
+	w.write(1, 1)
+	w.write(0, 1)
+	for i := 0; i < 16; i++ {
+		if i == 0 {
+			w.write(i, 3)
+		} else {
+			w.write(i, 4)
+		}
+	}
+	px := decodeBlock(FormatBC7, w.bytes(t))
+	for i, p := range px {
+		v := byte(((64-w4[i])*255 + 32) >> 6)
+		if p != [4]byte{v, v, v, v} {
+			t.Errorf("mode 6 pixel %d is %v, want %d", i, p, v)
+		}
+	}
+
+	// mode 5 with rotation 1 swaps red and alpha
+	w = bitWriter{}
+	w.write(1<<5, 6)
+	w.write(1, 2)
+	w.write(127, 7)
+	w.write(127, 7)
+	w.write(0, 7*4)
+	w.write(0, 8)
+	w.write(0, 8)
+	w.write(0, 31)
+	w.write(0, 31)
+	checkPixels(t, "mode 5", decodeBlock(FormatBC7, w.bytes(t)), map[int][4]byte{0: {0, 0, 0, 255}, 15: {0, 0, 0, 255}})
+
+	// mode 1, partition 0: the two right columns are subset 1
+	w = bitWriter{}
+	w.write(1<<1, 2)
+	w.write(0, 6)
+	for c := 0; c < 3; c++ {
+		w.write(63, 6)
+		w.write(63, 6)
+		w.write(0, 6)
+		w.write(0, 6)
+	}
+	w.write(1, 1)
+	w.write(0, 1)
+	w.write(0, 46)
+	checkPixels(t, "mode 1", decodeBlock(FormatBC7, w.bytes(t)), map[int][4]byte{
+		0: {255, 255, 255, 255}, 1: {255, 255, 255, 255}, 2: {0, 0, 0, 255}, 15: {0, 0, 0, 255},
+	})
+
+	// mode 4 with the index selector set: 3 bit color, 2 bit alpha indices
+	w = bitWriter{}
+	w.write(1<<4, 5)
+	w.write(0, 2)
+	w.write(1, 1)
+	for c := 0; c < 3; c++ {
+		w.write(0, 5)
+		w.write(31, 5)
+	}
+	w.write(0, 6)
+	w.write(63, 6)
+	w.write(0, 31)
+	w.write(7<<2, 47) // pixel 1 at index 7
+	checkPixels(t, "mode 4", decodeBlock(FormatBC7, w.bytes(t)), map[int][4]byte{
+		0: {0, 0, 0, 0}, 1: {255, 255, 255, 0},
+	})
+
+	checkPixels(t, "reserved", decodeBlock(FormatBC7, make([]byte, 16)), map[int][4]byte{0: {}, 15: {}})
+}
+
+func TestBC6H(t *testing.T) {
+	for key, m := range bc6hModes {
+		if len(m.fields) == 0 {
+			t.Errorf("mode %#x has no fields", key)
+		}
+	}
+
+	decode := func(b []byte, signed bool) [16][3]float32 {
+		var out [16][3]float32
+		decodeBC6HFloat(b, signed, &out)
+		return out
+	}
+
+	// mode 11, one region of plain 10 bit endpoints: 495 is 1.0
+	block := func(w, x int) []byte {
+		var bw bitWriter
+		bw.write(0x03, 5)
+		for c := 0; c < 3; c++ {
+			bw.write(w, 10)
+		}
+		for c := 0; c < 3; c++ {
+			bw.write(x, 10)
+		}
+		bw.write(0, 3)
+		bw.write(15, 4)
+		bw.write(0, 56)
+		return bw.bytes(t)
+	}
+	out := decode(block(495, 0), false)
+	if out[0] != [3]float32{1, 1, 1} || out[1] != [3]float32{} || out[2] != [3]float32{1, 1, 1} {
+		t.Errorf("mode 11: %v %v %v", out[0], out[1], out[2])
+	}
+	px := decodeBlock(FormatBC6H, block(1023, 0))
+	checkPixels(t, "BC6H", px, map[int][4]byte{0: {255, 255, 255, 255}, 1: {0, 0, 0, 255}})
+
+	// signed endpoints mirror
+	out = decode(block(300, -300&1023), true)
+	if out[0][0] <= 0 || out[0][0] != -out[1][0] {
+		t.Errorf("signed mode 11: %v %v", out[0], out[1])
+	}
+
+	// mode 12 stores the second endpoint as a 9 bit difference: -1
+	var bw bitWriter
+	bw.write(0x07, 5)
+	for c := 0; c < 3; c++ {
+		bw.write(990, 10)
+	}
+	for c := 0; c < 3; c++ {
+		bw.write(0x1ff, 9)
+		bw.write(0, 1)
+	}
+	bw.write(0, 3)
+	bw.write(15, 4)
+	bw.write(0, 56)
+	out = decode(bw.bytes(t), false)
+	if a, b := out[0][0], out[1][0]; !(a > b && a-b < .01 && a < 1) {
+		t.Errorf("mode 12: %v then %v", a, b)
+	}
+
+	// mode 1, two regions: zero differences leave every endpoint at w
+	bw = bitWriter{}
+	bw.write(0, 2)
+	bw.write(0, 3)
+	for c := 0; c < 3; c++ {
+		bw.write(495, 10)
+	}
+	bw.write(0, 42)
+	bw.write(13, 5)
+	bw.write(0, 46)
+	out = decode(bw.bytes(t), false)
+	if out[0] != [3]float32{1, 1, 1} || out[15] != [3]float32{1, 1, 1} {
+		t.Errorf("mode 1: %v %v", out[0], out[15])
+	}
+
+	var reserved bitWriter
+	reserved.write(0x13, 5)
+	reserved.write(-1, 123)
+	if out := decode(reserved.bytes(t), false); out != [16][3]float32{} {
+		t.Errorf("reserved mode: %v", out[0])
+	}
+}
+
+func TestHalfToFloat(t *testing.T) {
+	for h, want := range map[uint16]float32{
+		0x0000: 0, 0x3c00: 1, 0xc000: -2, 0x7bff: 65504, 0x0001: 1.0 / (1 << 24), 0x3555: 0.33325195,
+	} {
+		if got := HalfToFloat(h); got != want {
+			t.Errorf("HalfToFloat(%#04x) = %v, want %v", h, got, want)
+		}
+	}
+	if f := HalfToFloat(0x7c00); f <= 65504 || f != f+1 {
+		t.Errorf("infinity decoded as %v", f)
+	}
+}
+
+// etc builds an ETC2 block from fields of the 64 bit big endian word.
+func etc(fields ...uint64) []byte {
+	var v uint64
+	for i := 0; i < len(fields); i += 2 {
+		v |= fields[i] << fields[i+1]
+	}
+	return binary.BigEndian.AppendUint64(nil, v)
+}
+
+func TestETC2(t *testing.T) {
+	// individual: all bases 8, tables 0 and 7; pixel 0 at index 3, pixel
+	// (1, 0) at index 1
+	b := etc(0x888888, 40, 0, 37, 7, 34, 1, 16, 1<<4|1, 0)
+	checkPixels(t, "individual", decodeBlock(FormatETC2, b), map[int][4]byte{
+		0: {128, 128, 128, 255}, 1: {144, 144, 144, 255}, 4: {138, 138, 138, 255}, 2: {183, 183, 183, 255},
+	})
+	// flipped, the bottom half uses table 7
+	b = etc(0x888888, 40, 0, 37, 7, 34, 1, 32)
+	checkPixels(t, "flipped", decodeBlock(FormatETC2, b), map[int][4]byte{
+		0: {138, 138, 138, 255}, 3: {138, 138, 138, 255}, 8: {183, 183, 183, 255},
+	})
+
+	// differential: red 16 and 17, green and blue 0
+	b = etc(16, 59, 1, 56, 1, 33)
+	checkPixels(t, "differential", decodeBlock(FormatETC2, b), map[int][4]byte{
+		0: {134, 2, 2, 255}, 3: {142, 2, 2, 255},
+	})
+
+	// T: red overflows with bits 63 to 61 set and 58 clear
+	b = etc(7, 61, 2, 59, 2, 56, 5, 52, 0, 48, 4, 44, 4, 40, 4, 36, 1, 34, 1, 33, 1, 32,
+		// pixels 0 to 3 of the first column at indices 0 to 3
+		0b1100, 16, 0b1010, 0)
+	checkPixels(t, "T", decodeBlock(FormatETC2, b), map[int][4]byte{
+		0: {170, 85, 0, 255}, 4: {84, 84, 84, 255}, 8: {68, 68, 68, 255}, 12: {52, 52, 52, 255},
+	})
+
+	// H: green overflows with bits 55 to 53 clear and 50 set
+	b = etc(8, 59, 2, 56, 0, 52, 0, 51, 1, 50, 2, 47, 2, 43, 2, 39, 2, 35, 0, 34, 1, 33, 1, 32,
+		0b1100, 16, 0b1010, 0)
+	checkPixels(t, "H", decodeBlock(FormatETC2, b), map[int][4]byte{
+		0: {152, 84, 50, 255}, 4: {120, 52, 18, 255}, 8: {50, 50, 50, 255}, 12: {18, 18, 18, 255},
+	})
+
+	// planar: blue overflows with bits 47 to 45 clear and 42 set; red
+	// grows from 130 to 195 along x
+	b = etc(32, 57, 1, 56, 0, 49, 1, 48, 1, 42, 0, 39, 24, 34, 1, 33, 0, 32,
+		64, 25, 1, 24, 32, 13, 64, 6, 32, 0)
+	checkPixels(t, "planar", decodeBlock(FormatETC2, b), map[int][4]byte{
+		0: {130, 129, 130, 255}, 3: {179, 129, 130, 255}, 12: {130, 129, 130, 255},
+	})
+
+	// punch-through: opaque bit clear, index 2 transparent, index 0 the
+	// base itself
+	b = etc(16, 59, 0, 33, 0b0100, 16, 0b0000, 0)
+	checkPixels(t, "punch-through", decodeBlock(FormatETC2A1, b), map[int][4]byte{
+		0: {132, 0, 0, 255}, 8: {0, 0, 0, 0},
+	})
+}
+
+func TestEAC(t *testing.T) {
+	// base 128, multiplier 2, table 0; pixel 0 at index 4, pixel (0, 1) at
+	// index 3
+	alpha := etc(128, 56, 2, 52, 0, 48, 4, 45, 3, 42)
+	checkPixels(t, "ETC2A", decodeBlock(FormatETC2A, append(alpha, etc(16, 59, 1, 33)...)), map[int][4]byte{
+		0: {134, 2, 2, 132}, 4: {134, 2, 2, 98}, 1: {134, 2, 2, 128 - 6},
+	})
+	checkPixels(t, "R11", decodeBlock(FormatEACR11, alpha), map[int][4]byte{
+		0: {132, 0, 0, 255},
+	})
+	checkPixels(t, "RG11", decodeBlock(FormatEACRG11, append(alpha, alpha...)), map[int][4]byte{
+		0: {132, 132, 0, 255},
+	})
+
+	// signed: base -127 and the largest step down clamps to -1
+	signed := etc(0x81, 56, 15, 52, 0, 48, 3, 45)
+	checkPixels(t, "R11S", decodeBlock(FormatEACR11S, signed), map[int][4]byte{0: {0, 0, 0, 255}})
+	checkPixels(t, "RG11S", decodeBlock(FormatEACRG11S, append(signed, signed...)), map[int][4]byte{0: {0, 0, 0, 255}})
+}
+
+func TestFormats(t *testing.T) {
+	for f := FormatRGBA8; int(f) < len(formats); f++ {
+		info, ok := f.info()
+		if !ok || info.name == "" || info.gl == 0 || (info.block > 1) != (info.decode != nil) {
+			t.Errorf("%v: incomplete %+v", f, info)
+		}
+	}
+	for _, c := range []struct {
+		f    Format
+		w, h int
+		want int
+	}{
+		{FormatRGBA8, 3, 5, 60},
+		{FormatBC1, 1, 1, 8},
+		{FormatBC1, 5, 4, 16},
+		{FormatBC7, 8, 9, 96},
+		{Format(0), 4, 4, 0},
+	} {
+		if got := c.f.ImageSize(c.w, c.h); got != c.want {
+			t.Errorf("%v.ImageSize(%d, %d) = %d, want %d", c.f, c.w, c.h, got, c.want)
+		}
+	}
+	if s := fmt.Sprint(FormatBC6HS, Format(99)); s != "BC6HS Format(99)" {
+		t.Errorf("format names %q", s)
+	}
+}
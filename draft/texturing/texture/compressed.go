@@ -0,0 +1,245 @@
+package texture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Format is the pixel format of a Compressed texture. Block formats store
+// 4x4 pixel blocks; the color space is Compressed.SRGB, not part of the
+// format.
+type Format int
+
+const (
+	FormatRGBA8 Format = iota + 1
+	FormatBGRA8
+	// FormatBC1 is DXT1 without alpha, FormatBC1A with 1 bit alpha.
+	FormatBC1
+	FormatBC1A
+	FormatBC2
+	FormatBC3
+	// FormatBC4 and FormatBC5 hold one and two channels, the S variants
+	// signed ones.
+	FormatBC4
+	FormatBC4S
+	FormatBC5
+	FormatBC5S
+	// FormatBC6H holds unsigned half floats, FormatBC6HS signed ones.
+	FormatBC6H
+	FormatBC6HS
+	FormatBC7
+	FormatETC2
+	// FormatETC2A1 is ETC2 with punch-through alpha, FormatETC2A with an EAC
+	// alpha block.
+	FormatETC2A1
+	FormatETC2A
+	FormatEACR11
+	FormatEACR11S
+	FormatEACRG11
+	FormatEACRG11S
+)
+
+// GL has no constants for these outside extensions and later versions.
+const (
+	glCompressedRGBS3TCDXT1        = 0x83F0
+	glCompressedRGBAS3TCDXT1       = 0x83F1
+	glCompressedRGBAS3TCDXT3       = 0x83F2
+	glCompressedRGBAS3TCDXT5       = 0x83F3
+	glCompressedSRGBS3TCDXT1       = 0x8C4C
+	glCompressedSRGBAlphaS3TCDXT1  = 0x8C4D
+	glCompressedSRGBAlphaS3TCDXT3  = 0x8C4E
+	glCompressedSRGBAlphaS3TCDXT5  = 0x8C4F
+	glCompressedRGBABPTCUnorm      = 0x8E8C
+	glCompressedSRGBAlphaBPTCUnorm = 0x8E8D
+	glCompressedRGBBPTCSignedFloat = 0x8E8E
+	glCompressedRGBBPTCFloat       = 0x8E8F
+	glCompressedR11EAC             = 0x9270
+	glCompressedSignedR11EAC       = 0x9271
+	glCompressedRG11EAC            = 0x9272
+	glCompressedSignedRG11EAC      = 0x9273
+	glCompressedRGB8ETC2           = 0x9274
+	glCompressedSRGB8ETC2          = 0x9275
+	glCompressedRGB8A1ETC2         = 0x9276
+	glCompressedSRGB8A1ETC2        = 0x9277
+	glCompressedRGBA8ETC2EAC       = 0x9278
+	glCompressedSRGB8Alpha8ETC2EAC = 0x9279
+)
+
+type formatInfo struct {
+	name string
+	// block is the width and height of a block in pixels, 1 for plain
+	// formats, and size its bytes.
+	block, size int
+	// gl and glSRGB are the internal formats; glSRGB is 0 when the format
+	// has no sRGB variant.
+	gl, glSRGB uint32
+	// decode writes the 16 pixels of a block as RGBA, rows first.
+	decode func(b, out []byte)
+}
+
+var formats = [...]formatInfo{
+	FormatRGBA8:    {"RGBA8", 1, 4, gl.RGBA8, gl.SRGB8_ALPHA8, nil},
+	FormatBGRA8:    {"BGRA8", 1, 4, gl.RGBA8, gl.SRGB8_ALPHA8, nil},
+	FormatBC1:      {"BC1", 4, 8, glCompressedRGBS3TCDXT1, glCompressedSRGBS3TCDXT1, decodeBC1},
+	FormatBC1A:     {"BC1A", 4, 8, glCompressedRGBAS3TCDXT1, glCompressedSRGBAlphaS3TCDXT1, decodeBC1A},
+	FormatBC2:      {"BC2", 4, 16, glCompressedRGBAS3TCDXT3, glCompressedSRGBAlphaS3TCDXT3, decodeBC2},
+	FormatBC3:      {"BC3", 4, 16, glCompressedRGBAS3TCDXT5, glCompressedSRGBAlphaS3TCDXT5, decodeBC3},
+	FormatBC4:      {"BC4", 4, 8, gl.COMPRESSED_RED_RGTC1, 0, decodeBC4},
+	FormatBC4S:     {"BC4S", 4, 8, gl.COMPRESSED_SIGNED_RED_RGTC1, 0, decodeBC4S},
+	FormatBC5:      {"BC5", 4, 16, gl.COMPRESSED_RG_RGTC2, 0, decodeBC5},
+	FormatBC5S:     {"BC5S", 4, 16, gl.COMPRESSED_SIGNED_RG_RGTC2, 0, decodeBC5S},
+	FormatBC6H:     {"BC6H", 4, 16, glCompressedRGBBPTCFloat, 0, decodeBC6H},
+	FormatBC6HS:    {"BC6HS", 4, 16, glCompressedRGBBPTCSignedFloat, 0, decodeBC6HS},
+	FormatBC7:      {"BC7", 4, 16, glCompressedRGBABPTCUnorm, glCompressedSRGBAlphaBPTCUnorm, decodeBC7},
+	FormatETC2:     {"ETC2", 4, 8, glCompressedRGB8ETC2, glCompressedSRGB8ETC2, decodeETC2},
+	FormatETC2A1:   {"ETC2A1", 4, 8, glCompressedRGB8A1ETC2, glCompressedSRGB8A1ETC2, decodeETC2A1},
+	FormatETC2A:    {"ETC2A", 4, 16, glCompressedRGBA8ETC2EAC, glCompressedSRGB8Alpha8ETC2EAC, decodeETC2A},
+	FormatEACR11:   {"EACR11", 4, 8, glCompressedR11EAC, 0, decodeEACR11},
+	FormatEACR11S:  {"EACR11S", 4, 8, glCompressedSignedR11EAC, 0, decodeEACR11S},
+	FormatEACRG11:  {"EACRG11", 4, 16, glCompressedRG11EAC, 0, decodeEACRG11},
+	FormatEACRG11S: {"EACRG11S", 4, 16, glCompressedSignedRG11EAC, 0, decodeEACRG11S},
+}
+
+func (f Format) info() (formatInfo, bool) {
+	if f <= 0 || int(f) >= len(formats) {
+		return formatInfo{}, false
+	}
+	return formats[f], true
+}
+
+func (f Format) String() string {
+	if i, ok := f.info(); ok {
+		return i.name
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// ImageSize is the number of bytes of one width x height image in f.
+func (f Format) ImageSize(width, height int) int {
+	i, ok := f.info()
+	if !ok {
+		return 0
+	}
+	return (width + i.block - 1) / i.block * ((height + i.block - 1) / i.block) * i.size
+}
+
+// Compressed is a texture kept in the format it is stored in, as read from
+// a KTX2 or DDS file, with its mip levels, array layers and cube faces.
+type Compressed struct {
+	Format        Format
+	Width, Height int
+	// Layers is the number of array layers and Faces is 6 for a cube map;
+	// both are 1 for a plain 2D texture.
+	Layers, Faces int
+	// Levels[i][layer*Faces+face] is one image of mip level i.
+	Levels [][][]byte
+	// SRGB marks sRGB encoded color, as Image.SRGB.
+	SRGB bool
+}
+
+// Validate reports a texture whose images do not match its size and format.
+func (c *Compressed) Validate() error {
+	info, ok := c.Format.info()
+	if !ok {
+		return fmt.Errorf("texture: unknown format %v", c.Format)
+	}
+	if c.SRGB && info.glSRGB == 0 {
+		return fmt.Errorf("texture: %v has no sRGB variant", c.Format)
+	}
+	if c.Width <= 0 || c.Height <= 0 || c.Layers <= 0 {
+		return fmt.Errorf("texture: empty texture %dx%d with %d layers", c.Width, c.Height, c.Layers)
+	}
+	if c.Faces != 1 && c.Faces != 6 {
+		return fmt.Errorf("texture: %d faces", c.Faces)
+	}
+	if c.Faces == 6 && c.Width != c.Height {
+		return fmt.Errorf("texture: cube map faces are %dx%d", c.Width, c.Height)
+	}
+	if len(c.Levels) == 0 || len(c.Levels) > MipLevels(c.Width, c.Height) {
+		return fmt.Errorf("texture: %d levels for %dx%d", len(c.Levels), c.Width, c.Height)
+	}
+	for i, l := range c.Levels {
+		if len(l) != c.Layers*c.Faces {
+			return fmt.Errorf("texture: level %d has %d images, want %d", i, len(l), c.Layers*c.Faces)
+		}
+		w, h := MipSize(c.Width, c.Height, i)
+		for j, img := range l {
+			if want := c.Format.ImageSize(w, h); len(img) != want {
+				return fmt.Errorf("texture: level %d image %d has %d bytes, want %d", i, j, len(img), want)
+			}
+		}
+	}
+	return nil
+}
+
+// Decode converts one image to RGBA on the CPU. Channels a format lacks read
+// as GL samples them: 0 for green and blue, 1 for alpha. Signed channels are
+// mapped from [-1, 1] to [0, 255] and BC6H is clamped to [0, 1].
+func (c *Compressed) Decode(level, layer, face int) (*Image, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if level < 0 || level >= len(c.Levels) || layer < 0 || layer >= c.Layers || face < 0 || face >= c.Faces {
+		return nil, fmt.Errorf("texture: no image at level %d, layer %d, face %d", level, layer, face)
+	}
+	src := c.Levels[level][layer*c.Faces+face]
+	w, h := MipSize(c.Width, c.Height, level)
+	m := New(w, h)
+	m.SRGB = c.SRGB
+
+	info := formats[c.Format]
+	switch c.Format {
+	case FormatRGBA8:
+		copy(m.Pix, src)
+		return m, nil
+	case FormatBGRA8:
+		for i := 0; i < len(m.Pix); i += 4 {
+			m.Pix[i], m.Pix[i+1], m.Pix[i+2], m.Pix[i+3] = src[i+2], src[i+1], src[i], src[i+3]
+		}
+		return m, nil
+	}
+
+	var block [64]byte
+	bw := (w + 3) / 4
+	for by := 0; by < (h+3)/4; by++ {
+		for bx := 0; bx < bw; bx++ {
+			o := (by*bw + bx) * info.size
+			info.decode(src[o:o+info.size], block[:])
+			// blocks at the right and bottom edges may hang over the image
+			for y := 0; y < 4 && 4*by+y < h; y++ {
+				for x := 0; x < 4 && 4*bx+x < w; x++ {
+					copy(m.Pix[4*((4*by+y)*w+4*bx+x):], block[4*(4*y+x):4*(4*y+x)+4])
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// LoadCompressed reads a KTX2 or DDS file.
+func LoadCompressed(path string) (*Compressed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := DecodeCompressed(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// DecodeCompressed is LoadCompressed for a file in memory.
+func DecodeCompressed(data []byte) (*Compressed, error) {
+	switch {
+	case bytes.HasPrefix(data, ktx2Magic):
+		return DecodeKTX2(data)
+	case bytes.HasPrefix(data, ddsMagic):
+		return DecodeDDS(data)
+	}
+	return nil, errors.New("texture: not a KTX2 or DDS file")
+}
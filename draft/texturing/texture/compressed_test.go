@@ -0,0 +1,391 @@
+package texture
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// red565 is a BC1 block of solid red.
+var red565 = []byte{0x00, 0xf8, 0x00, 0xf8, 0, 0, 0, 0}
+
+// ktx2File builds a KTX2 file; levels are the whole levels, layers then
+// faces, compressed with zlib when scheme says so.
+func ktx2File(t *testing.T, vk, w, h, layers, faces, scheme uint32, levels ...[]byte) []byte {
+	t.Helper()
+	hdr := ktx2Header{
+		VkFormat:               vk,
+		TypeSize:               1,
+		PixelWidth:             w,
+		PixelHeight:            h,
+		LayerCount:             layers,
+		FaceCount:              faces,
+		LevelCount:             uint32(len(levels)),
+		SupercompressionScheme: scheme,
+	}
+	var buf bytes.Buffer
+	buf.Write(ktx2Magic)
+	binary.Write(&buf, binary.LittleEndian, hdr)
+
+	offset := uint64(buf.Len() + 24*len(levels))
+	var data bytes.Buffer
+	index := make([]ktx2Level, len(levels))
+	for i, l := range levels {
+		index[i].UncompressedByteLength = uint64(len(l))
+		if scheme == ktx2Zlib {
+			var z bytes.Buffer
+			zw := zlib.NewWriter(&z)
+			zw.Write(l)
+			zw.Close()
+			l = z.Bytes()
+		}
+		index[i].ByteOffset = offset + uint64(data.Len())
+		index[i].ByteLength = uint64(len(l))
+		data.Write(l)
+	}
+	binary.Write(&buf, binary.LittleEndian, index)
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+func TestKTX2(t *testing.T) {
+	// BC1 with its mip chain: 8x8, 4x4, 2x2, 1x1
+	data := ktx2File(t, 131, 8, 8, 0, 1, ktx2None, bytes.Repeat(red565, 4), red565, red565, red565)
+	c, err := DecodeCompressed(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Format != FormatBC1 || c.SRGB || c.Width != 8 || c.Height != 8 || c.Layers != 1 || c.Faces != 1 || len(c.Levels) != 4 {
+		t.Fatalf("got %v %dx%d, %d layers, %d faces, %d levels", c.Format, c.Width, c.Height, c.Layers, c.Faces, len(c.Levels))
+	}
+	for i := range c.Levels {
+		m, err := c.Decode(i, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w, h := MipSize(8, 8, i); m.Width != w || m.Height != h {
+			t.Fatalf("level %d is %dx%d", i, m.Width, m.Height)
+		}
+		if !bytes.Equal(m.Pix[:4], []byte{255, 0, 0, 255}) {
+			t.Errorf("level %d starts with %v", i, m.Pix[:4])
+		}
+	}
+
+	// an sRGB RGBA8 cube map whose faces are filled with their index
+	var cube []byte
+	for f := byte(0); f < 6; f++ {
+		cube = append(cube, bytes.Repeat([]byte{f, f, f, 255}, 4)...)
+	}
+	c, err = DecodeKTX2(ktx2File(t, 43, 2, 2, 0, 6, ktx2None, cube))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Faces != 6 || !c.SRGB {
+		t.Fatalf("%d faces, srgb %v", c.Faces, c.SRGB)
+	}
+	m, err := c.Decode(0, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.SRGB || m.Pix[0] != 4 {
+		t.Errorf("face 4: srgb %v, %v", m.SRGB, m.Pix[:4])
+	}
+
+	// a zlib supercompressed BGRA array of 3 layers
+	var layers []byte
+	for l := byte(0); l < 3; l++ {
+		layers = append(layers, 10*l, 20, 30, 255)
+	}
+	c, err = DecodeKTX2(ktx2File(t, 44, 1, 1, 3, 1, ktx2Zlib, layers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = c.Decode(0, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(m.Pix, []byte{30, 20, 20, 255}) {
+		t.Errorf("layer 2 is %v", m.Pix)
+	}
+}
+
+func TestKTX2Errors(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("KTX 20"), "bad magic"},
+		{ktx2Magic, "header"},
+		{ktx2File(t, 0, 4, 4, 0, 1, ktx2None, red565), "Basis"},
+		{ktx2File(t, 1000, 4, 4, 0, 1, ktx2None, red565), "VkFormat"},
+		{ktx2File(t, 131, 4, 4, 0, 1, 2, red565), "supercompression"},
+		{ktx2File(t, 131, 8, 8, 0, 1, ktx2None, red565), "want 32"},
+		{ktx2File(t, 131, 4, 4, 0, 1, ktx2None, red565, red565, red565, red565), "levels"},
+		{ktx2File(t, 140, 4, 4, 0, 1, ktx2None, red565)[:90], "level index"},
+		{ktx2File(t, 131, 4, 4, 0, 1<<30, ktx2None, red565), "1073741824 faces"},
+		{ktx2File(t, 131, 4, 4, 0, 0, ktx2None, red565), "0 faces"},
+		// the inflated size is checked before inflating
+		{ktx2File(t, 131, 8, 8, 0, 1, ktx2Zlib, red565), "to 8, want 32"},
+	}
+	for _, tt := range tests {
+		_, err := DecodeKTX2(tt.data)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got %v, want an error about %q", err, tt.want)
+		}
+	}
+}
+
+// ddsFile builds a DDS file with a legacy header, or a DX10 one when dx is
+// not nil; images are each layer and face with its whole mip chain.
+func ddsFile(w, h, levels uint32, pf ddsPixelFormat, caps2 uint32, dx *ddsHeaderDX10, images ...[]byte) []byte {
+	pf.Size = 32
+	hdr := ddsHeader{Size: 124, Width: w, Height: h, PixelFormat: pf, Caps2: caps2}
+	if levels > 0 {
+		hdr.Flags |= ddsdMipMapCount
+		hdr.MipMapCount = levels
+	}
+	if dx != nil {
+		hdr.PixelFormat = ddsPixelFormat{Size: 32, Flags: ddpfFourCC, FourCC: fourCC("DX10")}
+	}
+	var buf bytes.Buffer
+	buf.Write(ddsMagic)
+	binary.Write(&buf, binary.LittleEndian, hdr)
+	if dx != nil {
+		binary.Write(&buf, binary.LittleEndian, dx)
+	}
+	for _, img := range images {
+		buf.Write(img)
+	}
+	return buf.Bytes()
+}
+
+func TestDDS(t *testing.T) {
+	dxt1 := ddsPixelFormat{Flags: ddpfFourCC, FourCC: fourCC("DXT1")}
+	c, err := DecodeCompressed(ddsFile(8, 4, 4, dxt1, 0, nil, bytes.Repeat(red565, 2), red565, red565, red565))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Format != FormatBC1A || len(c.Levels) != 4 || len(c.Levels[0][0]) != 16 {
+		t.Fatalf("got %v with %d levels", c.Format, len(c.Levels))
+	}
+
+	// a BC7 cube map: each face is followed by its mips
+	var faces [][]byte
+	for f := 0; f < 6; f++ {
+		block := make([]byte, 16)
+		block[0] = 1 << 6 // mode 6
+		block[15] = byte(f)
+		faces = append(faces, append(append([]byte(nil), block...), block...))
+	}
+	cube := ddsFile(4, 4, 2, ddsPixelFormat{}, 0, &ddsHeaderDX10{DXGIFormat: 99, MiscFlag: dxgiMiscTextureCube, ArraySize: 1}, faces...)
+	c, err = DecodeDDS(cube)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Format != FormatBC7 || !c.SRGB || c.Faces != 6 || c.Layers != 1 || len(c.Levels) != 2 {
+		t.Fatalf("got %v srgb %v, %d faces, %d layers, %d levels", c.Format, c.SRGB, c.Faces, c.Layers, len(c.Levels))
+	}
+	for f := 0; f < 6; f++ {
+		if c.Levels[1][f][15] != byte(f) {
+			t.Errorf("level 1 face %d holds face %d", f, c.Levels[1][f][15])
+		}
+	}
+
+	// BGRA from pixel masks, then as a DX10 array of two layers
+	bgra := ddsPixelFormat{Flags: ddpfRGB, RGBBitCount: 32, RBitMask: 0xff0000, GBitMask: 0xff00, BBitMask: 0xff, ABitMask: 0xff000000}
+	c, err = DecodeDDS(ddsFile(1, 1, 0, bgra, 0, nil, []byte{1, 2, 3, 4}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := c.Decode(0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Format != FormatBGRA8 || !bytes.Equal(m.Pix, []byte{3, 2, 1, 4}) {
+		t.Errorf("got %v %v", c.Format, m.Pix)
+	}
+	c, err = DecodeDDS(ddsFile(1, 1, 0, ddsPixelFormat{}, 0, &ddsHeaderDX10{DXGIFormat: 87, ArraySize: 2}, []byte{1, 2, 3, 4}, []byte{5, 6, 7, 8}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Layers != 2 || c.Levels[0][1][0] != 5 {
+		t.Errorf("%d layers, %v", c.Layers, c.Levels[0])
+	}
+}
+
+func TestDDSErrors(t *testing.T) {
+	dxt1 := ddsPixelFormat{Flags: ddpfFourCC, FourCC: fourCC("DXT1")}
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("DDX "), "bad magic"},
+		{ddsFile(0, 4, 0, dxt1, 0, nil), "bad size"},
+		{ddsFile(4, 4, 0, ddsPixelFormat{Flags: ddpfFourCC, FourCC: fourCC("ETC1")}, 0, nil), "FourCC"},
+		{ddsFile(4, 4, 0, ddsPixelFormat{Flags: ddpfRGB, RGBBitCount: 16}, 0, nil), "pixel format"},
+		{ddsFile(4, 4, 0, ddsPixelFormat{}, 0, &ddsHeaderDX10{DXGIFormat: 2}), "DXGI format"},
+		{ddsFile(4, 4, 0, ddsPixelFormat{}, 0, &ddsHeaderDX10{DXGIFormat: 71, ResourceDimension: d3d10Texture3D}), "3D"},
+		{ddsFile(4, 4, 0, dxt1, ddsCaps2Cubemap|0x400, nil), "six faces"},
+		{ddsFile(4, 4, 0, dxt1, ddsCaps2Volume, nil), "volume"},
+		{ddsFile(4, 4, 4, dxt1, 0, nil), "levels"},
+		{ddsFile(8, 8, 2, dxt1, 0, nil, bytes.Repeat(red565, 4)), "truncated"},
+	}
+	for _, tt := range tests {
+		_, err := DecodeDDS(tt.data)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got %v, want an error about %q", err, tt.want)
+		}
+	}
+}
+
+func TestDecodeCrop(t *testing.T) {
+	// 6x5 takes 2x2 blocks; block i is solid gray 60*i
+	var blocks []byte
+	for i := 0; i < 4; i++ {
+		v := uint16(60*i) >> 3
+		c := v<<11 | v<<6 | v
+		blocks = append(blocks, byte(c), byte(c>>8), byte(c), byte(c>>8), 0, 0, 0, 0)
+	}
+	c := &Compressed{Format: FormatBC1, Width: 6, Height: 5, Layers: 1, Faces: 1, Levels: [][][]byte{{blocks}}}
+	m, err := c.Decode(0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Width != 6 || m.Height != 5 || len(m.Pix) != 6*5*4 {
+		t.Fatalf("%dx%d with %d bytes", m.Width, m.Height, len(m.Pix))
+	}
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 6; x++ {
+			block := y/4*2 + x/4
+			var px [64]byte
+			decodeBC1(blocks[8*block:], px[:])
+			if got := m.Pix[4*(y*6+x)]; got != px[0] {
+				t.Errorf("(%d, %d) is %d, want %d from block %d", x, y, got, px[0], block)
+			}
+		}
+	}
+
+	if _, err := c.Decode(1, 0, 0); err == nil {
+		t.Error("decoded a missing level")
+	}
+	if _, err := c.Decode(0, 0, 1); err == nil {
+		t.Error("decoded a missing face")
+	}
+}
+
+func TestValidateCompressed(t *testing.T) {
+	ok := func() *Compressed {
+		return &Compressed{Format: FormatBC1, Width: 4, Height: 4, Layers: 1, Faces: 1, Levels: [][][]byte{{red565}}}
+	}
+	if err := ok().Validate(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []func(c *Compressed){
+		func(c *Compressed) { c.Format = 0 },
+		func(c *Compressed) { c.Format, c.SRGB = FormatBC4, true },
+		func(c *Compressed) { c.Layers = 0 },
+		func(c *Compressed) { c.Faces = 2 },
+		func(c *Compressed) { c.Width = 8 },
+		func(c *Compressed) { c.Levels = nil },
+		func(c *Compressed) { c.Levels[0] = append(c.Levels[0], red565) },
+	}
+	for i, f := range tests {
+		c := ok()
+		f(c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("case %d: no error for %+v", i, c)
+		}
+	}
+}
+
+func TestPlanCompressed(t *testing.T) {
+	bc1 := &Compressed{Format: FormatBC1, Width: 4, Height: 4, Layers: 1, Faces: 1, Levels: [][][]byte{{red565}}, SRGB: true}
+	u, err := planCompressed(bc1, true, Sampler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := compressedUpload{
+		target:         gl.TEXTURE_2D,
+		params:         glParams{glCompressedSRGBS3TCDXT1, gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR},
+		compressed:     true,
+		native:         true,
+		internalFormat: glCompressedSRGBS3TCDXT1,
+		format:         gl.RGBA,
+	}
+	if u != want {
+		t.Errorf("native BC1: %+v, want %+v", u, want)
+	}
+
+	// without driver support, or to generate mips, it is decoded
+	for _, s := range []Sampler{{}, {Mipmaps: true}} {
+		native := s.Mipmaps
+		u, err = planCompressed(bc1, native, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.compressed || u.native || u.internalFormat != gl.SRGB8_ALPHA8 || u.generate != s.Mipmaps {
+			t.Errorf("%+v: %+v", s, u)
+		}
+	}
+
+	cube := &Compressed{Format: FormatBGRA8, Width: 1, Height: 1, Layers: 1, Faces: 6, Levels: [][][]byte{make([][]byte, 6)}}
+	for i := range cube.Levels[0] {
+		cube.Levels[0][i] = make([]byte, 4)
+	}
+	u, err = planCompressed(cube, true, Sampler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.target != gl.TEXTURE_CUBE_MAP || u.format != gl.BGRA || u.compressed || u.params.wrapS != gl.CLAMP_TO_EDGE || u.params.wrapT != gl.CLAMP_TO_EDGE {
+		t.Errorf("BGRA cube: %+v", u)
+	}
+
+	array := &Compressed{Format: FormatBC1, Width: 4, Height: 4, Layers: 2, Faces: 1, Levels: [][][]byte{{red565, red565}, {red565, red565}, {red565, red565}}}
+	u, err = planCompressed(array, true, Sampler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.target != gl.TEXTURE_2D_ARRAY || !u.compressed || u.generate || u.params.minFilter != gl.LINEAR_MIPMAP_LINEAR {
+		t.Errorf("BC1 array: %+v", u)
+	}
+
+	cube.Layers = 2
+	cube.Levels[0] = append(cube.Levels[0], cube.Levels[0]...)
+	if _, err := planCompressed(cube, true, Sampler{}); err == nil {
+		t.Error("planned a cube map array")
+	}
+}
+
+func TestSupported(t *testing.T) {
+	s3tc := map[string]bool{"GL_EXT_texture_compression_s3tc": true}
+	tests := []struct {
+		f       Format
+		srgb    bool
+		version int
+		exts    map[string]bool
+		want    bool
+	}{
+		{FormatRGBA8, true, 33, nil, true},
+		{FormatBC5S, false, 33, nil, true},
+		{FormatBC3, false, 46, nil, false},
+		{FormatBC3, false, 33, s3tc, true},
+		{FormatBC3, true, 33, s3tc, false},
+		{FormatBC1, true, 33, map[string]bool{"GL_EXT_texture_compression_s3tc": true, "GL_EXT_texture_sRGB": true}, true},
+		{FormatBC7, false, 33, nil, false},
+		{FormatBC7, true, 42, nil, true},
+		{FormatBC6H, false, 33, map[string]bool{"GL_ARB_texture_compression_bptc": true}, true},
+		{FormatETC2A, false, 42, nil, false},
+		{FormatETC2A, false, 33, map[string]bool{"GL_ARB_ES3_compatibility": true}, true},
+		{FormatEACRG11S, false, 45, nil, true},
+		{0, false, 46, nil, false},
+	}
+	for _, tt := range tests {
+		if got := supported(tt.f, tt.srgb, tt.version, tt.exts); got != tt.want {
+			t.Errorf("%v srgb %v on %d %v: %v", tt.f, tt.srgb, tt.version, tt.exts, got)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var ddsMagic = []byte("DDS ")
+
+type ddsPixelFormat struct {
+	Size, Flags, FourCC, RGBBitCount       uint32
+	RBitMask, GBitMask, BBitMask, ABitMask uint32
+}
+
+type ddsHeader struct {
+	Size, Flags, Height, Width uint32
+	PitchOrLinearSize, Depth   uint32
+	MipMapCount                uint32
+	Reserved1                  [11]uint32
+	PixelFormat                ddsPixelFormat
+	Caps, Caps2, Caps3, Caps4  uint32
+	Reserved2                  uint32
+}
+
+type ddsHeaderDX10 struct {
+	DXGIFormat, ResourceDimension, MiscFlag, ArraySize, MiscFlags2 uint32
+}
+
+const (
+	ddsdMipMapCount     = 0x20000
+	ddpfFourCC          = 0x4
+	ddpfRGB             = 0x40
+	ddsCaps2Cubemap     = 0x200
+	ddsCaps2AllFaces    = 0xfc00
+	ddsCaps2Volume      = 0x200000
+	dxgiMiscTextureCube = 0x4
+	d3d10Texture3D      = 4
+)
+
+func fourCC(s string) uint32 {
+	return binary.LittleEndian.Uint32([]byte(s))
+}
+
+// ddsFourCCs are the formats of legacy headers.
+var ddsFourCCs = map[uint32]Format{
+	fourCC("DXT1"): FormatBC1A,
+	fourCC("DXT2"): FormatBC2,
+	fourCC("DXT3"): FormatBC2,
+	fourCC("DXT4"): FormatBC3,
+	fourCC("DXT5"): FormatBC3,
+	fourCC("ATI1"): FormatBC4,
+	fourCC("BC4U"): FormatBC4,
+	fourCC("BC4S"): FormatBC4S,
+	fourCC("ATI2"): FormatBC5,
+	fourCC("BC5U"): FormatBC5,
+	fourCC("BC5S"): FormatBC5S,
+}
+
+// dxgiFormats are the DXGI_FORMAT values of DX10 headers.
+var dxgiFormats = map[uint32]formatSpace{
+	28: {FormatRGBA8, false},
+	29: {FormatRGBA8, true},
+	87: {FormatBGRA8, false},
+	91: {FormatBGRA8, true},
+	71: {FormatBC1A, false},
+	72: {FormatBC1A, true},
+	74: {FormatBC2, false},
+	75: {FormatBC2, true},
+	77: {FormatBC3, false},
+	78: {FormatBC3, true},
+	80: {FormatBC4, false},
+	81: {FormatBC4S, false},
+	83: {FormatBC5, false},
+	84: {FormatBC5S, false},
+	95: {FormatBC6H, false},
+	96: {FormatBC6HS, false},
+	98: {FormatBC7, false},
+	99: {FormatBC7, true},
+}
+
+// DecodeDDS reads a DDS file with a legacy or DX10 header. Volume textures
+// and uncompressed formats other than 32 bit RGBA and BGRA are not
+// supported; BC1 is read with 1 bit alpha, as Direct3D samples it.
+func DecodeDDS(data []byte) (*Compressed, error) {
+	if !bytes.HasPrefix(data, ddsMagic) {
+		return nil, errors.New("dds: bad magic")
+	}
+	r := bytes.NewReader(data[len(ddsMagic):])
+	var h ddsHeader
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("dds: header: %w", err)
+	}
+	if h.Size != 124 || h.PixelFormat.Size != 32 {
+		return nil, fmt.Errorf("dds: header size %d, pixel format size %d", h.Size, h.PixelFormat.Size)
+	}
+	if h.Width == 0 || h.Width > 1<<16 || h.Height == 0 || h.Height > 1<<16 {
+		return nil, fmt.Errorf("dds: bad size %dx%d", h.Width, h.Height)
+	}
+
+	c := &Compressed{Width: int(h.Width), Height: int(h.Height), Layers: 1, Faces: 1}
+	pf := h.PixelFormat
+	switch {
+	case pf.Flags&ddpfFourCC != 0 && pf.FourCC == fourCC("DX10"):
+		var dx ddsHeaderDX10
+		if err := binary.Read(r, binary.LittleEndian, &dx); err != nil {
+			return nil, fmt.Errorf("dds: DX10 header: %w", err)
+		}
+		fs, ok := dxgiFormats[dx.DXGIFormat]
+		if !ok {
+			return nil, fmt.Errorf("dds: unsupported DXGI format %d", dx.DXGIFormat)
+		}
+		if dx.ResourceDimension == d3d10Texture3D {
+			return nil, errors.New("dds: 3D textures are not supported")
+		}
+		if dx.ArraySize > 1<<12 {
+			return nil, fmt.Errorf("dds: %d layers", dx.ArraySize)
+		}
+		c.Format, c.SRGB = fs.format, fs.srgb
+		if dx.ArraySize > 1 {
+			c.Layers = int(dx.ArraySize)
+		}
+		if dx.MiscFlag&dxgiMiscTextureCube != 0 {
+			c.Faces = 6
+		}
+	case pf.Flags&ddpfFourCC != 0:
+		f, ok := ddsFourCCs[pf.FourCC]
+		if !ok {
+			return nil, fmt.Errorf("dds: unsupported FourCC %q", binary.LittleEndian.AppendUint32(nil, pf.FourCC))
+		}
+		c.Format = f
+	case pf.Flags&ddpfRGB != 0 && pf.RGBBitCount == 32 && pf.GBitMask == 0xff00:
+		switch {
+		case pf.RBitMask == 0xff && pf.BBitMask == 0xff0000:
+			c.Format = FormatRGBA8
+		case pf.RBitMask == 0xff0000 && pf.BBitMask == 0xff:
+			c.Format = FormatBGRA8
+		}
+	}
+	if c.Format == 0 {
+		return nil, fmt.Errorf("dds: unsupported pixel format %+v", pf)
+	}
+	if h.Caps2&ddsCaps2Volume != 0 {
+		return nil, errors.New("dds: volume textures are not supported")
+	}
+	if h.Caps2&ddsCaps2Cubemap != 0 {
+		if h.Caps2&ddsCaps2AllFaces != ddsCaps2AllFaces {
+			return nil, errors.New("dds: cube maps without all six faces are not supported")
+		}
+		c.Faces = 6
+	}
+
+	levels := 1
+	if h.Flags&ddsdMipMapCount != 0 && h.MipMapCount > 1 {
+		levels = int(h.MipMapCount)
+	}
+	if levels > MipLevels(c.Width, c.Height) {
+		return nil, fmt.Errorf("dds: %d levels for %dx%d", levels, c.Width, c.Height)
+	}
+
+	// each layer and face holds its whole mip chain
+	rest := data[len(data)-r.Len():]
+	n := c.Layers * c.Faces
+	c.Levels = make([][][]byte, levels)
+	for i := range c.Levels {
+		c.Levels[i] = make([][]byte, n)
+	}
+	for j := 0; j < n; j++ {
+		for i := 0; i < levels; i++ {
+			size := c.Format.ImageSize(MipSize(c.Width, c.Height, i))
+			if len(rest) < size {
+				return nil, fmt.Errorf("dds: image %d of level %d is truncated", j, i)
+			}
+			c.Levels[i][j], rest = rest[:size], rest[size:]
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
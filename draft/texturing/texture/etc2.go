@@ -0,0 +1,294 @@
+package texture
+
+import "encoding/binary"
+
+// ETC2 and EAC blocks are big endian and number their pixels by column:
+// index j is at x = j/4, y = j%4.
+
+var etcModifiers = [8][4]int{
+	{2, 8, -2, -8},
+	{5, 17, -5, -17},
+	{9, 29, -9, -29},
+	{13, 42, -13, -42},
+	{18, 60, -18, -60},
+	{24, 80, -24, -80},
+	{33, 106, -33, -106},
+	{47, 183, -47, -183},
+}
+
+// etcDistances are the distances of the T and H modes.
+var etcDistances = [8]int{3, 6, 11, 16, 20, 23, 27, 32}
+
+var eacModifiers = [16][8]int{
+	{-3, -6, -9, -15, 2, 5, 8, 14},
+	{-3, -7, -10, -13, 2, 6, 9, 12},
+	{-2, -5, -8, -13, 1, 4, 7, 12},
+	{-2, -4, -6, -13, 1, 3, 5, 12},
+	{-3, -6, -8, -12, 2, 5, 7, 11},
+	{-3, -7, -9, -11, 2, 6, 8, 10},
+	{-4, -7, -8, -11, 3, 6, 7, 10},
+	{-3, -5, -8, -11, 2, 4, 7, 10},
+	{-2, -6, -8, -10, 1, 5, 7, 9},
+	{-2, -5, -8, -10, 1, 4, 7, 9},
+	{-2, -4, -8, -10, 1, 3, 7, 9},
+	{-2, -5, -7, -10, 1, 4, 6, 9},
+	{-3, -4, -7, -10, 2, 3, 6, 9},
+	{-1, -2, -3, -10, 0, 1, 2, 9},
+	{-4, -6, -8, -9, 3, 5, 7, 8},
+	{-3, -5, -7, -9, 2, 4, 6, 8},
+}
+
+func clampByte(v int) byte {
+	switch {
+	case v < 0:
+		return 0
+	case v > 255:
+		return 255
+	}
+	return byte(v)
+}
+
+// bitField returns n bits of v starting at bit lo.
+func bitField(v uint64, lo, n int) int {
+	return int(v >> lo & (1<<n - 1))
+}
+
+func extend4(v int) int { return v<<4 | v }
+func extend5(v int) int { return v<<3 | v>>2 }
+func extend6(v int) int { return v<<2 | v>>4 }
+func extend7(v int) int { return v<<1 | v>>6 }
+
+// decodeETC2RGB decodes the 8 byte color block of ETC2. With punchThrough
+// the differential bit is the opaque bit instead; when it is clear, index 2
+// is transparent black and there is no individual mode.
+func decodeETC2RGB(b, out []byte, punchThrough bool) {
+	v := binary.BigEndian.Uint64(b)
+	diff := v>>33&1 == 1
+	opaque := true
+	if punchThrough {
+		opaque, diff = diff, true
+	}
+	indices := uint32(v)
+	index := func(j int) int {
+		return int(indices>>(16+j)&1)<<1 | int(indices>>j&1)
+	}
+	put := func(j int, c [3]int, transparent bool) {
+		px := out[4*(j%4*4+j/4):]
+		if transparent {
+			px[0], px[1], px[2], px[3] = 0, 0, 0, 0
+			return
+		}
+		px[0], px[1], px[2], px[3] = clampByte(c[0]), clampByte(c[1]), clampByte(c[2]), 255
+	}
+
+	var base [2][3]int
+	if diff {
+		var over [3]bool
+		for c := 0; c < 3; c++ {
+			v0 := bitField(v, 59-8*c, 5)
+			v1 := v0 + signExtend(bitField(v, 56-8*c, 3), 3)
+			over[c] = v1 < 0 || v1 > 31
+			base[0][c], base[1][c] = extend5(v0), extend5(v1&31)
+		}
+		switch {
+		case over[0]:
+			decodeETC2T(v, index, put, opaque)
+			return
+		case over[1]:
+			decodeETC2H(v, index, put, opaque)
+			return
+		case over[2]:
+			decodeETC2Planar(v, put)
+			return
+		}
+	} else {
+		for c := 0; c < 3; c++ {
+			base[0][c], base[1][c] = extend4(bitField(v, 60-8*c, 4)), extend4(bitField(v, 56-8*c, 4))
+		}
+	}
+
+	flip := v>>32&1 == 1
+	tables := [2]int{bitField(v, 37, 3), bitField(v, 34, 3)}
+	for j := 0; j < 16; j++ {
+		x, y := j/4, j%4
+		sub := x / 2
+		if flip {
+			sub = y / 2
+		}
+		i := index(j)
+		if !opaque && i == 2 {
+			put(j, [3]int{}, true)
+			continue
+		}
+		d := etcModifiers[tables[sub]][i]
+		if !opaque && i == 0 {
+			d = 0
+		}
+		c := base[sub]
+		put(j, [3]int{c[0] + d, c[1] + d, c[2] + d}, false)
+	}
+}
+
+func add(c [3]int, d int) [3]int {
+	return [3]int{c[0] + d, c[1] + d, c[2] + d}
+}
+
+// paint colors the pixels of the T and H modes; index 2 is transparent
+// unless opaque.
+func paint(p [4][3]int, index func(int) int, put func(int, [3]int, bool), opaque bool) {
+	for j := 0; j < 16; j++ {
+		i := index(j)
+		put(j, p[i], !opaque && i == 2)
+	}
+}
+
+func decodeETC2T(v uint64, index func(int) int, put func(int, [3]int, bool), opaque bool) {
+	c1 := [3]int{extend4(bitField(v, 59, 2)<<2 | bitField(v, 56, 2)), extend4(bitField(v, 52, 4)), extend4(bitField(v, 48, 4))}
+	c2 := [3]int{extend4(bitField(v, 44, 4)), extend4(bitField(v, 40, 4)), extend4(bitField(v, 36, 4))}
+	d := etcDistances[bitField(v, 34, 2)<<1|bitField(v, 32, 1)]
+	paint([4][3]int{c1, add(c2, d), c2, add(c2, -d)}, index, put, opaque)
+}
+
+func decodeETC2H(v uint64, index func(int) int, put func(int, [3]int, bool), opaque bool) {
+	r1, g1, b1 := bitField(v, 59, 4), bitField(v, 56, 3)<<1|bitField(v, 52, 1), bitField(v, 51, 1)<<3|bitField(v, 47, 3)
+	r2, g2, b2 := bitField(v, 43, 4), bitField(v, 39, 4), bitField(v, 35, 4)
+	c1 := [3]int{extend4(r1), extend4(g1), extend4(b1)}
+	c2 := [3]int{extend4(r2), extend4(g2), extend4(b2)}
+	di := bitField(v, 34, 1)<<2 | bitField(v, 32, 1)<<1
+	if r1<<8|g1<<4|b1 >= r2<<8|g2<<4|b2 {
+		di |= 1
+	}
+	d := etcDistances[di]
+	paint([4][3]int{add(c1, d), add(c1, -d), add(c2, d), add(c2, -d)}, index, put, opaque)
+}
+
+func decodeETC2Planar(v uint64, put func(int, [3]int, bool)) {
+	o := [3]int{
+		extend6(bitField(v, 57, 6)),
+		extend7(bitField(v, 56, 1)<<6 | bitField(v, 49, 6)),
+		extend6(bitField(v, 48, 1)<<5 | bitField(v, 43, 2)<<3 | bitField(v, 39, 3)),
+	}
+	h := [3]int{
+		extend6(bitField(v, 34, 5)<<1 | bitField(v, 32, 1)),
+		extend7(bitField(v, 25, 7)),
+		extend6(bitField(v, 19, 6)),
+	}
+	vv := [3]int{
+		extend6(bitField(v, 13, 6)),
+		extend7(bitField(v, 6, 7)),
+		extend6(bitField(v, 0, 6)),
+	}
+	for j := 0; j < 16; j++ {
+		x, y := j/4, j%4
+		var c [3]int
+		for k := range c {
+			c[k] = (x*(h[k]-o[k]) + y*(vv[k]-o[k]) + 4*o[k] + 2) >> 2
+		}
+		put(j, c, false)
+	}
+}
+
+// decodeEAC decodes an EAC block to 11 bit values, [0, 2047] or, signed,
+// [-1023, 1023]. alpha8 decodes the 8 bit alpha of ETC2 with EAC instead.
+func decodeEAC(b []byte, out *[16]int, signed, alpha8 bool) {
+	v := binary.BigEndian.Uint64(b)
+	base := int(b[0])
+	if signed {
+		base = int(int8(b[0]))
+		if base == -128 {
+			base = -127
+		}
+	}
+	mult := bitField(v, 52, 4)
+	table := eacModifiers[bitField(v, 48, 4)]
+	for j := 0; j < 16; j++ {
+		m := table[bitField(v, 45-3*j, 3)]
+		i := j%4*4 + j/4
+		switch {
+		case alpha8:
+			out[i] = int(clampByte(base + m*mult))
+		case signed:
+			x := base * 8
+			if mult == 0 {
+				x += m
+			} else {
+				x += m * mult * 8
+			}
+			out[i] = clamp(x, -1023, 1023)
+		default:
+			x := base*8 + 4
+			if mult == 0 {
+				x += m
+			} else {
+				x += m * mult * 8
+			}
+			out[i] = clamp(x, 0, 2047)
+		}
+	}
+}
+
+func clamp(v, lo, hi int) int {
+	switch {
+	case v < lo:
+		return lo
+	case v > hi:
+		return hi
+	}
+	return v
+}
+
+// eacChannel decodes one EAC block to bytes.
+func eacChannel(b []byte, out *[16]byte, signed bool) {
+	var v [16]int
+	decodeEAC(b, &v, signed, false)
+	for i, x := range v {
+		if signed {
+			out[i] = snormByte(float32(x) / 1023)
+		} else {
+			out[i] = Quantize(float32(x) / 2047)
+		}
+	}
+}
+
+func decodeETC2(b, out []byte) {
+	decodeETC2RGB(b, out, false)
+}
+
+func decodeETC2A1(b, out []byte) {
+	decodeETC2RGB(b, out, true)
+}
+
+func decodeETC2A(b, out []byte) {
+	decodeETC2RGB(b[8:], out, false)
+	var a [16]int
+	decodeEAC(b, &a, false, true)
+	for i, v := range a {
+		out[4*i+3] = byte(v)
+	}
+}
+
+func decodeEACR11(b, out []byte) {
+	var r [16]byte
+	eacChannel(b, &r, false)
+	writeChannels(out, &r, nil)
+}
+
+func decodeEACR11S(b, out []byte) {
+	var r [16]byte
+	eacChannel(b, &r, true)
+	writeChannels(out, &r, nil)
+}
+
+func decodeEACRG11(b, out []byte) {
+	var r, g [16]byte
+	eacChannel(b, &r, false)
+	eacChannel(b[8:], &g, false)
+	writeChannels(out, &r, &g)
+}
+
+func decodeEACRG11S(b, out []byte) {
+	var r, g [16]byte
+	eacChannel(b, &r, true)
+	eacChannel(b[8:], &g, true)
+	writeChannels(out, &r, &g)
+}
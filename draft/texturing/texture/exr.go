@@ -0,0 +1,381 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+var exrMagic = []byte{0x76, 0x2f, 0x31, 0x01}
+
+// EXR version flags.
+const (
+	exrTiled     = 0x200
+	exrDeep      = 0x800
+	exrMultipart = 0x1000
+)
+
+// EXR compressions and the scanlines each of their chunks holds.
+const (
+	exrNone = 0
+	exrRLE  = 1
+	exrZIPS = 2
+	exrZIP  = 3
+)
+
+var exrLines = map[byte]int{exrNone: 1, exrRLE: 1, exrZIPS: 1, exrZIP: 16}
+
+// exrMaxRatio bounds how much a chunk of each compression can expand: an RLE
+// run turns 2 bytes into up to 128, deflate 1 into at most 1032.
+var exrMaxRatio = map[byte]int{exrNone: 1, exrRLE: 64, exrZIPS: 1032, exrZIP: 1032}
+
+// EXR pixel types and their sizes.
+const (
+	exrUint  = 0
+	exrHalf  = 1
+	exrFloat = 2
+)
+
+var exrSizes = [...]int{exrUint: 4, exrHalf: 2, exrFloat: 4}
+
+type exrChannel struct {
+	name string
+	typ  int
+	// rgba is the component the channel fills, -1 for none and 4 for
+	// luminance.
+	rgba int
+}
+
+var exrComponents = map[string]int{"R": 0, "G": 1, "B": 2, "A": 3, "Y": 4}
+
+// DecodeEXR reads a single part OpenEXR scanline file, uncompressed or
+// compressed with RLE, ZIPS or ZIP. The R, G, B and A channels are read,
+// with alpha 1 when missing; a file with only Y is gray. Other channels,
+// layers among them, are skipped. The data window is the image.
+func DecodeEXR(data []byte) (*FloatImage, error) {
+	if !bytes.HasPrefix(data, exrMagic) || len(data) < 8 {
+		return nil, errors.New("exr: bad magic")
+	}
+	version := binary.LittleEndian.Uint32(data[4:])
+	switch {
+	case version&0xff != 2:
+		return nil, fmt.Errorf("exr: unsupported version %d", version&0xff)
+	case version&exrTiled != 0:
+		return nil, errors.New("exr: tiled files are not supported")
+	case version&(exrDeep|exrMultipart) != 0:
+		return nil, errors.New("exr: deep and multipart files are not supported")
+	}
+
+	r := exrReader{data: data, pos: 8}
+	var channels []exrChannel
+	compression := -1
+	var box [4]int32
+	haveBox := false
+	for {
+		name := r.str()
+		if name == "" {
+			break
+		}
+		typ := r.str()
+		size := int(r.i32())
+		value := r.bytes(size)
+		if r.err != nil {
+			return nil, fmt.Errorf("exr: header: %w", r.err)
+		}
+		switch name {
+		case "channels":
+			var err error
+			if channels, err = exrChannels(value); err != nil {
+				return nil, err
+			}
+		case "compression":
+			if size != 1 {
+				return nil, fmt.Errorf("exr: compression of %d bytes", size)
+			}
+			compression = int(value[0])
+		case "dataWindow":
+			if typ != "box2i" || size != 16 {
+				return nil, fmt.Errorf("exr: dataWindow is %s of %d bytes", typ, size)
+			}
+			for i := range box {
+				box[i] = int32(binary.LittleEndian.Uint32(value[4*i:]))
+			}
+			haveBox = true
+		}
+	}
+	lines, ok := exrLines[byte(compression)]
+	switch {
+	case len(channels) == 0 || compression < 0 || !haveBox:
+		return nil, errors.New("exr: missing channels, compression or dataWindow")
+	case !ok:
+		return nil, fmt.Errorf("exr: unsupported compression %d", compression)
+	}
+	x0, y0 := int(box[0]), int(box[1])
+	w, h := int(box[2])-x0+1, int(box[3])-y0+1
+	if w <= 0 || w > 1<<16 || h <= 0 || h > 1<<16 {
+		return nil, fmt.Errorf("exr: bad size %dx%d", w, h)
+	}
+
+	// one line holds each channel in turn, sorted by name
+	lineSize := 0
+	gray := true
+	for _, c := range channels {
+		lineSize += w * exrSizes[c.typ]
+		if c.rgba >= 0 && c.rgba < 3 {
+			gray = false
+		}
+	}
+
+	// the offset table and the chunk headers are read first, so a header
+	// asking for more than the file could hold fails before the image is
+	// allocated
+	chunks := (h + lines - 1) / lines
+	if len(data)-r.pos < 8*chunks {
+		return nil, fmt.Errorf("exr: offset table of %d chunks is truncated", chunks)
+	}
+	offsets := make([]uint64, chunks)
+	for i := range offsets {
+		offsets[i] = r.u64()
+	}
+	// the chunks hold at least a line: lineSize uncompressed, a byte a pixel
+	// compressed
+	need := w
+	if compression == exrNone {
+		need = lineSize
+	}
+	if len(data)-r.pos < need {
+		return nil, fmt.Errorf("exr: %d bytes of chunks cannot hold lines %d wide", len(data)-r.pos, w)
+	}
+	type chunk struct {
+		y      int
+		packed []byte
+		// start and end are where the chunk lies in the file
+		start, end int
+	}
+	cs := make([]chunk, chunks)
+	for i, o := range offsets {
+		if o > uint64(len(data)) {
+			return nil, fmt.Errorf("exr: chunk %d lies outside the file", i)
+		}
+		cr := exrReader{data: data, pos: int(o)}
+		y := int(cr.i32()) - y0
+		size := int(cr.i32())
+		packed := cr.bytes(size)
+		if cr.err != nil {
+			return nil, fmt.Errorf("exr: chunk %d: %w", i, cr.err)
+		}
+		// entry i of the table is the block of lines from i*lines, whatever
+		// order the chunks are stored in
+		if y != i*lines {
+			return nil, fmt.Errorf("exr: chunk %d starts at line %d, want %d", i, y+y0, i*lines+y0)
+		}
+		n := lines
+		if y+n > h {
+			n = h - y
+		}
+		if n*lineSize > exrMaxRatio[byte(compression)]*len(packed) {
+			return nil, fmt.Errorf("exr: chunk %d of %d bytes cannot hold %d lines", i, len(packed), n)
+		}
+		cs[i] = chunk{y, packed, int(o), cr.pos}
+	}
+	// chunks share no bytes, so together they are no larger than the file
+	// and the image no larger than they can expand to
+	byStart := make([]chunk, len(cs))
+	copy(byStart, cs)
+	sort.Slice(byStart, func(i, j int) bool { return byStart[i].start < byStart[j].start })
+	for i := 1; i < len(byStart); i++ {
+		if byStart[i].start < byStart[i-1].end {
+			return nil, fmt.Errorf("exr: chunks of lines %d and %d overlap", byStart[i-1].y+y0, byStart[i].y+y0)
+		}
+	}
+
+	m := NewFloat(w, h)
+	buf := make([]byte, lines*lineSize)
+	for i, ck := range cs {
+		y := ck.y
+		n := lines
+		if y+n > h {
+			n = h - y
+		}
+		raw := buf[:n*lineSize]
+		if err := exrUncompress(compression, ck.packed, raw); err != nil {
+			return nil, fmt.Errorf("exr: chunk %d: %w", i, err)
+		}
+
+		for l := 0; l < n; l++ {
+			row := m.Pix[4*(y+l)*w : 4*(y+l+1)*w]
+			for x := 3; x < len(row); x += 4 {
+				row[x] = 1
+			}
+			p := raw[l*lineSize:]
+			for _, c := range channels {
+				size := exrSizes[c.typ]
+				if c.rgba < 0 || c.rgba == 4 && !gray {
+					p = p[w*size:]
+					continue
+				}
+				for x := 0; x < w; x++ {
+					v := exrValue(c.typ, p[x*size:])
+					px := m.Pix[4*((y+l)*w+x):]
+					if c.rgba == 4 {
+						px[0], px[1], px[2] = v, v, v
+					} else {
+						px[c.rgba] = v
+					}
+				}
+				p = p[w*size:]
+			}
+		}
+	}
+	return m, nil
+}
+
+func exrValue(typ int, b []byte) float32 {
+	switch typ {
+	case exrUint:
+		return float32(binary.LittleEndian.Uint32(b))
+	case exrHalf:
+		return HalfToFloat(binary.LittleEndian.Uint16(b))
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(b))
+}
+
+func exrChannels(value []byte) ([]exrChannel, error) {
+	r := exrReader{data: value}
+	var channels []exrChannel
+	for {
+		name := r.str()
+		if name == "" || r.err != nil {
+			break
+		}
+		typ := int(r.i32())
+		r.bytes(4) // pLinear and reserved
+		xs, ys := r.i32(), r.i32()
+		if r.err != nil {
+			break
+		}
+		if typ < 0 || typ >= len(exrSizes) {
+			return nil, fmt.Errorf("exr: channel %s has pixel type %d", name, typ)
+		}
+		if xs != 1 || ys != 1 {
+			return nil, fmt.Errorf("exr: channel %s is subsampled", name)
+		}
+		c := exrChannel{name: name, typ: typ, rgba: -1}
+		if i, ok := exrComponents[name]; ok {
+			c.rgba = i
+		}
+		channels = append(channels, c)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("exr: channels: %w", r.err)
+	}
+	return channels, nil
+}
+
+// exrUncompress fills raw from a chunk. A chunk that would not shrink is
+// stored as it is.
+func exrUncompress(compression int, packed, raw []byte) error {
+	if compression == exrNone || len(packed) == len(raw) {
+		if len(packed) != len(raw) {
+			return fmt.Errorf("%d bytes, want %d", len(packed), len(raw))
+		}
+		copy(raw, packed)
+		return nil
+	}
+	var t []byte
+	switch compression {
+	case exrRLE:
+		t = make([]byte, 0, len(raw))
+		for len(packed) > 0 {
+			n := int(int8(packed[0]))
+			switch {
+			case n < 0 && len(packed) >= 1-n:
+				t = append(t, packed[1:1-n]...)
+				packed = packed[1-n:]
+			case n >= 0 && len(packed) >= 2:
+				for i := 0; i <= n; i++ {
+					t = append(t, packed[1])
+				}
+				packed = packed[2:]
+			default:
+				return errors.New("truncated run")
+			}
+			if len(t) > len(raw) {
+				return errors.New("runs overflow the chunk")
+			}
+		}
+	default:
+		var err error
+		if t, err = inflate(packed, uint64(len(raw))); err != nil {
+			return err
+		}
+	}
+	if len(t) != len(raw) {
+		return fmt.Errorf("%d bytes, want %d", len(t), len(raw))
+	}
+
+	// undo the byte deltas, then interleave the two halves
+	for i := 1; i < len(t); i++ {
+		t[i] += t[i-1] - 128
+	}
+	half := (len(t) + 1) / 2
+	for i := range raw {
+		if i%2 == 0 {
+			raw[i] = t[i/2]
+		} else {
+			raw[i] = t[half+i/2]
+		}
+	}
+	return nil
+}
+
+// exrReader reads little endian values, keeping the first error.
+type exrReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *exrReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data)-r.pos {
+		r.err = errors.New("truncated")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *exrReader) str() string {
+	if r.err != nil {
+		return ""
+	}
+	i := bytes.IndexByte(r.data[r.pos:], 0)
+	if i < 0 {
+		r.err = errors.New("unterminated string")
+		return ""
+	}
+	s := string(r.data[r.pos : r.pos+i])
+	r.pos += i + 1
+	return s
+}
+
+func (r *exrReader) i32() int32 {
+	if b := r.bytes(4); b != nil {
+		return int32(binary.LittleEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (r *exrReader) u64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
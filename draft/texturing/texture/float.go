@@ -0,0 +1,78 @@
+package texture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// FloatImage is linear float32 RGBA on the CPU, tightly packed, as HDR
+// files hold it. Values may exceed 1; alpha is straight.
+type FloatImage struct {
+	Width, Height int
+	// Pix holds 4 floats per pixel, rows top to bottom without padding.
+	Pix []float32
+}
+
+func NewFloat(width, height int) *FloatImage {
+	return &FloatImage{Width: width, Height: height, Pix: make([]float32, 4*width*height)}
+}
+
+// Validate reports an empty image or pixels that do not match the size.
+func (m *FloatImage) Validate() error {
+	if m.Width <= 0 || m.Height <= 0 {
+		return fmt.Errorf("texture: empty image %dx%d", m.Width, m.Height)
+	}
+	if len(m.Pix) != 4*m.Width*m.Height {
+		return fmt.Errorf("texture: %d floats of pixels for %dx%d", len(m.Pix), m.Width, m.Height)
+	}
+	return nil
+}
+
+// At returns the pixel at x, y.
+func (m *FloatImage) At(x, y int) [4]float32 {
+	i := 4 * (y*m.Width + x)
+	return [4]float32{m.Pix[i], m.Pix[i+1], m.Pix[i+2], m.Pix[i+3]}
+}
+
+func (m *FloatImage) Set(x, y int, p [4]float32) {
+	copy(m.Pix[4*(y*m.Width+x):], p[:])
+}
+
+// FlipY reverses the order of the rows in place.
+func (m *FloatImage) FlipY() {
+	row := 4 * m.Width
+	tmp := make([]float32, row)
+	for top, bottom := 0, m.Height-1; top < bottom; top, bottom = top+1, bottom-1 {
+		a := m.Pix[top*row : (top+1)*row]
+		b := m.Pix[bottom*row : (bottom+1)*row]
+		copy(tmp, a)
+		copy(a, b)
+		copy(b, tmp)
+	}
+}
+
+// LoadFloat reads a Radiance .hdr or OpenEXR file.
+func LoadFloat(path string) (*FloatImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := DecodeFloat(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// DecodeFloat is LoadFloat for a file in memory.
+func DecodeFloat(data []byte) (*FloatImage, error) {
+	switch {
+	case bytes.HasPrefix(data, hdrMagic):
+		return DecodeHDR(data)
+	case bytes.HasPrefix(data, exrMagic):
+		return DecodeEXR(data)
+	}
+	return nil, errors.New("texture: not a Radiance HDR or OpenEXR file")
+}
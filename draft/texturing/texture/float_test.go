@@ -0,0 +1,374 @@
+package texture
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// hdrFile builds a Radiance file from RGBE pixels, each row run-length
+// encoded when rle is set.
+func hdrFile(resolution string, w int, pixels [][4]byte, rle bool) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("#?RADIANCE\n# test\nFORMAT=32-bit_rle_rgbe\nEXPOSURE=1.0\n\n")
+	buf.WriteString(resolution + "\n")
+	for y := 0; y < len(pixels)/w; y++ {
+		row := pixels[y*w : (y+1)*w]
+		if !rle {
+			for _, p := range row {
+				buf.Write(p[:])
+			}
+			continue
+		}
+		buf.Write([]byte{2, 2, byte(w >> 8), byte(w)})
+		for c := 0; c < 4; c++ {
+			// a run for the first half, literals for the rest
+			half := w / 2
+			buf.Write([]byte{byte(128 + half), row[0][c]})
+			buf.WriteByte(byte(w - half))
+			for _, p := range row[half:] {
+				buf.WriteByte(p[c])
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestHDR(t *testing.T) {
+	// 1 is 128 << (129 - 136), 0.5 at exponent 128
+	one := [4]byte{128, 128, 128, 129}
+	half := [4]byte{128, 0, 0, 128}
+	want := func(p [4]byte) [4]float32 {
+		r, g, b := rgbe(p[0], p[1], p[2], p[3])
+		return [4]float32{r, g, b, 1}
+	}
+	if r, _, _ := rgbe(128, 0, 0, 129); math.Abs(float64(r)-1) > .01 {
+		t.Fatalf("rgbe 128 at 129 is %v", r)
+	}
+
+	// 8 wide rows may be run-length encoded; the first half of each row is
+	// a run of its first pixel
+	w, h := 8, 2
+	var pixels [][4]byte
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			switch {
+			case x < w/2 && y == 0:
+				pixels = append(pixels, one)
+			case x < w/2:
+				pixels = append(pixels, half)
+			default:
+				pixels = append(pixels, [4]byte{byte(x), byte(y), 7, 130})
+			}
+		}
+	}
+	for _, rle := range []bool{false, true} {
+		for _, res := range []string{"-Y 2 +X 8", "+Y 2 +X 8"} {
+			m, err := DecodeFloat(hdrFile(res, w, pixels, rle))
+			if err != nil {
+				t.Fatal(rle, res, err)
+			}
+			if m.Width != w || m.Height != h {
+				t.Fatalf("%dx%d", m.Width, m.Height)
+			}
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					row := y
+					if res[0] == '+' {
+						row = h - 1 - y
+					}
+					if got, want := m.At(x, y), want(pixels[row*w+x]); got != want {
+						t.Errorf("rle %v %s: (%d, %d) is %v, want %v", rle, res, x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+
+	// old style runs repeat the previous pixel
+	data := hdrFile("-Y 1 +X 4", 2, [][4]byte{half, {1, 1, 1, 3}}, false)
+	m, err := DecodeHDR(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 4; x++ {
+		if m.At(x, 0) != want(half) {
+			t.Errorf("old run: pixel %d is %v", x, m.At(x, 0))
+		}
+	}
+}
+
+func TestHDRErrors(t *testing.T) {
+	good := hdrFile("-Y 1 +X 2", 2, [][4]byte{{1, 2, 3, 128}, {4, 5, 6, 128}}, false)
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("RADIANCE"), "bad magic"},
+		{[]byte("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n"), "truncated"},
+		{[]byte("#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n-Y 1 +X 1\n"), "xyze"},
+		{[]byte("#?RADIANCE\n\n-Y 1 +Y 1\n"), "orientation"},
+		{[]byte("#?RADIANCE\n\n+X 1 -Y 1\n"), "orientation"},
+		{[]byte("#?RADIANCE\n\n-Y 0 +X 1\n"), "bad size"},
+		{[]byte("#?RADIANCE\n\n-Y 1 X\n"), "resolution"},
+		{good[:len(good)-1], "truncated"},
+		{append(hdrFile("-Y 1 +X 8", 8, nil, false), 2, 2, 0, 9), "wrong width"},
+		{append(hdrFile("-Y 1 +X 8", 8, nil, false), 2, 2, 0, 8, 128+9, 0), "overflows"},
+		{[]byte("#?RADIANCE\n\n-Y 65536 +X 65536\n\x02\x02\x80\x00"), "cannot hold 65536 rows"},
+	}
+	for _, tt := range tests {
+		_, err := DecodeHDR(tt.data)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got %v, want an error about %q", tt.data, err, tt.want)
+		}
+	}
+}
+
+type exrTestChannel struct {
+	name string
+	typ  int
+}
+
+// exrFile builds a scanline OpenEXR file. lines holds the raw data of each
+// scanline, channels in order, which the compression packs per chunk.
+func exrFile(t *testing.T, w, y0 int, channels []exrTestChannel, compression byte, lines [][]byte) []byte {
+	t.Helper()
+	var hdr bytes.Buffer
+	attr := func(name, typ string, value []byte) {
+		hdr.WriteString(name + "\x00" + typ + "\x00")
+		binary.Write(&hdr, binary.LittleEndian, int32(len(value)))
+		hdr.Write(value)
+	}
+	var chlist bytes.Buffer
+	for _, c := range channels {
+		chlist.WriteString(c.name + "\x00")
+		binary.Write(&chlist, binary.LittleEndian, []int32{int32(c.typ), 0, 1, 1})
+	}
+	chlist.WriteByte(0)
+	attr("channels", "chlist", chlist.Bytes())
+	attr("compression", "compression", []byte{compression})
+	box := new(bytes.Buffer)
+	binary.Write(box, binary.LittleEndian, []int32{0, int32(y0), int32(w - 1), int32(y0 + len(lines) - 1)})
+	attr("dataWindow", "box2i", box.Bytes())
+	attr("displayWindow", "box2i", box.Bytes())
+	attr("lineOrder", "lineOrder", []byte{0})
+	hdr.WriteByte(0)
+
+	per := exrLines[compression]
+	var chunks [][]byte
+	for y := 0; y < len(lines); y += per {
+		end := y + per
+		if end > len(lines) {
+			end = len(lines)
+		}
+		raw := bytes.Join(lines[y:end], nil)
+		packed := exrCompress(t, compression, raw)
+		chunk := binary.LittleEndian.AppendUint32(nil, uint32(y0+y))
+		chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(packed)))
+		chunks = append(chunks, append(chunk, packed...))
+	}
+
+	out := append([]byte(nil), exrMagic...)
+	out = binary.LittleEndian.AppendUint32(out, 2)
+	out = append(out, hdr.Bytes()...)
+	offset := len(out) + 8*len(chunks)
+	for _, c := range chunks {
+		out = binary.LittleEndian.AppendUint64(out, uint64(offset))
+		offset += len(c)
+	}
+	for _, c := range chunks {
+		out = append(out, c...)
+	}
+	return out
+}
+
+// exrCompress is the inverse of exrUncompress: it splits the bytes into two
+// halves, stores deltas and packs them.
+func exrCompress(t *testing.T, compression byte, raw []byte) []byte {
+	t.Helper()
+	if compression == exrNone {
+		return raw
+	}
+	tmp := make([]byte, len(raw))
+	half := (len(raw) + 1) / 2
+	for i, b := range raw {
+		if i%2 == 0 {
+			tmp[i/2] = b
+		} else {
+			tmp[half+i/2] = b
+		}
+	}
+	for i := len(tmp) - 1; i > 0; i-- {
+		tmp[i] = tmp[i] - tmp[i-1] + 128
+	}
+	var out bytes.Buffer
+	switch compression {
+	case exrRLE:
+		// runs of equal bytes, literals between them
+		for i := 0; i < len(tmp); {
+			j := i + 1
+			for j < len(tmp) && tmp[j] == tmp[i] && j-i < 128 {
+				j++
+			}
+			if j-i >= 3 {
+				out.Write([]byte{byte(j - i - 1), tmp[i]})
+				i = j
+				continue
+			}
+			j = i + 1
+			for j < len(tmp) && j-i < 127 && !(j+2 < len(tmp) && tmp[j] == tmp[j+1] && tmp[j] == tmp[j+2]) {
+				j++
+			}
+			out.WriteByte(byte(-int8(j - i)))
+			out.Write(tmp[i:j])
+			i = j
+		}
+	default:
+		z := zlib.NewWriter(&out)
+		z.Write(tmp)
+		z.Close()
+	}
+	if out.Len() >= len(raw) {
+		t.Fatalf("compression %d does not shrink %d bytes", compression, len(raw))
+	}
+	return out.Bytes()
+}
+
+func TestEXR(t *testing.T) {
+	// channels are sorted by name: A, B, G, R; A is half, the rest float
+	channels := []exrTestChannel{{"A", exrHalf}, {"B", exrFloat}, {"G", exrFloat}, {"R", exrFloat}}
+	w, h := 24, 20
+	value := func(x, y, c int) float32 {
+		// large flat areas so RLE and ZIP pack them
+		return float32(x/8+c) * float32(y/5+1) * 1.5
+	}
+	lines := make([][]byte, h)
+	for y := range lines {
+		var b []byte
+		for x := 0; x < w; x++ {
+			b = binary.LittleEndian.AppendUint16(b, 0x3800) // 0.5
+		}
+		for _, c := range []int{2, 1, 0} {
+			for x := 0; x < w; x++ {
+				b = binary.LittleEndian.AppendUint32(b, math.Float32bits(value(x, y, c)))
+			}
+		}
+		lines[y] = b
+	}
+
+	for _, compression := range []byte{exrNone, exrRLE, exrZIPS, exrZIP} {
+		t.Run(fmt.Sprint(compression), func(t *testing.T) {
+			m, err := DecodeFloat(exrFile(t, w, -3, channels, compression, lines))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Width != w || m.Height != h {
+				t.Fatalf("%dx%d", m.Width, m.Height)
+			}
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					want := [4]float32{value(x, y, 0), value(x, y, 1), value(x, y, 2), .5}
+					if got := m.At(x, y); got != want {
+						t.Fatalf("(%d, %d) is %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+
+	// luminance only is gray with opaque alpha; unknown channels are skipped
+	var line []byte
+	for _, v := range []uint32{3, 7} {
+		line = binary.LittleEndian.AppendUint32(line, v)
+	}
+	line = binary.LittleEndian.AppendUint16(line, 0x4000) // 2
+	m, err := DecodeEXR(exrFile(t, 1, 0, []exrTestChannel{{"Y", exrUint}, {"Z", exrUint}, {"depth.Y", exrHalf}}, exrNone, [][]byte{line}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.At(0, 0); got != [4]float32{3, 3, 3, 1} {
+		t.Errorf("gray pixel is %v", got)
+	}
+}
+
+func TestEXRErrors(t *testing.T) {
+	channels := []exrTestChannel{{"R", exrFloat}}
+	good := exrFile(t, 2, 0, channels, exrNone, [][]byte{make([]byte, 8)})
+	with := func(at int, b ...byte) []byte {
+		data := append([]byte(nil), good...)
+		copy(data[at:], b)
+		return data
+	}
+	compression := bytes.Index(good, []byte("compression\x00compression\x00")) + 28
+	window := bytes.Index(good, []byte("dataWindow\x00box2i\x00")) + 21
+	// two lines whose table entries both point at the first chunk, of 16
+	// bytes each
+	twice := exrFile(t, 2, 0, channels, exrNone, [][]byte{make([]byte, 8), make([]byte, 8)})
+	table := len(twice) - 2*16 - 2*8
+	copy(twice[table+8:], twice[table:table+8])
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte{0x76, 0x2f}, "bad magic"},
+		{with(4, 1), "version 1"},
+		{with(5, 2), "tiled"},
+		{with(5, 0x10), "multipart"},
+		{with(compression, 4), "compression 4"},
+		{exrFile(t, 2, 0, []exrTestChannel{{"R", 3}}, exrNone, [][]byte{make([]byte, 8)}), "pixel type"},
+		{good[:40], "header"},
+		{good[:len(good)-1], "chunk 0"},
+		{exrFile(t, 2, 0, channels, exrNone, [][]byte{make([]byte, 6)}), "cannot hold 1 lines"},
+		// sizes the file cannot hold fail before the image is allocated
+		{twice, "chunk 1 starts at line 0, want 1"},
+		{with(window+8, 0xff, 0xff, 0, 0, 0xff, 0xff), "offset table of 65536 chunks"},
+		{with(window+8, 0xff, 0xff), "cannot hold lines 65536 wide"},
+	}
+	for _, tt := range tests {
+		_, err := DecodeEXR(tt.data)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got %v, want an error about %q", err, tt.want)
+		}
+	}
+}
+
+func TestFloatImage(t *testing.T) {
+	m := NewFloat(2, 3)
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	m.Set(1, 0, [4]float32{1, 2, 3, 4})
+	m.FlipY()
+	if m.At(1, 2) != [4]float32{1, 2, 3, 4} || m.At(1, 0) != [4]float32{} {
+		t.Errorf("flipped: %v", m.Pix)
+	}
+	m.Pix = m.Pix[1:]
+	if err := m.Validate(); err == nil {
+		t.Error("validated a short image")
+	}
+	if _, err := DecodeFloat([]byte("P6")); err == nil {
+		t.Error("decoded an unknown file")
+	}
+}
+
+func TestFloatParams(t *testing.T) {
+	tests := []struct {
+		f    FloatFormat
+		s    Sampler
+		want glParams
+	}{
+		{RGBA16F, Sampler{}, glParams{gl.RGBA16F, gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR}},
+		{RGBA32F, Sampler{WrapS: ClampToEdge, Mipmaps: true}, glParams{gl.RGBA32F, gl.CLAMP_TO_EDGE, gl.REPEAT, gl.LINEAR_MIPMAP_LINEAR, gl.LINEAR}},
+	}
+	for _, tt := range tests {
+		if got := floatParams(tt.f, tt.s); got != tt.want {
+			t.Errorf("%v %+v: %+v, want %+v", tt.f, tt.s, got, tt.want)
+		}
+	}
+}
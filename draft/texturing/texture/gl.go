@@ -0,0 +1,433 @@
+package texture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+type Wrap int
+
+const (
+	Repeat Wrap = iota
+	ClampToEdge
+	MirroredRepeat
+)
+
+type Filter int
+
+const (
+	Linear Filter = iota
+	Nearest
+)
+
+// Sampler is the filtering and wrapping state of a texture.
+type Sampler struct {
+	WrapS, WrapT Wrap
+	MinFilter    Filter
+	MagFilter    Filter
+	// Mipmaps generates the mip chain on upload and filters between levels.
+	Mipmaps bool
+	// Anisotropy is the maximum anisotropic filtering ratio; 0 or 1 is off.
+	// It is clamped to what the driver supports.
+	Anisotropy float32
+}
+
+var glWrap = map[Wrap]int32{
+	Repeat:         gl.REPEAT,
+	ClampToEdge:    gl.CLAMP_TO_EDGE,
+	MirroredRepeat: gl.MIRRORED_REPEAT,
+}
+
+func glFilter(f Filter, mipmaps bool) int32 {
+	switch {
+	case f == Nearest && mipmaps:
+		return gl.NEAREST_MIPMAP_LINEAR
+	case f == Nearest:
+		return gl.NEAREST
+	case mipmaps:
+		return gl.LINEAR_MIPMAP_LINEAR
+	}
+	return gl.LINEAR
+}
+
+// glParams are the texture parameters Upload sets, apart so they can be
+// checked without a context.
+type glParams struct {
+	internalFormat       int32
+	wrapS, wrapT         int32
+	minFilter, magFilter int32
+}
+
+func params(m *Image, s Sampler) glParams {
+	p := glParams{
+		internalFormat: gl.RGBA8,
+		wrapS:          glWrap[s.WrapS],
+		wrapT:          glWrap[s.WrapT],
+		minFilter:      glFilter(s.MinFilter, s.Mipmaps),
+		magFilter:      glFilter(s.MagFilter, false),
+	}
+	if m.SRGB {
+		p.internalFormat = gl.SRGB8_ALPHA8
+	}
+	return p
+}
+
+// Upload creates a GL texture from m and returns its name. With
+// Sampler.Mipmaps the driver generates the mip chain. It must run on the
+// thread owning the context.
+func Upload(m *Image, s Sampler) (uint32, error) {
+	return UploadLevels([]*Image{m}, s)
+}
+
+// UploadLevels is Upload with the mip chain given, as Image.Mipmaps builds
+// it. More than one level implies mipmapped filtering and nothing is
+// generated. The unpack alignment and the TEXTURE_2D binding are restored
+// to what they were before the call.
+func UploadLevels(levels []*Image, s Sampler) (uint32, error) {
+	if len(levels) == 0 {
+		return 0, errors.New("texture: no levels")
+	}
+	m := levels[0]
+	for i, l := range levels {
+		if err := l.Validate(); err != nil {
+			return 0, fmt.Errorf("level %d: %w", i, err)
+		}
+		if w, h := MipSize(m.Width, m.Height, i); l.Width != w || l.Height != h {
+			return 0, fmt.Errorf("texture: level %d is %dx%d, want %dx%d", i, l.Width, l.Height, w, h)
+		}
+		if l.SRGB != m.SRGB {
+			return 0, fmt.Errorf("texture: level %d differs in color space", i)
+		}
+	}
+	generate := s.Mipmaps && len(levels) == 1
+	s.Mipmaps = s.Mipmaps || len(levels) > 1
+	p := params(m, s)
+
+	restore := keepUnpack(gl.TEXTURE_2D)
+	var id uint32
+	gl.GenTextures(1, &id)
+	gl.BindTexture(gl.TEXTURE_2D, id)
+	setParams(gl.TEXTURE_2D, p, s.Anisotropy)
+
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	for i, l := range levels {
+		gl.TexImage2D(gl.TEXTURE_2D, int32(i), p.internalFormat, int32(l.Width), int32(l.Height), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(l.Pix))
+	}
+	if generate {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	} else if s.Mipmaps {
+		// a partial chain must not sample missing levels
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, int32(len(levels)-1))
+	}
+	restore()
+	return id, nil
+}
+
+// FloatFormat is the internal format of a float texture.
+type FloatFormat int
+
+const (
+	// RGBA16F halves the memory and covers what displays need; it tops out
+	// at 65504.
+	RGBA16F FloatFormat = iota
+	RGBA32F
+)
+
+func floatParams(f FloatFormat, s Sampler) glParams {
+	p := params(&Image{}, s)
+	p.internalFormat = gl.RGBA16F
+	if f == RGBA32F {
+		p.internalFormat = gl.RGBA32F
+	}
+	return p
+}
+
+// UploadFloat creates a GL texture from m and returns its name. The floats
+// are converted to f by the driver. With Sampler.Mipmaps the driver
+// generates the mip chain. The unpack alignment and the TEXTURE_2D binding
+// are restored. It must run on the thread owning the context.
+func UploadFloat(m *FloatImage, f FloatFormat, s Sampler) (uint32, error) {
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
+	p := floatParams(f, s)
+
+	restore := keepUnpack(gl.TEXTURE_2D)
+	var id uint32
+	gl.GenTextures(1, &id)
+	gl.BindTexture(gl.TEXTURE_2D, id)
+	setParams(gl.TEXTURE_2D, p, s.Anisotropy)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, p.internalFormat, int32(m.Width), int32(m.Height), 0, gl.RGBA, gl.FLOAT, gl.Ptr(m.Pix))
+	if s.Mipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
+	restore()
+	if e := gl.GetError(); e != gl.NO_ERROR {
+		gl.DeleteTextures(1, &id)
+		return 0, fmt.Errorf("texture: uploading float texture: GL error 0x%x", e)
+	}
+	return id, nil
+}
+
+// UploadFloatCube creates a GL cube map from the faces of each mip level,
+// in the order of GL's TEXTURE_CUBE_MAP_POSITIVE_X and on, and returns its
+// name. Levels are square and halve as MipSize does; with Sampler.Mipmaps
+// and a single level the driver generates the chain. The faces clamp to
+// their edges. The unpack alignment and the TEXTURE_CUBE_MAP binding are
+// restored. It must run on the thread owning the context.
+func UploadFloatCube(levels [][6]*FloatImage, f FloatFormat, s Sampler) (uint32, error) {
+	if len(levels) == 0 {
+		return 0, errors.New("texture: no levels")
+	}
+	size := levels[0][0].Width
+	for i, l := range levels {
+		want, _ := MipSize(size, size, i)
+		for j, m := range l {
+			if m == nil {
+				return 0, fmt.Errorf("texture: level %d face %d is missing", i, j)
+			}
+			if err := m.Validate(); err != nil {
+				return 0, fmt.Errorf("level %d face %d: %w", i, j, err)
+			}
+			if m.Width != want || m.Height != want {
+				return 0, fmt.Errorf("texture: level %d face %d is %dx%d, want %dx%d", i, j, m.Width, m.Height, want, want)
+			}
+		}
+	}
+	generate := s.Mipmaps && len(levels) == 1
+	s.Mipmaps = s.Mipmaps || len(levels) > 1
+	s.WrapS, s.WrapT = ClampToEdge, ClampToEdge
+	p := floatParams(f, s)
+
+	restore := keepUnpack(gl.TEXTURE_CUBE_MAP)
+	var id uint32
+	gl.GenTextures(1, &id)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, id)
+	setParams(gl.TEXTURE_CUBE_MAP, p, s.Anisotropy)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	for i, l := range levels {
+		for j, m := range l {
+			gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(j), int32(i), p.internalFormat, int32(m.Width), int32(m.Height), 0, gl.RGBA, gl.FLOAT, gl.Ptr(m.Pix))
+		}
+	}
+	if generate {
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	} else if s.Mipmaps {
+		gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAX_LEVEL, int32(len(levels)-1))
+	}
+	restore()
+	if e := gl.GetError(); e != gl.NO_ERROR {
+		gl.DeleteTextures(1, &id)
+		return 0, fmt.Errorf("texture: uploading float cube map: GL error 0x%x", e)
+	}
+	return id, nil
+}
+
+// setParams sets the sampler state of the texture bound to target.
+func setParams(target uint32, p glParams, anisotropy float32) {
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_S, p.wrapS)
+	gl.TexParameteri(target, gl.TEXTURE_WRAP_T, p.wrapT)
+	gl.TexParameteri(target, gl.TEXTURE_MIN_FILTER, p.minFilter)
+	gl.TexParameteri(target, gl.TEXTURE_MAG_FILTER, p.magFilter)
+	if anisotropy > 1 {
+		// core only since 4.6; without the extension the query fails and
+		// max stays 0
+		var max float32
+		gl.GetFloatv(gl.MAX_TEXTURE_MAX_ANISOTROPY, &max)
+		if max > 1 {
+			if anisotropy < max {
+				max = anisotropy
+			}
+			gl.TexParameterf(target, gl.TEXTURE_MAX_ANISOTROPY, max)
+		}
+		gl.GetError()
+	}
+}
+
+// bindingQuery is the GetIntegerv name of the texture bound to each target.
+var bindingQuery = map[uint32]uint32{
+	gl.TEXTURE_2D:       gl.TEXTURE_BINDING_2D,
+	gl.TEXTURE_2D_ARRAY: gl.TEXTURE_BINDING_2D_ARRAY,
+	gl.TEXTURE_CUBE_MAP: gl.TEXTURE_BINDING_CUBE_MAP,
+}
+
+// keepUnpack saves the unpack alignment and the texture bound to target
+// and returns a func that puts both back.
+func keepUnpack(target uint32) func() {
+	var align, bound int32
+	gl.GetIntegerv(gl.UNPACK_ALIGNMENT, &align)
+	gl.GetIntegerv(bindingQuery[target], &bound)
+	return func() {
+		gl.PixelStorei(gl.UNPACK_ALIGNMENT, align)
+		gl.BindTexture(target, uint32(bound))
+	}
+}
+
+// Supported reports whether the current context samples f natively, so
+// UploadCompressed need not decode it. It must run on the thread owning the
+// context.
+func Supported(f Format, srgb bool) bool {
+	var major, minor, n int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &n)
+	exts := make(map[string]bool, n)
+	for i := uint32(0); i < uint32(n); i++ {
+		exts[gl.GoStr(gl.GetStringi(gl.EXTENSIONS, i))] = true
+	}
+	return supported(f, srgb, int(10*major+minor), exts)
+}
+
+// supported decides Supported for a GL version, 33 for 3.3, and the
+// extensions of the context.
+func supported(f Format, srgb bool, version int, exts map[string]bool) bool {
+	switch f {
+	case FormatRGBA8, FormatBGRA8, FormatBC4, FormatBC4S, FormatBC5, FormatBC5S:
+		// RGTC is core since 3.0
+		return true
+	case FormatBC1, FormatBC1A, FormatBC2, FormatBC3:
+		if !exts["GL_EXT_texture_compression_s3tc"] {
+			return false
+		}
+		return !srgb || exts["GL_EXT_texture_sRGB"] || exts["GL_EXT_texture_compression_s3tc_srgb"]
+	case FormatBC6H, FormatBC6HS, FormatBC7:
+		return version >= 42 || exts["GL_ARB_texture_compression_bptc"]
+	case FormatETC2, FormatETC2A1, FormatETC2A, FormatEACR11, FormatEACR11S, FormatEACRG11, FormatEACRG11S:
+		return version >= 43 || exts["GL_ARB_ES3_compatibility"]
+	}
+	return false
+}
+
+// compressedUpload is how UploadCompressed creates a texture, apart so it
+// can be checked without a context.
+type compressedUpload struct {
+	target uint32
+	params glParams
+	// compressed uploads the blocks as they are; otherwise the pixels are
+	// in format and, unless native, decoded to RGBA first.
+	compressed, native bool
+	internalFormat     uint32
+	format             uint32
+	generate           bool
+}
+
+func planCompressed(c *Compressed, native bool, s Sampler) (compressedUpload, error) {
+	if err := c.Validate(); err != nil {
+		return compressedUpload{}, err
+	}
+	if c.Faces == 6 && c.Layers > 1 {
+		return compressedUpload{}, errors.New("texture: cube map arrays need GL 4.0")
+	}
+	info := formats[c.Format]
+	u := compressedUpload{target: gl.TEXTURE_2D, native: native, internalFormat: info.gl, format: gl.RGBA}
+	if c.SRGB {
+		u.internalFormat = info.glSRGB
+	}
+	switch {
+	case c.Faces == 6:
+		u.target = gl.TEXTURE_CUBE_MAP
+		s.WrapS, s.WrapT = ClampToEdge, ClampToEdge
+	case c.Layers > 1:
+		u.target = gl.TEXTURE_2D_ARRAY
+	}
+
+	// the driver cannot generate the levels of a compressed texture
+	u.generate = s.Mipmaps && len(c.Levels) == 1
+	s.Mipmaps = s.Mipmaps || len(c.Levels) > 1
+	u.compressed = native && info.block > 1 && !u.generate
+	switch {
+	case u.compressed:
+	case native && c.Format == FormatBGRA8:
+		u.format = gl.BGRA
+	case native && c.Format == FormatRGBA8:
+	default:
+		u.native = false
+		u.internalFormat = gl.RGBA8
+		if c.SRGB {
+			u.internalFormat = gl.SRGB8_ALPHA8
+		}
+	}
+	u.params = params(&Image{SRGB: c.SRGB}, s)
+	u.params.internalFormat = int32(u.internalFormat)
+	return u, nil
+}
+
+// UploadCompressed creates a GL texture from c and returns its name: a
+// TEXTURE_CUBE_MAP for six faces, a TEXTURE_2D_ARRAY for several layers and
+// a TEXTURE_2D otherwise. Formats the driver lacks are decoded on the CPU
+// and uploaded as RGBA8, BC6H clamped to [0, 1]. With Sampler.Mipmaps and a
+// single level the chain is generated from decoded pixels. Cube maps clamp
+// to their edges. The unpack alignment and the binding of the target are
+// restored. It must run on the thread owning the context.
+func UploadCompressed(c *Compressed, s Sampler) (uint32, error) {
+	u, err := planCompressed(c, Supported(c.Format, c.SRGB), s)
+	if err != nil {
+		return 0, err
+	}
+
+	// the image data of each level, one per layer or face
+	levels := c.Levels
+	if !u.compressed && !u.native {
+		levels = make([][][]byte, len(c.Levels))
+		for i, l := range c.Levels {
+			for j := range l {
+				m, err := c.Decode(i, j/c.Faces, j%c.Faces)
+				if err != nil {
+					return 0, err
+				}
+				levels[i] = append(levels[i], m.Pix)
+			}
+		}
+	}
+
+	restore := keepUnpack(u.target)
+	var id uint32
+	gl.GenTextures(1, &id)
+	gl.BindTexture(u.target, id)
+	setParams(u.target, u.params, s.Anisotropy)
+	if u.target == gl.TEXTURE_CUBE_MAP {
+		gl.TexParameteri(u.target, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	}
+
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	for i, l := range levels {
+		w, h := MipSize(c.Width, c.Height, i)
+		switch u.target {
+		case gl.TEXTURE_2D_ARRAY:
+			data := bytes.Join(l, nil)
+			if u.compressed {
+				gl.CompressedTexImage3D(u.target, int32(i), u.internalFormat, int32(w), int32(h), int32(c.Layers), 0, int32(len(data)), gl.Ptr(data))
+			} else {
+				gl.TexImage3D(u.target, int32(i), int32(u.internalFormat), int32(w), int32(h), int32(c.Layers), 0, u.format, gl.UNSIGNED_BYTE, gl.Ptr(data))
+			}
+		default:
+			for j, data := range l {
+				target := u.target
+				if target == gl.TEXTURE_CUBE_MAP {
+					target = gl.TEXTURE_CUBE_MAP_POSITIVE_X + uint32(j)
+				}
+				if u.compressed {
+					gl.CompressedTexImage2D(target, int32(i), u.internalFormat, int32(w), int32(h), 0, int32(len(data)), gl.Ptr(data))
+				} else {
+					gl.TexImage2D(target, int32(i), int32(u.internalFormat), int32(w), int32(h), 0, u.format, gl.UNSIGNED_BYTE, gl.Ptr(data))
+				}
+			}
+		}
+	}
+	if u.generate {
+		gl.GenerateMipmap(u.target)
+	} else if len(levels) > 1 {
+		gl.TexParameteri(u.target, gl.TEXTURE_MAX_LEVEL, int32(len(levels)-1))
+	}
+	restore()
+	if e := gl.GetError(); e != gl.NO_ERROR {
+		gl.DeleteTextures(1, &id)
+		return 0, fmt.Errorf("texture: uploading %v: GL error 0x%x", c.Format, e)
+	}
+	return id, nil
+}
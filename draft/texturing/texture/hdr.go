@@ -0,0 +1,173 @@
+package texture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	m32 "github.com/chewxy/math32"
+)
+
+// hdrMagic starts both "#?RADIANCE" and "#?RGBE" headers.
+var hdrMagic = []byte("#?")
+
+// DecodeHDR reads a Radiance RGBE file, flat or run-length encoded. Only
+// the usual resolution strings, -Y h +X w and the bottom-up +Y h +X w, are
+// supported; XYZE color and EXPOSURE lines are not applied. Alpha is 1.
+func DecodeHDR(data []byte) (*FloatImage, error) {
+	if !bytes.HasPrefix(data, hdrMagic) {
+		return nil, errors.New("hdr: bad magic")
+	}
+	line := func() (string, bool) {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return "", false
+		}
+		s := string(data[:i])
+		data = data[i+1:]
+		return s, true
+	}
+
+	// header lines end with an empty one
+	for {
+		s, ok := line()
+		if !ok {
+			return nil, errors.New("hdr: header is truncated")
+		}
+		if s == "" {
+			break
+		}
+		if strings.HasPrefix(s, "FORMAT=") && s != "FORMAT=32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("hdr: unsupported format %q", strings.TrimPrefix(s, "FORMAT="))
+		}
+	}
+	s, ok := line()
+	if !ok {
+		return nil, errors.New("hdr: missing resolution")
+	}
+	var w, h int
+	var flip bool
+	var y, x string
+	if _, err := fmt.Sscanf(s, "%s %d %s %d", &y, &h, &x, &w); err != nil {
+		return nil, fmt.Errorf("hdr: resolution %q: %w", s, err)
+	}
+	switch {
+	case y == "-Y" && x == "+X":
+	case y == "+Y" && x == "+X":
+		flip = true
+	default:
+		return nil, fmt.Errorf("hdr: unsupported orientation %q", s)
+	}
+	if w <= 0 || w > 1<<16 || h <= 0 || h > 1<<16 {
+		return nil, fmt.Errorf("hdr: bad size %dx%d", w, h)
+	}
+
+	// every row takes 4 bytes at least, but old style runs can repeat a
+	// pixel across a whole row: the pixels grow with the rows decoded
+	// rather than with the size the header claims
+	if len(data) < 4*h {
+		return nil, fmt.Errorf("hdr: %d bytes cannot hold %d rows", len(data), h)
+	}
+	capacity := 4 * w * h
+	if c := 4 * len(data); c < capacity {
+		capacity = c
+	}
+	m := &FloatImage{Width: w, Height: h, Pix: make([]float32, 0, capacity)}
+	row := make([]byte, 4*w)
+	for y := 0; y < h; y++ {
+		var err error
+		if data, err = readRGBE(data, row); err != nil {
+			return nil, fmt.Errorf("hdr: row %d: %w", y, err)
+		}
+		for x := 0; x < w; x++ {
+			p := row[4*x:]
+			r, g, b := rgbe(p[0], p[1], p[2], p[3])
+			m.Pix = append(m.Pix, r, g, b, 1)
+		}
+	}
+	if flip {
+		m.FlipY()
+	}
+	return m, nil
+}
+
+// rgbe decodes a pixel as Radiance does, to the middle of its range.
+func rgbe(r, g, b, e byte) (float32, float32, float32) {
+	if e == 0 {
+		return 0, 0, 0
+	}
+	f := m32.Ldexp(1, int(e)-(128+8))
+	return (float32(r) + .5) * f, (float32(g) + .5) * f, (float32(b) + .5) * f
+}
+
+// readRGBE reads the scanline row is sized for and returns the rest of data.
+// Rows from 8 to 32767 pixels wide may be run-length encoded per channel;
+// others are flat, possibly with the old repeat-the-last-pixel runs.
+func readRGBE(data, row []byte) ([]byte, error) {
+	w := len(row) / 4
+	if w >= 8 && w < 0x8000 && len(data) >= 4 && data[0] == 2 && data[1] == 2 && data[2]&0x80 == 0 {
+		if int(data[2])<<8|int(data[3]) != w {
+			return nil, errors.New("run-length encoded row of the wrong width")
+		}
+		data = data[4:]
+		for c := 0; c < 4; c++ {
+			for x := 0; x < w; {
+				if len(data) == 0 {
+					return nil, errors.New("truncated")
+				}
+				n := int(data[0])
+				run := n > 128
+				if run {
+					n -= 128
+				}
+				if n == 0 || x+n > w {
+					return nil, errors.New("run overflows the row")
+				}
+				switch {
+				case run && len(data) >= 2:
+					for i := 0; i < n; i++ {
+						row[4*(x+i)+c] = data[1]
+					}
+					data = data[2:]
+				case !run && len(data) >= 1+n:
+					for i := 0; i < n; i++ {
+						row[4*(x+i)+c] = data[1+i]
+					}
+					data = data[1+n:]
+				default:
+					return nil, errors.New("truncated")
+				}
+				x += n
+			}
+		}
+		return data, nil
+	}
+
+	shift := 0
+	for x := 0; x < w; {
+		if len(data) < 4 {
+			return nil, errors.New("truncated")
+		}
+		p := data[:4]
+		data = data[4:]
+		if p[0] == 1 && p[1] == 1 && p[2] == 1 {
+			// repeat the previous pixel, counts in successive runs
+			// being higher digits
+			n := int(p[3]) << shift
+			if x == 0 || x+n > w {
+				return nil, errors.New("run overflows the row")
+			}
+			for i := 0; i < n; i++ {
+				copy(row[4*(x+i):], row[4*(x-1):4*x])
+			}
+			x += n
+			shift += 8
+			continue
+		}
+		copy(row[4*x:], p)
+		x++
+		shift = 0
+	}
+	return data, nil
+}
@@ -0,0 +1,202 @@
+package texture
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var ktx2Magic = []byte{0xab, 'K', 'T', 'X', ' ', '2', '0', 0xbb, '\r', '\n', 0x1a, '\n'}
+
+// formatSpace is a format with its color space.
+type formatSpace struct {
+	format Format
+	srgb   bool
+}
+
+// vkFormats are the VkFormat values KTX2 stores.
+var vkFormats = map[uint32]formatSpace{
+	37:  {FormatRGBA8, false},
+	43:  {FormatRGBA8, true},
+	44:  {FormatBGRA8, false},
+	50:  {FormatBGRA8, true},
+	131: {FormatBC1, false},
+	132: {FormatBC1, true},
+	133: {FormatBC1A, false},
+	134: {FormatBC1A, true},
+	135: {FormatBC2, false},
+	136: {FormatBC2, true},
+	137: {FormatBC3, false},
+	138: {FormatBC3, true},
+	139: {FormatBC4, false},
+	140: {FormatBC4S, false},
+	141: {FormatBC5, false},
+	142: {FormatBC5S, false},
+	143: {FormatBC6H, false},
+	144: {FormatBC6HS, false},
+	145: {FormatBC7, false},
+	146: {FormatBC7, true},
+	147: {FormatETC2, false},
+	148: {FormatETC2, true},
+	149: {FormatETC2A1, false},
+	150: {FormatETC2A1, true},
+	151: {FormatETC2A, false},
+	152: {FormatETC2A, true},
+	153: {FormatEACR11, false},
+	154: {FormatEACR11S, false},
+	155: {FormatEACRG11, false},
+	156: {FormatEACRG11S, false},
+}
+
+// KTX2 supercompression schemes.
+const (
+	ktx2None = 0
+	ktx2Zlib = 3
+)
+
+type ktx2Header struct {
+	VkFormat               uint32
+	TypeSize               uint32
+	PixelWidth             uint32
+	PixelHeight            uint32
+	PixelDepth             uint32
+	LayerCount             uint32
+	FaceCount              uint32
+	LevelCount             uint32
+	SupercompressionScheme uint32
+	DFDByteOffset          uint32
+	DFDByteLength          uint32
+	KVDByteOffset          uint32
+	KVDByteLength          uint32
+	SGDByteOffset          uint64
+	SGDByteLength          uint64
+}
+
+type ktx2Level struct {
+	ByteOffset             uint64
+	ByteLength             uint64
+	UncompressedByteLength uint64
+}
+
+// DecodeKTX2 reads a KTX2 file. Levels may be zlib supercompressed; Basis
+// Universal and Zstandard are not supported, nor are 3D textures.
+func DecodeKTX2(data []byte) (*Compressed, error) {
+	if !bytes.HasPrefix(data, ktx2Magic) {
+		return nil, errors.New("ktx2: bad magic")
+	}
+	r := bytes.NewReader(data[len(ktx2Magic):])
+	var h ktx2Header
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("ktx2: header: %w", err)
+	}
+
+	fs, ok := vkFormats[h.VkFormat]
+	switch {
+	case h.VkFormat == 0:
+		return nil, errors.New("ktx2: Basis Universal textures are not supported")
+	case !ok:
+		return nil, fmt.Errorf("ktx2: unsupported VkFormat %d", h.VkFormat)
+	case h.PixelDepth > 1:
+		return nil, errors.New("ktx2: 3D textures are not supported")
+	case h.SupercompressionScheme != ktx2None && h.SupercompressionScheme != ktx2Zlib:
+		return nil, fmt.Errorf("ktx2: unsupported supercompression scheme %d", h.SupercompressionScheme)
+	case h.PixelWidth == 0 || h.PixelWidth > 1<<16 || h.PixelHeight > 1<<16 || h.LayerCount > 1<<12 || h.LevelCount > 32:
+		return nil, fmt.Errorf("ktx2: bad size %dx%d, %d layers, %d levels", h.PixelWidth, h.PixelHeight, h.LayerCount, h.LevelCount)
+	case h.FaceCount != 1 && h.FaceCount != 6:
+		return nil, fmt.Errorf("ktx2: %d faces", h.FaceCount)
+	}
+
+	c := &Compressed{
+		Format: fs.format,
+		SRGB:   fs.srgb,
+		Width:  int(h.PixelWidth),
+		Height: int(h.PixelHeight),
+		Layers: int(h.LayerCount),
+		Faces:  int(h.FaceCount),
+	}
+	// zero height is a 1D texture, zero layers not an array, zero levels a
+	// request to generate the mip chain
+	if c.Height == 0 {
+		c.Height = 1
+	}
+	if c.Layers == 0 {
+		c.Layers = 1
+	}
+	levels := int(h.LevelCount)
+	if levels == 0 {
+		levels = 1
+	}
+
+	index := make([]ktx2Level, levels)
+	if err := binary.Read(r, binary.LittleEndian, index); err != nil {
+		return nil, fmt.Errorf("ktx2: level index: %w", err)
+	}
+	n := c.Layers * c.Faces
+	for i, l := range index {
+		if l.ByteOffset > uint64(len(data)) || l.ByteLength > uint64(len(data))-l.ByteOffset {
+			return nil, fmt.Errorf("ktx2: level %d lies outside the file", i)
+		}
+		// the size the level must have is known before reading it, so a
+		// small zlib stream cannot claim a large one
+		size := c.Format.ImageSize(MipSize(c.Width, c.Height, i))
+		want, ok := mulSize(n, size)
+		if !ok {
+			return nil, fmt.Errorf("ktx2: level %d of %d images of %d bytes is too large", i, n, size)
+		}
+		level := data[l.ByteOffset : l.ByteOffset+l.ByteLength]
+		if h.SupercompressionScheme == ktx2Zlib {
+			if l.UncompressedByteLength != uint64(want) || l.UncompressedByteLength > maxInflateRatio*l.ByteLength {
+				return nil, fmt.Errorf("ktx2: level %d inflates %d bytes to %d, want %d", i, l.ByteLength, l.UncompressedByteLength, want)
+			}
+			var err error
+			if level, err = inflate(level, l.UncompressedByteLength); err != nil {
+				return nil, fmt.Errorf("ktx2: level %d: %w", i, err)
+			}
+		}
+		if len(level) != want {
+			return nil, fmt.Errorf("ktx2: level %d has %d bytes, want %d", i, len(level), want)
+		}
+		// layers, then faces
+		images := make([][]byte, n)
+		for j := range images {
+			images[j] = level[j*size : (j+1)*size]
+		}
+		c.Levels = append(c.Levels, images)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// maxInflateRatio is the most deflate expands its input.
+const maxInflateRatio = 1032
+
+// mulSize multiplies two sizes, reporting whether the product fits an int.
+func mulSize(a, b int) (int, bool) {
+	if a < 0 || b < 0 || a != 0 && b > math.MaxInt/a {
+		return 0, false
+	}
+	return a * b, true
+}
+
+func inflate(data []byte, size uint64) ([]byte, error) {
+	z, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	out, err := io.ReadAll(io.LimitReader(z, int64(size)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(out)) != size {
+		return nil, fmt.Errorf("inflated %d bytes, want %d", len(out), size)
+	}
+	return out, nil
+}
@@ -0,0 +1,137 @@
+package texture
+
+import (
+	"image"
+)
+
+// MipOptions control Mipmaps.
+type MipOptions struct {
+	ResampleOptions
+	// AlphaCutoff, when above zero, is the alpha test threshold of a cutout
+	// texture. The alpha of every level is scaled so the same fraction of
+	// texels passes the test as in level 0, which keeps foliage and fences
+	// from thinning out in the distance.
+	AlphaCutoff float32
+}
+
+// MipSize is the size of level n of a width x height image, halving and
+// rounding down as GL does, and never below 1.
+func MipSize(width, height, n int) (int, int) {
+	w, h := width>>n, height>>n
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// MipLevels is the length of the full mip chain of a width x height image.
+func MipLevels(width, height int) int {
+	n := 1
+	for width > 1 || height > 1 {
+		width, height = width/2, height/2
+		n++
+	}
+	return n
+}
+
+// Mipmaps returns the full mip chain of img down to 1x1, starting with a
+// copy of img. Every level is filtered from the one above it without
+// rounding to 8 bits in between, so sizes need not be powers of two.
+func Mipmaps(img *image.RGBA, o MipOptions) []*image.RGBA {
+	p := toPlanes(img, o.ResampleOptions)
+	var target float32
+	if o.AlphaCutoff > 0 {
+		target = p.coverage(o.AlphaCutoff, 1)
+	}
+
+	n := MipLevels(p.w, p.h)
+	levels := make([]*image.RGBA, 0, n)
+	levels = append(levels, p.toRGBA(o.ResampleOptions))
+	for i := 1; i < n; i++ {
+		w, h := MipSize(p.w, p.h, 1)
+		p = p.resize(w, h, o.ResampleOptions)
+		out := p
+		if o.AlphaCutoff > 0 {
+			out = p.scaleAlpha(p.coverageScale(o.AlphaCutoff, target))
+		}
+		levels = append(levels, out.toRGBA(o.ResampleOptions))
+	}
+	return levels
+}
+
+// Mipmaps builds the mip chain of m with the color space and alpha of m.
+func (m *Image) Mipmaps(k Kernel, wrap Wrap, alphaCutoff float32) []*Image {
+	o := MipOptions{
+		ResampleOptions: ResampleOptions{Kernel: k, SRGB: m.SRGB, Premultiplied: m.Premultiplied, Wrap: wrap},
+		AlphaCutoff:     alphaCutoff,
+	}
+	var out []*Image
+	for _, l := range Mipmaps(m.RGBA(), o) {
+		// levels are freshly allocated and tightly packed
+		out = append(out, &Image{Width: l.Rect.Dx(), Height: l.Rect.Dy(), Pix: l.Pix, SRGB: m.SRGB, Premultiplied: m.Premultiplied})
+	}
+	return out
+}
+
+// Coverage is the fraction of the pixels of img with an alpha above cutoff.
+func Coverage(img *image.RGBA, cutoff float32) float32 {
+	return toPlanes(img, ResampleOptions{}).coverage(cutoff, 1)
+}
+
+// coverage is the fraction of pixels with alpha * scale above cutoff.
+func (p planes) coverage(cutoff, scale float32) float32 {
+	if p.w*p.h == 0 {
+		return 0
+	}
+	n := 0
+	for i := 3; i < len(p.pix); i += 4 {
+		// compare the alpha as it will be stored
+		if float32(Quantize(p.pix[i]*scale))/255 > cutoff {
+			n++
+		}
+	}
+	return float32(n) / float32(p.w*p.h)
+}
+
+// coverageScale finds the alpha scale whose coverage is closest to target.
+// Coverage only grows with the scale, in steps on small levels.
+func (p planes) coverageScale(cutoff, target float32) float32 {
+	lo, hi := float32(0), float32(8)
+	if p.coverage(cutoff, hi) < target {
+		return hi
+	}
+	for i := 0; i < 20; i++ {
+		mid := (lo + hi) / 2
+		if p.coverage(cutoff, mid) < target {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if target-p.coverage(cutoff, lo) < p.coverage(cutoff, hi)-target {
+		return lo
+	}
+	return hi
+}
+
+// scaleAlpha returns a copy with the alpha scaled and clamped to 1, the
+// straight color unchanged.
+func (p planes) scaleAlpha(s float32) planes {
+	q := planes{p.w, p.h, make([]float32, len(p.pix))}
+	for i := 0; i < len(p.pix); i += 4 {
+		a := p.pix[i+3]
+		na := a * s
+		if na > 1 {
+			na = 1
+		}
+		f := float32(0)
+		if a > 0 {
+			f = na / a
+		}
+		q.pix[i], q.pix[i+1], q.pix[i+2], q.pix[i+3] = p.pix[i]*f, p.pix[i+1]*f, p.pix[i+2]*f, na
+	}
+	return q
+}
@@ -0,0 +1,310 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	m32 "github.com/chewxy/math32"
+)
+
+func loadRGBA(t *testing.T, path string) *image.RGBA {
+	t.Helper()
+	m, err := Load(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m.RGBA()
+}
+
+func uniform(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// mean is the average of each channel in [0, 1], color weighted by alpha
+// and averaged in linear light for sRGB.
+func mean(img *image.RGBA, srgb bool) [4]float64 {
+	var sum [4]float64
+	for i := 0; i < len(img.Pix); i += 4 {
+		a := float64(img.Pix[i+3]) / 255
+		for c := 0; c < 3; c++ {
+			v := float64(img.Pix[i+c]) / 255
+			if srgb {
+				v = float64(DecodeSRGB(img.Pix[i+c]))
+			}
+			sum[c] += v * a
+		}
+		sum[3] += a
+	}
+	n := float64(len(img.Pix) / 4)
+	for c := range sum {
+		sum[c] /= n
+	}
+	return sum
+}
+
+func TestMipSize(t *testing.T) {
+	var sizes []string
+	for i := 0; i < MipLevels(5, 3); i++ {
+		w, h := MipSize(5, 3, i)
+		sizes = append(sizes, fmt.Sprintf("%dx%d", w, h))
+	}
+	if got := fmt.Sprint(sizes); got != "[5x3 2x1 1x1]" {
+		t.Errorf("5x3 chain %s", got)
+	}
+	if n := MipLevels(800, 800); n != 10 {
+		t.Errorf("800x800 has %d levels, want 10", n)
+	}
+	if n := MipLevels(1, 1); n != 1 {
+		t.Errorf("1x1 has %d levels", n)
+	}
+}
+
+func TestKernels(t *testing.T) {
+	for _, k := range []Kernel{Box, Kaiser, Lanczos} {
+		if k.At(0) != 1 {
+			t.Errorf("%s(0) = %v", k.Name, k.At(0))
+		}
+		if k.At(k.Support+.01) != 0 || k.At(-k.Support-.01) != 0 {
+			t.Errorf("%s is not zero past its support", k.Name)
+		}
+		// every destination pixel gets weights summing to one, for shrinking,
+		// enlarging and odd ratios alike
+		for _, sizes := range [][2]int{{800, 400}, {25, 12}, {5, 2}, {3, 7}} {
+			for i, cs := range contributions(sizes[0], sizes[1], k, ClampToEdge) {
+				var sum float32
+				for _, c := range cs {
+					sum += c.weight
+					if c.index < 0 || c.index >= sizes[0] {
+						t.Fatalf("%s %v: index %d out of range", k.Name, sizes, c.index)
+					}
+				}
+				if m32.Abs(sum-1) > 1e-5 {
+					t.Errorf("%s %v: weights of %d sum to %v", k.Name, sizes, i, sum)
+				}
+			}
+		}
+	}
+
+	// 5 to 2 with a box: each output covers two and a half inputs
+	cs := contributions(5, 2, Box, ClampToEdge)
+	if got := fmt.Sprint(cs[0]); got != "[{0 0.4} {1 0.4} {2 0.2}]" {
+		t.Errorf("box 5 to 2 = %s", got)
+	}
+}
+
+func TestWrapIndex(t *testing.T) {
+	tests := []struct {
+		w    Wrap
+		want string
+	}{
+		{Repeat, "[2 0 1 2 0]"},
+		{ClampToEdge, "[0 0 1 2 2]"},
+		{MirroredRepeat, "[0 0 1 2 2]"},
+	}
+	for _, tt := range tests {
+		var got []int
+		for i := -1; i <= 3; i++ {
+			got = append(got, wrapIndex(i, 3, tt.w))
+		}
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("wrap %d: %v, want %s", tt.w, got, tt.want)
+		}
+	}
+}
+
+func TestMipmapsAssets(t *testing.T) {
+	for _, path := range []string{"../tex.png", "../lumi.jpg"} {
+		img := loadRGBA(t, path)
+		for _, k := range []Kernel{Box, Kaiser, Lanczos} {
+			for _, srgb := range []bool{false, true} {
+				name := fmt.Sprintf("%s %s srgb %v", path, k.Name, srgb)
+				levels := Mipmaps(img, MipOptions{ResampleOptions: ResampleOptions{Kernel: k, SRGB: srgb}})
+				if len(levels) != MipLevels(img.Rect.Dx(), img.Rect.Dy()) {
+					t.Fatalf("%s: %d levels", name, len(levels))
+				}
+				if !levels[0].Rect.Eq(img.Rect) || string(levels[0].Pix) != string(img.Pix) {
+					t.Errorf("%s: level 0 is not the image", name)
+				}
+
+				// filtering keeps the average, up to rounding to 8 bits
+				const tol = .01
+				want := mean(img, srgb)
+				for i, l := range levels {
+					w, h := MipSize(img.Rect.Dx(), img.Rect.Dy(), i)
+					if l.Rect.Dx() != w || l.Rect.Dy() != h {
+						t.Fatalf("%s: level %d is %v", name, i, l.Rect)
+					}
+					got := mean(l, srgb)
+					for c := range got {
+						if d := got[c] - want[c]; d > tol || d < -tol {
+							t.Errorf("%s: level %d channel %d mean %.3f, want %.3f", name, i, c, got[c], want[c])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMipmapsUniform(t *testing.T) {
+	// a flat color stays flat through every kernel and odd size, with no
+	// ringing at the edges for any wrap mode
+	c := color.RGBA{200, 100, 30, 255}
+	for _, k := range []Kernel{Box, Kaiser, Lanczos} {
+		for _, w := range []Wrap{Repeat, ClampToEdge, MirroredRepeat} {
+			levels := Mipmaps(uniform(13, 7, c), MipOptions{ResampleOptions: ResampleOptions{Kernel: k, SRGB: true, Wrap: w}})
+			for i, l := range levels {
+				for p := 0; p < len(l.Pix); p += 4 {
+					if got := (color.RGBA{l.Pix[p], l.Pix[p+1], l.Pix[p+2], l.Pix[p+3]}); got != c {
+						t.Fatalf("%s wrap %d level %d pixel %d = %v", k.Name, w, i, p/4, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGammaCorrect(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.SetRGBA(0, 0, color.RGBA{255, 255, 255, 255})
+	img.SetRGBA(1, 1, color.RGBA{255, 255, 255, 255})
+	img.SetRGBA(1, 0, color.RGBA{0, 0, 0, 255})
+	img.SetRGBA(0, 1, color.RGBA{0, 0, 0, 255})
+
+	linear := Mipmaps(img, MipOptions{})[1]
+	if got := linear.Pix[0]; got != 128 {
+		t.Errorf("linear average %d, want 128", got)
+	}
+	// half the light of white is 188 in sRGB
+	srgb := Mipmaps(img, MipOptions{ResampleOptions: ResampleOptions{SRGB: true}})[1]
+	if got := srgb.Pix[0]; got != 188 {
+		t.Errorf("sRGB average %d, want 188", got)
+	}
+}
+
+func TestNoColorBleeding(t *testing.T) {
+	// invisible red next to opaque green must not tint the average
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.SetRGBA(0, 0, color.RGBA{255, 0, 0, 0})
+	img.SetRGBA(1, 0, color.RGBA{0, 255, 0, 255})
+	got := Resize(img, 1, 1, ResampleOptions{})
+	if p := got.RGBAAt(0, 0); p != (color.RGBA{0, 255, 0, 128}) {
+		t.Errorf("straight alpha average %v, want pure green at half alpha", p)
+	}
+
+	// premultiplied data is averaged as is
+	img.SetRGBA(0, 0, color.RGBA{0, 0, 0, 0})
+	got = Resize(img, 1, 1, ResampleOptions{Premultiplied: true})
+	if p := got.RGBAAt(0, 0); p != (color.RGBA{0, 128, 0, 128}) {
+		t.Errorf("premultiplied average %v", p)
+	}
+}
+
+// cutout has random alpha, mostly low, like the soft edges of foliage.
+func cutout(size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	rnd := rand.New(rand.NewSource(1))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			u := rnd.Float32()
+			img.SetRGBA(x, y, color.RGBA{40, 160, 40, Quantize(u * u * u)})
+		}
+	}
+	return img
+}
+
+func TestAlphaCoverage(t *testing.T) {
+	const cutoff = .5
+	img := cutout(64)
+	want := Coverage(img, cutoff)
+
+	plain := Mipmaps(img, MipOptions{})
+	kept := Mipmaps(img, MipOptions{AlphaCutoff: cutoff})
+	for i := 1; i < len(kept)-2; i++ {
+		got := Coverage(kept[i], cutoff)
+		if d := got - want; d > .05 || d < -.05 {
+			t.Errorf("level %d coverage %.3f, want %.3f", i, got, want)
+		}
+		// color is left alone
+		for p := 0; p < len(kept[i].Pix); p += 4 {
+			if kept[i].Pix[p+3] > 0 && kept[i].Pix[p+1] != 160 {
+				t.Fatalf("level %d pixel %d color %v", i, p/4, kept[i].Pix[p:p+4])
+			}
+		}
+	}
+	// without it the averages fall under the cutoff
+	if got := Coverage(plain[3], cutoff); got > want/2 {
+		t.Errorf("plain level 3 coverage %.3f, expected to fall well below %.3f", got, want)
+	}
+}
+
+func TestResize(t *testing.T) {
+	img := loadRGBA(t, "../lumi.jpg")
+	b := img.Rect
+
+	same := Resize(img, b.Dx(), b.Dy(), ResampleOptions{Kernel: Lanczos})
+	if string(same.Pix) != string(img.Pix) {
+		t.Error("resizing to the same size changed the pixels")
+	}
+
+	for _, k := range []Kernel{Box, Kaiser, Lanczos} {
+		for _, size := range [][2]int{{b.Dx() / 3, b.Dy() / 5}, {b.Dx()*2 + 1, b.Dy() + 3}, {1, 1}} {
+			out := Resize(img, size[0], size[1], ResampleOptions{Kernel: k, SRGB: true, Wrap: ClampToEdge})
+			if out.Rect.Dx() != size[0] || out.Rect.Dy() != size[1] {
+				t.Fatalf("%s: resized to %v, want %v", k.Name, out.Rect, size)
+			}
+			// clamping repeats the edges under the wide lobes of the sinc
+			// kernels, so only the box averages down to one pixel
+			if size == [2]int{1, 1} && k.Name != Box.Name {
+				continue
+			}
+			got, want := mean(out, true), mean(img, true)
+			for c := 0; c < 3; c++ {
+				if d := got[c] - want[c]; d > .01 || d < -.01 {
+					t.Errorf("%s %v: channel %d mean %.3f, want %.3f", k.Name, size, c, got[c], want[c])
+				}
+			}
+		}
+	}
+
+	// enlarging a 2x1 image with nearest-like box keeps the halves
+	two := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	two.SetRGBA(0, 0, color.RGBA{0, 0, 0, 255})
+	two.SetRGBA(1, 0, color.RGBA{255, 255, 255, 255})
+	wide := Resize(two, 4, 1, ResampleOptions{Wrap: ClampToEdge})
+	if got := fmt.Sprint(wide.Pix[0], wide.Pix[4], wide.Pix[8], wide.Pix[12]); got != "0 0 255 255" {
+		t.Errorf("box enlarge %s", got)
+	}
+}
+
+func TestImageMipmaps(t *testing.T) {
+	m := New(4, 2)
+	m.SRGB = true
+	for i := range m.Pix {
+		m.Pix[i] = 255
+	}
+	levels := m.Mipmaps(Box, Repeat, 0)
+	if len(levels) != 3 {
+		t.Fatalf("%d levels", len(levels))
+	}
+	for i, l := range levels {
+		if err := l.Validate(); err != nil {
+			t.Error(i, err)
+		}
+		if !l.SRGB {
+			t.Errorf("level %d lost the color space", i)
+		}
+	}
+	if levels[2].Width != 1 || levels[2].Height != 1 || levels[2].At(0, 0) != [4]byte{255, 255, 255, 255} {
+		t.Errorf("last level %+v", levels[2])
+	}
+}
@@ -0,0 +1,275 @@
+package texture
+
+import (
+	"image"
+
+	m32 "github.com/chewxy/math32"
+)
+
+// Kernel is a separable resampling filter. At is evaluated in units of
+// destination pixels when shrinking and source pixels when enlarging; it is
+// zero beyond Support.
+type Kernel struct {
+	Name    string
+	Support float32
+	At      func(x float32) float32
+}
+
+var (
+	// Box averages the source pixels under each destination pixel, weighting
+	// partly covered ones by their coverage.
+	Box = Kernel{"box", .5, box}
+	// Kaiser is a windowed sinc with a Kaiser window, alpha 4 and width 3.
+	// It is sharper than Box without the ringing of Lanczos.
+	Kaiser = Kernel{"kaiser", 3, kaiser}
+	// Lanczos is the three lobe Lanczos filter.
+	Lanczos = Kernel{"lanczos3", 3, lanczos}
+)
+
+func box(x float32) float32 {
+	if m32.Abs(x) < .5 {
+		return 1
+	}
+	return 0
+}
+
+func sinc(x float32) float32 {
+	if x == 0 {
+		return 1
+	}
+	x *= m32.Pi
+	return m32.Sin(x) / x
+}
+
+func lanczos(x float32) float32 {
+	if m32.Abs(x) >= 3 {
+		return 0
+	}
+	return sinc(x) * sinc(x/3)
+}
+
+// bessel0 is the modified Bessel function of the first kind of order 0.
+func bessel0(x float32) float32 {
+	sum, term := float32(1), float32(1)
+	for k := float32(1); term > 1e-8*sum; k++ {
+		term *= (x / (2 * k)) * (x / (2 * k))
+		sum += term
+	}
+	return sum
+}
+
+func kaiser(x float32) float32 {
+	const alpha, width = 4, 3
+	t := x / width
+	if t*t >= 1 {
+		return 0
+	}
+	return sinc(x) * bessel0(alpha*m32.Sqrt(1-t*t)) / bessel0(alpha)
+}
+
+// ResampleOptions control Resize and Mipmaps. The zero value is a box
+// filter over linear, straight alpha data that repeats at the edges.
+type ResampleOptions struct {
+	// Kernel defaults to Box.
+	Kernel Kernel
+	// SRGB decodes color from sRGB before filtering and encodes the result,
+	// so averages are taken in linear light.
+	SRGB bool
+	// Premultiplied marks color already multiplied by alpha. Straight alpha
+	// is weighted by alpha while filtering so transparent texels do not
+	// bleed their color.
+	Premultiplied bool
+	// Wrap is how the filter reads past the edges, the wrap mode the texture
+	// is sampled with.
+	Wrap Wrap
+}
+
+func (o ResampleOptions) kernel() Kernel {
+	if o.Kernel.At == nil {
+		return Box
+	}
+	return o.Kernel
+}
+
+// contribution is the weight of one source pixel.
+type contribution struct {
+	index  int
+	weight float32
+}
+
+// wrapIndex maps i into [0, n).
+func wrapIndex(i, n int, w Wrap) int {
+	switch w {
+	case ClampToEdge:
+		if i < 0 {
+			return 0
+		}
+		if i >= n {
+			return n - 1
+		}
+		return i
+	case MirroredRepeat:
+		period := 2 * n
+		i = ((i % period) + period) % period
+		if i >= n {
+			i = period - 1 - i
+		}
+		return i
+	}
+	return ((i % n) + n) % n
+}
+
+// contributions returns, for each of dst pixels, the normalized weights of
+// the src pixels along one axis.
+func contributions(src, dst int, k Kernel, wrap Wrap) [][]contribution {
+	scale := float32(src) / float32(dst)
+	// widen the kernel when shrinking so it covers every source pixel
+	stretch := m32.Max(scale, 1)
+	support := k.Support * stretch
+
+	out := make([][]contribution, dst)
+	for i := range out {
+		center := (float32(i)+.5)*scale - .5
+		lo := int(m32.Floor(center - support))
+		hi := int(m32.Ceil(center + support))
+
+		var sum float32
+		var cs []contribution
+		for j := lo; j <= hi; j++ {
+			w := weight(k, float32(j)-center, stretch)
+			if w == 0 {
+				continue
+			}
+			cs = append(cs, contribution{wrapIndex(j, src, wrap), w})
+			sum += w
+		}
+		if sum == 0 {
+			// a kernel narrower than the pixel spacing when enlarging
+			cs = []contribution{{wrapIndex(int(m32.Round(center)), src, wrap), 1}}
+			sum = 1
+		}
+		for c := range cs {
+			cs[c].weight /= sum
+		}
+		out[i] = cs
+	}
+	return out
+}
+
+// weight is the kernel over the source pixel at offset x from the center.
+// When shrinking, it is averaged over the footprint of the pixel so partly
+// covered pixels count by coverage whatever the ratio; when enlarging it is
+// the kernel at the pixel center.
+func weight(k Kernel, x, stretch float32) float32 {
+	if stretch == 1 {
+		return k.At(x)
+	}
+	const n = 8
+	var w float32
+	for i := 0; i < n; i++ {
+		w += k.At((x - .5 + (float32(i)+.5)/n) / stretch)
+	}
+	return w / n
+}
+
+// planes is an image as float32 RGBA with premultiplied, linear color.
+type planes struct {
+	w, h int
+	pix  []float32
+}
+
+func toPlanes(img *image.RGBA, o ResampleOptions) planes {
+	b := img.Bounds()
+	p := planes{b.Dx(), b.Dy(), make([]float32, 4*b.Dx()*b.Dy())}
+	for y := 0; y < p.h; y++ {
+		for x := 0; x < p.w; x++ {
+			s := img.Pix[img.PixOffset(b.Min.X+x, b.Min.Y+y):]
+			d := p.pix[4*(y*p.w+x):]
+			a := float32(s[3]) / 255
+			for c := 0; c < 3; c++ {
+				v := float32(s[c]) / 255
+				if o.SRGB {
+					v = DecodeSRGB(s[c])
+				}
+				if !o.Premultiplied {
+					v *= a
+				}
+				d[c] = v
+			}
+			d[3] = a
+		}
+	}
+	return p
+}
+
+func (p planes) toRGBA(o ResampleOptions) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, p.w, p.h))
+	for i := 0; i < len(p.pix); i += 4 {
+		s := p.pix[i : i+4]
+		a := m32.Min(m32.Max(s[3], 0), 1)
+		for c := 0; c < 3; c++ {
+			v := s[c]
+			if !o.Premultiplied {
+				if a == 0 {
+					v = 0
+				} else {
+					v /= a
+				}
+			}
+			v = m32.Min(m32.Max(v, 0), 1)
+			if o.SRGB {
+				img.Pix[i+c] = EncodeSRGB(v)
+			} else {
+				img.Pix[i+c] = Quantize(v)
+			}
+		}
+		img.Pix[i+3] = Quantize(a)
+	}
+	return img
+}
+
+// resize filters rows, then columns.
+func (p planes) resize(w, h int, o ResampleOptions) planes {
+	k := o.kernel()
+	if w != p.w {
+		cs := contributions(p.w, w, k, o.Wrap)
+		q := planes{w, p.h, make([]float32, 4*w*p.h)}
+		for y := 0; y < p.h; y++ {
+			row := p.pix[4*y*p.w:]
+			out := q.pix[4*y*w:]
+			for x, c := range cs {
+				var r, g, b, a float32
+				for _, t := range c {
+					s := row[4*t.index:]
+					r += s[0] * t.weight
+					g += s[1] * t.weight
+					b += s[2] * t.weight
+					a += s[3] * t.weight
+				}
+				out[4*x], out[4*x+1], out[4*x+2], out[4*x+3] = r, g, b, a
+			}
+		}
+		p = q
+	}
+	if h != p.h {
+		cs := contributions(p.h, h, k, o.Wrap)
+		q := planes{p.w, h, make([]float32, 4*p.w*h)}
+		for y, c := range cs {
+			out := q.pix[4*y*p.w : 4*(y+1)*p.w]
+			for _, t := range c {
+				row := p.pix[4*t.index*p.w : 4*(t.index+1)*p.w]
+				for i, v := range row {
+					out[i] += v * t.weight
+				}
+			}
+		}
+		p = q
+	}
+	return p
+}
+
+// Resize returns img scaled to width x height. img holds straight alpha
+// unless o says otherwise, as stbi returns it.
+func Resize(img *image.RGBA, width, height int, o ResampleOptions) *image.RGBA {
+	return toPlanes(img, o).resize(width, height, o).toRGBA(o)
+}
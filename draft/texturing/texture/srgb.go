@@ -0,0 +1,51 @@
+package texture
+
+import (
+	m32 "github.com/chewxy/math32"
+)
+
+var srgbToLinear [256]float32
+
+func init() {
+	for i := range srgbToLinear {
+		srgbToLinear[i] = SRGBToLinear(float32(i) / 255)
+	}
+}
+
+// SRGBToLinear decodes an sRGB channel value in [0, 1].
+func SRGBToLinear(c float32) float32 {
+	if c <= .04045 {
+		return c / 12.92
+	}
+	return m32.Pow((c+.055)/1.055, 2.4)
+}
+
+// LinearToSRGB encodes a linear channel value in [0, 1].
+func LinearToSRGB(c float32) float32 {
+	if c <= .0031308 {
+		return c * 12.92
+	}
+	return 1.055*m32.Pow(c, 1/2.4) - .055
+}
+
+// DecodeSRGB returns the linear value of an 8 bit sRGB channel.
+func DecodeSRGB(c byte) float32 {
+	return srgbToLinear[c]
+}
+
+// EncodeSRGB returns the 8 bit sRGB channel for a linear value, clamped to
+// [0, 1].
+func EncodeSRGB(c float32) byte {
+	return Quantize(LinearToSRGB(c))
+}
+
+// Quantize rounds a value in [0, 1] to 8 bits, clamping.
+func Quantize(c float32) byte {
+	switch {
+	case c <= 0:
+		return 0
+	case c >= 1:
+		return 255
+	}
+	return byte(c*255 + .5)
+}
@@ -0,0 +1,193 @@
+// Package texture loads images through stbi, prepares them on the CPU and
+// uploads them as GL textures.
+//
+// Images keep the first row first, so texture coordinate (0, 0) samples the
+// top left of the file as elsewhere in the project. FlipY is for content
+// authored with (0, 0) at the bottom.
+package texture
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+
+	"neilpa.me/go-stbi"
+)
+
+// Image is 8 bit RGBA on the CPU, tightly packed.
+type Image struct {
+	Width, Height int
+	// Pix holds 4 bytes per pixel, rows top to bottom without padding.
+	Pix []byte
+	// SRGB marks sRGB encoded color. It is uploaded as SRGB8_ALPHA8 so
+	// sampling returns linear values; data like normal maps stays linear.
+	SRGB bool
+	// Premultiplied is set when the color channels are multiplied by alpha.
+	Premultiplied bool
+}
+
+func New(width, height int) *Image {
+	return &Image{Width: width, Height: height, Pix: make([]byte, 4*width*height)}
+}
+
+// FromRGBA copies img into a tightly packed image. It fails when the stride
+// is too short for the width, as go-stbi reports it.
+func FromRGBA(img *image.RGBA) (*Image, error) {
+	b := img.Bounds()
+	if img.Stride < 4*b.Dx() {
+		return nil, fmt.Errorf("texture: unsupported stride %d for width %d", img.Stride, b.Dx())
+	}
+	m := New(b.Dx(), b.Dy())
+	for y := 0; y < m.Height; y++ {
+		i := img.PixOffset(b.Min.X, b.Min.Y+y)
+		if i+4*m.Width > len(img.Pix) {
+			return nil, fmt.Errorf("texture: %d bytes of pixels for %dx%d", len(img.Pix), b.Dx(), b.Dy())
+		}
+		copy(m.Pix[4*m.Width*y:], img.Pix[i:i+4*m.Width])
+	}
+	return m, nil
+}
+
+// FromImage converts any image with straight alpha, as files store it.
+func FromImage(img image.Image) *Image {
+	if rgba, ok := img.(*image.RGBA); ok {
+		if m, err := FromRGBA(rgba); err == nil {
+			return m
+		}
+	}
+	b := img.Bounds()
+	m := New(b.Dx(), b.Dy())
+	if n, ok := img.(*image.NRGBA); ok {
+		for y := 0; y < m.Height; y++ {
+			copy(m.Pix[4*m.Width*y:4*m.Width*(y+1)], n.Pix[n.PixOffset(b.Min.X, b.Min.Y+y):])
+		}
+		return m
+	}
+	// draw premultiplies; undo it so the result stays straight
+	n := image.NewNRGBA(image.Rect(0, 0, m.Width, m.Height))
+	draw.Draw(n, n.Rect, img, b.Min, draw.Src)
+	copy(m.Pix, n.Pix)
+	return m
+}
+
+// RGBA returns an image.RGBA sharing the pixels of m.
+func (m *Image) RGBA() *image.RGBA {
+	return &image.RGBA{Pix: m.Pix, Stride: 4 * m.Width, Rect: image.Rect(0, 0, m.Width, m.Height)}
+}
+
+// Validate reports an empty image or pixels that do not match the size.
+func (m *Image) Validate() error {
+	if m.Width <= 0 || m.Height <= 0 {
+		return fmt.Errorf("texture: empty image %dx%d", m.Width, m.Height)
+	}
+	if len(m.Pix) != 4*m.Width*m.Height {
+		return fmt.Errorf("texture: %d bytes of pixels for %dx%d", len(m.Pix), m.Width, m.Height)
+	}
+	return nil
+}
+
+// At returns the pixel at x, y.
+func (m *Image) At(x, y int) [4]byte {
+	i := 4 * (y*m.Width + x)
+	return [4]byte{m.Pix[i], m.Pix[i+1], m.Pix[i+2], m.Pix[i+3]}
+}
+
+func (m *Image) Set(x, y int, p [4]byte) {
+	copy(m.Pix[4*(y*m.Width+x):], p[:])
+}
+
+// FlipY reverses the order of the rows in place.
+func (m *Image) FlipY() {
+	row := 4 * m.Width
+	tmp := make([]byte, row)
+	for top, bottom := 0, m.Height-1; top < bottom; top, bottom = top+1, bottom-1 {
+		a := m.Pix[top*row : (top+1)*row]
+		b := m.Pix[bottom*row : (bottom+1)*row]
+		copy(tmp, a)
+		copy(a, b)
+		copy(b, tmp)
+	}
+}
+
+// Premultiply multiplies the color channels by alpha so filtering does not
+// bleed the color of transparent texels. sRGB color is multiplied in linear
+// space.
+func (m *Image) Premultiply() {
+	if m.Premultiplied {
+		return
+	}
+	for i := 0; i < len(m.Pix); i += 4 {
+		a := m.Pix[i+3]
+		if a == 255 {
+			continue
+		}
+		for c := i; c < i+3; c++ {
+			if m.SRGB {
+				m.Pix[c] = EncodeSRGB(DecodeSRGB(m.Pix[c]) * float32(a) / 255)
+			} else {
+				m.Pix[c] = byte((int(m.Pix[c])*int(a) + 127) / 255)
+			}
+		}
+	}
+	m.Premultiplied = true
+}
+
+// Options describe how a file is prepared after decoding.
+type Options struct {
+	// FlipY puts the last row first.
+	FlipY bool
+	// SRGB marks the file as sRGB encoded color rather than linear data.
+	SRGB bool
+	// Premultiply converts to premultiplied alpha.
+	Premultiply bool
+}
+
+func (o Options) apply(m *Image) *Image {
+	m.SRGB = o.SRGB
+	if o.FlipY {
+		m.FlipY()
+	}
+	if o.Premultiply {
+		m.Premultiply()
+	}
+	return m
+}
+
+// Load decodes a PNG, JPEG, TGA, BMP, PSD, GIF or PNM file through stbi.
+func Load(path string, o Options) (*Image, error) {
+	rgba, err := stbi.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m, err := fromSTBI(rgba)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return o.apply(m), nil
+}
+
+// Decode is Load for a file in memory.
+func Decode(data []byte, o Options) (*Image, error) {
+	rgba, err := stbi.LoadMemory(data)
+	if err != nil {
+		return nil, err
+	}
+	m, err := fromSTBI(rgba)
+	if err != nil {
+		return nil, err
+	}
+	return o.apply(m), nil
+}
+
+// fromSTBI takes over the pixels of a go-stbi image. stbi packs the rows
+// tightly but go-stbi reports a stride of 4 whatever the width, so the
+// stride is checked against the pixel count instead.
+func fromSTBI(rgba *image.RGBA) (*Image, error) {
+	w, h := rgba.Rect.Dx(), rgba.Rect.Dy()
+	if len(rgba.Pix) != 4*w*h {
+		return nil, errors.New("texture: stbi returned rows that are not tightly packed")
+	}
+	rgba.Stride = 4 * w
+	return FromRGBA(rgba)
+}
@@ -0,0 +1,219 @@
+package texture
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestLoad(t *testing.T) {
+	for _, path := range []string{"../tex.png", "../lumi.jpg"} {
+		m, err := Load(path, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Validate(); err != nil {
+			t.Fatal(path, err)
+		}
+
+		// the stdlib decoder agrees with stbi
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != m.Width || b.Dy() != m.Height {
+			t.Fatalf("%s: %dx%d, want %v", path, m.Width, m.Height, b.Size())
+		}
+		if path == "../tex.png" {
+			if want := FromImage(img); !bytes.Equal(want.Pix, m.Pix) {
+				t.Errorf("%s: stbi and image/png pixels differ", path)
+			}
+		}
+	}
+
+	if _, err := Load("../missing.png", Options{}); err == nil {
+		t.Error("loading a missing file did not fail")
+	}
+}
+
+func TestLoadOptions(t *testing.T) {
+	plain, err := Load("../tex.png", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flipped, err := Load("../tex.png", Options{FlipY: true, SRGB: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !flipped.SRGB || plain.SRGB {
+		t.Error("SRGB option not applied")
+	}
+	if plain.At(3, 0) != flipped.At(3, plain.Height-1) || plain.At(0, plain.Height-1) != flipped.At(0, 0) {
+		t.Error("FlipY did not reverse the rows")
+	}
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeStride(t *testing.T) {
+	// go-stbi reports a stride of 4; a width of 3 catches a missing fix
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i := range src.Pix {
+		src.Pix[i] = byte(i)
+	}
+	m, err := Decode(encodePNG(t, src), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(m.Pix, src.Pix) {
+		t.Errorf("pixels %v, want %v", m.Pix, src.Pix)
+	}
+	if r := m.RGBA(); r.Stride != 12 || r.RGBAAt(2, 1) != (color.RGBA{20, 21, 22, 23}) {
+		t.Errorf("RGBA view stride %d pixel %v", r.Stride, r.RGBAAt(2, 1))
+	}
+}
+
+func TestFromRGBA(t *testing.T) {
+	big := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	big.SetRGBA(1, 1, color.RGBA{1, 2, 3, 4})
+	sub := big.SubImage(image.Rect(1, 1, 3, 3)).(*image.RGBA)
+	m, err := FromRGBA(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Width != 2 || m.Height != 2 || m.At(0, 0) != [4]byte{1, 2, 3, 4} {
+		t.Errorf("sub image copied as %dx%d %v", m.Width, m.Height, m.Pix)
+	}
+
+	// the stride go-stbi reports
+	bad := &image.RGBA{Pix: make([]byte, 16), Stride: 4, Rect: image.Rect(0, 0, 2, 2)}
+	if _, err := FromRGBA(bad); err == nil {
+		t.Error("short stride accepted")
+	}
+}
+
+func TestFromImageStraightAlpha(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{200, 100, 50, 128})
+	if got := FromImage(src).At(0, 0); got != [4]byte{200, 100, 50, 128} {
+		t.Errorf("NRGBA: %v", got)
+	}
+	// a paletted image goes through draw
+	pal := image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.NRGBA{200, 100, 50, 128}})
+	if got := FromImage(pal).At(0, 0); got != [4]byte{200, 100, 50, 128} {
+		t.Errorf("paletted: %v", got)
+	}
+}
+
+func TestFlipY(t *testing.T) {
+	m := New(1, 3)
+	for y := 0; y < 3; y++ {
+		m.Set(0, y, [4]byte{byte(y)})
+	}
+	m.FlipY()
+	for y := 0; y < 3; y++ {
+		if got := m.At(0, y)[0]; got != byte(2-y) {
+			t.Errorf("row %d is %d after flip", y, got)
+		}
+	}
+}
+
+func TestPremultiply(t *testing.T) {
+	m := New(3, 1)
+	m.Set(0, 0, [4]byte{255, 128, 0, 128})
+	m.Set(1, 0, [4]byte{255, 255, 255, 0})
+	m.Set(2, 0, [4]byte{10, 20, 30, 255})
+	m.Premultiply()
+	if got := m.At(0, 0); got != [4]byte{128, 64, 0, 128} {
+		t.Errorf("half alpha: %v", got)
+	}
+	if got := m.At(1, 0); got != [4]byte{0, 0, 0, 0} {
+		t.Errorf("transparent: %v", got)
+	}
+	if got := m.At(2, 0); got != [4]byte{10, 20, 30, 255} {
+		t.Errorf("opaque: %v", got)
+	}
+	// a second call does nothing
+	m.Premultiply()
+	if got := m.At(0, 0); got != [4]byte{128, 64, 0, 128} {
+		t.Errorf("premultiplied twice: %v", got)
+	}
+
+	// sRGB white at half alpha is half the light: 0.5 linear encodes to 188
+	s := New(1, 1)
+	s.SRGB = true
+	s.Set(0, 0, [4]byte{255, 255, 255, 128})
+	s.Premultiply()
+	if got := s.At(0, 0); got != [4]byte{188, 188, 188, 128} {
+		t.Errorf("sRGB half alpha: %v", got)
+	}
+}
+
+func TestSRGB(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		if got := EncodeSRGB(DecodeSRGB(byte(i))); got != byte(i) {
+			t.Errorf("round trip of %d gives %d", i, got)
+		}
+	}
+	if DecodeSRGB(0) != 0 || DecodeSRGB(255) != 1 {
+		t.Error("end points")
+	}
+	if EncodeSRGB(-1) != 0 || EncodeSRGB(2) != 255 {
+		t.Error("no clamping")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []*Image{
+		{},
+		{Width: 2, Height: 2, Pix: make([]byte, 15)},
+		{Width: -1, Height: 2},
+	}
+	for _, m := range tests {
+		if err := m.Validate(); err == nil {
+			t.Errorf("%dx%d with %d bytes is valid", m.Width, m.Height, len(m.Pix))
+		}
+	}
+	if err := New(2, 2).Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParams(t *testing.T) {
+	tests := []struct {
+		srgb bool
+		s    Sampler
+		want glParams
+	}{
+		{false, Sampler{}, glParams{gl.RGBA8, gl.REPEAT, gl.REPEAT, gl.LINEAR, gl.LINEAR}},
+		{true, Sampler{Mipmaps: true}, glParams{gl.SRGB8_ALPHA8, gl.REPEAT, gl.REPEAT, gl.LINEAR_MIPMAP_LINEAR, gl.LINEAR}},
+		{false, Sampler{WrapS: ClampToEdge, WrapT: MirroredRepeat, MinFilter: Nearest, MagFilter: Nearest},
+			glParams{gl.RGBA8, gl.CLAMP_TO_EDGE, gl.MIRRORED_REPEAT, gl.NEAREST, gl.NEAREST}},
+		{false, Sampler{MinFilter: Nearest, Mipmaps: true}, glParams{gl.RGBA8, gl.REPEAT, gl.REPEAT, gl.NEAREST_MIPMAP_LINEAR, gl.LINEAR}},
+	}
+	for _, tt := range tests {
+		m := New(1, 1)
+		m.SRGB = tt.srgb
+		if got := params(m, tt.s); got != tt.want {
+			t.Errorf("srgb %v %+v: %+v, want %+v", tt.srgb, tt.s, got, tt.want)
+		}
+	}
+}
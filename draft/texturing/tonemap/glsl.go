@@ -0,0 +1,92 @@
+package tonemap
+
+import (
+	"fmt"
+
+	"github.com/pgeowng/rende/draft/texturing/device"
+)
+
+// Source declares the operators in GLSL for shaders to paste in after their
+// #version line: vec3 tonemap(vec3 c, int op, float ev) matches Map, op
+// being one of the TONEMAP_ defines, and vec3 linearToSRGB(vec3 c) encodes
+// the result.
+var Source = fmt.Sprintf(`#define TONEMAP_REINHARD %d
+#define TONEMAP_ACES_FITTED %d
+#define TONEMAP_EXPOSURE %d
+
+const mat3 acesInput = mat3(
+  0.59719, 0.07600, 0.02840,
+  0.35458, 0.90834, 0.13383,
+  0.04823, 0.01566, 0.83777);
+const mat3 acesOutput = mat3(
+  1.60475, -0.10208, -0.00327,
+  -0.53108, 1.10813, -0.07276,
+  -0.07367, -0.00605, 1.07602);
+
+vec3 tonemapReinhard(vec3 c)
+{
+  return c / (1.0 + c);
+}
+
+vec3 tonemapACESFitted(vec3 c)
+{
+  c = acesInput * c;
+  vec3 a = c * (c + 0.0245786) - 0.000090537;
+  vec3 b = c * (0.983729 * c + 0.4329510) + 0.238081;
+  return clamp(acesOutput * (a / b), 0.0, 1.0);
+}
+
+vec3 tonemapExposure(vec3 c)
+{
+  return 1.0 - exp(-c);
+}
+
+vec3 tonemap(vec3 c, int op, float ev)
+{
+  c = max(c, 0.0) * exp2(ev);
+  if (op == TONEMAP_ACES_FITTED) {
+    return tonemapACESFitted(c);
+  }
+  if (op == TONEMAP_EXPOSURE) {
+    return tonemapExposure(c);
+  }
+  return tonemapReinhard(c);
+}
+
+vec3 linearToSRGB(vec3 c)
+{
+  c = clamp(c, 0.0, 1.0);
+  return mix(c * 12.92, 1.055 * pow(c, vec3(1.0 / 2.4)) - 0.055, step(0.0031308, c));
+}
+`, Reinhard, ACESFitted, Exposure)
+
+// FragmentSource is a full screen pass sampling the HDR color texture at
+// TexCoord, as the vertex shaders of the demos pass it, and writing sRGB
+// encoded color for a framebuffer without GL_FRAMEBUFFER_SRGB. Set its
+// uniforms with Uniforms.
+var FragmentSource = `#version 330 core
+
+in vec2 TexCoord;
+out vec4 FragColor;
+
+uniform sampler2D hdr;
+uniform int tonemapOperator;
+uniform float exposure;
+
+` + Source + `
+void main()
+{
+  vec4 c = texture(hdr, TexCoord);
+  FragColor = vec4(linearToSRGB(tonemap(c.rgb, tonemapOperator, exposure)), clamp(c.a, 0.0, 1.0));
+}
+`
+
+// Uniforms are the values FragmentSource needs besides the hdr texture on
+// unit 0.
+func (o Operator) Uniforms(ev float32) []device.Uniform {
+	return []device.Uniform{
+		{Name: "hdr", Value: int32(0)},
+		{Name: "tonemapOperator", Value: int32(o)},
+		{Name: "exposure", Value: ev},
+	}
+}
@@ -0,0 +1,125 @@
+// Package tonemap maps linear HDR color into [0, 1] for display. The
+// operators run on the CPU, for tests and baking, and as GLSL in the
+// renderer; both compute the same curves.
+package tonemap
+
+import (
+	"fmt"
+
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/texture"
+)
+
+type Operator int
+
+const (
+	// Reinhard is c / (1 + c) per channel.
+	Reinhard Operator = iota
+	// ACESFitted is Stephen Hill's fit of the ACES reference rendering and
+	// output transforms, for sRGB primaries.
+	ACESFitted
+	// Exposure is 1 - exp(-c), which saturates smoothly.
+	Exposure
+)
+
+func (o Operator) String() string {
+	switch o {
+	case Reinhard:
+		return "Reinhard"
+	case ACESFitted:
+		return "ACESFitted"
+	case Exposure:
+		return "Exposure"
+	}
+	return fmt.Sprintf("Operator(%d)", int(o))
+}
+
+// acesInput and acesOutput convert sRGB to the ACES rendering space and
+// back, column-major as glm keeps them.
+var (
+	acesInput = glm.Mat3{
+		.59719, .07600, .02840,
+		.35458, .90834, .13383,
+		.04823, .01566, .83777,
+	}
+	acesOutput = glm.Mat3{
+		1.60475, -.10208, -.00327,
+		-.53108, 1.10813, -.07276,
+		-.07367, -.00605, 1.07602,
+	}
+)
+
+func reinhard(c glm.Vec3) glm.Vec3 {
+	for i, v := range c {
+		c[i] = v / (1 + v)
+	}
+	return c
+}
+
+func acesFitted(c glm.Vec3) glm.Vec3 {
+	c = acesInput.Mulv(c)
+	for i, v := range c {
+		// the RRT and ODT curves
+		a := v*(v+.0245786) - .000090537
+		b := v*(.983729*v+.4329510) + .238081
+		c[i] = a / b
+	}
+	c = acesOutput.Mulv(c)
+	for i, v := range c {
+		c[i] = clamp01(v)
+	}
+	return c
+}
+
+func exposure(c glm.Vec3) glm.Vec3 {
+	for i, v := range c {
+		c[i] = 1 - m32.Exp(-v)
+	}
+	return c
+}
+
+func clamp01(v float32) float32 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	}
+	return v
+}
+
+// Map scales linear color c by 2^ev, ev being the exposure in stops, and
+// maps it into [0, 1]. Negative values are treated as 0.
+func (o Operator) Map(c glm.Vec3, ev float32) glm.Vec3 {
+	scale := m32.Exp2(ev)
+	for i, v := range c {
+		if v < 0 {
+			v = 0
+		}
+		c[i] = v * scale
+	}
+	switch o {
+	case ACESFitted:
+		return acesFitted(c)
+	case Exposure:
+		return exposure(c)
+	}
+	return reinhard(c)
+}
+
+// Image tone maps m into an sRGB encoded 8 bit image, as the renderer's pass
+// writes it. Alpha is clamped and kept straight.
+func (o Operator) Image(m *texture.FloatImage, ev float32) *texture.Image {
+	out := texture.New(m.Width, m.Height)
+	out.SRGB = true
+	for i := 0; i < len(m.Pix); i += 4 {
+		c := o.Map(glm.Vec3{m.Pix[i], m.Pix[i+1], m.Pix[i+2]}, ev)
+		for k, v := range c {
+			out.Pix[i+k] = texture.EncodeSRGB(v)
+		}
+		out.Pix[i+3] = texture.Quantize(m.Pix[i+3])
+	}
+	return out
+}
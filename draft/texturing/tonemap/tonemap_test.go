@@ -0,0 +1,96 @@
+package tonemap
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	m32 "github.com/chewxy/math32"
+
+	"github.com/pgeowng/rende/draft/texturing/glm"
+	"github.com/pgeowng/rende/draft/texturing/texture"
+)
+
+func TestMap(t *testing.T) {
+	tests := []struct {
+		o    Operator
+		c    glm.Vec3
+		ev   float32
+		want glm.Vec3
+	}{
+		{Reinhard, glm.Vec3{1, 3, 0}, 0, glm.Vec3{.5, .75, 0}},
+		{Reinhard, glm.Vec3{1, .5, -4}, 1, glm.Vec3{2. / 3, .5, 0}},
+		{Exposure, glm.Vec3{m32.Ln2, 0, 1}, 0, glm.Vec3{.5, 0, 1 - m32.Exp(-1)}},
+		{Exposure, glm.Vec3{2 * m32.Ln2, 0, 0}, -1, glm.Vec3{.5, 0, 0}},
+		{ACESFitted, glm.Vec3{}, 0, glm.Vec3{}},
+		{ACESFitted, glm.Vec3{1e4, 1e4, 1e4}, 0, glm.Vec3{1, 1, 1}},
+	}
+	for _, tt := range tests {
+		if got := tt.o.Map(tt.c, tt.ev); !got.ApproxEqual(tt.want) {
+			t.Errorf("%v %v at %v EV: %v, want %v", tt.o, tt.c, tt.ev, got, tt.want)
+		}
+	}
+}
+
+func TestCurves(t *testing.T) {
+	for _, o := range []Operator{Reinhard, ACESFitted, Exposure} {
+		// gray stays gray and brighter stays brighter, within [0, 1]
+		prev := float32(-1)
+		for v := float32(0); v < 64; v += .125 {
+			c := o.Map(glm.Vec3{v, v, v}, 0)
+			if m32.Abs(c[0]-c[1]) > 1e-4 || m32.Abs(c[1]-c[2]) > 1e-4 {
+				t.Fatalf("%v: gray %v maps to %v", o, v, c)
+			}
+			if c[0] < 0 || c[0] > 1 || c[0] < prev {
+				t.Fatalf("%v: %v maps to %v after %v", o, v, c[0], prev)
+			}
+			prev = c[0]
+		}
+	}
+
+	// ACES has a toe and a brighter shoulder than Reinhard
+	for _, tt := range []struct {
+		v      float32
+		darker bool
+	}{{.18, true}, {4, false}} {
+		aces, reinhard := ACESFitted.Map(glm.Vec3{tt.v, tt.v, tt.v}, 0), Reinhard.Map(glm.Vec3{tt.v, tt.v, tt.v}, 0)
+		if aces[0] < reinhard[0] != tt.darker {
+			t.Errorf("%v: ACES %v, Reinhard %v", tt.v, aces[0], reinhard[0])
+		}
+	}
+}
+
+func TestImage(t *testing.T) {
+	m := texture.NewFloat(2, 1)
+	m.Set(0, 0, [4]float32{1, 3, 0, 2})
+	m.Set(1, 0, [4]float32{8, 8, 8, .5})
+	out := Reinhard.Image(m, 0)
+	if !out.SRGB || out.Width != 2 || out.Height != 1 {
+		t.Fatalf("%dx%d srgb %v", out.Width, out.Height, out.SRGB)
+	}
+	want := [4]byte{texture.EncodeSRGB(.5), texture.EncodeSRGB(.75), 0, 255}
+	if got := out.At(0, 0); got != want {
+		t.Errorf("pixel 0 is %v, want %v", got, want)
+	}
+	v := texture.EncodeSRGB(8. / 9)
+	if got := out.At(1, 0); got != [4]byte{v, v, v, 128} {
+		t.Errorf("pixel 1 is %v", got)
+	}
+}
+
+func TestGLSL(t *testing.T) {
+	for o, name := range map[Operator]string{Reinhard: "REINHARD", ACESFitted: "ACES_FITTED", Exposure: "EXPOSURE"} {
+		if def := fmt.Sprintf("#define TONEMAP_%s %d\n", name, o); !strings.Contains(Source, def) {
+			t.Errorf("Source lacks %q", def)
+		}
+	}
+	for _, u := range Exposure.Uniforms(0) {
+		if !regexp.MustCompile(`uniform \w+ ` + u.Name + `;`).MatchString(FragmentSource) {
+			t.Errorf("FragmentSource does not declare %s", u.Name)
+		}
+	}
+	if !strings.HasPrefix(FragmentSource, "#version 330 core\n") {
+		t.Error("FragmentSource does not start with its version")
+	}
+}